@@ -0,0 +1,389 @@
+// Package apidiff compares two OpenAPI documents produced by rpc.Router or
+// httpapi.Router and classifies each difference as breaking or
+// non-breaking for existing clients.
+package apidiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/swetjen/virtuous/schema"
+)
+
+// Change describes one difference between two API contracts.
+type Change struct {
+	// Breaking reports whether existing clients may fail against the new
+	// contract.
+	Breaking bool `json:"breaking"`
+	// Operation is the affected route, such as "POST /rpc/users/create".
+	Operation string `json:"operation"`
+	// Location is the part of the operation that changed, such as
+	// "request.email" or "response 200.user.roles[]". It is empty for changes
+	// to the operation itself.
+	Location string `json:"location,omitempty"`
+	// Message describes the change.
+	Message string `json:"message"`
+}
+
+// String formats the change as one changelog line.
+func (c Change) String() string {
+	var b strings.Builder
+	b.WriteString(c.Operation)
+	if c.Location != "" {
+		b.WriteString(" ")
+		b.WriteString(c.Location)
+	}
+	b.WriteString(": ")
+	b.WriteString(c.Message)
+	return b.String()
+}
+
+// Report lists the changes between a base and a head document, ordered by
+// operation.
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// HasBreaking reports whether any change is breaking.
+func (r Report) HasBreaking() bool {
+	for _, change := range r.Changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Breaking returns the breaking changes.
+func (r Report) Breaking() []Change {
+	return r.filter(true)
+}
+
+// NonBreaking returns the changes existing clients are unaffected by.
+func (r Report) NonBreaking() []Change {
+	return r.filter(false)
+}
+
+func (r Report) filter(breaking bool) []Change {
+	var out []Change
+	for _, change := range r.Changes {
+		if change.Breaking == breaking {
+			out = append(out, change)
+		}
+	}
+	return out
+}
+
+// Changelog renders the report as Markdown with breaking changes first.
+func (r Report) Changelog() string {
+	if len(r.Changes) == 0 {
+		return "No API changes.\n"
+	}
+	var b strings.Builder
+	writeSection := func(title string, changes []Change) {
+		if len(changes) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## " + title + "\n\n")
+		for _, change := range changes {
+			b.WriteString("- `" + change.Operation + "`")
+			if change.Location != "" {
+				b.WriteString(" " + change.Location)
+			}
+			b.WriteString(": " + change.Message + "\n")
+		}
+	}
+	writeSection("Breaking changes", r.Breaking())
+	writeSection("Non-breaking changes", r.NonBreaking())
+	return b.String()
+}
+
+// Compare parses two OpenAPI JSON documents and reports how head differs
+// from base.
+func Compare(base, head []byte) (Report, error) {
+	baseDoc, err := parseDocument(base)
+	if err != nil {
+		return Report{}, fmt.Errorf("apidiff: base: %w", err)
+	}
+	headDoc, err := parseDocument(head)
+	if err != nil {
+		return Report{}, fmt.Errorf("apidiff: head: %w", err)
+	}
+	d := &differ{base: baseDoc, head: headDoc}
+	d.compare()
+	return Report{Changes: d.changes}, nil
+}
+
+type document struct {
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema.OpenAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	Parameters  []parameter           `json:"parameters"`
+	RequestBody *requestBody          `json:"requestBody"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	Deprecated  bool                  `json:"deprecated"`
+}
+
+type parameter struct {
+	Name     string                `json:"name"`
+	In       string                `json:"in"`
+	Required bool                  `json:"required"`
+	Schema   *schema.OpenAPISchema `json:"schema"`
+	Content  map[string]mediaType  `json:"content"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Content map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema.OpenAPISchema `json:"schema"`
+}
+
+func parseDocument(data []byte) (*document, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Paths == nil {
+		return nil, fmt.Errorf("document has no paths")
+	}
+	return &doc, nil
+}
+
+type differ struct {
+	base    *document
+	head    *document
+	changes []Change
+	op      string
+}
+
+func (d *differ) add(breaking bool, location, format string, args ...any) {
+	d.changes = append(d.changes, Change{
+		Breaking:  breaking,
+		Operation: d.op,
+		Location:  location,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) compare() {
+	for _, key := range operationKeys(d.base, d.head) {
+		d.op = strings.ToUpper(key.method) + " " + key.path
+		baseOp := d.base.Paths[key.path][key.method]
+		headOp := d.head.Paths[key.path][key.method]
+		switch {
+		case headOp == nil:
+			d.add(true, "", "route removed")
+		case baseOp == nil:
+			d.add(false, "", "route added")
+		default:
+			d.compareOperation(baseOp, headOp)
+		}
+	}
+}
+
+type operationKey struct {
+	path   string
+	method string
+}
+
+func operationKeys(docs ...*document) []operationKey {
+	seen := map[operationKey]bool{}
+	var keys []operationKey
+	for _, doc := range docs {
+		for path, methods := range doc.Paths {
+			for method, op := range methods {
+				key := operationKey{path: path, method: method}
+				if op == nil || seen[key] {
+					continue
+				}
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		return keys[i].method < keys[j].method
+	})
+	return keys
+}
+
+func (d *differ) compareOperation(base, head *operation) {
+	if head.Deprecated && !base.Deprecated {
+		d.add(false, "", "route deprecated")
+	}
+	d.compareSecurity(base.Security, head.Security)
+	d.compareParameters(base.Parameters, head.Parameters)
+	d.compareRequestBody(base.RequestBody, head.RequestBody)
+	d.compareResponses(base.Responses, head.Responses)
+}
+
+// compareSecurity treats every scheme named in a requirement as a guard the
+// caller must satisfy.
+func (d *differ) compareSecurity(base, head []map[string][]string) {
+	baseGuards, headGuards := securityNames(base), securityNames(head)
+	for _, name := range sortedKeys(headGuards) {
+		if !baseGuards[name] {
+			d.add(true, "", "guard %s added", name)
+		}
+	}
+	for _, name := range sortedKeys(baseGuards) {
+		if !headGuards[name] {
+			d.add(false, "", "guard %s removed", name)
+		}
+	}
+}
+
+func securityNames(reqs []map[string][]string) map[string]bool {
+	names := map[string]bool{}
+	for _, req := range reqs {
+		for name := range req {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+func (d *differ) compareParameters(base, head []parameter) {
+	index := func(params []parameter) map[string]parameter {
+		out := make(map[string]parameter, len(params))
+		for _, param := range params {
+			out[param.In+":"+param.Name] = param
+		}
+		return out
+	}
+	baseParams, headParams := index(base), index(head)
+	for _, key := range sortedKeys(headParams) {
+		param := headParams[key]
+		location := "parameter " + param.Name + " (" + param.In + ")"
+		old, ok := baseParams[key]
+		switch {
+		case !ok && param.Required:
+			d.add(true, location, "added as required")
+		case !ok:
+			d.add(false, location, "added")
+		default:
+			if param.Required && !old.Required {
+				d.add(true, location, "became required")
+			} else if !param.Required && old.Required {
+				d.add(false, location, "became optional")
+			}
+			d.compareSchema(old.schema(), param.schema(), location, requestSide)
+		}
+	}
+	for _, key := range sortedKeys(baseParams) {
+		if _, ok := headParams[key]; !ok {
+			param := baseParams[key]
+			d.add(false, "parameter "+param.Name+" ("+param.In+")", "removed")
+		}
+	}
+}
+
+func (p parameter) schema() *schema.OpenAPISchema {
+	if p.Schema != nil {
+		return p.Schema
+	}
+	for _, mediaType := range sortedKeys(p.Content) {
+		return p.Content[mediaType].Schema
+	}
+	return nil
+}
+
+func (d *differ) compareRequestBody(base, head *requestBody) {
+	switch {
+	case base == nil && head == nil:
+		return
+	case base == nil:
+		d.add(head.Required, "request", "body added")
+		return
+	case head == nil:
+		d.add(false, "request", "body removed")
+		return
+	}
+	if head.Required && !base.Required {
+		d.add(true, "request", "body became required")
+	}
+	for _, mediaType := range sortedKeys(base.Content) {
+		if _, ok := head.Content[mediaType]; !ok {
+			d.add(true, "request", "%s no longer accepted", mediaType)
+		}
+	}
+	for _, mediaType := range sortedKeys(head.Content) {
+		if _, ok := base.Content[mediaType]; !ok {
+			d.add(false, "request", "%s accepted", mediaType)
+		}
+	}
+	d.compareSchema(primarySchema(base.Content), primarySchema(head.Content), "request", requestSide)
+}
+
+func (d *differ) compareResponses(base, head map[string]response) {
+	for _, status := range sortedKeys(base) {
+		location := "response " + status
+		next, ok := head[status]
+		if !ok {
+			d.add(isSuccess(status), location, "removed")
+			continue
+		}
+		old := base[status]
+		for _, mediaType := range sortedKeys(old.Content) {
+			if _, ok := next.Content[mediaType]; !ok {
+				d.add(true, location, "%s no longer returned", mediaType)
+			}
+		}
+		for _, mediaType := range sortedKeys(next.Content) {
+			if _, ok := old.Content[mediaType]; !ok {
+				d.add(false, location, "%s returned", mediaType)
+			}
+		}
+		d.compareSchema(primarySchema(old.Content), primarySchema(next.Content), location, responseSide)
+	}
+	for _, status := range sortedKeys(head) {
+		if _, ok := base[status]; !ok {
+			d.add(false, "response "+status, "added")
+		}
+	}
+}
+
+func isSuccess(status string) bool {
+	return strings.HasPrefix(status, "2")
+}
+
+// primarySchema returns the JSON schema of a content map, falling back to
+// the first media type.
+func primarySchema(content map[string]mediaType) *schema.OpenAPISchema {
+	if media, ok := content["application/json"]; ok {
+		return media.Schema
+	}
+	for _, mediaType := range sortedKeys(content) {
+		return content[mediaType].Schema
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+package apidiff
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/httpapi"
+	"github.com/swetjen/virtuous/rpc"
+)
+
+type createUserV1 struct {
+	Email    string  `json:"email"`
+	Nickname *string `json:"nickname,omitempty"`
+	Role     string  `json:"role" enum:"admin,member,guest"`
+	Note     string  `json:"note,omitempty" maxLength:"200"`
+}
+
+type createUserV2 struct {
+	Email    string `json:"email"`
+	Nickname string `json:"nickname"`
+	Role     string `json:"role" enum:"admin,member"`
+	Note     string `json:"note,omitempty" maxLength:"100"`
+	Team     string `json:"team,omitempty"`
+}
+
+type userV1 struct {
+	ID     string   `json:"id"`
+	Email  string   `json:"email"`
+	Status string   `json:"status" enum:"active,disabled"`
+	Tags   []string `json:"tags"`
+}
+
+type userV2 struct {
+	ID     int      `json:"id"`
+	Status string   `json:"status" enum:"active,disabled,invited"`
+	Tags   []string `json:"tags"`
+	Avatar string   `json:"avatar"`
+}
+
+type bearerGuard struct{}
+
+func (bearerGuard) Spec() httpapi.GuardSpec {
+	return httpapi.GuardSpec{Name: "BearerAuth", In: "header", Param: "Authorization", Prefix: "Bearer"}
+}
+
+func (bearerGuard) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler { return next }
+}
+
+func openAPI(t *testing.T, src Source) []byte {
+	t.Helper()
+	doc, err := src.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	return doc
+}
+
+func hasChange(report Report, breaking bool, line string) bool {
+	for _, change := range report.Changes {
+		if change.Breaking == breaking && change.String() == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCompareClassifiesSchemaChanges(t *testing.T) {
+	base := httpapi.NewRouter()
+	base.Describe("POST /users", createUserV1{}, userV1{}, httpapi.HandlerMeta{Service: "Users", Method: "Create"})
+	base.Describe("GET /users/legacy", nil, userV1{}, httpapi.HandlerMeta{Service: "Users", Method: "Legacy"})
+
+	head := httpapi.NewRouter()
+	head.Describe("POST /users", createUserV2{}, userV2{}, httpapi.HandlerMeta{Service: "Users", Method: "Create"}, bearerGuard{})
+	head.Describe("GET /users/search", nil, userV2{}, httpapi.HandlerMeta{Service: "Users", Method: "Search"})
+
+	report, err := Compare(openAPI(t, base), openAPI(t, head))
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	breaking := []string{
+		"GET /users/legacy: route removed",
+		"POST /users: guard BearerAuth added",
+		"POST /users request.nickname: became required",
+		`POST /users request.role: enum value "guest" removed`,
+		"POST /users request.note: maxLength changed from 200 to 100",
+		"POST /users response 200.id: type changed from string to integer",
+		"POST /users response 200.email: removed",
+		`POST /users response 200.status: enum value "invited" added`,
+	}
+	for _, line := range breaking {
+		if !hasChange(report, true, line) {
+			t.Errorf("missing breaking change %q in:\n%s", line, report.Changelog())
+		}
+	}
+	nonBreaking := []string{
+		"GET /users/search: route added",
+		"POST /users request.team: added",
+		"POST /users response 200.avatar: added",
+	}
+	for _, line := range nonBreaking {
+		if !hasChange(report, false, line) {
+			t.Errorf("missing non-breaking change %q in:\n%s", line, report.Changelog())
+		}
+	}
+	for _, change := range report.Changes {
+		if strings.Contains(change.Location, ".tags") {
+			t.Errorf("unchanged field reported: %s", change)
+		}
+	}
+	if !report.HasBreaking() || !strings.HasPrefix(report.Changelog(), "## Breaking changes\n\n- `POST /users`: guard BearerAuth added\n") {
+		t.Fatalf("unexpected changelog:\n%s", report.Changelog())
+	}
+}
+
+type greetRequest struct {
+	Name string `json:"name"`
+}
+
+type greetResponse struct {
+	Message string `json:"message"`
+}
+
+func Greet(_ context.Context, req greetRequest) (greetResponse, int) {
+	return greetResponse{Message: "hello " + req.Name}, rpc.StatusOK
+}
+
+func Farewell(_ context.Context, req greetRequest) (greetResponse, error) {
+	return greetResponse{Message: "bye " + req.Name}, nil
+}
+
+func TestCompareIdenticalRPCRouters(t *testing.T) {
+	newRouter := func() *rpc.Router {
+		router := rpc.NewRouter()
+		router.HandleRPC(Greet)
+		router.HandleRPC(Farewell)
+		return router
+	}
+	report, err := Compare(openAPI(t, newRouter()), openAPI(t, newRouter()))
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if len(report.Changes) != 0 || report.Changelog() != "No API changes.\n" {
+		t.Fatalf("expected no changes, got:\n%s", report.Changelog())
+	}
+
+	if _, err := Compare([]byte(`{}`), openAPI(t, newRouter())); err == nil {
+		t.Fatalf("expected error for a document without paths")
+	}
+}
+
+func TestCheckBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api", "openapi.json")
+	base := rpc.NewRouter()
+	base.HandleRPC(Greet)
+	base.HandleRPC(Farewell)
+
+	t.Setenv(UpdateBaselineEnv, "1")
+	CheckBaseline(t, path, base)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected baseline to be written: %v", err)
+	}
+
+	t.Setenv(UpdateBaselineEnv, "")
+	if report := CheckBaseline(t, path, base); len(report.Changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", report)
+	}
+
+	head := rpc.NewRouter()
+	head.HandleRPC(Greet)
+	ft := &fakeTB{TB: t}
+	report := CheckBaseline(ft, path, head)
+	if !ft.failed || !strings.Contains(ft.message, "`POST /rpc/apidiff/farewell`: route removed") {
+		t.Fatalf("expected removed route to fail the test, got failed=%v %q", ft.failed, ft.message)
+	}
+	if len(report.Breaking()) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+// fakeTB records Errorf calls instead of failing the real test.
+type fakeTB struct {
+	testing.TB
+	failed  bool
+	message string
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.failed = true
+	f.message = strings.TrimSpace(strings.Join([]string{f.message, fmt.Sprintf(format, args...)}, "\n"))
+}
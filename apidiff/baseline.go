@@ -0,0 +1,61 @@
+package apidiff
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateBaselineEnv names the environment variable that makes CheckBaseline
+// rewrite the baseline instead of comparing against it.
+const UpdateBaselineEnv = "VIRTUOUS_UPDATE_API_BASELINE"
+
+// Source produces an OpenAPI document. *rpc.Router and *httpapi.Router
+// implement it.
+type Source interface {
+	OpenAPI() ([]byte, error)
+}
+
+// CheckBaseline compares the live OpenAPI document of src against the
+// baseline committed at path. Breaking changes fail the test with a
+// changelog; non-breaking changes are logged.
+//
+// Set VIRTUOUS_UPDATE_API_BASELINE=1 to write the current document to path
+// instead, for example after an intentional breaking change or to create the
+// baseline.
+func CheckBaseline(t testing.TB, path string, src Source) Report {
+	t.Helper()
+	head, err := src.OpenAPI()
+	if err != nil {
+		t.Fatalf("apidiff: generate OpenAPI: %v", err)
+	}
+	if os.Getenv(UpdateBaselineEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("apidiff: %v", err)
+		}
+		if err := os.WriteFile(path, head, 0o644); err != nil {
+			t.Fatalf("apidiff: write baseline: %v", err)
+		}
+		t.Logf("apidiff: wrote baseline %s", path)
+		return Report{}
+	}
+	base, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("apidiff: baseline %s does not exist; run the test with %s=1 to create it", path, UpdateBaselineEnv)
+	}
+	if err != nil {
+		t.Fatalf("apidiff: read baseline: %v", err)
+	}
+	report, err := Compare(base, head)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if report.HasBreaking() {
+		t.Errorf("apidiff: breaking API changes against %s; rerun with %s=1 to accept them\n\n%s", path, UpdateBaselineEnv, report.Changelog())
+	} else if len(report.Changes) > 0 {
+		t.Logf("apidiff: API changes against %s\n\n%s", path, report.Changelog())
+	}
+	return report
+}
@@ -0,0 +1,245 @@
+package apidiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/swetjen/virtuous/schema"
+)
+
+// side selects which direction a schema travels. Clients send request
+// schemas and read response schemas, so the same change can break one and
+// not the other.
+type side int
+
+const (
+	requestSide side = iota
+	responseSide
+)
+
+// breaks returns whether a change that narrows or widens the accepted
+// values breaks clients on s. Narrowing what the server accepts breaks
+// requests; widening what the server returns breaks responses.
+func (s side) breaks(narrowed bool) bool {
+	if s == requestSide {
+		return narrowed
+	}
+	return !narrowed
+}
+
+func (d *differ) compareSchema(base, head *schema.OpenAPISchema, location string, side side) {
+	d.walk(base, head, location, side, map[string]bool{})
+}
+
+// walk compares two schemas. visited holds the component pairs on the
+// current path so recursive types terminate.
+func (d *differ) walk(base, head *schema.OpenAPISchema, location string, side side, visited map[string]bool) {
+	base, baseRef := resolve(d.base, base)
+	head, headRef := resolve(d.head, head)
+	if base == nil || head == nil {
+		return
+	}
+	if baseRef != "" || headRef != "" {
+		key := baseRef + "|" + headRef
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		defer delete(visited, key)
+	}
+
+	if base.Type != "" && head.Type != "" && base.Type != head.Type {
+		d.add(true, location, "type changed from %s to %s", base.Type, head.Type)
+		return
+	}
+	if base.Format != "" && head.Format != "" && base.Format != head.Format {
+		d.add(true, location, "format changed from %s to %s", base.Format, head.Format)
+	}
+	if base.Nullable != head.Nullable {
+		if head.Nullable {
+			d.add(side.breaks(false), location, "became nullable")
+		} else {
+			d.add(side.breaks(true), location, "no longer nullable")
+		}
+	}
+	d.compareEnum(base.Enum, head.Enum, location, side)
+	if side == requestSide {
+		d.compareConstraints(base, head, location)
+	}
+	d.compareProperties(base, head, location, side, visited)
+
+	if base.Items != nil && head.Items != nil {
+		d.walk(base.Items, head.Items, location+"[]", side, visited)
+	}
+	if base.AdditionalProperties != nil && head.AdditionalProperties != nil {
+		d.walk(base.AdditionalProperties, head.AdditionalProperties, location+"{}", side, visited)
+	}
+	d.compareAlternatives(base.OneOf, head.OneOf, location, side, visited)
+	if len(base.AllOf) == len(head.AllOf) {
+		for i := range base.AllOf {
+			d.walk(base.AllOf[i], head.AllOf[i], location, side, visited)
+		}
+	}
+}
+
+// resolve follows $ref pointers and single-element allOf wrappers, which the
+// schema generator uses for nullable and documented references. It returns
+// the last component name it passed through.
+func resolve(doc *document, s *schema.OpenAPISchema) (*schema.OpenAPISchema, string) {
+	name := ""
+	nullable := false
+	for depth := 0; s != nil && depth < 32; depth++ {
+		nullable = nullable || s.Nullable
+		if s.Ref != "" {
+			name = strings.TrimPrefix(s.Ref, "#/components/schemas/")
+			s = doc.Components.Schemas[name]
+			continue
+		}
+		if len(s.AllOf) == 1 && s.Type == "" && len(s.Properties) == 0 {
+			s = s.AllOf[0]
+			continue
+		}
+		break
+	}
+	if s != nil && nullable && !s.Nullable {
+		copied := *s
+		copied.Nullable = true
+		s = &copied
+	}
+	return s, name
+}
+
+func (d *differ) compareEnum(base, head []any, location string, side side) {
+	switch {
+	case len(base) == 0 && len(head) == 0:
+		return
+	case len(base) == 0:
+		d.add(side.breaks(true), location, "restricted to enum values")
+		return
+	case len(head) == 0:
+		d.add(side.breaks(false), location, "no longer restricted to enum values")
+		return
+	}
+	baseValues, headValues := enumSet(base), enumSet(head)
+	for _, value := range sortedKeys(baseValues) {
+		if !headValues[value] {
+			d.add(side.breaks(true), location, "enum value %s removed", value)
+		}
+	}
+	for _, value := range sortedKeys(headValues) {
+		if !baseValues[value] {
+			d.add(side.breaks(false), location, "enum value %s added", value)
+		}
+	}
+}
+
+func enumSet(values []any) map[string]bool {
+	out := make(map[string]bool, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		out[string(data)] = true
+	}
+	return out
+}
+
+// compareConstraints reports changed validation bounds on request schemas.
+// Tightening a bound rejects requests that used to pass.
+func (d *differ) compareConstraints(base, head *schema.OpenAPISchema, location string) {
+	bound := func(name string, old, next *float64, upper bool) {
+		switch {
+		case old == nil && next == nil:
+			return
+		case old == nil:
+			d.add(true, location, "%s %s added", name, formatNumber(*next))
+		case next == nil:
+			d.add(false, location, "%s %s removed", name, formatNumber(*old))
+		case *old != *next:
+			tightened := *next < *old
+			if !upper {
+				tightened = *next > *old
+			}
+			d.add(tightened, location, "%s changed from %s to %s", name, formatNumber(*old), formatNumber(*next))
+		}
+	}
+	bound("minimum", base.Minimum, head.Minimum, false)
+	bound("maximum", base.Maximum, head.Maximum, true)
+	bound("minLength", intBound(base.MinLength), intBound(head.MinLength), false)
+	bound("maxLength", intBound(base.MaxLength), intBound(head.MaxLength), true)
+	bound("minItems", intBound(base.MinItems), intBound(head.MinItems), false)
+	bound("maxItems", intBound(base.MaxItems), intBound(head.MaxItems), true)
+	if base.Pattern != head.Pattern {
+		switch {
+		case base.Pattern == "":
+			d.add(true, location, "pattern %q added", head.Pattern)
+		case head.Pattern == "":
+			d.add(false, location, "pattern %q removed", base.Pattern)
+		default:
+			d.add(true, location, "pattern changed from %q to %q", base.Pattern, head.Pattern)
+		}
+	}
+}
+
+func intBound(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
+
+func formatNumber(f float64) string {
+	return fmt.Sprint(f)
+}
+
+func (d *differ) compareProperties(base, head *schema.OpenAPISchema, location string, side side, visited map[string]bool) {
+	baseRequired, headRequired := stringSet(base.Required), stringSet(head.Required)
+	for _, name := range sortedKeys(head.Properties) {
+		field := location + "." + name
+		if _, ok := base.Properties[name]; !ok {
+			if side == requestSide && headRequired[name] {
+				d.add(true, field, "added as required")
+			} else {
+				d.add(false, field, "added")
+			}
+			continue
+		}
+		switch {
+		case headRequired[name] && !baseRequired[name]:
+			d.add(side == requestSide, field, "became required")
+		case !headRequired[name] && baseRequired[name]:
+			d.add(side == responseSide, field, "became optional")
+		}
+		d.walk(base.Properties[name], head.Properties[name], field, side, visited)
+	}
+	for _, name := range sortedKeys(base.Properties) {
+		if _, ok := head.Properties[name]; !ok {
+			d.add(side == responseSide, location+"."+name, "removed")
+		}
+	}
+}
+
+// compareAlternatives compares oneOf lists by position. Fewer alternatives
+// narrow the accepted values.
+func (d *differ) compareAlternatives(base, head []*schema.OpenAPISchema, location string, side side, visited map[string]bool) {
+	if len(base) == 0 && len(head) == 0 {
+		return
+	}
+	if len(base) != len(head) {
+		d.add(side.breaks(len(head) < len(base)), location, "alternatives changed from %d to %d", len(base), len(head))
+	}
+	for i := 0; i < len(base) && i < len(head); i++ {
+		d.walk(base[i], head[i], location, side, visited)
+	}
+}
+
+func stringSet(values []string) map[string]bool {
+	out := make(map[string]bool, len(values))
+	for _, value := range values {
+		out[value] = true
+	}
+	return out
+}
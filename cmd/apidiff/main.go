@@ -0,0 +1,95 @@
+// Command apidiff compares two OpenAPI documents produced by Virtuous routers
+// and reports breaking and non-breaking changes.
+//
+// Usage:
+//
+//	apidiff [-json] [-allow-breaking] BASE HEAD
+//
+// BASE and HEAD are OpenAPI JSON files or http(s) URLs, such as a committed
+// baseline and a running server's /rpc/openapi.json. apidiff prints a
+// Markdown changelog and exits with status 1 when a change is breaking,
+// unless -allow-breaking is set. Errors exit with status 2.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/swetjen/virtuous/apidiff"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("apidiff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	allowBreaking := flags.Bool("allow-breaking", false, "exit 0 even when changes are breaking")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: apidiff [-json] [-allow-breaking] BASE HEAD")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	base, err := load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "apidiff: %v\n", err)
+		return 2
+	}
+	head, err := load(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "apidiff: %v\n", err)
+		return 2
+	}
+	report, err := apidiff.Compare(base, head)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "apidiff: %v\n", err)
+			return 2
+		}
+	} else {
+		fmt.Fprint(stdout, report.Changelog())
+	}
+	if report.HasBreaking() && !*allowBreaking {
+		return 1
+	}
+	return 0
+}
+
+// load reads a document from a file or an http(s) URL.
+func load(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const baseDoc = `{"openapi":"3.0.3","paths":{"/rpc/users/get":{"post":{"responses":{"200":{"description":"OK"}}}},"/rpc/users/list":{"post":{"responses":{"200":{"description":"OK"}}}}}}`
+
+const headDoc = `{"openapi":"3.0.3","paths":{"/rpc/users/get":{"post":{"responses":{"200":{"description":"OK"}}}}}}`
+
+func writeDoc(t *testing.T, name, doc string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestRunExitCodes(t *testing.T) {
+	base, head := writeDoc(t, "base.json", baseDoc), writeDoc(t, "head.json", headDoc)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{base, head}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1 for breaking changes, got %d (%s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "`POST /rpc/users/list`: route removed") {
+		t.Fatalf("unexpected changelog: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-json", "-allow-breaking", base, head}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0 with -allow-breaking, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), `"breaking": true`) {
+		t.Fatalf("unexpected JSON report: %s", stdout.String())
+	}
+
+	if code := run([]string{head, base}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0 for an added route, got %d", code)
+	}
+	if code := run([]string{base}, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected usage error, got %d", code)
+	}
+}
@@ -0,0 +1,211 @@
+package virtuous
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/swetjen/virtuous/internal/clientgen"
+)
+
+// Generated artifact file names written by Generate.
+const (
+	OpenAPIFile       = "openapi.json"
+	ClientJSFile      = "client.gen.js"
+	ClientTSFile      = "client.gen.ts"
+	ClientPYFile      = "client.gen.py"
+	ClientPYAsyncFile = "client.gen.async.py"
+	ReactQueryTSFile  = "react-query.client.gen.ts"
+	ClientZodFile     = "client.gen.zod.ts"
+)
+
+// GenerateSource produces the OpenAPI document and clients of a router.
+// *rpc.Router and *httpapi.Router implement it.
+type GenerateSource interface {
+	OpenAPI() ([]byte, error)
+	WriteClientJS(w io.Writer) error
+	WriteClientTS(w io.Writer) error
+	WriteClientPY(w io.Writer) error
+}
+
+// reactQuerySource is implemented by routers that generate a React Query
+// companion client.
+type reactQuerySource interface {
+	WriteReactQueryTS(w io.Writer) error
+}
+
+// asyncPythonSource is implemented by routers that generate an async Python
+// client.
+type asyncPythonSource interface {
+	WriteClientPYAsync(w io.Writer) error
+}
+
+// zodSource is implemented by routers that generate Zod schemas for the TS
+// client.
+type zodSource interface {
+	WriteClientZod(w io.Writer) error
+}
+
+// GenerateOptions configures Generate.
+type GenerateOptions struct {
+	// Dir receives the artifacts. It defaults to the current directory.
+	Dir string
+	// Check compares the artifacts with the files in Dir instead of writing
+	// them, ignoring the generated-at header line.
+	Check bool
+	// Zod also writes the Zod schemas for the TS client. Only RPC routers
+	// provide them.
+	Zod bool
+}
+
+// StaleArtifactsError lists generated files that are missing or differ from
+// what the router produces now.
+type StaleArtifactsError struct {
+	Files []string
+}
+
+// Error implements the error interface.
+func (e *StaleArtifactsError) Error() string {
+	return "virtuous: generated files are out of date: " + strings.Join(e.Files, ", ")
+}
+
+// Generate writes the OpenAPI document and the JS, TS, and Python clients of
+// src to opts.Dir, plus the async Python and React Query clients for routers
+// that provide them, and the Zod schemas when opts.Zod is set.
+// In check mode it writes nothing and returns a *StaleArtifactsError when any
+// file would change.
+func Generate(src GenerateSource, opts GenerateOptions) error {
+	if src == nil {
+		return errors.New("virtuous: generate source is nil")
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+	artifacts, err := renderArtifacts(src, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Check {
+		var stale []string
+		for _, artifact := range artifacts {
+			path := filepath.Join(dir, artifact.name)
+			current, err := os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				stale = append(stale, path)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(clientgen.StripGeneratedLine(current), clientgen.StripGeneratedLine(artifact.data)) {
+				stale = append(stale, path)
+			}
+		}
+		if len(stale) > 0 {
+			return &StaleArtifactsError{Files: stale}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, artifact := range artifacts {
+		if err := os.WriteFile(filepath.Join(dir, artifact.name), artifact.data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type artifact struct {
+	name string
+	data []byte
+}
+
+type artifactWriter struct {
+	name  string
+	write func(io.Writer) error
+}
+
+func renderArtifacts(src GenerateSource, opts GenerateOptions) ([]artifact, error) {
+	doc, err := src.OpenAPI()
+	if err != nil {
+		return nil, fmt.Errorf("virtuous: generate %s: %w", OpenAPIFile, err)
+	}
+	artifacts := []artifact{{name: OpenAPIFile, data: doc}}
+	writers := []artifactWriter{
+		{ClientJSFile, src.WriteClientJS},
+		{ClientTSFile, src.WriteClientTS},
+		{ClientPYFile, src.WriteClientPY},
+	}
+	if py, ok := src.(asyncPythonSource); ok {
+		writers = append(writers, artifactWriter{ClientPYAsyncFile, py.WriteClientPYAsync})
+	}
+	if rq, ok := src.(reactQuerySource); ok {
+		writers = append(writers, artifactWriter{ReactQueryTSFile, rq.WriteReactQueryTS})
+	}
+	if opts.Zod {
+		zod, ok := src.(zodSource)
+		if !ok {
+			return nil, fmt.Errorf("virtuous: generate %s: router does not provide Zod schemas", ClientZodFile)
+		}
+		writers = append(writers, artifactWriter{ClientZodFile, zod.WriteClientZod})
+	}
+	for _, w := range writers {
+		var buf bytes.Buffer
+		if err := w.write(&buf); err != nil {
+			return nil, fmt.Errorf("virtuous: generate %s: %w", w.name, err)
+		}
+		artifacts = append(artifacts, artifact{name: w.name, data: buf.Bytes()})
+	}
+	return artifacts, nil
+}
+
+// GenerateMain runs Generate with command-line flags and returns the exit
+// status, so a generator command is one line:
+//
+//	func main() { os.Exit(virtuous.GenerateMain(app.NewRouter(), os.Args[1:])) }
+//
+// Flags: -dir sets GenerateOptions.Dir, -zod sets GenerateOptions.Zod, and
+// -check enables check mode, which exits with 1 when files are stale.
+func GenerateMain(src GenerateSource, args []string) int {
+	return generateMain(src, args, os.Stdout, os.Stderr)
+}
+
+func generateMain(src GenerateSource, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("dir", ".", "directory for generated files")
+	check := flags.Bool("check", false, "fail when generated files differ instead of writing them")
+	zod := flags.Bool("zod", false, "also write Zod schemas for the TS client")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	err := Generate(src, GenerateOptions{Dir: *dir, Check: *check, Zod: *zod})
+	var stale *StaleArtifactsError
+	switch {
+	case errors.As(err, &stale):
+		fmt.Fprintln(stderr, "generated files are out of date; rerun without -check:")
+		for _, file := range stale.Files {
+			fmt.Fprintln(stderr, "  "+file)
+		}
+		return 1
+	case err != nil:
+		fmt.Fprintln(stderr, err)
+		return 2
+	case *check:
+		fmt.Fprintln(stdout, "generated files are up to date")
+	default:
+		fmt.Fprintln(stdout, "wrote generated files to "+*dir)
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package virtuous
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/httpapi"
+	"github.com/swetjen/virtuous/rpc"
+)
+
+type generatePing struct {
+	Message string `json:"message"`
+}
+
+func GeneratePing(_ context.Context) (generatePing, int) {
+	return generatePing{Message: "pong"}, rpc.StatusOK
+}
+
+func TestGenerateWritesAndChecksArtifacts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "gen")
+	router := rpc.NewRouter()
+	router.HandleRPC(GeneratePing)
+
+	if err := Generate(router, GenerateOptions{Dir: dir}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	for _, name := range []string{OpenAPIFile, ClientJSFile, ClientTSFile, ClientPYFile, ClientPYAsyncFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ReactQueryTSFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("rpc routers should not write %s", ReactQueryTSFile)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ClientZodFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("%s should be opt-in", ClientZodFile)
+	}
+
+	// Only the generated-at line differs from a fresh render.
+	jsPath := filepath.Join(dir, ClientJSFile)
+	js, _ := os.ReadFile(jsPath)
+	lines := strings.SplitAfter(string(js), "\n")
+	lines[1] = "// Code generated by Virtuous v0.0.1 on 2001-01-01 00:00:00 UTC; DO NOT EDIT.\n"
+	if err := os.WriteFile(jsPath, []byte(strings.Join(lines, "")), 0644); err != nil {
+		t.Fatalf("rewrite header: %v", err)
+	}
+	if err := Generate(router, GenerateOptions{Dir: dir, Check: true}); err != nil {
+		t.Fatalf("expected check to ignore the generated-at line: %v", err)
+	}
+
+	if err := os.WriteFile(jsPath, append(js, "// edited\n"...), 0644); err != nil {
+		t.Fatalf("edit client: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, ClientPYFile)); err != nil {
+		t.Fatalf("remove client: %v", err)
+	}
+	var stale *StaleArtifactsError
+	err := Generate(router, GenerateOptions{Dir: dir, Check: true})
+	if !errors.As(err, &stale) || len(stale.Files) != 2 || stale.Files[0] != jsPath {
+		t.Fatalf("expected stale JS and PY clients, got %v", err)
+	}
+}
+
+func TestGenerateIncludesReactQueryForHTTPAPI(t *testing.T) {
+	dir := t.TempDir()
+	router := httpapi.NewRouter()
+	router.Describe("GET /ping", nil, generatePing{}, httpapi.HandlerMeta{Service: "Ping", Method: "Get"})
+
+	var stdout, stderr bytes.Buffer
+	if code := generateMain(router, []string{"-dir", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("generate exit %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, ReactQueryTSFile)); err != nil {
+		t.Fatalf("expected React Query client: %v", err)
+	}
+	if code := generateMain(router, []string{"--check", "-dir", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("check exit %d: %s", code, stderr.String())
+	}
+
+	router.Describe("GET /pong", nil, generatePing{}, httpapi.HandlerMeta{Service: "Ping", Method: "Pong"})
+	stderr.Reset()
+	if code := generateMain(router, []string{"-check", "-dir", dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected drift to exit 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), OpenAPIFile) {
+		t.Fatalf("expected stale OpenAPI file in output: %s", stderr.String())
+	}
+}
+
+func TestGenerateWritesZodSchemasWhenRequested(t *testing.T) {
+	dir := t.TempDir()
+	router := rpc.NewRouter()
+	router.HandleRPC(GeneratePing)
+
+	var stdout, stderr bytes.Buffer
+	if code := generateMain(router, []string{"-zod", "-dir", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("generate exit %d: %s", code, stderr.String())
+	}
+	zod, err := os.ReadFile(filepath.Join(dir, ClientZodFile))
+	if err != nil {
+		t.Fatalf("expected Zod schemas: %v", err)
+	}
+	if !strings.Contains(string(zod), "export const generatePingSchema = z.object({") {
+		t.Fatalf("unexpected Zod schemas:\n%s", zod)
+	}
+
+	api := httpapi.NewRouter()
+	api.Describe("GET /ping", nil, generatePing{}, httpapi.HandlerMeta{Service: "Ping", Method: "Get"})
+	if err := Generate(api, GenerateOptions{Dir: t.TempDir(), Zod: true}); err == nil || !strings.Contains(err.Error(), ClientZodFile) {
+		t.Fatalf("expected httpapi routers to reject Zod, got %v", err)
+	}
+}
@@ -3,8 +3,10 @@ module github.com/swetjen/virtuous
 go 1.25.11
 
 require (
+	github.com/andybalholm/brotli v1.2.5
 	github.com/jackc/pgtype v1.14.4
 	github.com/jackc/pgx/v5 v5.8.0
+	github.com/klauspost/compress v1.20.1
 )
 
 require (
@@ -0,0 +1,50 @@
+package guard
+
+import (
+	"context"
+	"strings"
+)
+
+type principalKey struct{}
+
+// Identifier is implemented by principals that expose a stable identifier,
+// such as a user or service account ID, for observability.
+type Identifier interface {
+	PrincipalID() string
+}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+// Guards call it from Middleware before passing the request to the next handler.
+func WithPrincipal(ctx context.Context, principal any) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal attached by a guard, if any.
+func PrincipalFrom(ctx context.Context) (any, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	principal := ctx.Value(principalKey{})
+	return principal, principal != nil
+}
+
+// Principal returns the principal attached by a guard when it has type T.
+func Principal[T any](ctx context.Context) (T, bool) {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	typed, ok := principal.(T)
+	return typed, ok
+}
+
+// PrincipalID returns the identifier recorded for principal. Only principals
+// that implement Identifier have one; for any other value it returns "" so
+// claims never reach observability storage, not even as a hash.
+func PrincipalID(principal any) string {
+	if identifier, ok := principal.(Identifier); ok {
+		return strings.TrimSpace(identifier.PrincipalID())
+	}
+	return ""
+}
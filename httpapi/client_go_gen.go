@@ -0,0 +1,952 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/schema"
+)
+
+// GoClientOptions configures generated Go clients.
+type GoClientOptions = clientgen.GoClientOptions
+
+var clientGoTemplate = template.Must(template.New("virtuous-go").Parse(`// Package {{ .Package }} is a typed client for a Virtuous HTTP API.
+package {{ .Package }}
+
+import (
+{{- range $imp := .Imports }}
+	{{ $imp.Spec }}
+{{- end }}
+)
+
+// Error is returned when a request responds with a non-2xx status.
+type Error struct {
+	// Status is the HTTP status code.
+	Status int
+	// Message is read from the error or message field of a JSON body.
+	Message string
+	// Body is the raw response body.
+	Body []byte
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return strconv.Itoa(e.Status) + " " + http.StatusText(e.Status) + ": " + e.Message
+	}
+	return strconv.Itoa(e.Status) + " " + http.StatusText(e.Status)
+}
+
+// Decode unmarshals the error body into v.
+func (e *Error) Decode(v any) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+func newError(status int, body []byte) *Error {
+	err := &Error{Status: status, Body: body}
+	var envelope struct {
+		Error   string ` + "`json:\"error\"`" + `
+		Message string ` + "`json:\"message\"`" + `
+	}
+	if json.Unmarshal(body, &envelope) == nil {
+		err.Message = envelope.Error
+		if err.Message == "" {
+			err.Message = envelope.Message
+		}
+	}
+	return err
+}
+
+// ErrAuthRequired is returned, wrapped, when a guarded route is called
+// without a credential for any of its guards.
+var ErrAuthRequired = errors.New("auth required")
+{{- if .HasFiles }}
+
+// File is a file part of a multipart request.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+{{- end }}
+
+// Client calls the routes of a Virtuous HTTP API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+{{- range $service := .Services }}
+	{{ $service.Field }} *{{ $service.Type }}
+{{- end }}
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests. It defaults to
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// New returns a client for the API served at baseURL, such as
+// "https://api.example.com".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+{{- range $service := .Services }}
+	c.{{ $service.Field }} = &{{ $service.Type }}{client: c}
+{{- end }}
+	return c
+}
+
+// CallOption configures a single request.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	auth           string
+	guardAuth      map[string]string
+	idempotencyKey string
+	header         http.Header
+}
+
+// WithAuth sets the credential for guarded routes. It is sent where the
+// route's guard reads it, with the guard's prefix, such as "Bearer ".
+func WithAuth(value string) CallOption {
+	return func(o *callOptions) {
+		o.auth = value
+	}
+}
+
+// WithGuardAuth sets the credential for the guard named guard, for routes
+// that accept several guards or require more than one.
+func WithGuardAuth(guard, value string) CallOption {
+	return func(o *callOptions) {
+		if o.guardAuth == nil {
+			o.guardAuth = map[string]string{}
+		}
+		o.guardAuth[guard] = value
+	}
+}
+
+// WithHeader adds a request header.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Add(key, value)
+	}
+}
+{{- if .HasIdempotent }}
+
+// WithIdempotencyKey sets the Idempotency-Key of an idempotent route. A
+// random key is generated when it is not set.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
+{{- end }}
+
+type authGuard struct {
+	name    string
+	in      string
+	param   string
+	prefix  string
+	generic bool
+}
+
+type queryItem struct {
+	key      string
+	value    any
+	optional bool
+}
+
+type bodyField struct {
+	wire   string
+	name   string
+	isFile bool
+}
+
+type routeCall struct {
+	method      string
+	path        string
+	accept      string
+	response    string
+	contentType string
+	body        any
+	hasBody     bool
+	bodyMode    string
+	bodyFields  []bodyField
+	query       []queryItem
+	auth        [][]authGuard
+	idempotent  bool
+}
+
+func (c *Client) do(ctx context.Context, req routeCall, out any, opts []CallOption) error {
+	var options callOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	query := url.Values{}
+	for _, item := range req.query {
+		appendQuery(query, item.key, item.value, item.optional)
+	}
+	var body io.Reader
+	contentType := req.contentType
+	if req.hasBody {
+		data, mediaType, err := encodeBody(req.body, req.bodyMode, req.bodyFields)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+		if mediaType != "" {
+			contentType = mediaType
+		}
+	}
+	var auth []appliedAuth
+	if len(req.auth) > 0 {
+		auth = resolveAuth(req.auth, options)
+		if auth == nil {
+			return fmt.Errorf("%s %s: %w", req.method, req.path, ErrAuthRequired)
+		}
+		for _, applied := range auth {
+			if applied.guard.in == "query" {
+				query.Set(applied.guard.param, applied.value)
+			}
+		}
+	}
+	endpoint := c.baseURL + req.path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	for key, values := range options.header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	httpReq.Header.Set("Accept", req.accept)
+	if body != nil && contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	for _, applied := range auth {
+		switch applied.guard.in {
+		case "header":
+			httpReq.Header.Set(applied.guard.param, applied.value)
+		case "cookie":
+			httpReq.AddCookie(&http.Cookie{Name: applied.guard.param, Value: applied.value})
+		}
+	}
+{{- if .HasIdempotent }}
+	if req.idempotent {
+		key := options.idempotencyKey
+		if key == "" {
+			key = newIdempotencyKey()
+		}
+		httpReq.Header.Set("Idempotency-Key", key)
+	}
+{{- end }}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp.StatusCode, data)
+	}
+	switch target := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		if req.response == "text" {
+			*target = string(data)
+			return nil
+		}
+	case *[]byte:
+		if req.response == "bytes" {
+			*target = data
+			return nil
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+type appliedAuth struct {
+	guard authGuard
+	value string
+}
+
+// resolveAuth returns the credentials of the first guard alternative that
+// has a value for every guard, or nil when none does.
+func resolveAuth(alternatives [][]authGuard, options callOptions) []appliedAuth {
+	for _, guards := range alternatives {
+		var applied []appliedAuth
+		for _, guard := range guards {
+			value := options.guardAuth[guard.name]
+			if value == "" && guard.generic {
+				value = options.auth
+			}
+			if value == "" {
+				applied = nil
+				break
+			}
+			if guard.prefix != "" {
+				value = guard.prefix + " " + value
+			}
+			applied = append(applied, appliedAuth{guard: guard, value: value})
+		}
+		if applied != nil {
+			return applied
+		}
+	}
+	return nil
+}
+
+func appendQuery(values url.Values, key string, value any, optional bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		if v.Len() == 0 && !optional {
+			values.Add(key, "")
+		}
+		for i := 0; i < v.Len(); i++ {
+			values.Add(key, formatValue(v.Index(i).Interface()))
+		}
+		return
+	}
+	if optional && v.IsZero() {
+		return
+	}
+	values.Add(key, formatValue(v.Interface()))
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+func pathValue(value any) string {
+	return url.PathEscape(formatValue(value))
+}
+
+func encodeBody(value any, mode string, fields []bodyField) ([]byte, string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, "", err
+	}
+	if mode == "json" && len(fields) == 0 {
+		return encoded, "", nil
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, "", err
+	}
+	if mode == "json" {
+		// Path and query fields of the request struct stay out of the body.
+		body := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if raw, ok := data[field.name]; ok {
+				body[field.wire] = raw
+			}
+		}
+		encoded, err := json.Marshal(body)
+		return encoded, "", err
+	}
+	if len(fields) == 0 {
+		for name := range data {
+			fields = append(fields, bodyField{wire: name, name: name})
+		}
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].name < fields[j].name
+		})
+	}
+	if mode == "form" {
+		form := url.Values{}
+		for _, field := range fields {
+			for _, item := range formItems(data[field.name]) {
+				form.Add(field.wire, item)
+			}
+		}
+		return []byte(form.Encode()), "", nil
+	}
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, field := range fields {
+		raw := data[field.name]
+		if field.isFile {
+			if err := writeFileParts(writer, field.wire, raw); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		for _, item := range formItems(raw) {
+			if err := writer.WriteField(field.wire, item); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// formItems flattens a JSON value into form values, one per array element.
+func formItems(raw json.RawMessage) []string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var items []json.RawMessage
+	if raw[0] != '[' || json.Unmarshal(raw, &items) != nil {
+		items = []json.RawMessage{raw}
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		var text string
+		if json.Unmarshal(item, &text) == nil {
+			out = append(out, text)
+			continue
+		}
+		if string(item) != "null" {
+			out = append(out, string(item))
+		}
+	}
+	return out
+}
+
+func writeFileParts(writer *multipart.Writer, name string, raw json.RawMessage) error {
+{{- if .HasFiles }}
+	var files []File
+	if len(raw) > 0 && raw[0] == '[' {
+		if err := json.Unmarshal(raw, &files); err != nil {
+			return err
+		}
+	} else if len(raw) > 0 && string(raw) != "null" {
+		var file File
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	for _, file := range files {
+		if file.Name == "" && len(file.Data) == 0 {
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", ` + "`form-data; name=\"`" + ` + escapeQuotes(name) + ` + "`\"; filename=\"`" + ` + escapeQuotes(file.Name) + ` + "`\"`" + `)
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(file.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", ` + "`\"`" + `, "\\\"").Replace(s)
+{{- else }}
+	return nil
+{{- end }}
+}
+{{- range $object := .Objects }}
+
+type {{ $object.Name }} struct {
+{{- range $field := $object.Fields }}
+{{- range $line := $field.Doc }}
+	// {{ $line }}
+{{- end }}
+	{{ $field.Name }} {{ $field.Type }} {{ $field.Tag }}
+{{- end }}
+}
+{{- end }}
+{{- range $params := .Params }}
+
+type {{ $params.Name }} struct {
+{{- range $field := $params.Fields }}
+{{- range $line := $field.Doc }}
+	// {{ $line }}
+{{- end }}
+	{{ $field.Name }} {{ $field.Type }}
+{{- end }}
+}
+{{- end }}
+{{- range $service := .Services }}
+
+// {{ $service.Type }} calls the {{ $service.Name }} routes.
+type {{ $service.Type }} struct {
+	client *Client
+}
+{{- range $method := $service.Methods }}
+{{ if $method.Summary }}
+// {{ $method.Name }} {{ $method.Summary }}
+{{- end }}
+func (s *{{ $service.Type }}) {{ $method.Name }}(ctx context.Context{{ range $arg := $method.Args }}, {{ $arg }}{{ end }}, opts ...CallOption) {{ if $method.ResponseType }}({{ $method.ResponseType }}, error){{ else }}error{{ end }} {
+	path := {{ printf "%q" $method.Path }}
+{{- range $param := $method.PathParams }}
+	path = strings.Replace(path, {{ printf "%q" (printf "{%s}" $param.Wire) }}, pathValue(pathParams.{{ $param.Name }}), 1)
+{{- end }}
+	req := {{ $method.Request }}
+{{- if $method.QueryParams }}
+	if query != nil {
+		req.query = []queryItem{
+{{- range $param := $method.QueryParams }}
+			{ {{- printf "%q" $param.Wire }}, query.{{ $param.Name }}, {{ $param.Optional }}},
+{{- end }}
+		}
+	}
+{{- end }}
+{{- if $method.ResponseType }}
+	var response {{ $method.ResponseType }}
+	err := s.client.do(ctx, req, &response, opts)
+	return response, err
+{{- else }}
+	return s.client.do(ctx, req, nil, opts)
+{{- end }}
+}
+{{- end }}
+{{- end }}
+`))
+
+type goClientSpec struct {
+	Package       string
+	Imports       []clientgen.GoImport
+	Services      []goClientService
+	Objects       []goClientObject
+	Params        []goClientObject
+	HasFiles      bool
+	HasIdempotent bool
+}
+
+type goClientService struct {
+	Name    string
+	Field   string
+	Type    string
+	Methods []goClientMethod
+}
+
+type goClientMethod struct {
+	Name         string
+	Summary      string
+	Path         string
+	Args         []string
+	PathParams   []goClientParam
+	QueryParams  []goClientParam
+	Request      string
+	ResponseType string
+}
+
+type goClientParam struct {
+	Name     string
+	Wire     string
+	Optional bool
+}
+
+type goClientObject struct {
+	Name   string
+	Fields []goClientField
+}
+
+type goClientField struct {
+	Name string
+	Type string
+	Tag  string
+	Doc  []string
+}
+
+// goClientRuntimeNames are the file-scope names of the generated runtime.
+var goClientRuntimeNames = []string{
+	"CallOption", "Client", "ErrAuthRequired", "Error", "File", "New", "Option",
+	"WithAuth", "WithGuardAuth", "WithHTTPClient", "WithHeader", "WithIdempotencyKey",
+	"appendQuery", "appliedAuth", "authGuard", "bodyField", "callOptions", "encodeBody",
+	"escapeQuotes", "formItems", "formatValue", "newError", "newIdempotencyKey",
+	"pathValue", "queryItem", "resolveAuth", "routeCall", "writeFileParts",
+}
+
+func goClientImports(hasIdempotent bool, hasFiles bool) []clientgen.GoImport {
+	imports := []clientgen.GoImport{
+		{Name: "bytes", Path: "bytes"},
+		{Name: "context", Path: "context"},
+		{Name: "encoding", Path: "encoding"},
+		{Name: "json", Path: "encoding/json"},
+		{Name: "errors", Path: "errors"},
+		{Name: "fmt", Path: "fmt"},
+		{Name: "io", Path: "io"},
+		{Name: "multipart", Path: "mime/multipart"},
+		{Name: "http", Path: "net/http"},
+		{Name: "url", Path: "net/url"},
+		{Name: "reflect", Path: "reflect"},
+		{Name: "sort", Path: "sort"},
+		{Name: "strconv", Path: "strconv"},
+		{Name: "strings", Path: "strings"},
+		{Name: "time", Path: "time"},
+	}
+	if hasFiles {
+		imports = append(imports, clientgen.GoImport{Name: "textproto", Path: "net/textproto"})
+	}
+	if hasIdempotent {
+		imports = append(imports, clientgen.GoImport{Name: "rand", Path: "crypto/rand"}, clientgen.GoImport{Name: "hex", Path: "encoding/hex"})
+	}
+	return imports
+}
+
+func buildGoClientSpec(routes []Route, overrides map[string]TypeOverride, opts GoClientOptions) (goClientSpec, error) {
+	naming := clientSchemaNaming{
+		PreferredName: func(route Route, t reflect.Type) string {
+			return preferredSchemaName(route.Meta, t)
+		},
+		CollisionNames: routeCollisionSchemaNames,
+	}
+	probe, err := buildClientSpec(routes, overrides)
+	if err != nil {
+		return goClientSpec{}, err
+	}
+	hasFiles := goClientHasFiles(probe.Services)
+	imports := goClientImports(probe.HasIdempotent, hasFiles)
+	var spec clientSpec
+	if opts.ImportTypes {
+		namer := clientgen.NewGoTypeNamer(imports, goClientReservedNames(probe.Services)...)
+		spec, err = buildClientSpecWith(routes, overrides, func(*schema.Registry) func(reflect.Type) string {
+			return namer.TypeOf
+		}, "[]byte", naming)
+		if err != nil {
+			return goClientSpec{}, err
+		}
+		if err := namer.Err(); err != nil {
+			return goClientSpec{}, err
+		}
+		spec.Objects = nil
+		imports = append(imports, namer.ImportsUsedBy(goClientTypes(spec.Services)...)...)
+	} else {
+		spec, err = buildClientSpecWith(routes, overrides, func(registry *schema.Registry) func(reflect.Type) string {
+			return registry.GoTypeOf
+		}, "[]byte", naming)
+		if err != nil {
+			return goClientSpec{}, err
+		}
+	}
+
+	used := map[string]struct{}{}
+	for _, name := range goClientReservedNames(spec.Services) {
+		used[name] = struct{}{}
+	}
+	typeNames := make(map[string]string, len(spec.Objects))
+	for _, object := range spec.Objects {
+		typeNames[object.Name] = clientgen.UniqueGoIdentifier(object.Name, used)
+	}
+	out := goClientSpec{
+		Package:       opts.GoPackageName(),
+		Imports:       imports,
+		HasFiles:      hasFiles,
+		HasIdempotent: spec.HasIdempotent,
+	}
+	for _, object := range spec.Objects {
+		goObject := goClientObject{Name: typeNames[object.Name]}
+		fieldNames := map[string]struct{}{}
+		for _, field := range object.Fields {
+			goObject.Fields = append(goObject.Fields, goClientFieldFor(field, typeNames, fieldNames))
+		}
+		out.Objects = append(out.Objects, goObject)
+	}
+	serviceFields := map[string]struct{}{}
+	for _, service := range spec.Services {
+		goService := goClientService{
+			Name:  service.Name,
+			Field: clientgen.UniqueGoIdentifier(service.Name, serviceFields),
+			Type:  goClientServiceType(service.Name),
+		}
+		methodNames := map[string]struct{}{}
+		for _, method := range service.Methods {
+			goMethod, params := goClientMethodFor(method, typeNames, used, methodNames)
+			goService.Methods = append(goService.Methods, goMethod)
+			out.Params = append(out.Params, params...)
+		}
+		out.Services = append(out.Services, goService)
+	}
+	return out, nil
+}
+
+func goClientMethodFor(method clientMethod, typeNames map[string]string, used, methodNames map[string]struct{}) (goClientMethod, []goClientObject) {
+	goMethod := goClientMethod{
+		Name:    clientgen.UniqueGoIdentifier(method.Name, methodNames),
+		Summary: strings.Join(strings.Fields(method.Summary), " "),
+		Path:    method.Path,
+	}
+	var params []goClientObject
+	if len(method.PathParams) > 0 {
+		object := goClientObject{Name: clientgen.UniqueGoIdentifier(method.PathParamsType, used)}
+		fieldNames := map[string]struct{}{}
+		for _, param := range method.PathParams {
+			name := clientgen.UniqueGoIdentifier(param.Name, fieldNames)
+			object.Fields = append(object.Fields, goClientField{Name: name, Type: clientgen.RenameGoTypes(param.Type, typeNames)})
+			goMethod.PathParams = append(goMethod.PathParams, goClientParam{Name: name, Wire: param.Name})
+		}
+		params = append(params, object)
+		goMethod.Args = append(goMethod.Args, "pathParams "+object.Name)
+	}
+	if method.HasBody {
+		requestType := clientgen.RenameGoTypes(method.RequestType, typeNames)
+		if method.BodyOptional && !strings.HasPrefix(requestType, "*") && requestType != "any" {
+			requestType = "*" + requestType
+		}
+		goMethod.Args = append(goMethod.Args, "request "+requestType)
+	}
+	if method.HasQuery {
+		object := goClientObject{Name: clientgen.UniqueGoIdentifier(method.QueryParamsType, used)}
+		fieldNames := map[string]struct{}{}
+		for _, param := range method.QueryParams {
+			name := clientgen.UniqueGoIdentifier(param.Name, fieldNames)
+			field := goClientField{Name: name, Type: clientgen.RenameGoTypes(param.Type, typeNames)}
+			if param.Doc != "" {
+				field.Doc = []string{param.Doc}
+			}
+			object.Fields = append(object.Fields, field)
+			goMethod.QueryParams = append(goMethod.QueryParams, goClientParam{Name: name, Wire: param.Name, Optional: param.Optional})
+		}
+		params = append(params, object)
+		goMethod.Args = append(goMethod.Args, "query *"+object.Name)
+	}
+	switch method.ResponseMode {
+	case "text":
+		goMethod.ResponseType = "string"
+	case "bytes":
+		goMethod.ResponseType = "[]byte"
+	case "json":
+		goMethod.ResponseType = clientgen.RenameGoTypes(method.ResponseType, typeNames)
+		if goMethod.ResponseType == "" {
+			goMethod.ResponseType = "json.RawMessage"
+		}
+	}
+	goMethod.Request = goClientRequest(method)
+	return goMethod, params
+}
+
+// goClientRequest renders the request literal passed to the runtime.
+func goClientRequest(method clientMethod) string {
+	var b strings.Builder
+	b.WriteString("routeCall{method: " + strconv.Quote(method.HTTPMethod) + ", path: path, accept: " + strconv.Quote(method.AcceptType) + ", response: " + strconv.Quote(method.ResponseMode))
+	if method.HasBody {
+		b.WriteString(", body: request, hasBody: ")
+		if method.BodyOptional {
+			b.WriteString("request != nil")
+		} else {
+			b.WriteString("true")
+		}
+		b.WriteString(", bodyMode: " + strconv.Quote(method.BodyMode))
+		if method.BodyMode == "json" {
+			b.WriteString(", contentType: " + strconv.Quote(method.RequestMedia))
+		} else if method.BodyMode == "form" {
+			b.WriteString(", contentType: " + strconv.Quote(MediaTypeFormURLEncoded))
+		}
+		if len(method.BodyFields) > 0 {
+			b.WriteString(", bodyFields: []bodyField{")
+			for i, field := range method.BodyFields {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString("{" + strconv.Quote(field.WireName) + ", " + strconv.Quote(field.Name) + ", " + strconv.FormatBool(field.IsFile) + "}")
+			}
+			b.WriteString("}")
+		}
+	}
+	if method.HasAuth {
+		b.WriteString(", auth: [][]authGuard{")
+		for i, req := range method.AuthReqs {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString("{")
+			for j, guard := range req.Guards {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				generic := len(method.AuthReqs) == 1 && len(req.Guards) == 1
+				b.WriteString("{" + strconv.Quote(guard.Spec.Name) + ", " + strconv.Quote(guard.Spec.In) + ", " + strconv.Quote(guard.Spec.Param) + ", " + strconv.Quote(guard.Spec.Prefix) + ", " + strconv.FormatBool(generic) + "}")
+			}
+			b.WriteString("}")
+		}
+		b.WriteString("}")
+	}
+	if method.Idempotent {
+		b.WriteString(", idempotent: true")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func goClientHasFiles(services []clientService) bool {
+	for _, service := range services {
+		for _, method := range service.Methods {
+			if method.HasBody && method.BodyMode == "multipart" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func goClientTypes(services []clientService) []string {
+	var types []string
+	for _, service := range services {
+		for _, method := range service.Methods {
+			types = append(types, method.RequestType, method.ResponseType)
+			for _, param := range method.PathParams {
+				types = append(types, param.Type)
+			}
+			for _, param := range method.QueryParams {
+				types = append(types, param.Type)
+			}
+		}
+	}
+	return types
+}
+
+func goClientReservedNames(services []clientService) []string {
+	names := append([]string(nil), goClientRuntimeNames...)
+	for _, service := range services {
+		names = append(names, goClientServiceType(service.Name))
+	}
+	return names
+}
+
+func goClientServiceType(service string) string {
+	return clientgen.GoIdentifier(service) + "Service"
+}
+
+func goClientFieldFor(field schema.Field, typeNames map[string]string, used map[string]struct{}) goClientField {
+	tag := field.Name
+	if field.Optional {
+		tag += ",omitempty"
+	}
+	goField := goClientField{
+		Name: clientgen.UniqueGoIdentifier(field.Name, used),
+		Type: clientgen.RenameGoTypes(field.Type, typeNames),
+		Tag:  "`json:" + strconv.Quote(tag) + "`",
+	}
+	if field.Doc != "" {
+		goField.Doc = append(goField.Doc, field.Doc)
+	}
+	if comment := clientgen.ConstraintComment(field.EnumType, field.Constraints); comment != "" {
+		goField.Doc = append(goField.Doc, comment)
+	}
+	return goField
+}
+
+// WriteClientGo writes a generated Go client to w.
+func (r *Router) WriteClientGo(w io.Writer) error {
+	body, err := r.clientGoBody()
+	if err != nil {
+		return err
+	}
+	hash := clientgen.HashBytes(body)
+	if err := clientgen.WriteArtifactHeader(w, "//", "Virtuous client hash", hash); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteClientGoFile writes a generated Go client to the file at path.
+func (r *Router) WriteClientGoFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WriteClientGo(f)
+}
+
+// WriteClientGoHash writes the hash of the stable Go client body to w.
+func (r *Router) WriteClientGoHash(w io.Writer) error {
+	body, err := r.clientGoBody()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, clientgen.HashBytes(body))
+	return err
+}
+
+// ServeClientGo writes a generated Go client as an HTTP response.
+func (r *Router) ServeClientGo(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/x-go; charset=utf-8")
+	if err := r.WriteClientGo(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SetGoClientOptions replaces the settings of generated Go clients.
+func (r *Router) SetGoClientOptions(opts GoClientOptions) {
+	r.goClient = opts
+}
+
+func (r *Router) clientGoBody() ([]byte, error) {
+	spec, err := buildGoClientSpec(r.Routes(), r.typeOverrides, r.goClient)
+	if err != nil {
+		return nil, err
+	}
+	body, err := clientgen.RenderTemplate(clientGoTemplate, spec)
+	if err != nil {
+		return nil, err
+	}
+	return clientgen.FormatGo(body)
+}
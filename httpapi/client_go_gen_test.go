@@ -0,0 +1,156 @@
+package httpapi
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func newGoClientRouter(t *testing.T) *Router {
+	t.Helper()
+	router := newLiveClientE2ERouter(t)
+	router.HandleTyped("POST /assets/upload", WrapFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		if header.Filename != "a.txt" || string(data) != "hello" || r.FormValue("client_id") != "c1" {
+			http.Error(w, "bad upload", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}, nil, NoResponse200{}, HandlerMeta{
+		Service:     "Assets",
+		Method:      "Upload",
+		RequestBody: MultipartBody(multipartUploadRequest{}),
+	}))
+	router.HandleTyped("GET /assets/text", WrapFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "plain")
+	}, nil, "", HandlerMeta{Service: "Assets", Method: "GetText"}), AuthAny(
+		headerValueGuard{name: "ApiKeyAuth", param: "X-API-Key", want: "k1"},
+		headerValueGuard{name: "TokenAuth", param: "X-Token", want: "t1"},
+	))
+	return router
+}
+
+func TestHTTPAPIGoClientRendersTypedServices(t *testing.T) {
+	var buf bytes.Buffer
+	router := newGoClientRouter(t)
+	if err := router.WriteClientGo(&buf); err != nil {
+		t.Fatalf("write go client: %v", err)
+	}
+	out := strings.Join(strings.Fields(buf.String()), " ")
+	for _, want := range []string{
+		"// Code generated by Virtuous ",
+		"package client",
+		"type File struct",
+		"func (s *ContractsService) Mixed(ctx context.Context, pathParams LiveMixedPathParams, request ContractsclientRuntimeMixedRequest, query *LiveMixedQuery, opts ...CallOption) (ContractsclientRuntimeResponse, error)",
+		"func (s *ContractsService) Optional(ctx context.Context, request *ContractsoptionalClientRequest, opts ...CallOption) (ContractsclientRuntimeResponse, error)",
+		"func (s *ContractsService) ClearCache(ctx context.Context, pathParams LiveClearCachePathParams, opts ...CallOption) error",
+		"func (s *AssetsService) GetText(ctx context.Context, opts ...CallOption) (string, error)",
+		`{"file", "file", true}, {"client_id", "clientID", false}`,
+		`{{"ApiKeyAuth", "header", "X-API-Key", "", false}}, {{"TokenAuth", "header", "X-Token", "", false}}`,
+		"When *time.Time `json:\"when\"`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("go client missing %q:\n%s", want, out)
+		}
+	}
+
+	router.SetGoClientOptions(GoClientOptions{ImportTypes: true})
+	if err := router.WriteClientGo(&buf); err == nil || !strings.Contains(err.Error(), "httpapi.clientRuntimeMixedRequest") {
+		t.Fatalf("expected unexported types to be rejected, got %v", err)
+	}
+}
+
+func TestHTTPAPIGoClientCallsServer(t *testing.T) {
+	goBin := filepath.Join(runtime.GOROOT(), "bin", "go")
+	if _, err := os.Stat(goBin); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	router := newGoClientRouter(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "client"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	files := map[string]string{
+		"go.mod": "module gentest\n\ngo 1.22\n",
+		"main.go": `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gentest/client"
+)
+
+func main() {
+	ctx := context.Background()
+	c := client.New(os.Args[1])
+	mixed, err := c.Contracts.Mixed(ctx,
+		client.LiveMixedPathParams{AccountID: "acct 1"},
+		client.ContractsclientRuntimeMixedRequest{AccountID: "body-leak", IDs: []string{"body-leak"}, Name: "mixed", Count: 3},
+		&client.LiveMixedQuery{ID: []string{"a", "b"}, Limit: 25},
+	)
+	fmt.Println(mixed.Accepted, err)
+	optional, err := c.Contracts.Optional(ctx, nil)
+	fmt.Println(optional.Accepted, err)
+	fmt.Println(c.Contracts.ClearCache(ctx, client.LiveClearCachePathParams{AccountID: "acct-2"}))
+	err = c.Contracts.ClearCache(ctx, client.LiveClearCachePathParams{AccountID: "other"})
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		fmt.Println(apiErr.Status, apiErr.Message)
+	}
+	fmt.Println(c.Assets.Upload(ctx, client.AssetsmultipartUploadRequest{
+		File:     client.File{Name: "a.txt", ContentType: "text/plain", Data: []byte("hello")},
+		ClientID: "c1",
+	}))
+	_, err = c.Assets.GetText(ctx)
+	fmt.Println(errors.Is(err, client.ErrAuthRequired))
+	text, err := c.Assets.GetText(ctx, client.WithGuardAuth("TokenAuth", "t1"))
+	fmt.Println(text, err)
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := router.WriteClientGoFile(filepath.Join(dir, "client", "client.gen.go")); err != nil {
+		t.Fatalf("write go client: %v", err)
+	}
+	cmd := exec.Command(goBin, "run", ".", server.URL)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+	want := "true <nil>\n" +
+		"false <nil>\n" +
+		"<nil>\n" +
+		"400 \n" +
+		"<nil>\n" +
+		"true\n" +
+		"plain <nil>\n"
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
@@ -11,14 +11,31 @@ import (
 var clientJSTemplate = template.Must(template.New("virtuous-js").Parse(`/**
  * @typedef {Object} AuthOptions
  * @property {string} [auth]
+{{- if .HasIdempotent }}
+ * @property {string} [idempotencyKey]
+{{- end }}
+ */
+{{- if .HasIdempotent }}
+
+/**
+ * @returns {string}
  */
+function newIdempotencyKey() {
+	const cryptoObj = globalThis.crypto
+	if (cryptoObj && cryptoObj.randomUUID) {
+		return cryptoObj.randomUUID()
+	}
+	return Date.now().toString(16) + "-" + Math.random().toString(16).slice(2) + Math.random().toString(16).slice(2)
+}
+{{- end }}
 
 // Type definitions
 {{- range $object := .Objects }}
 /**
  * @typedef {Object} {{ $object.Name }}
 {{- range $field := $object.Fields }}
- * @property{{- if $field.Nullable }} {{ printf "{%s|null}" $field.Type }}{{ else }} {{ printf "{%s}" $field.Type }}{{ end }} {{ if $field.Optional }}[{{ $field.Name }}]{{ else }}{{ $field.Name }}{{ end }}{{ if $field.Doc }} - {{ $field.Doc }}{{ end }}
+{{- $fieldType := $field.Type }}{{ if $field.EnumType }}{{ $fieldType = $field.EnumType }}{{ end }}
+ * @property{{- if $field.Nullable }} {{ printf "{%s|null}" $fieldType }}{{ else }} {{ printf "{%s}" $fieldType }}{{ end }} {{ if $field.Optional }}[{{ $field.Name }}]{{ else }}{{ $field.Name }}{{ end }}{{ if $field.Doc }} - {{ $field.Doc }}{{ end }}{{ if $field.Constraints }}{{ if not $field.Doc }} -{{ end }} ({{ range $i, $constraint := $field.Constraints }}{{ if $i }}; {{ end }}{{ $constraint }}{{ end }}){{ end }}
 {{- end }}
  */
 
@@ -62,6 +79,9 @@ export function createClient(basepath = "/") {
 {{- end }}
 {{- end }}
 				}
+{{- if $method.Idempotent }}
+				headers["Idempotency-Key"] = (options && options.idempotencyKey) || newIdempotencyKey()
+{{- end }}
 				let url = basepath + "{{ $method.Path }}"
 {{- if $method.PathParams }}
 				if (!pathParams) {
@@ -15,6 +15,7 @@ var clientPyTemplate = template.Must(template.New("virtuous-py").Parse(`"""Gener
 from dataclasses import dataclass, field, fields, is_dataclass
 from datetime import date as _date, datetime as _datetime
 from decimal import Decimal as _Decimal
+import gzip
 import http
 import json
 import types
@@ -33,6 +34,9 @@ class {{ $object.Name }}:
 {{- range $field := $object.Fields }}
 {{- if $field.Doc }}
     # {{ $field.Doc }}
+{{- end }}
+{{- if $field.Constraints }}
+    # {{ $field.Constraints }}
 {{- end }}
     {{ $field.Declaration }}
 {{- end }}
@@ -93,6 +97,9 @@ class {{ $service.ClassName }}:
 {{- end }}
         if not auth_applied:
             raise RuntimeError("auth not configured: {{ $method.AuthError }}")
+{{- end }}
+{{- if $method.IdempotencyParam }}
+        headers["Idempotency-Key"] = {{ $method.IdempotencyParam }} if {{ $method.IdempotencyParam }} is not None else str(uuid.uuid4())
 {{- end }}
         data = None
 {{- if $method.HasBody }}
@@ -130,15 +137,17 @@ def create_client(base_url: str = "/"{{- if .AuthParams }}, *{{- range $auth :=
 
 def _request(method: str, url: str, headers: dict[str, str], data: Any, response_mode: str, response_type: Any) -> Any:
     req = request.Request(url, data=data, method=method, headers=headers)
+    if not req.has_header("Accept-encoding"):
+        req.add_header("Accept-Encoding", "gzip")
     status = 0
     payload = b""
     try:
         with request.urlopen(req) as resp:
             status = resp.getcode()
-            payload = resp.read()
+            payload = _read_body(resp)
     except error.HTTPError as err:
         status = err.code
-        payload = err.read()
+        payload = _read_body(err)
     if response_mode == "text":
         text = payload.decode("utf-8") if payload else ""
         if status >= 400:
@@ -170,6 +179,15 @@ def _request(method: str, url: str, headers: dict[str, str], data: Any, response
     return _decode_value(response_type, decoded)
 
 
+def _read_body(resp: Any) -> bytes:
+    payload = resp.read()
+    headers = getattr(resp, "headers", None)
+    encoding = headers.get("Content-Encoding", "") if headers is not None else ""
+    if encoding.strip().lower() == "gzip":
+        payload = gzip.decompress(payload)
+    return payload
+
+
 def _apply_auth(url: str, headers: dict[str, str], location: str, param: str, prefix: str, value: str) -> str:
     auth_value = prefix + " " + value if prefix else value
     if location == "header":
@@ -438,6 +456,7 @@ type pythonClientMethod struct {
 	RequestType        string
 	ResponseType       string
 	ResponseDecodeType string
+	IdempotencyParam   string
 }
 
 type pythonClientDirectMethod struct {
@@ -459,6 +478,7 @@ type pythonClientField struct {
 	WireName    string
 	Declaration string
 	Doc         string
+	Constraints string
 }
 
 type pythonPathParam struct {
@@ -565,6 +585,7 @@ func pythonReservedModuleNames(services []clientService) map[string]struct{} {
 		"_Decimal",
 		"_multipart_file_value",
 		"_multipart_quote",
+		"_read_body",
 		"_request",
 		"_status_text",
 		"create_client",
@@ -576,6 +597,7 @@ func pythonReservedModuleNames(services []clientService) map[string]struct{} {
 		"get_args",
 		"get_origin",
 		"get_type_hints",
+		"gzip",
 		"http",
 		"id",
 		"int",
@@ -618,6 +640,7 @@ func pythonObjects(objects []clientObject, typeNames map[string]string) []python
 				WireName:    field.Name,
 				Declaration: pythonFieldDeclaration(name, field.Name, fieldType, field.Optional || field.Nullable),
 				Doc:         field.Doc,
+				Constraints: clientgen.PythonConstraintComment(field.EnumType, field.Constraints),
 			})
 		}
 		out = append(out, pyObject)
@@ -717,6 +740,9 @@ func pythonMethod(method clientMethod, typeNames map[string]string, methodNames
 		pyMethod.AuthReqs = append(pyMethod.AuthReqs, pyReq)
 	}
 	pyMethod.AuthError = pythonAuthError(pyMethod.AuthReqs)
+	if method.Idempotent {
+		pyMethod.IdempotencyParam = clientgen.UniquePythonIdentifier("idempotency_key", usedParams)
+	}
 	pyMethod.SignatureParams = pythonMethodSignatureParams(pyMethod)
 	pyMethod.CallArgs = pythonMethodCallArgs(pyMethod)
 	return pyMethod
@@ -797,6 +823,9 @@ func pythonMethodSignatureParams(method pythonClientMethod) string {
 	for _, auth := range method.AuthParams {
 		keywords = append(keywords, auth.ParamName+": Optional[str] = None")
 	}
+	if method.IdempotencyParam != "" {
+		keywords = append(keywords, method.IdempotencyParam+": Optional[str] = None")
+	}
 	var out strings.Builder
 	for _, part := range parts {
 		out.WriteString(", ")
@@ -826,6 +855,9 @@ func pythonMethodCallArgs(method pythonClientMethod) string {
 	for _, auth := range method.AuthParams {
 		args = append(args, auth.ParamName+"="+auth.ParamName)
 	}
+	if method.IdempotencyParam != "" {
+		args = append(args, method.IdempotencyParam+"="+method.IdempotencyParam)
+	}
 	return strings.Join(args, ", ")
 }
 
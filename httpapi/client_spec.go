@@ -10,9 +10,10 @@ import (
 )
 
 type clientSpec struct {
-	Services   []clientService
-	Objects    []clientObject
-	AuthParams []clientAuthGuard
+	Services      []clientService
+	Objects       []clientObject
+	AuthParams    []clientAuthGuard
+	HasIdempotent bool
 }
 
 type clientService struct {
@@ -47,6 +48,7 @@ type clientMethod struct {
 	AuthParams      []clientAuthGuard
 	RequestType     string
 	ResponseType    string
+	Idempotent      bool
 }
 
 type clientObject = schema.Object
@@ -267,6 +269,7 @@ func buildClientSpecWith(
 			ResponseMode:    responseMode,
 			RequestType:     requestType,
 			ResponseType:    responseType,
+			Idempotent:      route.Meta.Idempotent,
 		}
 		if len(route.Meta.Security.Alternatives) > 0 {
 			method.HasAuth = true
@@ -293,12 +296,24 @@ func buildClientSpecWith(
 	})
 
 	return clientSpec{
-		Services:   services,
-		Objects:    registry.ObjectsWith(typeFn),
-		AuthParams: clientSpecAuthParams(services),
+		Services:      services,
+		Objects:       registry.ObjectsWith(typeFn),
+		AuthParams:    clientSpecAuthParams(services),
+		HasIdempotent: clientSpecHasIdempotent(services),
 	}, nil
 }
 
+func clientSpecHasIdempotent(services []clientService) bool {
+	for _, service := range services {
+		for _, method := range service.Methods {
+			if method.Idempotent {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func clientSpecAuthParams(services []clientService) []clientAuthGuard {
 	seen := map[string]struct{}{"auth": {}}
 	var out []clientAuthGuard
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/swetjen/virtuous/internal/compression"
+)
+
+// CompressionOptions configures response compression. gzip is built in;
+// zstd and brotli are available when built with the virtuous_zstd or
+// virtuous_brotli tags.
+type CompressionOptions = compression.Options
+
+// WithCompression compresses responses of every route for clients that send
+// a matching Accept-Encoding. Routes can override it with
+// HandlerMeta.Compression.
+func WithCompression(opts CompressionOptions) RouterOption {
+	return func(o *RouterOptions) {
+		copyOpts := opts
+		o.Compression = &copyOpts
+	}
+}
+
+// compressHandler wraps h with the route's compression options, falling back
+// to the router's.
+func (r *Router) compressHandler(h http.Handler, typed TypedHandler) http.Handler {
+	opts := r.compression
+	if typed != nil && typed.Metadata().Compression != nil {
+		opts = typed.Metadata().Compression
+	}
+	if opts == nil || opts.Disabled {
+		return h
+	}
+	return compression.Handler(*opts, h)
+}
@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/idempotency"
+)
+
+func largeReportHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(strings.Repeat("row,", 1024)))
+}
+
+func TestCompressionRouterAndRouteOptions(t *testing.T) {
+	router := NewRouter(WithCompression(CompressionOptions{}))
+	router.HandleFunc("GET /api/v1/reports", largeReportHandler)
+	router.HandleTyped("GET /api/v1/exports", WrapFunc(largeReportHandler, nil, "", HandlerMeta{
+		Service:     "Reports",
+		Method:      "Export",
+		Compression: &CompressionOptions{Disabled: true},
+	}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/api/v1/reports")
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Body.Len() >= 4096 {
+		t.Fatalf("expected gzip response, got %v (%d bytes)", rec.Header(), rec.Body.Len())
+	}
+	rec = get("/api/v1/exports")
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 4096 {
+		t.Fatalf("expected route override to disable compression, got %v (%d bytes)", rec.Header(), rec.Body.Len())
+	}
+}
+
+func TestCompressionReplaysIdempotentResponses(t *testing.T) {
+	router := NewRouter(WithCompression(CompressionOptions{}), WithIdempotencyStore(idempotency.NewMemoryStore(0)))
+	router.HandleTyped("POST /api/v1/reports", WrapFunc(largeReportHandler, typedHandlerRequest{}, "", HandlerMeta{
+		Service:    "Reports",
+		Method:     "Create",
+		Idempotent: true,
+	}))
+
+	post := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/reports", strings.NewReader(`{"name":"Ada"}`))
+		req.Header.Set(idempotency.Header, "report-1")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	body := func(rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		if rec.Header().Get("Content-Encoding") != "gzip" {
+			return rec.Body.String()
+		}
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v (headers %v)", err, rec.Header())
+		}
+		plain, _ := io.ReadAll(zr)
+		return string(plain)
+	}
+	want := strings.Repeat("row,", 1024)
+
+	first := post("gzip")
+	if first.Header().Get("Content-Encoding") != "gzip" || body(first) != want {
+		t.Fatalf("expected gzip response, got %v", first.Header())
+	}
+	replayed := post("gzip")
+	if replayed.Header().Get(idempotency.ReplayedHeader) != "true" || replayed.Header().Get("Content-Encoding") != "gzip" || body(replayed) != want {
+		t.Fatalf("expected compressed replay, got %v", replayed.Header())
+	}
+	plain := post("")
+	if plain.Header().Get(idempotency.ReplayedHeader) != "true" || plain.Header().Get("Content-Encoding") != "" || body(plain) != want {
+		t.Fatalf("expected uncompressed replay, got %v", plain.Header())
+	}
+}
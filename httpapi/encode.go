@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 
 	"github.com/swetjen/virtuous/internal/jsondecode"
 	"github.com/swetjen/virtuous/internal/jsonlimit"
+	"github.com/swetjen/virtuous/schema"
 )
 
 var ErrRequestBodyTooLarge = jsonlimit.ErrBodyTooLarge
@@ -44,6 +46,12 @@ func decodeWithMaxBytes[T any](r *http.Request, maxBytes int64, opts jsondecode.
 	if err := jsondecode.Decode(body, &v, opts); err != nil {
 		return v, fmt.Errorf("decode json: %w", err)
 	}
+	// A type whose tags do not compile is not validated; handle logs why
+	// when the route is registered.
+	validator, _ := schema.ValidatorFor(reflect.TypeFor[T]())
+	if err := validator.Validate(&v); err != nil {
+		return v, err
+	}
 	return v, nil
 }
 
@@ -1,7 +1,9 @@
 package httpapi
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -71,3 +73,91 @@ func TestDecodeStrictRejectsUnknownFieldsDuplicateKeysAndTrailingTokens(t *testi
 		})
 	}
 }
+
+type validatedCreateRequest struct {
+	Name  string `json:"name" minLength:"2"`
+	Kind  string `json:"kind,omitempty" enum:"a,b" default:"a"`
+	Email string `json:"email,omitempty" format:"email"`
+}
+
+func TestDecodeEnforcesConstraintTags(t *testing.T) {
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"x","kind":"c","email":"bad"}`))
+	_, err := Decode[validatedCreateRequest](req)
+	if !IsValidationError(err) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Violations) != 3 {
+		t.Fatalf("expected 3 violations, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	if !EncodeValidationError(rec, req, err) {
+		t.Fatalf("expected validation error to be encoded")
+	}
+	if rec.Code != 422 {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	var body ValidationErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Violations[0].Field != "name" || body.Violations[0].Code != "minLength" {
+		t.Fatalf("unexpected violations: %+v", body.Violations)
+	}
+
+	valid := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"ok"}`))
+	got, err := DecodeStrict[validatedCreateRequest](valid)
+	if err != nil {
+		t.Fatalf("expected valid request, got %v", err)
+	}
+	if got.Kind != "a" {
+		t.Fatalf("kind default = %q, want a", got.Kind)
+	}
+	if EncodeValidationError(httptest.NewRecorder(), valid, errors.New("other")) {
+		t.Fatalf("non-validation errors should not be encoded")
+	}
+}
+
+type docOnlyTagsRequest struct {
+	Level int `json:"level" enum:"low,high"`
+}
+
+func TestDecodeSkipsValidationWhenTagsDoNotParse(t *testing.T) {
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"level":7}`))
+	got, err := Decode[docOnlyTagsRequest](req)
+	if err != nil || got.Level != 7 {
+		t.Fatalf("expected the body to decode unvalidated, got %+v %v", got, err)
+	}
+}
+
+func TestEncodeDecodeErrorPicksStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		max  int64
+		want int
+	}{
+		{name: "violations", body: `{"name":"x"}`, max: 1 << 20, want: http.StatusUnprocessableEntity},
+		{name: "malformed", body: `{"name":`, max: 1 << 20, want: http.StatusBadRequest},
+		{name: "too large", body: `{"name":"` + strings.Repeat("x", 64) + `"}`, max: 16, want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/items", strings.NewReader(tt.body))
+			_, err := DecodeWithMaxBytes[validatedCreateRequest](req, tt.max)
+			if got := DecodeStatus(err); got != tt.want {
+				t.Fatalf("DecodeStatus = %d, want %d (%v)", got, tt.want, err)
+			}
+			rec := httptest.NewRecorder()
+			EncodeDecodeError(rec, req, err)
+			var body ValidationErrorResponse
+			if rec.Code != tt.want || json.NewDecoder(rec.Body).Decode(&body) != nil || body.Error == "" {
+				t.Fatalf("unexpected response %d %+v", rec.Code, body)
+			}
+			if (tt.want == http.StatusUnprocessableEntity) != (len(body.Violations) > 0) {
+				t.Fatalf("unexpected violations %+v", body.Violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/swetjen/virtuous/idempotency"
+	"github.com/swetjen/virtuous/schema"
+)
+
+func idempotentHandler(store idempotency.Store, h http.Handler) http.Handler {
+	return idempotency.Middleware(store, idempotency.Options{
+		Reject: func(w http.ResponseWriter, r *http.Request, err *idempotency.Error) {
+			Encode(w, r, err.Status, map[string]string{"error": err.Message, "code": err.Code})
+		},
+	})(h)
+}
+
+func applyIdempotencyDocs(op *openAPIOperation) {
+	maxLength := idempotency.MaxKeyLength
+	op.Parameters = append(op.Parameters, openAPIParameter{
+		Name:        idempotency.Header,
+		In:          ParamInHeader,
+		Description: "Unique key for safely retrying this request. Repeats with the same key and body replay the first response.",
+		Schema:      schema.OpenAPISchema{Type: "string", MaxLength: &maxLength},
+	})
+	for status, description := range map[string]string{
+		"400": "Invalid idempotency key",
+		"409": "A request with this idempotency key is in progress",
+		"422": "Idempotency key reused with a different request",
+	} {
+		if _, ok := op.Responses[status]; !ok {
+			op.Responses[status] = openAPIResponse{Description: description}
+		}
+	}
+}
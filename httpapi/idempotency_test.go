@@ -0,0 +1,106 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/idempotency"
+)
+
+func newIdempotentRouter(calls *int) *Router {
+	router := NewRouter(WithIdempotencyStore(idempotency.NewMemoryStore(0)))
+	router.HandleTyped("POST /api/v1/payments", WrapFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		req, _ := Decode[typedHandlerRequest](r)
+		Encode(w, r, http.StatusCreated, typedHandlerResponse{Name: req.Name})
+	}, typedHandlerRequest{}, typedHandlerResponse{}, HandlerMeta{Service: "Payments", Method: "Create", Idempotent: true}))
+	router.HandleTyped("GET /api/v1/payments", WrapFunc(typedHandlerFunc, nil, []typedHandlerResponse{}, HandlerMeta{Service: "Payments", Method: "List"}))
+	return router
+}
+
+func TestIdempotentRouteReplaysAndRejectsReuse(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", strings.NewReader(body))
+		req.Header.Set(idempotency.Header, "pay-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := post(`{"name":"Ada"}`)
+	second := post(`{"name":"Ada"}`)
+	if calls != 1 || second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected replay without a second call, calls=%d %d %s", calls, second.Code, second.Body.String())
+	}
+	reused := post(`{"name":"Grace"}`)
+	if reused.Code != http.StatusUnprocessableEntity || !strings.Contains(reused.Body.String(), `"code":"idempotency_key_reused"`) {
+		t.Fatalf("expected 422 JSON error, got %d %s", reused.Code, reused.Body.String())
+	}
+}
+
+func TestIdempotentRouteRequiresStore(t *testing.T) {
+	defer func() {
+		if got := recover(); got == nil {
+			t.Fatalf("expected registration to panic without a store")
+		}
+	}()
+	NewRouter().HandleTyped("POST /api/v1/payments", WrapFunc(typedHandlerFunc, typedHandlerRequest{}, typedHandlerResponse{}, HandlerMeta{Idempotent: true}))
+}
+
+func TestIdempotentRouteDocsAndClients(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls)
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []openAPIParameter         `json:"parameters"`
+			Responses  map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	op := doc.Paths["/api/v1/payments"]["post"]
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != idempotency.Header || op.Parameters[0].In != ParamInHeader {
+		t.Fatalf("expected Idempotency-Key header, got %+v", op.Parameters)
+	}
+	if _, ok := op.Responses["409"]; !ok {
+		t.Fatalf("expected 409 response, got %v", op.Responses)
+	}
+	if list := doc.Paths["/api/v1/payments"]["get"]; len(list.Parameters) != 0 {
+		t.Fatalf("expected no header on non-idempotent route, got %+v", list.Parameters)
+	}
+
+	var ts, js, py, rq bytes.Buffer
+	for _, write := range []func() error{
+		func() error { return router.WriteClientTS(&ts) },
+		func() error { return router.WriteClientJS(&js) },
+		func() error { return router.WriteClientPY(&py) },
+		func() error { return router.WriteReactQueryTS(&rq) },
+	} {
+		if err := write(); err != nil {
+			t.Fatalf("write client: %v", err)
+		}
+	}
+	for _, out := range []string{ts.String(), rq.String()} {
+		if !strings.Contains(out, "idempotencyKey?: string") || strings.Count(out, "idempotent: true,") != 1 {
+			t.Fatalf("expected TS idempotency support on one method:\n%s", out)
+		}
+	}
+	if !strings.Contains(js.String(), `headers["Idempotency-Key"] = (options && options.idempotencyKey) || newIdempotencyKey()`) {
+		t.Fatalf("expected JS client to send a key:\n%s", js.String())
+	}
+	if !strings.Contains(py.String(), "idempotency_key: Optional[str] = None") ||
+		!strings.Contains(py.String(), `headers["Idempotency-Key"] = idempotency_key if idempotency_key is not None else str(uuid.uuid4())`) {
+		t.Fatalf("expected Python client to send a key:\n%s", py.String())
+	}
+}
@@ -135,6 +135,9 @@ func (r *Router) OpenAPI() ([]byte, error) {
 		for _, param := range route.Meta.Params {
 			op.Parameters = append(op.Parameters, openAPIParameterForSpec(gen, param))
 		}
+		if route.Meta.Idempotent {
+			applyIdempotencyDocs(op)
+		}
 
 		if _, ok := paths[route.Path]; !ok {
 			paths[route.Path] = make(map[string]*openAPIOperation)
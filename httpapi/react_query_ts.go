@@ -13,6 +13,7 @@ import (
 type reactQueryTSSpec struct {
 	HasQueries     bool
 	HasMutations   bool
+	HasIdempotent  bool
 	AuthParams     []clientAuthGuard
 	ClientServices []clientService
 	Objects        []clientObject
@@ -65,6 +66,9 @@ import { {{ if .HasMutations }}useMutation{{ end }}{{ if and .HasQueries .HasMut
 export type RequestOptions = {
 	signal?: AbortSignal
 	auth?: RequestAuth
+{{- if .HasIdempotent }}
+	idempotencyKey?: string
+{{- end }}
 }
 
 export type RequestAuth = {
@@ -94,7 +98,10 @@ export class AuthNotReadyError extends Error {
 {{range $object := .Objects}}
 export interface {{$object.Name}} {
 {{- range $field := $object.Fields}}
-	{{$field.Name}}{{if $field.Optional}}?{{end}}: {{$field.Type}}{{if $field.Nullable}} | null{{end}};
+{{- if or $field.Doc $field.Constraints }}
+	/**{{ if $field.Doc }} {{ $field.Doc }}{{ end }}{{ range $constraint := $field.Constraints }} @{{ $constraint }}{{ end }} */
+{{- end }}
+	{{$field.Name}}{{if $field.Optional}}?{{end}}: {{if $field.EnumType}}{{$field.EnumType}}{{else}}{{$field.Type}}{{end}}{{if $field.Nullable}} | null{{end}};
 {{- end}}
 }
 {{end}}
@@ -173,6 +180,9 @@ export function createClient(options: ClientOptions = {}) {
 {{- end }}
 {{- if $method.HasCookieAuth }}
 					cookie: true,
+{{- end }}
+{{- if $method.Idempotent }}
+					idempotent: true,
 {{- end }}
 					options,
 				})
@@ -198,6 +208,9 @@ type RequestConfig = {
 	query?: QueryItem[]
 	auth?: AuthGuard[][]
 	cookie?: boolean
+{{- if .HasIdempotent }}
+	idempotent?: boolean
+{{- end }}
 	options?: RequestOptions
 }
 
@@ -235,6 +248,11 @@ async function _request<T>(clientOptions: ClientOptions, config: RequestConfig):
 			throw new AuthNotReadyError(config.method + " " + config.path)
 		}
 	}
+{{- if .HasIdempotent }}
+	if (config.idempotent) {
+		headers["Idempotency-Key"] = config.options?.idempotencyKey || _newIdempotencyKey()
+	}
+{{- end }}
 	const init: RequestInit = { method: config.method, headers, signal: config.options?.signal }
 	if (config.cookie) {
 		init.credentials = "same-origin"
@@ -247,7 +265,15 @@ async function _request<T>(clientOptions: ClientOptions, config: RequestConfig):
 	return await _decodeResponse<T>(response, config.response)
 }
 
-async function _resolveAuth(provider: AuthProvider | undefined): Promise<RequestAuth | null | undefined> {
+{{ if .HasIdempotent }}function _newIdempotencyKey(): string {
+	const cryptoObj = (globalThis as { crypto?: { randomUUID?: () => string } }).crypto
+	if (cryptoObj && cryptoObj.randomUUID) {
+		return cryptoObj.randomUUID()
+	}
+	return Date.now().toString(16) + "-" + Math.random().toString(16).slice(2) + Math.random().toString(16).slice(2)
+}
+
+{{ end }}async function _resolveAuth(provider: AuthProvider | undefined): Promise<RequestAuth | null | undefined> {
 	return typeof provider === "function" ? await provider() : provider
 }
 
@@ -489,6 +515,7 @@ func buildReactQueryTSSpec(spec clientSpec) reactQueryTSSpec {
 		AuthParams:     reactQueryAuthParams(spec),
 		ClientServices: spec.Services,
 		Objects:        spec.Objects,
+		HasIdempotent:  spec.HasIdempotent,
 	}
 	nameCounts := reactQueryMethodNameCounts(spec)
 	for _, service := range spec.Services {
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/swetjen/virtuous/internal/debugconsole"
+	"github.com/swetjen/virtuous/internal/incident"
+)
+
+// PanicResponse is the body written for a recovered panic when the router
+// uses WithPanicRecovery.
+type PanicResponse struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	IncidentID string `json:"incidentId"`
+}
+
+// recoverHandler answers handler panics with a 500 PanicResponse and logs the
+// panic and stack with the incident ID, which the debug console prints too.
+func (r *Router) recoverHandler(pattern string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tracked := &startedWriter{ResponseWriter: w}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			incidentID := incident.NewID()
+			debugconsole.SetIncidentID(req.Context(), incidentID)
+			logger := r.logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.Error("httpapi handler panicked",
+				"pattern", pattern,
+				"incident_id", incidentID,
+				"panic", fmt.Sprint(rec),
+				"stack", string(debug.Stack()),
+			)
+			if !tracked.started {
+				Encode(w, req, http.StatusInternalServerError, PanicResponse{
+					Error:      "internal server error",
+					Code:       "internal",
+					IncidentID: incidentID,
+				})
+			}
+		}()
+		h.ServeHTTP(tracked, req)
+	})
+}
+
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) WriteHeader(status int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *startedWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *startedWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.started = true
+		flusher.Flush()
+	}
+}
+
+func (w *startedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func panicHandler(http.ResponseWriter, *http.Request) {
+	panic("payment exploded")
+}
+
+func TestPanicRecoveryWritesIncident(t *testing.T) {
+	router := NewRouter(WithPanicRecovery())
+	router.HandleFunc("GET /api/v1/payments", panicHandler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/payments", nil))
+
+	var body PanicResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v (%s)", err, rec.Body.String())
+	}
+	if rec.Code != http.StatusInternalServerError || body.Code != "internal" || body.IncidentID == "" {
+		t.Fatalf("expected 500 with incident ID, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPanicRecoveryLinksIncidentToLogsAndDebugConsole(t *testing.T) {
+	var console, logs bytes.Buffer
+	router := NewRouter(WithPanicRecovery(), WithDebugConsoleWriter(&console))
+	router.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	router.HandleFunc("GET /api/v1/payments", panicHandler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/payments", nil))
+
+	var body PanicResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.IncidentID == "" {
+		t.Fatalf("decode: %v (%s)", err, rec.Body.String())
+	}
+	if !strings.Contains(console.String(), " incident="+body.IncidentID+"\n") {
+		t.Fatalf("expected the debug console line to carry the incident, got %q", console.String())
+	}
+	if !strings.Contains(logs.String(), "incident_id="+body.IncidentID) {
+		t.Fatalf("expected the log to carry the incident, got %q", logs.String())
+	}
+}
+
+func TestPanicRecoveryIsOptIn(t *testing.T) {
+	defer func() {
+		if got := recover(); got != "payment exploded" {
+			t.Fatalf("expected panic to propagate, got %v", got)
+		}
+	}()
+	router := NewRouter()
+	router.HandleFunc("GET /api/v1/payments", panicHandler)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/payments", nil))
+}
@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/swetjen/virtuous/idempotency"
 	"github.com/swetjen/virtuous/internal/adminui"
 	"github.com/swetjen/virtuous/internal/clientgen"
 	"github.com/swetjen/virtuous/internal/debugconsole"
+	"github.com/swetjen/virtuous/schema"
 )
 
 // PythonClientSigning configures embedded signatures for generated Python clients.
@@ -27,6 +29,12 @@ type HandlerMeta struct {
 	RequestBody *RequestBodySpec
 	Responses   []ResponseSpec
 	Security    SecuritySpec
+	// Idempotent makes the route honor the Idempotency-Key header using the
+	// router's store (see WithIdempotencyStore).
+	Idempotent bool
+	// Compression overrides the router's compression options for the route.
+	// Set Disabled to turn compression off for it.
+	Compression *CompressionOptions
 }
 
 // ParamSpec describes an explicit operation parameter.
@@ -107,6 +115,10 @@ type Router struct {
 	debugConsole   *debugconsole.Logger
 	debugHandler   http.Handler
 	pythonSigning  *clientgen.PythonClientSigning
+	goClient       GoClientOptions
+	idempotency    idempotency.Store
+	recoverPanics  bool
+	compression    *CompressionOptions
 }
 
 // RouterOptions configures a Router.
@@ -114,6 +126,9 @@ type RouterOptions struct {
 	DebugConsole       bool
 	DebugConsoleWriter io.Writer
 	PythonSigning      *clientgen.PythonClientSigning
+	IdempotencyStore   idempotency.Store
+	PanicRecovery      bool
+	Compression        *CompressionOptions
 }
 
 // RouterOption mutates RouterOptions.
@@ -142,6 +157,21 @@ func WithPythonClientSigning(signing PythonClientSigning) RouterOption {
 	}
 }
 
+// WithIdempotencyStore sets the store used by routes marked Idempotent.
+func WithIdempotencyStore(store idempotency.Store) RouterOption {
+	return func(o *RouterOptions) {
+		o.IdempotencyStore = store
+	}
+}
+
+// WithPanicRecovery answers handler panics with a 500 JSON body carrying an
+// incident ID and logs the panic and stack with the same ID.
+func WithPanicRecovery() RouterOption {
+	return func(o *RouterOptions) {
+		o.PanicRecovery = true
+	}
+}
+
 // NewEd25519PythonClientSigning builds a Python client signing configuration
 // from caller-provided Ed25519 root and artifact private keys.
 func NewEd25519PythonClientSigning(rootKeyID string, rootPrivateKey ed25519.PrivateKey, artifactKeyID string, artifactPrivateKey ed25519.PrivateKey) (PythonClientSigning, error) {
@@ -155,9 +185,12 @@ func NewRouter(opts ...RouterOption) *Router {
 		opt(&config)
 	}
 	router := &Router{
-		mux:    http.NewServeMux(),
-		logger: slog.Default(),
-		events: adminui.NewEventFeed(600),
+		mux:           http.NewServeMux(),
+		logger:        slog.Default(),
+		events:        adminui.NewEventFeed(600),
+		idempotency:   config.IdempotencyStore,
+		recoverPanics: config.PanicRecovery,
+		compression:   config.Compression,
 	}
 	if config.PythonSigning != nil {
 		copySigning := *config.PythonSigning
@@ -260,8 +293,25 @@ func (r *Router) handle(pattern string, h http.Handler, typed TypedHandler, guar
 	if !ok && r.logger != nil {
 		r.logger.Warn("virtuous: pattern missing HTTP method prefix; skipping docs/client registration", "pattern", pattern)
 	}
+	if typed != nil && typed.Metadata().Idempotent {
+		if r.idempotency == nil {
+			panic("httpapi: idempotent route " + pattern + " requires WithIdempotencyStore")
+		}
+		h = idempotentHandler(r.idempotency, h)
+	}
 	h = wrapWithGuards(h, guards)
+	if r.recoverPanics {
+		h = r.recoverHandler(pattern, h)
+	}
+	h = r.compressHandler(h, typed)
 	r.mux.Handle(pattern, h)
+	if typed != nil && r.logger != nil {
+		if reqInfo := resolveRequestType(typed.RequestType()); reqInfo.Present {
+			if _, err := schema.ValidatorFor(reqInfo.Type); err != nil {
+				r.logger.Warn("virtuous: request validation disabled", "pattern", pattern, "error", err)
+			}
+		}
+	}
 
 	if !ok || typed == nil {
 		return
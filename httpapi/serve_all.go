@@ -8,6 +8,7 @@ type ServeAllDocsOptions struct {
 	ClientTSPath     string
 	ClientPYPath     string
 	ReactQueryTSPath string
+	ClientGoPath     string
 }
 
 // ServeAllDocsOpt mutates ServeAllDocsOptions.
@@ -58,6 +59,15 @@ func WithReactQueryTSPath(path string) ServeAllDocsOpt {
 	}
 }
 
+// WithClientGoPath enables and overrides the Go client route path.
+func WithClientGoPath(path string) ServeAllDocsOpt {
+	return func(o *ServeAllDocsOptions) {
+		if path != "" {
+			o.ClientGoPath = ensureLeadingSlash(path)
+		}
+	}
+}
+
 // WithoutDocs disables docs/OpenAPI route registration.
 func WithoutDocs() ServeAllDocsOpt {
 	return func(o *ServeAllDocsOptions) {
@@ -95,4 +105,8 @@ func (r *Router) ServeAllDocs(opts ...ServeAllDocsOpt) {
 		r.HandleFunc("GET "+config.ReactQueryTSPath, r.ServeReactQueryTS)
 		r.logger.Info("react query ts client available", "path", config.ReactQueryTSPath)
 	}
+	if config.ClientGoPath != "" {
+		r.HandleFunc("GET "+config.ClientGoPath, r.ServeClientGo)
+		r.logger.Info("client go available", "path", config.ClientGoPath)
+	}
 }
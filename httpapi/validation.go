@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/swetjen/virtuous/schema"
+)
+
+// ValidationError reports request constraint violations found by Decode.
+type ValidationError = schema.ValidationError
+
+// Violation describes one request field that failed a constraint tag.
+type Violation = schema.Violation
+
+// ValidationErrorResponse is the body written by EncodeValidationError and
+// EncodeDecodeError. Violations is only set for 422 responses.
+type ValidationErrorResponse struct {
+	Error      string      `json:"error"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// IsValidationError reports whether err carries request constraint violations
+// returned by Decode.
+func IsValidationError(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr)
+}
+
+// EncodeValidationError writes a 422 ValidationErrorResponse when err carries
+// request constraint violations and reports whether it did.
+func EncodeValidationError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+	Encode(w, r, http.StatusUnprocessableEntity, ValidationErrorResponse{
+		Error:      "request validation failed",
+		Violations: validationErr.Violations,
+	})
+	return true
+}
+
+// DecodeStatus returns the status to reply with for an error returned by
+// Decode: 422 for constraint violations, 413 for oversized bodies, and 400
+// otherwise.
+func DecodeStatus(err error) int {
+	switch {
+	case IsValidationError(err):
+		return http.StatusUnprocessableEntity
+	case IsRequestBodyTooLarge(err):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// EncodeDecodeError writes err, as returned by Decode, with its DecodeStatus.
+// Constraint violations are written as by EncodeValidationError.
+func EncodeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if EncodeValidationError(w, r, err) {
+		return
+	}
+	status := DecodeStatus(err)
+	message := "invalid request body"
+	if status == http.StatusRequestEntityTooLarge {
+		message = "request body too large"
+	}
+	Encode(w, r, status, ValidationErrorResponse{Error: message})
+}
@@ -34,6 +34,7 @@ type Module = httpapi.Module
 
 type ServeAllDocsOptions = httpapi.ServeAllDocsOptions
 type ServeAllDocsOpt = httpapi.ServeAllDocsOpt
+type GoClientOptions = httpapi.GoClientOptions
 
 type OpenAPIOptions = httpapi.OpenAPIOptions
 type OpenAPIServer = httpapi.OpenAPIServer
@@ -200,6 +201,10 @@ func WithClientPYPath(path string) ServeAllDocsOpt {
 	return httpapi.WithClientPYPath(path)
 }
 
+func WithClientGoPath(path string) ServeAllDocsOpt {
+	return httpapi.WithClientGoPath(path)
+}
+
 func WithoutDocs() ServeAllDocsOpt {
 	return httpapi.WithoutDocs()
 }
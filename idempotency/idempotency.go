@@ -0,0 +1,258 @@
+// Package idempotency replays stored responses for requests that repeat an
+// Idempotency-Key header, so clients can safely retry mutations.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/swetjen/virtuous/guard"
+)
+
+const (
+	// Header is the request header carrying the client-chosen key.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set to "true" on replayed responses.
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength is the longest accepted key.
+	MaxKeyLength = 255
+	// DefaultMaxBodyBytes caps the request body read for fingerprinting.
+	DefaultMaxBodyBytes int64 = 1 << 20
+)
+
+// Error codes reported through Options.Reject.
+const (
+	CodeInvalidKey = "idempotency_key_invalid"
+	CodeKeyReused  = "idempotency_key_reused"
+	CodeInProgress = "idempotency_in_progress"
+)
+
+// Record is the stored state of one key. Done is false while the first
+// request is still running.
+type Record struct {
+	Fingerprint string
+	Done        bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// Store persists idempotency records. Implementations decide how long
+// records are kept.
+type Store interface {
+	// Begin atomically claims key with an in-flight record for fingerprint.
+	// When key already has a record, Begin returns it with claimed false.
+	Begin(ctx context.Context, key, fingerprint string) (existing Record, claimed bool, err error)
+	// Complete stores the final response for a key claimed by Begin.
+	Complete(ctx context.Context, key string, record Record) error
+	// Release drops a claimed key without a response so it can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Error describes a rejected request.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Options configures Middleware.
+type Options struct {
+	// MaxBodyBytes caps the body read for fingerprinting. Larger requests
+	// are passed through without idempotency. Defaults to 1 MiB.
+	MaxBodyBytes int64
+	// Reject writes rejected requests. Defaults to a plain-text response.
+	Reject func(w http.ResponseWriter, r *http.Request, err *Error)
+}
+
+// Middleware honors Idempotency-Key on the wrapped handler. The first
+// response for a key is stored unless it is a 5xx, and later requests with
+// the same key and body get it replayed. Reusing a key with a different body
+// is rejected with 422, and a key whose first request is still running with
+// 409. Requests without the header are served normally.
+//
+// Keys are scoped to the method, path, and the guard principal on the
+// request context, so run Middleware inside guards.
+func Middleware(store Store, opts Options) func(http.Handler) http.Handler {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if opts.Reject == nil {
+		opts.Reject = func(w http.ResponseWriter, _ *http.Request, err *Error) {
+			http.Error(w, err.Message, err.Status)
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get(Header))
+			if key == "" || store == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > MaxKeyLength {
+				opts.Reject(w, r, &Error{Status: http.StatusBadRequest, Code: CodeInvalidKey, Message: "idempotency key is longer than 255 characters"})
+				return
+			}
+
+			body, complete, err := readBody(r, opts.MaxBodyBytes)
+			if err != nil || !complete {
+				next.ServeHTTP(w, r)
+				return
+			}
+			scoped := scopedKey(r, key)
+			fingerprint := Fingerprint(r.Method, r.URL.RequestURI(), body)
+			existing, claimed, err := store.Begin(r.Context(), scoped, fingerprint)
+			if err != nil {
+				opts.Reject(w, r, &Error{Status: http.StatusInternalServerError, Code: "internal", Message: "idempotency store unavailable"})
+				return
+			}
+			if !claimed {
+				switch {
+				case existing.Fingerprint != fingerprint:
+					opts.Reject(w, r, &Error{Status: http.StatusUnprocessableEntity, Code: CodeKeyReused, Message: "idempotency key was reused with a different request"})
+				case !existing.Done:
+					opts.Reject(w, r, &Error{Status: http.StatusConflict, Code: CodeInProgress, Message: "a request with this idempotency key is in progress"})
+				default:
+					replay(w, existing)
+				}
+				return
+			}
+
+			capture := &captureWriter{ResponseWriter: w}
+			ctx := context.WithoutCancel(r.Context())
+			finished := false
+			defer func() {
+				if !finished || capture.status() >= 500 {
+					_ = store.Release(ctx, scoped)
+					return
+				}
+				_ = store.Complete(ctx, scoped, Record{
+					Fingerprint: fingerprint,
+					Done:        true,
+					Status:      capture.status(),
+					Header:      capture.header(),
+					Body:        capture.body.Bytes(),
+				})
+			}()
+			next.ServeHTTP(capture, r)
+			finished = true
+		})
+	}
+}
+
+// Fingerprint hashes the parts of a request that must match on replay.
+func Fingerprint(method, uri string, body []byte) string {
+	sum := sha256.New()
+	_, _ = io.WriteString(sum, method+" "+uri+"\n")
+	_, _ = sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// scopedKey namespaces key by route and caller so different users cannot
+// replay each other's responses.
+func scopedKey(r *http.Request, key string) string {
+	scope := r.Method + " " + r.URL.Path
+	if principal, ok := guard.PrincipalFrom(r.Context()); ok {
+		scope = principalScope(principal) + " " + scope
+	}
+	return scope + " " + key
+}
+
+// principalScope identifies the caller within the store. Principals without
+// a guard.Identifier are scoped by a hash of their JSON encoding, which
+// changes whenever any claim does, so a retry after a token refresh runs again.
+func principalScope(principal any) string {
+	if id := guard.PrincipalID(principal); id != "" {
+		return "id:" + id
+	}
+	data, err := json.Marshal(principal)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%#v", principal))
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// readBody buffers up to limit bytes of the body and restores it on r. It
+// reports complete false when the body is larger than limit.
+func readBody(r *http.Request, limit int64) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) > limit {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+		return nil, false, nil
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	return data, true, nil
+}
+
+func replay(w http.ResponseWriter, record Record) {
+	for name, values := range record.Header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(record.Status)
+	_, _ = w.Write(record.Body)
+}
+
+// captureWriter records the response while writing it through.
+type captureWriter struct {
+	http.ResponseWriter
+	code     int
+	body     bytes.Buffer
+	snapshot http.Header
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if c.code == 0 {
+		c.code = status
+		c.snapshot = c.Header().Clone()
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	if c.code == 0 {
+		c.code = http.StatusOK
+		c.snapshot = c.Header().Clone()
+	}
+	c.body.Write(p)
+	return c.ResponseWriter.Write(p)
+}
+
+// header returns the headers as the handler wrote them. The snapshot is taken
+// before writing through, since outer middleware such as compression may add
+// Content-Encoding to the shared map for a body the capture stores unencoded.
+func (c *captureWriter) header() http.Header {
+	if c.snapshot == nil {
+		return c.Header().Clone()
+	}
+	return c.snapshot
+}
+
+func (c *captureWriter) status() int {
+	if c.code == 0 {
+		return http.StatusOK
+	}
+	return c.code
+}
@@ -0,0 +1,155 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swetjen/virtuous/guard"
+)
+
+func countingHandler(calls *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Call", strings.Repeat("i", *calls))
+		w.WriteHeader(status)
+		_, _ = w.Write(append([]byte("echo:"), body...))
+	})
+}
+
+func send(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/rpc/users/create", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	h := Middleware(NewMemoryStore(0), Options{})(countingHandler(&calls, http.StatusCreated))
+
+	first := send(h, "k1", `{"name":"Ada"}`)
+	second := send(h, "k1", `{"name":"Ada"}`)
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected replay of %d %q, got %d %q", first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+	if second.Header().Get("X-Call") != "i" || second.Header().Get(ReplayedHeader) != "true" {
+		t.Fatalf("expected replayed headers, got %v", second.Header())
+	}
+	if first.Header().Get(ReplayedHeader) != "" {
+		t.Fatalf("first response must not be marked replayed")
+	}
+
+	send(h, "", `{"name":"Ada"}`)
+	send(h, "k2", `{"name":"Ada"}`)
+	if calls != 3 {
+		t.Fatalf("expected requests without the key or with a new key to run, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareRejectsReusedKeyAndLongKey(t *testing.T) {
+	calls := 0
+	var rejected []*Error
+	h := Middleware(NewMemoryStore(0), Options{
+		Reject: func(w http.ResponseWriter, _ *http.Request, err *Error) {
+			rejected = append(rejected, err)
+			w.WriteHeader(err.Status)
+		},
+	})(countingHandler(&calls, http.StatusOK))
+
+	send(h, "k1", `{"name":"Ada"}`)
+	reused := send(h, "k1", `{"name":"Grace"}`)
+	if reused.Code != http.StatusUnprocessableEntity || rejected[0].Code != CodeKeyReused {
+		t.Fatalf("expected 422 %s, got %d %+v", CodeKeyReused, reused.Code, rejected)
+	}
+	long := send(h, strings.Repeat("k", MaxKeyLength+1), `{}`)
+	if long.Code != http.StatusBadRequest || rejected[1].Code != CodeInvalidKey {
+		t.Fatalf("expected 400 %s, got %d %+v", CodeInvalidKey, long.Code, rejected)
+	}
+	if calls != 1 {
+		t.Fatalf("expected rejected requests to skip the handler, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareRejectsConcurrentKey(t *testing.T) {
+	store := NewMemoryStore(0)
+	var inner *httptest.ResponseRecorder
+	var h http.Handler
+	h = Middleware(store, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner = send(h, "k1", `{}`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	send(h, "k1", `{}`)
+	if inner == nil || inner.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while the first request runs, got %+v", inner)
+	}
+}
+
+func TestMiddlewareReleasesServerErrors(t *testing.T) {
+	calls := 0
+	h := Middleware(NewMemoryStore(0), Options{})(countingHandler(&calls, http.StatusInternalServerError))
+	send(h, "k1", `{}`)
+	send(h, "k1", `{}`)
+	if calls != 2 {
+		t.Fatalf("expected 5xx responses to be retried, ran %d times", calls)
+	}
+
+	panicking := Middleware(NewMemoryStore(0), Options{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls++
+		panic("boom")
+	}))
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() { _ = recover() }()
+			send(panicking, "k1", `{}`)
+		}()
+	}
+	if calls != 4 {
+		t.Fatalf("expected panicking requests to release the key, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareScopesKeysByPrincipal(t *testing.T) {
+	calls := 0
+	h := Middleware(NewMemoryStore(0), Options{})(countingHandler(&calls, http.StatusOK))
+	for _, user := range []string{"alice", "bob"} {
+		req := httptest.NewRequest(http.MethodPost, "/rpc/users/create", strings.NewReader(`{}`))
+		req.Header.Set(Header, "shared")
+		req = req.WithContext(guard.WithPrincipal(req.Context(), map[string]string{"sub": user}))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls != 2 {
+		t.Fatalf("expected keys to be scoped per principal, ran %d times", calls)
+	}
+}
+
+func TestMemoryStoreExpiresRecords(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, claimed, _ := store.Begin(ctx, "k", "fp"); !claimed {
+		t.Fatalf("expected first Begin to claim")
+	}
+	_ = store.Complete(ctx, "k", Record{Fingerprint: "fp", Done: true, Status: http.StatusOK, Body: []byte("ok")})
+	existing, claimed, _ := store.Begin(ctx, "k", "fp")
+	if claimed || !existing.Done || string(existing.Body) != "ok" {
+		t.Fatalf("expected stored record, got claimed=%v %+v", claimed, existing)
+	}
+	now = now.Add(time.Minute)
+	if _, claimed, _ := store.Begin(ctx, "k", "fp"); !claimed {
+		t.Fatalf("expected expired record to be claimable")
+	}
+}
@@ -0,0 +1,91 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long MemoryStore keeps records by default.
+const DefaultTTL = 24 * time.Hour
+
+// MemoryStore is an in-process Store for single-instance deployments and
+// tests. Records expire after the store's TTL.
+type MemoryStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	records map[string]memoryRecord
+}
+
+type memoryRecord struct {
+	record  Record
+	expires time.Time
+}
+
+// NewMemoryStore returns a MemoryStore that keeps records for ttl, or for
+// DefaultTTL when ttl is not positive.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &MemoryStore{
+		ttl:     ttl,
+		now:     time.Now,
+		records: map[string]memoryRecord{},
+	}
+}
+
+// Begin implements Store.
+func (s *MemoryStore) Begin(_ context.Context, key, fingerprint string) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.pruneLocked(now)
+	if existing, ok := s.records[key]; ok {
+		return cloneRecord(existing.record), false, nil
+	}
+	s.records[key] = memoryRecord{
+		record:  Record{Fingerprint: fingerprint},
+		expires: now.Add(s.ttl),
+	}
+	return Record{}, true, nil
+}
+
+// Complete implements Store.
+func (s *MemoryStore) Complete(_ context.Context, key string, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = memoryRecord{
+		record:  cloneRecord(record),
+		expires: s.now().Add(s.ttl),
+	}
+	return nil
+}
+
+// Release implements Store.
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *MemoryStore) pruneLocked(now time.Time) {
+	for key, entry := range s.records {
+		if !now.Before(entry.expires) {
+			delete(s.records, key)
+		}
+	}
+}
+
+func cloneRecord(record Record) Record {
+	record.Body = append([]byte(nil), record.Body...)
+	if record.Header != nil {
+		record.Header = record.Header.Clone()
+	} else {
+		record.Header = http.Header{}
+	}
+	return record
+}
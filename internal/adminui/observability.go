@@ -13,8 +13,9 @@ import (
 )
 
 const (
-	defaultTraceSampleRate = 0.1
-	maxTraceSamples        = 200
+	defaultTraceSampleRate  = 0.1
+	maxTraceSamples         = 200
+	maxFingerprintIncidents = 20
 )
 
 // ObservabilityOptions configures the in-memory tracker.
@@ -32,8 +33,11 @@ type RequestEvent struct {
 	DurationMS     int64     `json:"durationMs"`
 	Timestamp      time.Time `json:"timestamp"`
 	GuardOutcome   string    `json:"guardOutcome,omitempty"`
+	Principal      string    `json:"principal,omitempty"`
 	ErrorMessage   string    `json:"errorMessage,omitempty"`
 	StackSignature string    `json:"stackSignature,omitempty"`
+	TimedOut       bool      `json:"timedOut,omitempty"`
+	IncidentID     string    `json:"incidentId,omitempty"`
 }
 
 // GuardDecisionEvent records one guard allow/deny result.
@@ -44,7 +48,8 @@ type GuardDecisionEvent struct {
 	Allowed   bool      `json:"allowed"`
 }
 
-// RouteAggregate summarizes request activity for one RPC.
+// RouteAggregate summarizes request activity for one RPC. Timed-out requests
+// count toward TimeoutsLast24H rather than ServerErrorsLast24H.
 type RouteAggregate struct {
 	RPCName             string  `json:"rpcName"`
 	Path                string  `json:"path"`
@@ -57,10 +62,13 @@ type RouteAggregate struct {
 	P95LatencyLastHour  float64 `json:"p95LatencyLastHourMs"`
 	ClientErrorsLast24H int     `json:"clientErrorsLast24h"`
 	ServerErrorsLast24H int     `json:"serverErrorsLast24h"`
+	TimeoutsLast24H     int     `json:"timeoutsLast24h"`
 	TraceSamplesLast24H int     `json:"traceSamplesLast24h"`
 }
 
 // ErrorFingerprint groups repeated server-side failures for one RPC.
+// IncidentIDs lists the incident IDs of recovered panics in the group, oldest
+// first, keeping the most recent ones.
 type ErrorFingerprint struct {
 	RPCName         string    `json:"rpcName"`
 	ErrorHash       string    `json:"errorHash"`
@@ -70,6 +78,7 @@ type ErrorFingerprint struct {
 	Sparkline       []int     `json:"sparkline"`
 	LastSeen        time.Time `json:"lastSeen"`
 	TraceSampleHint bool      `json:"traceSampleHint"`
+	IncidentIDs     []string  `json:"incidentIds,omitempty"`
 }
 
 // GuardAggregate summarizes allow/deny activity for one guard on one RPC.
@@ -91,8 +100,11 @@ type TraceSample struct {
 	DurationMS     int64     `json:"durationMs"`
 	Timestamp      time.Time `json:"timestamp"`
 	GuardOutcome   string    `json:"guardOutcome,omitempty"`
+	Principal      string    `json:"principal,omitempty"`
 	ErrorMessage   string    `json:"errorMessage,omitempty"`
 	StackSignature string    `json:"stackSignature,omitempty"`
+	TimedOut       bool      `json:"timedOut,omitempty"`
+	IncidentID     string    `json:"incidentId,omitempty"`
 }
 
 // MetricsTotals provides top-level summary counts for the dashboard.
@@ -102,6 +114,7 @@ type MetricsTotals struct {
 	RequestsLast24H     int `json:"requestsLast24h"`
 	ClientErrorsLast24H int `json:"clientErrorsLast24h"`
 	ServerErrorsLast24H int `json:"serverErrorsLast24h"`
+	TimeoutsLast24H     int `json:"timeoutsLast24h"`
 }
 
 // MetricsSnapshot is the JSON payload for the observability dashboard.
@@ -197,6 +210,8 @@ func (t *ObservabilityTracker) RecordRequest(event RequestEvent, guards []GuardD
 	event.ErrorMessage = strings.TrimSpace(event.ErrorMessage)
 	event.StackSignature = strings.TrimSpace(event.StackSignature)
 	event.GuardOutcome = strings.TrimSpace(strings.ToLower(event.GuardOutcome))
+	event.Principal = strings.TrimSpace(event.Principal)
+	event.IncidentID = strings.TrimSpace(event.IncidentID)
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -237,8 +252,11 @@ func (t *ObservabilityTracker) RecordRequest(event RequestEvent, guards []GuardD
 				DurationMS:     event.DurationMS,
 				Timestamp:      event.Timestamp,
 				GuardOutcome:   event.GuardOutcome,
+				Principal:      event.Principal,
 				ErrorMessage:   event.ErrorMessage,
 				StackSignature: event.StackSignature,
+				TimedOut:       event.TimedOut,
+				IncidentID:     event.IncidentID,
 			})
 		}
 	}
@@ -282,6 +300,7 @@ func (t *ObservabilityTracker) Snapshot() MetricsSnapshot {
 		snapshot.Totals.RequestsLast24H += aggregate.RequestsLast24H
 		snapshot.Totals.ClientErrorsLast24H += aggregate.ClientErrorsLast24H
 		snapshot.Totals.ServerErrorsLast24H += aggregate.ServerErrorsLast24H
+		snapshot.Totals.TimeoutsLast24H += aggregate.TimeoutsLast24H
 
 		if t.advanced {
 			accumulateErrors(errorMap, rpcName, route, now)
@@ -432,6 +451,8 @@ func summarizeRoute(rpcName string, route *observabilityRoute, now time.Time) Ro
 		}
 		out.RequestsLast24H++
 		switch {
+		case event.TimedOut:
+			out.TimeoutsLast24H++
 		case event.StatusCode >= 500:
 			out.ServerErrorsLast24H++
 		case event.StatusCode >= 400:
@@ -477,7 +498,7 @@ func accumulateErrors(out map[string]*ErrorFingerprint, rpcName string, route *o
 		if event.Timestamp.Before(cutoff) {
 			continue
 		}
-		if event.StatusCode < 500 {
+		if event.StatusCode < 500 || event.TimedOut {
 			continue
 		}
 		if event.ErrorMessage == "" && event.StackSignature == "" {
@@ -500,6 +521,12 @@ func accumulateErrors(out map[string]*ErrorFingerprint, rpcName string, route *o
 			item.LastSeen = event.Timestamp
 		}
 		item.TraceSampleHint = item.TraceSampleHint || event.StackSignature != ""
+		if event.IncidentID != "" {
+			item.IncidentIDs = append(item.IncidentIDs, event.IncidentID)
+			if len(item.IncidentIDs) > maxFingerprintIncidents {
+				item.IncidentIDs = item.IncidentIDs[1:]
+			}
+		}
 		if bucket := sparklineBucket(now, event.Timestamp); bucket >= 0 && bucket < len(item.Sparkline) {
 			item.Sparkline[bucket]++
 		}
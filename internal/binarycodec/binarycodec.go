@@ -0,0 +1,171 @@
+// Package binarycodec encodes Go values as MessagePack or CBOR using the JSON
+// data model: struct fields use their json tag names and options, and types
+// with MarshalJSON, UnmarshalJSON, MarshalText, or UnmarshalText methods keep
+// the shape they have in JSON. A payload therefore decodes to the same
+// values, and matches the same schema, as its JSON equivalent.
+package binarycodec
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/swetjen/virtuous/internal/reflectutil"
+)
+
+// Format selects the binary encoding.
+type Format int
+
+const (
+	// MessagePack is https://msgpack.org.
+	MessagePack Format = iota + 1
+	// CBOR is RFC 8949.
+	CBOR
+)
+
+// maxDepth bounds nesting while encoding and decoding.
+const maxDepth = 10000
+
+func (f Format) String() string {
+	switch f {
+	case MessagePack:
+		return "msgpack"
+	case CBOR:
+		return "cbor"
+	default:
+		return "binarycodec"
+	}
+}
+
+// Marshal encodes v in format f.
+func Marshal(f Format, v any) ([]byte, error) {
+	var out emitter
+	switch f {
+	case MessagePack:
+		out = &msgpackWriter{}
+	case CBOR:
+		out = &cborWriter{}
+	default:
+		return nil, errors.New("binarycodec: unknown format")
+	}
+	state := &encodeState{format: f, out: out}
+	if err := state.value(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return out.bytes(), nil
+}
+
+// Unmarshal decodes one value in format f from data into v, which must be a
+// non-nil pointer. In strict mode it rejects unknown struct fields, duplicate
+// map keys, and trailing data, like strict JSON decoding.
+func Unmarshal(f Format, data []byte, v any, strict bool) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return errors.New(f.String() + ": Unmarshal requires a non-nil pointer")
+	}
+	in := &reader{format: f, data: data, strict: strict}
+	var (
+		src any
+		err error
+	)
+	switch f {
+	case MessagePack:
+		src, err = in.msgpackValue()
+	case CBOR:
+		src, err = in.cborValue()
+	default:
+		return errors.New("binarycodec: unknown format")
+	}
+	if err != nil {
+		return in.breakError(err)
+	}
+	if strict && in.pos < len(in.data) {
+		return errors.New(f.String() + ": trailing data after top-level value")
+	}
+	state := &decodeState{format: f, strict: strict}
+	return state.assign(src, target.Elem())
+}
+
+// object is a decoded map. Keys keep their wire order so values handed to
+// UnmarshalJSON see the fields as they were sent.
+type object struct {
+	keys   []string
+	values []any
+}
+
+// field is a struct field as it appears on the wire.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+}
+
+type structFields struct {
+	list   []field
+	byName map[string]int
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+func cachedFields(t reflect.Type) *structFields {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(*structFields)
+	}
+	resolved := reflectutil.JSONFields(t)
+	fields := &structFields{
+		list:   make([]field, 0, len(resolved)),
+		byName: make(map[string]int, len(resolved)),
+	}
+	for _, jsonField := range resolved {
+		opts := strings.Split(jsonField.Field.Tag.Get("json"), ",")[1:]
+		f := field{
+			name:      jsonField.Name,
+			index:     jsonField.Index,
+			omitEmpty: jsonField.OmitEmpty,
+		}
+		for _, opt := range opts {
+			switch opt {
+			case "omitzero":
+				f.omitZero = true
+			case "string":
+				f.quoted = quotable(jsonField.Field.Type)
+			}
+		}
+		fields.byName[f.name] = len(fields.list)
+		fields.list = append(fields.list, f)
+	}
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.(*structFields)
+}
+
+// lookup finds the field for a wire key, preferring an exact match and
+// falling back to a case-insensitive one like encoding/json.
+func (s *structFields) lookup(key string) (field, bool) {
+	if i, ok := s.byName[key]; ok {
+		return s.list[i], true
+	}
+	for _, f := range s.list {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// quotable reports whether the json ",string" option applies to t.
+func quotable(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
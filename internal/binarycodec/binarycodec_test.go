@@ -0,0 +1,190 @@
+package binarycodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	Label string `json:"label"`
+}
+
+type Embedded struct {
+	Shared string `json:"shared"`
+}
+
+type sample struct {
+	Embedded
+	ID        int64             `json:"id"`
+	Ratio     float64           `json:"ratio"`
+	Small     float32           `json:"small"`
+	Negative  int8              `json:"negative"`
+	Name      string            `json:"name"`
+	Tags      []string          `json:"tags"`
+	Counts    map[string]uint32 `json:"counts"`
+	ByID      map[int]string    `json:"byId"`
+	Blob      []byte            `json:"blob"`
+	At        time.Time         `json:"at"`
+	Inner     *inner            `json:"inner"`
+	Missing   *inner            `json:"missing"`
+	Optional  string            `json:"optional,omitempty"`
+	Zero      time.Time         `json:"zero,omitzero"`
+	Quoted    int               `json:"quoted,string"`
+	Any       any               `json:"any"`
+	Skipped   string            `json:"-"`
+	Untagged  bool
+	unexposed int
+}
+
+func newSample() sample {
+	return sample{
+		Embedded: Embedded{Shared: "embedded"},
+		ID:       1 << 40,
+		Ratio:    0.25,
+		Small:    1.5,
+		Negative: -100,
+		Name:     "Ada",
+		Tags:     []string{"a", "b"},
+		Counts:   map[string]uint32{"x": 70000, "y": 3},
+		ByID:     map[int]string{2: "two", 10: "ten"},
+		Blob:     []byte{0, 1, 2, 255},
+		At:       time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Inner:    &inner{Label: strings.Repeat("x", 300)},
+		Quoted:   42,
+		Any:      map[string]any{"k": []any{1.5, "v", nil, true}},
+		Untagged: true,
+	}
+}
+
+func TestRoundTripMatchesJSON(t *testing.T) {
+	for _, format := range []Format{MessagePack, CBOR} {
+		t.Run(format.String(), func(t *testing.T) {
+			in := newSample()
+			data, err := Marshal(format, in)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			var out sample
+			if err := Unmarshal(format, data, &out, true); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(out, in) {
+				t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", out, in)
+			}
+
+			var generic any
+			if err := Unmarshal(format, data, &generic, true); err != nil {
+				t.Fatalf("unmarshal generic: %v", err)
+			}
+			jsonData, _ := json.Marshal(in)
+			var want any
+			_ = json.Unmarshal(jsonData, &want)
+			if !reflect.DeepEqual(generic, want) {
+				t.Fatalf("data model differs from JSON:\n got %v\nwant %v", generic, want)
+			}
+		})
+	}
+}
+
+func TestWireBytes(t *testing.T) {
+	value := map[string]any{"a": 1, "b": []any{-1, true, nil}}
+	cases := map[Format][]byte{
+		MessagePack: {0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xff, 0xc3, 0xc0},
+		CBOR:        {0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x83, 0x20, 0xf5, 0xf6},
+	}
+	for format, want := range cases {
+		got, err := Marshal(format, value)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("%s: got % x (%v), want % x", format, got, err, want)
+		}
+	}
+
+	var f float64
+	if err := Unmarshal(CBOR, []byte{0xf9, 0x3c, 0x00}, &f, true); err != nil || f != 1 {
+		t.Fatalf("expected half float 1, got %v (%v)", f, err)
+	}
+	var items []int
+	if err := Unmarshal(CBOR, []byte{0x9f, 0x01, 0x02, 0xff}, &items, true); err != nil || !reflect.DeepEqual(items, []int{1, 2}) {
+		t.Fatalf("expected indefinite array, got %v (%v)", items, err)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	type target struct {
+		Name string `json:"name"`
+	}
+	cases := []struct {
+		name   string
+		format Format
+		data   []byte
+		want   string
+	}{
+		{"unknown field", MessagePack, []byte{0x81, 0xa5, 'o', 't', 'h', 'e', 'r', 0x01}, `unknown field "other"`},
+		{"duplicate key", MessagePack, []byte{0x82, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'a', 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'b'}, `duplicate map key "name"`},
+		{"trailing data", CBOR, []byte{0xa0, 0x00}, "trailing data"},
+		{"wrong type", CBOR, []byte{0xa1, 0x64, 'n', 'a', 'm', 'e', 0x01}, "cannot decode number"},
+		{"hostile length", MessagePack, []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, "unexpected end of data"},
+		{"truncated", CBOR, []byte{0x78, 0x10, 'a'}, "unexpected end of data"},
+	}
+	for _, tc := range cases {
+		var out target
+		err := Unmarshal(tc.format, tc.data, &out, true)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	var out target
+	if err := Unmarshal(MessagePack, []byte{0x82, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'a', 0xa1, 'x', 0x01, 0xc0}, &out, false); err != nil || out.Name != "a" {
+		t.Fatalf("expected lenient decoding to ignore unknown fields and trailing data, got %+v (%v)", out, err)
+	}
+}
+
+func FuzzDecodeMessagePack(f *testing.F) {
+	fuzzDecode(f, MessagePack)
+}
+
+func FuzzDecodeCBOR(f *testing.F) {
+	fuzzDecode(f, CBOR)
+}
+
+// fuzzDecode checks that arbitrary input never panics and that whatever
+// decodes encodes to bytes that decode to the same value again.
+func fuzzDecode(f *testing.F, format Format) {
+	for _, seed := range []any{newSample(), nil, []any{1.5, "v", true}, map[string]any{"k": -1}} {
+		data, err := Marshal(format, seed)
+		if err != nil {
+			f.Fatalf("marshal seed: %v", err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var typed sample
+		_ = Unmarshal(format, data, &typed, true)
+
+		var decoded any
+		if err := Unmarshal(format, data, &decoded, false); err != nil {
+			return
+		}
+		encoded, err := Marshal(format, decoded)
+		if err != nil {
+			t.Fatalf("encode decoded value %#v: %v", decoded, err)
+		}
+		var again any
+		if err := Unmarshal(format, encoded, &again, true); err != nil {
+			t.Fatalf("decode re-encoded %x: %v", encoded, err)
+		}
+		reencoded, err := Marshal(format, again)
+		if err != nil {
+			t.Fatalf("encode round-tripped value %#v: %v", again, err)
+		}
+		if !bytes.Equal(encoded, reencoded) {
+			t.Fatalf("round trip changed %x to %x", encoded, reencoded)
+		}
+	})
+}
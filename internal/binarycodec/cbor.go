@@ -0,0 +1,310 @@
+package binarycodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+// errCBORBreak marks the end of an indefinite-length item.
+var errCBORBreak = errors.New("cbor: break")
+
+type cborWriter struct {
+	buf []byte
+}
+
+func (w *cborWriter) bytes() []byte { return w.buf }
+
+// head writes the initial byte and argument of an item.
+func (w *cborWriter) head(major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, m|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, m|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, m|26), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, m|27), n)
+	}
+}
+
+func (w *cborWriter) writeNil() { w.buf = append(w.buf, 0xf6) }
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xf5)
+		return
+	}
+	w.buf = append(w.buf, 0xf4)
+}
+
+func (w *cborWriter) writeInt(i int64) {
+	if i >= 0 {
+		w.head(cborUint, uint64(i))
+		return
+	}
+	w.head(cborNegint, uint64(-1-i))
+}
+
+func (w *cborWriter) writeUint(u uint64) { w.head(cborUint, u) }
+
+func (w *cborWriter) writeFloat(f float64, bits int) {
+	if bits == 32 {
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xfa), math.Float32bits(float32(f)))
+		return
+	}
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xfb), math.Float64bits(f))
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.head(cborText, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *cborWriter) writeArrayHeader(n int) { w.head(cborArray, uint64(n)) }
+
+func (w *cborWriter) writeMapHeader(n int) { w.head(cborMap, uint64(n)) }
+
+func (r *reader) cborValue() (any, error) {
+	ib, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	if ib == cborBreak {
+		return nil, errCBORBreak
+	}
+	major, info := ib>>5, ib&0x1f
+	if major == cborSimple {
+		return r.cborSimple(info)
+	}
+	if info == cborIndefinite {
+		switch major {
+		case cborBytes, cborText:
+			return r.cborChunks(major)
+		case cborArray:
+			return r.cborArray(0, true)
+		case cborMap:
+			return r.cborMap(0, true)
+		}
+		return nil, r.errorf("invalid indefinite-length item")
+	}
+	n, err := r.cborArgument(info)
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return n, nil
+	case cborNegint:
+		if n > math.MaxInt64 {
+			return nil, r.errorf("negative integer overflows int64")
+		}
+		return -1 - int64(n), nil
+	case cborBytes:
+		return r.binary(n)
+	case cborText:
+		return r.text(n)
+	case cborArray:
+		return r.cborArray(n, false)
+	case cborMap:
+		return r.cborMap(n, false)
+	default:
+		return r.cborTagged(n)
+	}
+}
+
+func (r *reader) cborArgument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return r.uint(1 << int(info-24))
+	}
+	return 0, r.errorf("invalid additional information %d", info)
+}
+
+func (r *reader) cborSimple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		bits, err := r.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		return halfToFloat(uint16(bits)), nil
+	case 26:
+		bits, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(bits))), nil
+	case 27:
+		bits, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(bits), nil
+	}
+	return nil, r.errorf("unsupported simple value %d", info)
+}
+
+// cborTagged decodes a tagged item as its content. Bignums that fit in 64
+// bits become integers.
+func (r *reader) cborTagged(tag uint64) (any, error) {
+	if err := r.enter(0); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	content, err := r.cborValue()
+	if err != nil {
+		return nil, err
+	}
+	if tag != 2 && tag != 3 {
+		return content, nil
+	}
+	digits, ok := content.([]byte)
+	if !ok || len(digits) > 8 {
+		return nil, r.errorf("bignum out of range")
+	}
+	var n uint64
+	for _, d := range digits {
+		n = n<<8 | uint64(d)
+	}
+	if tag == 2 {
+		return n, nil
+	}
+	if n > math.MaxInt64 {
+		return nil, r.errorf("negative integer overflows int64")
+	}
+	return -1 - int64(n), nil
+}
+
+// cborChunks joins the definite-length chunks of an indefinite-length byte
+// or text string.
+func (r *reader) cborChunks(major byte) (any, error) {
+	var out []byte
+	for {
+		ib, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if ib == cborBreak {
+			break
+		}
+		if ib>>5 != major || ib&0x1f == cborIndefinite {
+			return nil, r.errorf("invalid chunk in indefinite-length string")
+		}
+		n, err := r.cborArgument(ib & 0x1f)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := r.take(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+	}
+	if major == cborText {
+		return validString(out), nil
+	}
+	if out == nil {
+		out = []byte{}
+	}
+	return out, nil
+}
+
+func (r *reader) cborArray(n uint64, indefinite bool) (any, error) {
+	if err := r.enter(n); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	items := make([]any, 0, n)
+	for i := uint64(0); indefinite || i < n; i++ {
+		item, err := r.cborValue()
+		if indefinite && err == errCBORBreak {
+			break
+		}
+		if err != nil {
+			return nil, r.breakError(err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *reader) cborMap(n uint64, indefinite bool) (any, error) {
+	if err := r.enter(n); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	obj := r.newObject(int(n))
+	for i := uint64(0); indefinite || i < n; i++ {
+		key, err := r.cborValue()
+		if indefinite && err == errCBORBreak {
+			break
+		}
+		if err != nil {
+			return nil, r.breakError(err)
+		}
+		value, err := r.cborValue()
+		if err != nil {
+			return nil, r.breakError(err)
+		}
+		if err := r.add(obj, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return obj.obj, nil
+}
+
+func (r *reader) breakError(err error) error {
+	if err == errCBORBreak {
+		return r.errorf("unexpected break")
+	}
+	return err
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
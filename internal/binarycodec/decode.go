@@ -0,0 +1,417 @@
+package binarycodec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+type decodeState struct {
+	format Format
+	strict bool
+}
+
+// assign stores a decoded tree in v following encoding/json's rules.
+func (d *decodeState) assign(src any, v reflect.Value) error {
+	if src == nil {
+		return d.assignNil(v)
+	}
+	v = allocate(v)
+	if v.CanAddr() {
+		addr := v.Addr()
+		if addr.Type().Implements(jsonUnmarshalerType) {
+			data, err := toJSON(src)
+			if err != nil {
+				return err
+			}
+			return addr.Interface().(json.Unmarshaler).UnmarshalJSON(data)
+		}
+		if addr.Type().Implements(textUnmarshalerType) {
+			str, ok := src.(string)
+			if !ok {
+				return d.typeError(src, v.Type())
+			}
+			return addr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError(src, v.Type())
+		}
+		v.Set(reflect.ValueOf(toInterface(src)))
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			return d.typeError(src, v.Type())
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt(src)
+		if !ok || v.OverflowInt(i) {
+			return d.typeError(src, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := toUint(src)
+		if !ok || v.OverflowUint(u) {
+			return d.typeError(src, v.Type())
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(src)
+		if !ok || v.OverflowFloat(f) {
+			return d.typeError(src, v.Type())
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		if v.Type() == jsonNumberType {
+			if literal, ok := numberLiteral(src); ok {
+				v.SetString(literal)
+				return nil
+			}
+		}
+		str, ok := src.(string)
+		if !ok {
+			return d.typeError(src, v.Type())
+		}
+		v.SetString(str)
+	case reflect.Slice:
+		return d.slice(src, v)
+	case reflect.Array:
+		items, ok := src.([]any)
+		if !ok {
+			return d.typeError(src, v.Type())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if i >= len(items) {
+				v.Index(i).SetZero()
+				continue
+			}
+			if err := d.assign(items[i], v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return d.mapValue(src, v)
+	case reflect.Struct:
+		return d.structValue(src, v)
+	default:
+		return d.typeError(src, v.Type())
+	}
+	return nil
+}
+
+// assignNil applies a nil value: pointers, maps, slices, and interfaces are
+// cleared, JSON unmarshalers receive null, and other values are unchanged.
+func (d *decodeState) assignNil(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		v.SetZero()
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(jsonUnmarshalerType) {
+		return v.Addr().Interface().(json.Unmarshaler).UnmarshalJSON([]byte("null"))
+	}
+	return nil
+}
+
+func (d *decodeState) slice(src any, v reflect.Value) error {
+	if isByteSlice(v.Type()) {
+		switch src := src.(type) {
+		case string:
+			data, err := base64.StdEncoding.DecodeString(src)
+			if err != nil {
+				return fmt.Errorf("%s: %w", d.format, err)
+			}
+			v.SetBytes(data)
+			return nil
+		case []byte:
+			v.SetBytes(append([]byte(nil), src...))
+			return nil
+		}
+	}
+	items, ok := src.([]any)
+	if !ok {
+		return d.typeError(src, v.Type())
+	}
+	out := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := d.assign(item, out.Index(i)); err != nil {
+			return err
+		}
+	}
+	v.Set(out)
+	return nil
+}
+
+func (d *decodeState) mapValue(src any, v reflect.Value) error {
+	obj, ok := src.(*object)
+	if !ok {
+		return d.typeError(src, v.Type())
+	}
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(t, len(obj.keys)))
+	}
+	for i, key := range obj.keys {
+		k := reflect.New(t.Key()).Elem()
+		if err := d.mapKey(key, k); err != nil {
+			return err
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := d.assign(obj.values[i], elem); err != nil {
+			return err
+		}
+		v.SetMapIndex(k, elem)
+	}
+	return nil
+}
+
+func (d *decodeState) mapKey(key string, k reflect.Value) error {
+	if k.Kind() == reflect.String {
+		k.SetString(key)
+		return nil
+	}
+	if reflect.PointerTo(k.Type()).Implements(textUnmarshalerType) {
+		return k.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key))
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || k.OverflowInt(i) {
+			return fmt.Errorf("%s: invalid map key %q for %s", d.format, key, k.Type())
+		}
+		k.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || k.OverflowUint(u) {
+			return fmt.Errorf("%s: invalid map key %q for %s", d.format, key, k.Type())
+		}
+		k.SetUint(u)
+		return nil
+	}
+	return fmt.Errorf("%s: unsupported map key type %s", d.format, k.Type())
+}
+
+func (d *decodeState) structValue(src any, v reflect.Value) error {
+	obj, ok := src.(*object)
+	if !ok {
+		return d.typeError(src, v.Type())
+	}
+	fields := cachedFields(v.Type())
+	for i, key := range obj.keys {
+		f, ok := fields.lookup(key)
+		if !ok {
+			if d.strict {
+				return fmt.Errorf("%s: unknown field %q", d.format, key)
+			}
+			continue
+		}
+		fv, ok := allocateField(v, f.index)
+		if !ok {
+			continue
+		}
+		value := obj.values[i]
+		if f.quoted && value != nil {
+			str, ok := value.(string)
+			if !ok {
+				return d.typeError(value, fv.Type())
+			}
+			if err := json.Unmarshal([]byte(str), fv.Addr().Interface()); err != nil {
+				return fmt.Errorf("%s: field %s: %w", d.format, f.name, err)
+			}
+			continue
+		}
+		if err := d.assign(value, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decodeState) typeError(src any, t reflect.Type) error {
+	return fmt.Errorf("%s: cannot decode %s into Go value of type %s", d.format, describe(src), t)
+}
+
+// allocate follows pointers, allocating nil ones, and returns the value they
+// point to.
+func allocate(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// allocateField walks index, allocating nil embedded pointers. It reports
+// false for fields behind unexported embedded pointers, which cannot be set.
+func allocateField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanSet()
+}
+
+func toInt(src any) (int64, bool) {
+	switch src := src.(type) {
+	case int64:
+		return src, true
+	case uint64:
+		return int64(src), src <= math.MaxInt64
+	case float64:
+		return int64(src), src == math.Trunc(src) && src >= math.MinInt64 && src < math.MaxInt64
+	}
+	return 0, false
+}
+
+func toUint(src any) (uint64, bool) {
+	switch src := src.(type) {
+	case uint64:
+		return src, true
+	case int64:
+		return uint64(src), src >= 0
+	case float64:
+		return uint64(src), src == math.Trunc(src) && src >= 0 && src < math.MaxUint64
+	}
+	return 0, false
+}
+
+func toFloat(src any) (float64, bool) {
+	switch src := src.(type) {
+	case float64:
+		return src, true
+	case int64:
+		return float64(src), true
+	case uint64:
+		return float64(src), true
+	}
+	return 0, false
+}
+
+func numberLiteral(src any) (string, bool) {
+	switch src := src.(type) {
+	case int64:
+		return strconv.FormatInt(src, 10), true
+	case uint64:
+		return strconv.FormatUint(src, 10), true
+	case float64:
+		return strconv.FormatFloat(src, 'g', -1, 64), true
+	}
+	return "", false
+}
+
+// toInterface converts a decoded tree to the values encoding/json produces
+// for an empty interface.
+func toInterface(src any) any {
+	switch src := src.(type) {
+	case int64, uint64:
+		f, _ := toFloat(src)
+		return f
+	case []byte:
+		return base64.StdEncoding.EncodeToString(src)
+	case []any:
+		out := make([]any, len(src))
+		for i, item := range src {
+			out[i] = toInterface(item)
+		}
+		return out
+	case *object:
+		out := make(map[string]any, len(src.keys))
+		for i, key := range src.keys {
+			out[key] = toInterface(src.values[i])
+		}
+		return out
+	}
+	return src
+}
+
+// toJSON renders a decoded tree as JSON for UnmarshalJSON methods.
+func toJSON(src any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, src any) error {
+	switch src := src.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(src))
+	case int64:
+		buf.WriteString(strconv.FormatInt(src, 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(src, 10))
+	case float64, string, []byte:
+		data, err := json.Marshal(src)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range src {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case *object:
+		buf.WriteByte('{')
+		for i, key := range src.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			data, _ := json.Marshal(key)
+			buf.Write(data)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, src.values[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return nil
+}
+
+func describe(src any) string {
+	switch src.(type) {
+	case bool:
+		return "bool"
+	case int64, uint64, float64:
+		return "number"
+	case string:
+		return "string"
+	case []byte:
+		return "binary"
+	case []any:
+		return "array"
+	case *object:
+		return "map"
+	}
+	return "null"
+}
@@ -0,0 +1,415 @@
+package binarycodec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// emitter writes the wire form of JSON data model values.
+type emitter interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(i int64)
+	writeUint(u uint64)
+	writeFloat(f float64, bits int)
+	writeString(s string)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+	bytes() []byte
+}
+
+var (
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	jsonNumberType      = reflect.TypeFor[json.Number]()
+)
+
+type encodeState struct {
+	format Format
+	out    emitter
+	depth  int
+}
+
+func (s *encodeState) value(v reflect.Value) error {
+	if !v.IsValid() {
+		s.out.writeNil()
+		return nil
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+	if s.depth > maxDepth {
+		return fmt.Errorf("%s: exceeded max depth", s.format)
+	}
+
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			s.out.writeNil()
+			return nil
+		}
+		if t.Kind() == reflect.Interface {
+			return s.value(v.Elem())
+		}
+	}
+	if t.Implements(jsonMarshalerType) {
+		return s.marshalJSON(v.Interface().(json.Marshaler))
+	}
+	if t.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return s.marshalJSON(v.Addr().Interface().(json.Marshaler))
+	}
+	if t.Implements(textMarshalerType) {
+		return s.marshalText(v.Interface().(encoding.TextMarshaler))
+	}
+	if t.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(t).Implements(textMarshalerType) {
+		return s.marshalText(v.Addr().Interface().(encoding.TextMarshaler))
+	}
+	if t == jsonNumberType {
+		return s.number(v.String())
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		s.out.writeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.out.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.out.writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%s: unsupported value %v", s.format, f)
+		}
+		s.out.writeFloat(f, t.Bits())
+	case reflect.String:
+		s.string(v.String())
+	case reflect.Pointer:
+		return s.value(v.Elem())
+	case reflect.Struct:
+		return s.structValue(v)
+	case reflect.Map:
+		return s.mapValue(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			s.out.writeNil()
+			return nil
+		}
+		if isByteSlice(t) {
+			s.out.writeString(base64.StdEncoding.EncodeToString(v.Bytes()))
+			return nil
+		}
+		return s.array(v)
+	case reflect.Array:
+		return s.array(v)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", s.format, t)
+	}
+	return nil
+}
+
+func (s *encodeState) string(str string) {
+	if !utf8.ValidString(str) {
+		str = strings.ToValidUTF8(str, "�")
+	}
+	s.out.writeString(str)
+}
+
+func (s *encodeState) number(literal string) error {
+	if literal == "" {
+		literal = "0"
+	}
+	if i, err := strconv.ParseInt(literal, 10, 64); err == nil {
+		s.out.writeInt(i)
+		return nil
+	}
+	if u, err := strconv.ParseUint(literal, 10, 64); err == nil {
+		s.out.writeUint(u)
+		return nil
+	}
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return fmt.Errorf("%s: invalid number literal %q", s.format, literal)
+	}
+	s.out.writeFloat(f, 64)
+	return nil
+}
+
+func (s *encodeState) array(v reflect.Value) error {
+	s.out.writeArrayHeader(v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := s.value(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *encodeState) structValue(v reflect.Value) error {
+	fields := cachedFields(v.Type())
+	type entry struct {
+		field field
+		value reflect.Value
+	}
+	entries := make([]entry, 0, len(fields.list))
+	for _, f := range fields.list {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) || f.omitZero && isZeroValue(fv) {
+			continue
+		}
+		entries = append(entries, entry{field: f, value: fv})
+	}
+	s.out.writeMapHeader(len(entries))
+	for _, e := range entries {
+		s.out.writeString(e.field.name)
+		if e.field.quoted {
+			if err := s.quoted(e.value); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.value(e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoted encodes a field with the json ",string" option as the string JSON
+// would produce.
+func (s *encodeState) quoted(v reflect.Value) error {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		s.out.writeNil()
+		return nil
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	s.string(string(data))
+	return nil
+}
+
+func (s *encodeState) mapValue(v reflect.Value) error {
+	if v.IsNil() {
+		s.out.writeNil()
+		return nil
+	}
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := s.mapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	s.out.writeMapHeader(len(entries))
+	for _, e := range entries {
+		s.string(e.key)
+		if err := s.value(e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *encodeState) mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("%s: unsupported map key type %s", s.format, k.Type())
+}
+
+func (s *encodeState) marshalJSON(m json.Marshaler) error {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tree, err := jsonTree(dec, 0)
+	if err != nil {
+		return fmt.Errorf("%s: MarshalJSON returned invalid JSON: %w", s.format, err)
+	}
+	return s.generic(tree)
+}
+
+func (s *encodeState) marshalText(m encoding.TextMarshaler) error {
+	text, err := m.MarshalText()
+	if err != nil {
+		return err
+	}
+	s.string(string(text))
+	return nil
+}
+
+// generic encodes a decoded tree: nil, bool, int64, uint64, float64,
+// json.Number, string, []byte, []any, or *object.
+func (s *encodeState) generic(value any) error {
+	switch value := value.(type) {
+	case nil:
+		s.out.writeNil()
+	case bool:
+		s.out.writeBool(value)
+	case int64:
+		s.out.writeInt(value)
+	case uint64:
+		s.out.writeUint(value)
+	case float64:
+		s.out.writeFloat(value, 64)
+	case json.Number:
+		return s.number(value.String())
+	case string:
+		s.string(value)
+	case []byte:
+		s.out.writeString(base64.StdEncoding.EncodeToString(value))
+	case []any:
+		s.out.writeArrayHeader(len(value))
+		for _, item := range value {
+			if err := s.generic(item); err != nil {
+				return err
+			}
+		}
+	case *object:
+		s.out.writeMapHeader(len(value.keys))
+		for i, key := range value.keys {
+			s.string(key)
+			if err := s.generic(value.values[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unsupported value %T", s.format, value)
+	}
+	return nil
+}
+
+// jsonTree reads one JSON value into a tree that keeps object key order.
+func jsonTree(dec *json.Decoder, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("exceeded max depth")
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '[':
+		var items []any
+		for dec.More() {
+			item, err := jsonTree(dec, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		if items == nil {
+			items = []any{}
+		}
+		return items, err
+	case '{':
+		obj := &object{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := tok.(string)
+			value, err := jsonTree(dec, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key)
+			obj.values = append(obj.values, value)
+		}
+		_, err := dec.Token()
+		return obj, err
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// fieldByIndex walks index like reflect.Value.FieldByIndex, reporting false
+// when it passes through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isByteSlice(t reflect.Type) bool {
+	if t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	elem := reflect.PointerTo(t.Elem())
+	return !elem.Implements(jsonMarshalerType) && !elem.Implements(textMarshalerType)
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+func isZeroValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return true
+		}
+		return z.IsZero()
+	}
+	return v.IsZero()
+}
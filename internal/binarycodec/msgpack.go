@@ -0,0 +1,218 @@
+package binarycodec
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) bytes() []byte { return w.buf }
+
+func (w *msgpackWriter) writeNil() { w.buf = append(w.buf, 0xc0) }
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xc3)
+		return
+	}
+	w.buf = append(w.buf, 0xc2)
+}
+
+func (w *msgpackWriter) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		w.writeUint(uint64(i))
+	case i >= -32:
+		w.buf = append(w.buf, byte(int8(i)))
+	case i >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(int8(i)))
+	case i >= math.MinInt16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xd1), uint16(int16(i)))
+	case i >= math.MinInt32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xd2), uint32(int32(i)))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xd3), uint64(i))
+	}
+}
+
+func (w *msgpackWriter) writeUint(u uint64) {
+	switch {
+	case u < 0x80:
+		w.buf = append(w.buf, byte(u))
+	case u <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xce), uint32(u))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcf), u)
+	}
+}
+
+func (w *msgpackWriter) writeFloat(f float64, bits int) {
+	if bits == 32 {
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xca), math.Float32bits(float32(f)))
+		return
+	}
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcb), math.Float64bits(f))
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xda), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xdb), uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xdc), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xdd), uint32(n))
+	}
+}
+
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xde), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xdf), uint32(n))
+	}
+}
+
+func (r *reader) msgpackValue() (any, error) {
+	b, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b <= 0x7f:
+		return uint64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return r.msgpackMap(uint64(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return r.msgpackArray(uint64(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return r.text(uint64(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << int(b-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return r.binary(n)
+	case 0xca:
+		bits, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(bits))), nil
+	case 0xcb:
+		bits, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(bits), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return r.uint(1 << int(b-0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << int(b-0xd0)
+		u, err := r.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*size
+		i := int64(u<<shift) >> shift
+		if i >= 0 {
+			return uint64(i), nil
+		}
+		return i, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << int(b-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.text(n)
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << int(b-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.msgpackArray(n)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << int(b-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.msgpackMap(n)
+	case 0xc7, 0xc8, 0xc9, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return nil, r.errorf("extension types are not supported")
+	}
+	return nil, r.errorf("invalid type byte 0x%02x", b)
+}
+
+func (r *reader) msgpackArray(n uint64) (any, error) {
+	if err := r.enter(n); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	items := make([]any, 0, n)
+	for i := uint64(0); i < n; i++ {
+		item, err := r.msgpackValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *reader) msgpackMap(n uint64) (any, error) {
+	if err := r.enter(n); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	obj := r.newObject(int(n))
+	for i := uint64(0); i < n; i++ {
+		key, err := r.msgpackValue()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.msgpackValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.add(obj, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return obj.obj, nil
+}
@@ -0,0 +1,137 @@
+package binarycodec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// reader parses one wire value into a tree of nil, bool, int64 (negative
+// integers), uint64, float64, string, []byte, []any, and *object.
+type reader struct {
+	format Format
+	data   []byte
+	pos    int
+	strict bool
+	depth  int
+}
+
+func (r *reader) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s: "+format, append([]any{r.format}, args...)...)
+}
+
+func (r *reader) truncated() error {
+	return r.errorf("unexpected end of data")
+}
+
+func (r *reader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, r.truncated()
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) take(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, r.truncated()
+	}
+	out := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return out, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (r *reader) uint(size int) (uint64, error) {
+	b, err := r.take(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (r *reader) text(n uint64) (string, error) {
+	b, err := r.take(n)
+	if err != nil {
+		return "", err
+	}
+	return validString(b), nil
+}
+
+func (r *reader) binary(n uint64) ([]byte, error) {
+	b, err := r.take(n)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// enter guards nesting depth and checks that a container of n items could
+// fit in the remaining data, so hostile length prefixes cannot force large
+// allocations.
+func (r *reader) enter(n uint64) error {
+	r.depth++
+	if r.depth > maxDepth {
+		return r.errorf("exceeded max depth")
+	}
+	if n > uint64(len(r.data)-r.pos) {
+		return r.truncated()
+	}
+	return nil
+}
+
+func (r *reader) leave() {
+	r.depth--
+}
+
+// objectBuilder collects map entries and rejects duplicate keys in strict
+// mode.
+type objectBuilder struct {
+	obj  *object
+	seen map[string]struct{}
+}
+
+func (r *reader) newObject(n int) *objectBuilder {
+	b := &objectBuilder{obj: &object{
+		keys:   make([]string, 0, n),
+		values: make([]any, 0, n),
+	}}
+	if r.strict {
+		b.seen = make(map[string]struct{}, n)
+	}
+	return b
+}
+
+func (r *reader) add(b *objectBuilder, key any, value any) error {
+	name, ok := key.(string)
+	if !ok {
+		return r.errorf("map key is not a string")
+	}
+	if b.seen != nil {
+		if _, exists := b.seen[name]; exists {
+			return r.errorf("duplicate map key %q", name)
+		}
+		b.seen[name] = struct{}{}
+	}
+	b.obj.keys = append(b.obj.keys, name)
+	b.obj.values = append(b.obj.values, value)
+	return nil
+}
+
+func validString(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return strings.ToValidUTF8(string(b), "�")
+}
@@ -14,7 +14,7 @@ import (
 
 const (
 	virtuousModulePath       = "github.com/swetjen/virtuous"
-	fallbackVirtuousVersion  = "0.0.56"
+	fallbackVirtuousVersion  = "0.0.57"
 	generatedTimestampFormat = "2006-01-02 15:04:05 UTC"
 )
 
@@ -53,6 +53,21 @@ func GeneratedLine(commentPrefix string, generatedAt time.Time) string {
 	)
 }
 
+// StripGeneratedLine removes the provenance line written by
+// WriteArtifactHeader, whose version and timestamp change between runs.
+func StripGeneratedLine(data []byte) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	out := make([]byte, 0, len(data))
+	for _, line := range lines {
+		text := strings.TrimSpace(string(line))
+		if strings.Contains(text, " Code generated by Virtuous ") && strings.HasSuffix(text, "; DO NOT EDIT.") {
+			continue
+		}
+		out = append(out, line...)
+	}
+	return out
+}
+
 // FormatGeneratedAt renders generation timestamps in a stable UTC format.
 func FormatGeneratedAt(generatedAt time.Time) string {
 	return generatedAt.UTC().Format(generatedTimestampFormat)
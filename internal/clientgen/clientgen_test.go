@@ -46,3 +46,14 @@ func TestBuildVersionRejectsLocalReplaceSentinel(t *testing.T) {
 		}
 	}
 }
+
+func TestStripGeneratedLineIgnoresTimestamp(t *testing.T) {
+	first := "// Virtuous client hash: abc\n" + GeneratedLine("//", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) + "export {}\n"
+	second := "// Virtuous client hash: abc\n" + GeneratedLine("//", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) + "export {}\n"
+	if string(StripGeneratedLine([]byte(first))) != string(StripGeneratedLine([]byte(second))) {
+		t.Fatalf("expected stripped outputs to match")
+	}
+	if got := string(StripGeneratedLine([]byte(first))); got != "// Virtuous client hash: abc\nexport {}\n" {
+		t.Fatalf("unexpected stripped output: %q", got)
+	}
+}
@@ -0,0 +1,22 @@
+package clientgen
+
+import "strings"
+
+// DocLines splits route documentation into lines for generated JSDoc blocks.
+// Comment terminators are escaped; blank lines between paragraphs are kept.
+func DocLines(parts ...string) []string {
+	var out []string
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		for _, line := range strings.Split(part, "\n") {
+			out = append(out, strings.ReplaceAll(strings.TrimRight(line, " \t\r"), "*/", `*\/`))
+		}
+	}
+	return out
+}
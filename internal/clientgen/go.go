@@ -0,0 +1,341 @@
+package clientgen
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GoClientOptions configures generated Go clients.
+type GoClientOptions struct {
+	// Package is the package name of the generated file. It defaults to
+	// "client".
+	Package string
+	// ImportTypes makes the client import the server's request and response
+	// types instead of generating copies. The types must be exported and
+	// live outside package main.
+	ImportTypes bool
+}
+
+// GoPackageName returns the configured package name or the default.
+func (o GoClientOptions) GoPackageName() string {
+	if o.Package == "" {
+		return "client"
+	}
+	name := strings.ToLower(GoIdentifier(o.Package))
+	if token.IsKeyword(name) {
+		return "client"
+	}
+	return name
+}
+
+var goInitialisms = map[string]struct{}{
+	"API":  {},
+	"HTML": {},
+	"HTTP": {},
+	"ID":   {},
+	"IP":   {},
+	"JSON": {},
+	"SQL":  {},
+	"URI":  {},
+	"URL":  {},
+	"UUID": {},
+}
+
+// GoIdentifier returns an exported Go identifier for a wire or schema name,
+// such as "UserID" for "user_id" or "userId".
+func GoIdentifier(name string) string {
+	var out strings.Builder
+	for _, word := range goWords(name) {
+		upper := strings.ToUpper(word)
+		if _, ok := goInitialisms[upper]; ok {
+			out.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		out.WriteString(string(runes))
+	}
+	ident := out.String()
+	if ident == "" {
+		return "X"
+	}
+	if first := []rune(ident)[0]; !unicode.IsLetter(first) {
+		return "X" + ident
+	}
+	return ident
+}
+
+// UniqueGoIdentifier returns an exported Go identifier that has not been used.
+func UniqueGoIdentifier(name string, used map[string]struct{}) string {
+	base := GoIdentifier(name)
+	candidate := base
+	for i := 2; ; i++ {
+		if _, ok := used[candidate]; !ok {
+			used[candidate] = struct{}{}
+			return candidate
+		}
+		candidate = base + strconv.Itoa(i)
+	}
+}
+
+// goWords splits a name on separators and lower-to-upper case changes.
+func goWords(name string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			flush()
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// RenameGoTypes replaces schema object names inside a rendered Go type, such
+// as "[]User" or "map[string]*User", using names.
+func RenameGoTypes(typ string, names map[string]string) string {
+	if typ == "" || len(names) == 0 {
+		return typ
+	}
+	oldNames := make([]string, 0, len(names))
+	for oldName, newName := range names {
+		if oldName != newName {
+			oldNames = append(oldNames, oldName)
+		}
+	}
+	if len(oldNames) == 0 {
+		return typ
+	}
+	sort.Slice(oldNames, func(i, j int) bool {
+		if len(oldNames[i]) != len(oldNames[j]) {
+			return len(oldNames[i]) > len(oldNames[j])
+		}
+		return oldNames[i] < oldNames[j]
+	})
+	var out strings.Builder
+	for i := 0; i < len(typ); {
+		replaced := false
+		if i == 0 || strings.ContainsRune("*[]", rune(typ[i-1])) {
+			for _, oldName := range oldNames {
+				end := i + len(oldName)
+				if strings.HasPrefix(typ[i:], oldName) && (end == len(typ) || typ[end] == ']') {
+					out.WriteString(names[oldName])
+					i = end
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			out.WriteByte(typ[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+// GoImport is an import line of a generated Go client.
+type GoImport struct {
+	Name string
+	Path string
+}
+
+// Spec renders the import spec, naming the package only when the name
+// differs from the last path element.
+func (i GoImport) Spec() string {
+	if i.Name == path.Base(i.Path) {
+		return strconv.Quote(i.Path)
+	}
+	return i.Name + " " + strconv.Quote(i.Path)
+}
+
+// GoTypeNamer renders Go types qualified by their packages for clients that
+// import the server's types, and collects the imports those types need.
+type GoTypeNamer struct {
+	taken   map[string]struct{}
+	aliases map[string]string
+	fixed   map[string]bool
+	errs    []string
+}
+
+// NewGoTypeNamer returns a namer that reuses the client's own imports and
+// avoids the reserved file-scope names.
+func NewGoTypeNamer(imports []GoImport, reserved ...string) *GoTypeNamer {
+	n := &GoTypeNamer{
+		taken:   map[string]struct{}{},
+		aliases: map[string]string{},
+		fixed:   map[string]bool{},
+	}
+	for _, imp := range imports {
+		n.taken[imp.Name] = struct{}{}
+		n.aliases[imp.Path] = imp.Name
+		n.fixed[imp.Path] = true
+	}
+	for _, name := range reserved {
+		n.taken[name] = struct{}{}
+	}
+	return n
+}
+
+// TypeOf renders t as Go source.
+func (n *GoTypeNamer) TypeOf(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		if t.PkgPath() == "main" || !token.IsExported(t.Name()) || strings.Contains(t.Name(), "[") {
+			n.reject(t)
+			return "any"
+		}
+		return n.alias(t.PkgPath()) + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + n.TypeOf(t.Elem())
+	case reflect.Slice:
+		return "[]" + n.TypeOf(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + n.TypeOf(t.Elem())
+	case reflect.Map:
+		return "map[" + n.TypeOf(t.Key()) + "]" + n.TypeOf(t.Elem())
+	case reflect.Struct:
+		return "map[string]any"
+	default:
+		return "any"
+	}
+}
+
+func (n *GoTypeNamer) reject(t reflect.Type) {
+	name := t.String()
+	for _, seen := range n.errs {
+		if seen == name {
+			return
+		}
+	}
+	n.errs = append(n.errs, name)
+}
+
+func (n *GoTypeNamer) alias(pkgPath string) string {
+	if alias, ok := n.aliases[pkgPath]; ok {
+		return alias
+	}
+	base := goPackageBase(pkgPath)
+	alias := base
+	for i := 2; ; i++ {
+		if _, ok := n.taken[alias]; !ok && !token.IsKeyword(alias) {
+			break
+		}
+		alias = base + strconv.Itoa(i)
+	}
+	n.taken[alias] = struct{}{}
+	n.aliases[pkgPath] = alias
+	return alias
+}
+
+// goPackageBase guesses a package name from an import path, skipping major
+// version suffixes such as "/v5".
+func goPackageBase(pkgPath string) string {
+	base := path.Base(pkgPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(pkgPath))
+	}
+	var out strings.Builder
+	for _, r := range strings.ToLower(base) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out.WriteRune(r)
+		}
+	}
+	name := out.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "pkg" + name
+	}
+	return name
+}
+
+// Imports returns the additional imports needed by the rendered types,
+// sorted by path.
+func (n *GoTypeNamer) Imports() []GoImport {
+	out := make([]GoImport, 0, len(n.aliases))
+	for pkgPath, alias := range n.aliases {
+		if !n.fixed[pkgPath] {
+			out = append(out, GoImport{Name: alias, Path: pkgPath})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// ImportsUsedBy returns the additional imports referenced by the rendered
+// types, sorted by path.
+func (n *GoTypeNamer) ImportsUsedBy(types ...string) []GoImport {
+	var out []GoImport
+	for _, imp := range n.Imports() {
+		for _, typ := range types {
+			if goTypeUsesPackage(typ, imp.Name) {
+				out = append(out, imp)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func goTypeUsesPackage(typ, name string) bool {
+	prefix := name + "."
+	for i := 0; i+len(prefix) <= len(typ); i++ {
+		if (i == 0 || strings.ContainsRune("*[]", rune(typ[i-1]))) && strings.HasPrefix(typ[i:], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Err reports types that cannot be imported by a generated client.
+func (n *GoTypeNamer) Err() error {
+	if len(n.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("go client cannot import types %s; export them from a non-main package or disable ImportTypes", strings.Join(n.errs, ", "))
+}
+
+// FormatGo formats generated Go source.
+func FormatGo(src []byte) ([]byte, error) {
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("format generated Go client: %w", err)
+	}
+	return out, nil
+}
+
+// GoCommentLines unescapes DocLines output for Go line comments.
+func GoCommentLines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.ReplaceAll(line, `*\/`, "*/")
+	}
+	return out
+}
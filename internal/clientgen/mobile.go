@@ -0,0 +1,186 @@
+package clientgen
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// KotlinClientOptions configures generated Kotlin clients.
+type KotlinClientOptions struct {
+	// Package is the package declaration of the generated file, such as
+	// "com.example.api". It defaults to "virtuous.client".
+	Package string
+}
+
+// KotlinPackageName returns the configured package or the default.
+func (o KotlinClientOptions) KotlinPackageName() string {
+	var segments []string
+	for _, segment := range strings.Split(o.Package, ".") {
+		var out strings.Builder
+		for _, r := range segment {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+				out.WriteRune(r)
+			}
+		}
+		name := out.String()
+		if name == "" {
+			continue
+		}
+		if unicode.IsDigit([]rune(name)[0]) {
+			name = "x" + name
+		}
+		if _, ok := kotlinKeywords[name]; ok {
+			name += "_"
+		}
+		segments = append(segments, name)
+	}
+	if len(segments) == 0 {
+		return "virtuous.client"
+	}
+	return strings.Join(segments, ".")
+}
+
+var swiftKeywords = keywordSet(
+	"Any", "Protocol", "Self", "Type", "as", "associatedtype", "break", "case", "catch", "class",
+	"continue", "default", "defer", "deinit", "do", "else", "enum", "extension", "fallthrough",
+	"false", "fileprivate", "for", "func", "guard", "if", "import", "in", "init", "inout",
+	"internal", "is", "let", "nil", "open", "operator", "private", "protocol", "public",
+	"repeat", "rethrows", "return", "self", "static", "struct", "subscript", "super", "switch",
+	"throw", "throws", "true", "try", "typealias", "var", "where", "while",
+)
+
+var kotlinKeywords = keywordSet(
+	"as", "break", "class", "continue", "do", "else", "false", "for", "fun", "if", "in",
+	"interface", "is", "null", "object", "package", "return", "super", "this", "throw", "true",
+	"try", "typealias", "typeof", "val", "var", "when", "while",
+)
+
+func keywordSet(words ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		set[word] = struct{}{}
+	}
+	return set
+}
+
+// LowerCamelIdentifier returns a lowerCamelCase identifier for a wire or Go
+// name, such as "userId" for "user_id" and "urlPath" for "URLPath".
+func LowerCamelIdentifier(name string) string {
+	words := goWords(name)
+	if len(words) == 0 {
+		return "x"
+	}
+	var out strings.Builder
+	for i, word := range words {
+		runes := []rune(word)
+		if i == 0 {
+			out.WriteString(lowerLeadingUpper(runes))
+			continue
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		out.WriteString(string(runes))
+	}
+	ident := out.String()
+	if !unicode.IsLetter([]rune(ident)[0]) {
+		return "x" + ident
+	}
+	return ident
+}
+
+// lowerLeadingUpper lowercases a leading run of capitals, keeping the last
+// one when it starts the next hump: "URLPath" becomes "urlPath".
+func lowerLeadingUpper(runes []rune) string {
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	if n == 0 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// UniqueLowerCamelIdentifier returns a lowerCamelCase identifier that has
+// not been used.
+func UniqueLowerCamelIdentifier(name string, used map[string]struct{}) string {
+	base := LowerCamelIdentifier(name)
+	candidate := base
+	for i := 2; ; i++ {
+		if _, ok := used[candidate]; !ok {
+			used[candidate] = struct{}{}
+			return candidate
+		}
+		candidate = base + strconv.Itoa(i)
+	}
+}
+
+// SwiftName escapes a Swift keyword used as an identifier.
+func SwiftName(ident string) string {
+	if _, ok := swiftKeywords[ident]; ok {
+		return "`" + ident + "`"
+	}
+	return ident
+}
+
+// KotlinName escapes a Kotlin keyword used as an identifier.
+func KotlinName(ident string) string {
+	if _, ok := kotlinKeywords[ident]; ok {
+		return "`" + ident + "`"
+	}
+	return ident
+}
+
+// SwiftString renders s as a Swift string literal.
+func SwiftString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s) + `"`
+}
+
+// KotlinString renders s as a Kotlin string literal.
+func KotlinString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s) + `"`
+}
+
+// RenameTypeTokens replaces schema object names inside a rendered type, such
+// as "[String: User]" or "List<User>", using names. Only whole identifiers are
+// replaced.
+func RenameTypeTokens(typ string, names map[string]string) string {
+	if typ == "" || len(names) == 0 {
+		return typ
+	}
+	var out strings.Builder
+	runes := []rune(typ)
+	for i := 0; i < len(runes); {
+		if !isIdentRune(runes[i]) {
+			out.WriteRune(runes[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && isIdentRune(runes[end]) {
+			end++
+		}
+		token := string(runes[i:end])
+		if renamed, ok := names[token]; ok {
+			token = renamed
+		}
+		out.WriteString(token)
+		i = end
+	}
+	return out.String()
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// BlockCommentText keeps doc text from closing a /** */ comment early.
+func BlockCommentText(text string) string {
+	return strings.ReplaceAll(text, "*/", `*\/`)
+}
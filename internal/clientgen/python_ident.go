@@ -3,6 +3,7 @@ package clientgen
 import (
 	"encoding/json"
 	"strconv"
+	"strings"
 )
 
 var pythonKeywords = map[string]struct{}{
@@ -104,3 +105,23 @@ func isPythonIdentChar(ch byte) bool {
 func isPythonDigit(ch byte) bool {
 	return ch >= '0' && ch <= '9'
 }
+
+// PythonConstraintComment renders field enum values and validation
+// constraints as a single comment body, or "" when there are none.
+func PythonConstraintComment(enumType string, constraints []string) string {
+	return ConstraintComment(enumType, constraints)
+}
+
+// ConstraintComment renders field enum values and validation constraints as a
+// single comment body, or "" when there are none.
+func ConstraintComment(enumType string, constraints []string) string {
+	parts := make([]string, 0, len(constraints)+1)
+	if enumType != "" {
+		parts = append(parts, "enum "+enumType)
+	}
+	parts = append(parts, constraints...)
+	if len(parts) == 0 {
+		return ""
+	}
+	return "constraints: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,19 @@
+//go:build virtuous_brotli
+
+package compression
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Building with -tags virtuous_brotli registers br with andybalholm/brotli.
+func init() {
+	Register("br", func(w io.Writer, level int) (io.WriteCloser, error) {
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		return brotli.NewWriterLevel(w, level), nil
+	})
+}
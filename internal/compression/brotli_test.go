@@ -0,0 +1,32 @@
+//go:build virtuous_brotli
+
+package compression
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestHandlerCompressesWithBrotli(t *testing.T) {
+	body := strings.Repeat("virtuous ", 200)
+	handler := Handler(Options{Level: 5}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+
+	rec := serve(handler, "gzip, br")
+	if rec.Header().Get("Content-Encoding") != "br" {
+		t.Fatalf("expected brotli response, got %v", rec.Header())
+	}
+	data, err := io.ReadAll(brotli.NewReader(rec.Body))
+	if err != nil {
+		t.Fatalf("unbrotli: %v", err)
+	}
+	if string(data) != body {
+		t.Fatalf("unexpected decompressed body %q", data)
+	}
+}
@@ -0,0 +1,135 @@
+// Package compression negotiates Accept-Encoding and compresses HTTP
+// responses for the rpc and httpapi routers.
+//
+// gzip is always available. zstd and brotli register themselves when the
+// binary is built with the virtuous_zstd or virtuous_brotli build tags.
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMinSize is the smallest response body compressed when Options.MinSize
+// is zero.
+const DefaultMinSize = 1024
+
+// Options configures response compression.
+type Options struct {
+	// Disabled turns compression off, for example on one route of a router
+	// that compresses by default.
+	Disabled bool
+	// MinSize is the smallest response body, in bytes, that is compressed.
+	// Zero uses DefaultMinSize.
+	MinSize int
+	// Encodings lists the encodings to offer in server preference order.
+	// Empty offers every registered encoding: br, zstd, then gzip.
+	// Unregistered names are ignored.
+	Encodings []string
+	// Level is passed to the encoder. Zero uses the encoder's default.
+	Level int
+}
+
+// WriterFunc returns a compressing writer for w at level, where zero means
+// the encoder's default.
+type WriterFunc func(w io.Writer, level int) (io.WriteCloser, error)
+
+var (
+	mu       sync.RWMutex
+	encoders = map[string]WriterFunc{"gzip": newGzipWriter}
+	// preference is the default server order when Options.Encodings is empty.
+	preference = []string{"br", "zstd", "gzip"}
+)
+
+// Register makes an encoding available under its Content-Encoding name.
+func Register(name string, fn WriterFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	encoders[strings.ToLower(name)] = fn
+}
+
+func lookup(name string) (WriterFunc, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	fn, ok := encoders[name]
+	return fn, ok
+}
+
+func newGzipWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+// Negotiate picks the first encoding in offered, in server preference order,
+// that acceptEncoding allows and that is registered. It returns "" for
+// identity.
+func Negotiate(acceptEncoding string, offered []string) string {
+	if len(offered) == 0 {
+		offered = preference
+	}
+	accepted := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		accepted[name] = q
+	}
+	for _, name := range offered {
+		name = strings.ToLower(name)
+		if _, ok := lookup(name); !ok {
+			continue
+		}
+		q, ok := accepted[name]
+		if !ok {
+			q = wildcard
+		}
+		if q > 0 {
+			return name
+		}
+	}
+	return ""
+}
+
+// Handler compresses next's responses according to opts.
+func Handler(opts Options, next http.Handler) http.Handler {
+	if opts.Disabled {
+		return next
+	}
+	minSize := opts.MinSize
+	if minSize <= 0 {
+		minSize = DefaultMinSize
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := Negotiate(req.Header.Get("Accept-Encoding"), opts.Encodings)
+		if encoding == "" || req.Method == http.MethodHead {
+			next.ServeHTTP(w, req)
+			return
+		}
+		cw := &writer{ResponseWriter: w, encoding: encoding, level: opts.Level, minSize: minSize}
+		defer cw.close()
+		next.ServeHTTP(cw, req)
+	})
+}
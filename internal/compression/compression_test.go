@@ -0,0 +1,113 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip;q=0.5", "gzip"},
+		{"gzip;q=0", ""},
+		{"*", preferred("br", "zstd", "gzip")},
+		{"*, gzip;q=0", preferred("br", "zstd")},
+		{"identity", ""},
+		{"br, GZIP", preferred("br", "gzip")},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.accept, nil); got != tc.want {
+			t.Fatalf("Negotiate(%q) = %q, want %q", tc.accept, got, tc.want)
+		}
+	}
+	if got := Negotiate("gzip", []string{"zstd"}); got != "" {
+		t.Fatalf("expected unregistered encodings to be skipped, got %q", got)
+	}
+}
+
+func TestHandlerCompressesAboveMinSize(t *testing.T) {
+	body := strings.Repeat("virtuous ", 200)
+	handler := Handler(Options{}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"abc"`)
+		_, _ = io.WriteString(w, body)
+	}))
+
+	rec := serve(handler, "gzip")
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("ETag") != `W/"abc"` {
+		t.Fatalf("expected gzip response with weak ETag, got %v", rec.Header())
+	}
+	if got := gunzip(t, rec.Body); got != body {
+		t.Fatalf("unexpected decompressed body %q", got)
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected Vary header, got %v", rec.Header())
+	}
+
+	rec = serve(handler, "")
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != body {
+		t.Fatalf("expected identity response without Accept-Encoding, got %v", rec.Header())
+	}
+}
+
+func TestHandlerSkipsSmallAndStreamedResponses(t *testing.T) {
+	small := Handler(Options{MinSize: 64}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	}))
+	rec := serve(small, "gzip")
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != `{"ok":true}` {
+		t.Fatalf("expected small response uncompressed, got %d %v", rec.Code, rec.Header())
+	}
+
+	stream := Handler(Options{MinSize: 1}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, strings.Repeat("data: x\n\n", 10))
+	}))
+	rec = serve(stream, "gzip")
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected event streams uncompressed, got %v", rec.Header())
+	}
+}
+
+// preferred returns the first of names that is registered, so the
+// expectations hold with and without the codec build tags.
+func preferred(names ...string) string {
+	for _, name := range names {
+		if _, ok := lookup(name); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func serve(handler http.Handler, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept-Encoding", accept)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func gunzip(t *testing.T, r io.Reader) string {
+	t.Helper()
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,149 @@
+package compression
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// writer buffers the start of a response until it reaches minSize, then
+// commits to compressing it. Smaller responses, responses flushed before
+// reaching minSize, and responses that are not worth compressing are written
+// as-is.
+type writer struct {
+	http.ResponseWriter
+	encoding string
+	level    int
+	minSize  int
+	status   int
+	buf      []byte
+	decided  bool
+	enc      io.WriteCloser
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	if status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		w.decide(false)
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if !w.compressible() {
+			w.decide(false)
+		} else {
+			w.buf = append(w.buf, p...)
+			if len(w.buf) >= w.minSize {
+				if err := w.decide(true); err != nil {
+					return 0, err
+				}
+			}
+			return len(p), nil
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush commits to the current decision, so streamed responses that flush
+// before reaching minSize are sent uncompressed.
+func (w *writer) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		_ = w.decide(len(w.buf) >= w.minSize)
+	}
+	if flusher, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *writer) close() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return
+		}
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		_ = w.decide(len(w.buf) >= w.minSize)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+	}
+}
+
+// decide writes the status line and any buffered bytes, compressing them when
+// compress is set and the response allows it.
+func (w *writer) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	compress = compress && w.compressible()
+	if compress {
+		if fn, ok := lookup(w.encoding); ok {
+			enc, err := fn(w.ResponseWriter, w.level)
+			if err == nil {
+				w.enc = enc
+			}
+		}
+	}
+	if w.enc != nil {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// compressible reports whether the response headers allow compression.
+func (w *writer) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		return false
+	case strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "image/svg"):
+		return false
+	case strings.HasPrefix(contentType, "video/"), strings.HasPrefix(contentType, "audio/"):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,19 @@
+//go:build virtuous_zstd
+
+package compression
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Building with -tags virtuous_zstd registers zstd with klauspost/compress.
+func init() {
+	Register("zstd", func(w io.Writer, level int) (io.WriteCloser, error) {
+		if level == 0 {
+			return zstd.NewWriter(w)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	})
+}
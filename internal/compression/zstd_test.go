@@ -0,0 +1,37 @@
+//go:build virtuous_zstd
+
+package compression
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestHandlerCompressesWithZstd(t *testing.T) {
+	body := strings.Repeat("virtuous ", 200)
+	handler := Handler(Options{}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+
+	rec := serve(handler, "gzip, zstd")
+	if rec.Header().Get("Content-Encoding") != "zstd" {
+		t.Fatalf("expected zstd response, got %v", rec.Header())
+	}
+	zr, err := zstd.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("zstd reader: %v", err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("unzstd: %v", err)
+	}
+	if string(data) != body {
+		t.Fatalf("unexpected decompressed body %q", data)
+	}
+}
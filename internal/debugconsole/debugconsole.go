@@ -2,6 +2,7 @@ package debugconsole
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -37,6 +38,8 @@ func (l *Logger) Capture(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		start := time.Now()
 		rec := &responseRecorder{ResponseWriter: w}
+		incident := new(string)
+		req = req.WithContext(context.WithValue(req.Context(), incidentKey{}, incident))
 		defer func() {
 			recovered := recover()
 			status := rec.Status()
@@ -51,6 +54,7 @@ func (l *Logger) Capture(next http.Handler) http.Handler {
 				Bytes:    rec.BytesWritten(),
 				Duration: time.Since(start),
 				IP:       clientIP(req),
+				Incident: *incident,
 			})
 			if recovered != nil {
 				panic(recovered)
@@ -60,6 +64,16 @@ func (l *Logger) Capture(next http.Handler) http.Handler {
 	})
 }
 
+type incidentKey struct{}
+
+// SetIncidentID records the incident ID of a recovered panic so the request
+// line printed by Capture carries it. It does nothing outside Capture.
+func SetIncidentID(ctx context.Context, id string) {
+	if incident, ok := ctx.Value(incidentKey{}).(*string); ok {
+		*incident = id
+	}
+}
+
 // RequestLine describes one completed HTTP request.
 type RequestLine struct {
 	Method   string
@@ -69,6 +83,8 @@ type RequestLine struct {
 	Bytes    int64
 	Duration time.Duration
 	IP       string
+	// Incident is the incident ID of a recovered panic.
+	Incident string
 }
 
 // Print writes one compact request line.
@@ -102,7 +118,11 @@ func (l *Logger) Print(line RequestLine) {
 	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	fmt.Fprintf(l.writer, "[virtuous] %s %s %s %s %s ip=%s route=%s bytes=%d\n",
+	incident := ""
+	if line.Incident != "" {
+		incident = " incident=" + line.Incident
+	}
+	fmt.Fprintf(l.writer, "[virtuous] %s %s %s %s %s ip=%s route=%s bytes=%d%s\n",
 		badge,
 		status,
 		method,
@@ -111,6 +131,7 @@ func (l *Logger) Print(line RequestLine) {
 		ip,
 		route,
 		line.Bytes,
+		incident,
 	)
 }
 
@@ -0,0 +1,19 @@
+// Package incident generates the IDs that link a recovered panic's response,
+// log line, and observability records.
+package incident
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// NewID returns a random identifier for one recovered panic.
+func NewID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
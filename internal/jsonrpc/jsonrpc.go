@@ -0,0 +1,108 @@
+// Package jsonrpc implements the JSON-RPC 2.0 message envelope shared by the
+// MCP server and the RPC batch endpoint.
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Version is the only supported JSON-RPC protocol version.
+const Version = "2.0"
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// CodeServerError is the first of the implementation-defined server
+	// error codes (-32000 to -32099).
+	CodeServerError = -32000
+)
+
+// Request is one JSON-RPC request or notification.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification reports whether the request has no id and expects no response.
+func (r Request) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+// Response is one JSON-RPC response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Result returns a success response for id.
+func Result(id json.RawMessage, result any) Response {
+	return Response{JSONRPC: Version, ID: responseID(id), Result: result}
+}
+
+// Failure returns an error response for id.
+func Failure(id json.RawMessage, code int, message string, data any) Response {
+	return Response{JSONRPC: Version, ID: responseID(id), Error: &Error{Code: code, Message: message, Data: data}}
+}
+
+func responseID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// Decode parses a single request or a batch. A batch reports batch=true even
+// when it is empty or contains invalid members. Members that are not valid
+// request objects are returned as invalid (non-nil) error responses in place.
+func Decode(data []byte) (requests []Request, invalid []*Response, batch bool, err error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, nil, true, err
+		}
+		requests = make([]Request, len(raw))
+		invalid = make([]*Response, len(raw))
+		for i, item := range raw {
+			req, failure := decodeOne(item)
+			requests[i] = req
+			invalid[i] = failure
+		}
+		return requests, invalid, true, nil
+	}
+	var probe json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, nil, false, err
+	}
+	req, failure := decodeOne(data)
+	return []Request{req}, []*Response{failure}, false, nil
+}
+
+func decodeOne(data json.RawMessage) (Request, *Response) {
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		failure := Failure(nil, CodeInvalidRequest, "invalid request", nil)
+		return Request{}, &failure
+	}
+	if req.JSONRPC != Version || req.Method == "" {
+		failure := Failure(req.ID, CodeInvalidRequest, "invalid request", nil)
+		return Request{}, &failure
+	}
+	return req, nil
+}
@@ -37,6 +37,9 @@ type JSONField struct {
 	OmitEmpty      bool
 	ParentOptional bool
 	Field          reflect.StructField
+	// Index is the full field index path from the resolved struct, suitable
+	// for reflect.Value.FieldByIndex.
+	Index []int
 }
 
 type jsonFieldCandidate struct {
@@ -71,6 +74,7 @@ func JSONFields(t reflect.Type) []JSONField {
 	resolved := make([]JSONField, 0, len(fields))
 	for _, field := range fields {
 		resolved = append(resolved, field.JSONField)
+		resolved[len(resolved)-1].Index = field.index
 	}
 	return resolved
 }
@@ -0,0 +1,663 @@
+package rpc
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"text/template"
+
+	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/schema"
+)
+
+// GoClientOptions configures generated Go clients.
+type GoClientOptions = clientgen.GoClientOptions
+
+var clientGoTemplate = template.Must(template.New("virtuous-rpc-go").Parse(`// Package {{ .Package }} is a typed client for a Virtuous RPC API.
+package {{ .Package }}
+
+import (
+{{- range $imp := .Imports }}
+	{{ $imp.Spec }}
+{{- end }}
+)
+
+// Error is returned when a call responds with a non-2xx status.
+type Error struct {
+	// Status is the HTTP status code.
+	Status int
+	// Code and Message are read from the Virtuous error envelope when the
+	// body carries one.
+	Code    string
+	Message string
+	// Body is the raw response body.
+	Body []byte
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return strconv.Itoa(e.Status) + " " + http.StatusText(e.Status) + ": " + e.Message
+	}
+	return strconv.Itoa(e.Status) + " " + http.StatusText(e.Status)
+}
+
+// Decode unmarshals the error body into v, such as the method's response
+// type for handlers that return it with an error status.
+func (e *Error) Decode(v any) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+func newError(status int, body []byte) *Error {
+	err := &Error{Status: status, Body: body}
+	var envelope struct {
+		Code    string ` + "`json:\"code\"`" + `
+		Message string ` + "`json:\"message\"`" + `
+	}
+	if json.Unmarshal(body, &envelope) == nil {
+		err.Code = envelope.Code
+		err.Message = envelope.Message
+	}
+	return err
+}
+
+// Client calls the services of a Virtuous RPC router.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+{{- range $service := .Services }}
+	{{ $service.Field }} *{{ $service.Type }}
+{{- end }}
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for calls. It defaults to
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// New returns a client for the router served at baseURL, such as
+// "https://api.example.com".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+{{- range $service := .Services }}
+	c.{{ $service.Field }} = &{{ $service.Type }}{client: c}
+{{- end }}
+	return c
+}
+
+// CallOption configures a single call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	auth           string
+	timeout        time.Duration
+	idempotencyKey string
+	header         http.Header
+}
+
+// WithAuth sets the credential for guarded methods. It is sent where the
+// method's guard reads it, with the guard's prefix, such as "Bearer ".
+func WithAuth(value string) CallOption {
+	return func(o *callOptions) {
+		o.auth = value
+	}
+}
+
+// WithTimeout asks the server to bound the call, sent as
+// X-Virtuous-Timeout-Ms. Use the context to bound it on the client.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithHeader adds a request header.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Add(key, value)
+	}
+}
+{{- if .HasIdempotent }}
+
+// WithIdempotencyKey sets the Idempotency-Key of an idempotent method. A
+// random key is generated when it is not set.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
+{{- end }}
+
+type authGuard struct {
+	in     string
+	param  string
+	prefix string
+}
+
+type rpcCall struct {
+	path       string
+	body       any
+	hasBody    bool
+	streaming  bool
+	readOnly   bool
+	idempotent bool
+	auth       *authGuard
+}
+
+func (c *Client) call(ctx context.Context, call rpcCall, out any, opts []CallOption) error {
+	resp, err := c.send(ctx, call, "application/json", opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp.StatusCode, body)
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (c *Client) send(ctx context.Context, call rpcCall, accept string, opts []CallOption) (*http.Response, error) {
+	var options callOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	method := http.MethodPost
+	query := url.Values{}
+	var body io.Reader
+	if call.hasBody {
+		data, err := json.Marshal(call.body)
+		if err != nil {
+			return nil, err
+		}
+		if call.readOnly {
+			query.Set("request", string(data))
+		} else {
+			body = bytes.NewReader(data)
+		}
+	}
+	if call.readOnly {
+		method = http.MethodGet
+	}
+	authValue := ""
+	if call.auth != nil && options.auth != "" {
+		authValue = options.auth
+		if call.auth.prefix != "" {
+			authValue = call.auth.prefix + " " + authValue
+		}
+		if call.auth.in == "query" {
+			query.Set(call.auth.param, authValue)
+		}
+	}
+	endpoint := c.baseURL + call.path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range options.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Accept", accept)
+	if !call.readOnly {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if options.timeout > 0 && !call.streaming {
+		req.Header.Set("X-Virtuous-Timeout-Ms", strconv.FormatInt((options.timeout+time.Millisecond-1).Milliseconds(), 10))
+	}
+{{- if .HasIdempotent }}
+	if call.idempotent {
+		key := options.idempotencyKey
+		if key == "" {
+			key = newIdempotencyKey()
+		}
+		req.Header.Set("Idempotency-Key", key)
+	}
+{{- end }}
+	if authValue != "" {
+		switch call.auth.in {
+		case "header":
+			req.Header.Set(call.auth.param, authValue)
+		case "cookie":
+			req.AddCookie(&http.Cookie{Name: call.auth.param, Value: authValue})
+		}
+	}
+	return c.httpClient.Do(req)
+}
+{{- if .HasStreams }}
+
+// Stream reads the messages of a streaming method. Call Next until it
+// returns false, then check Err.
+type Stream[T any] struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	value  T
+	err    error
+	done   bool
+}
+
+func openStream[T any](ctx context.Context, c *Client, call rpcCall, opts []CallOption) (*Stream[T], error) {
+	resp, err := c.send(ctx, call, "text/event-stream", opts)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newError(resp.StatusCode, body)
+	}
+	return &Stream[T]{body: resp.Body, reader: bufio.NewReader(resp.Body)}, nil
+}
+
+// Next reads the next message and reports whether there is one.
+func (s *Stream[T]) Next() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	event := "message"
+	var data []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			s.err = errors.New("stream closed before completion")
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[len("event:"):])
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(line[len("data:"):]))
+		case line == "":
+			payload := []byte(strings.Join(data, "\n"))
+			switch {
+			case event == "done":
+				s.done = true
+				return false
+			case event == "error":
+				var status struct {
+					Status int ` + "`json:\"status\"`" + `
+				}
+				status.Status = http.StatusInternalServerError
+				if len(payload) > 0 {
+					_ = json.Unmarshal(payload, &status)
+				}
+				s.err = newError(status.Status, payload)
+				return false
+			case len(data) > 0:
+				var value T
+				if err := json.Unmarshal(payload, &value); err != nil {
+					s.err = err
+					return false
+				}
+				s.value = value
+				return true
+			}
+			event = "message"
+			data = data[:0]
+		}
+	}
+}
+
+// Value returns the message read by the last call to Next.
+func (s *Stream[T]) Value() T {
+	return s.value
+}
+
+// Err returns the error that ended the stream, or nil when the server
+// completed it.
+func (s *Stream[T]) Err() error {
+	return s.err
+}
+
+// Close releases the stream's connection.
+func (s *Stream[T]) Close() error {
+	return s.body.Close()
+}
+{{- end }}
+{{- range $object := .Objects }}
+
+type {{ $object.Name }} struct {
+{{- range $field := $object.Fields }}
+{{- range $line := $field.Doc }}
+	// {{ $line }}
+{{- end }}
+	{{ $field.Name }} {{ $field.Type }} {{ $field.Tag }}
+{{- end }}
+}
+{{- end }}
+{{- range $service := .Services }}
+
+// {{ $service.Type }} calls the {{ $service.Name }} service.
+type {{ $service.Type }} struct {
+	client *Client
+}
+{{- range $method := $service.Methods }}
+{{ range $line := $method.Doc }}
+//{{ if $line }} {{ $line }}{{ end }}
+{{- end }}
+{{- if $method.Deprecated }}
+{{- if $method.Doc }}
+//
+{{- end }}
+// Deprecated: {{ if $method.DeprecationMessage }}{{ $method.DeprecationMessage }}{{ else }}{{ $method.Name }} is deprecated.{{ end }}
+{{- end }}
+{{- if $method.Streaming }}
+func (s *{{ $service.Type }}) {{ $method.Name }}(ctx context.Context{{ if $method.HasBody }}, request {{ $method.RequestType }}{{ end }}, opts ...CallOption) (*Stream[{{ $method.ResponseType }}], error) {
+	return openStream[{{ $method.ResponseType }}](ctx, s.client, {{ $method.Call }}, opts)
+}
+{{- else if $method.ResponseType }}
+func (s *{{ $service.Type }}) {{ $method.Name }}(ctx context.Context{{ if $method.HasBody }}, request {{ $method.RequestType }}{{ end }}, opts ...CallOption) ({{ $method.ResponseType }}, error) {
+	var response {{ $method.ResponseType }}
+	err := s.client.call(ctx, {{ $method.Call }}, &response, opts)
+	return response, err
+}
+{{- else }}
+func (s *{{ $service.Type }}) {{ $method.Name }}(ctx context.Context{{ if $method.HasBody }}, request {{ $method.RequestType }}{{ end }}, opts ...CallOption) error {
+	return s.client.call(ctx, {{ $method.Call }}, nil, opts)
+}
+{{- end }}
+{{- end }}
+{{- end }}
+`))
+
+type goClientSpec struct {
+	Package       string
+	Imports       []clientgen.GoImport
+	Services      []goClientService
+	Objects       []goClientObject
+	HasStreams    bool
+	HasIdempotent bool
+}
+
+type goClientService struct {
+	Name    string
+	Field   string
+	Type    string
+	Methods []goClientMethod
+}
+
+type goClientMethod struct {
+	Name               string
+	HasBody            bool
+	Streaming          bool
+	RequestType        string
+	ResponseType       string
+	Call               string
+	Doc                []string
+	Deprecated         bool
+	DeprecationMessage string
+}
+
+type goClientObject struct {
+	Name   string
+	Fields []goClientField
+}
+
+type goClientField struct {
+	Name string
+	Type string
+	Tag  string
+	Doc  []string
+}
+
+// goClientRuntimeNames are the file-scope names of the generated runtime.
+var goClientRuntimeNames = []string{
+	"CallOption", "Client", "Error", "New", "Option", "Stream",
+	"WithAuth", "WithHTTPClient", "WithHeader", "WithIdempotencyKey", "WithTimeout",
+	"authGuard", "callOptions", "newError", "newIdempotencyKey", "openStream", "rpcCall",
+}
+
+func goClientImports(spec clientSpec) []clientgen.GoImport {
+	imports := []clientgen.GoImport{
+		{Name: "bytes", Path: "bytes"},
+		{Name: "context", Path: "context"},
+		{Name: "json", Path: "encoding/json"},
+		{Name: "io", Path: "io"},
+		{Name: "http", Path: "net/http"},
+		{Name: "url", Path: "net/url"},
+		{Name: "strconv", Path: "strconv"},
+		{Name: "strings", Path: "strings"},
+		{Name: "time", Path: "time"},
+	}
+	if spec.HasStreams {
+		imports = append(imports, clientgen.GoImport{Name: "bufio", Path: "bufio"}, clientgen.GoImport{Name: "errors", Path: "errors"})
+	}
+	if spec.HasIdempotent {
+		imports = append(imports, clientgen.GoImport{Name: "rand", Path: "crypto/rand"}, clientgen.GoImport{Name: "hex", Path: "encoding/hex"})
+	}
+	return imports
+}
+
+func buildGoClientSpec(routes []Route, overrides map[string]TypeOverride, opts GoClientOptions) (goClientSpec, error) {
+	probe := buildClientSpec(routes, overrides)
+	imports := goClientImports(probe)
+	var namer *clientgen.GoTypeNamer
+	var spec clientSpec
+	if opts.ImportTypes {
+		namer = clientgen.NewGoTypeNamer(imports, goClientReservedNames(probe.Services)...)
+		spec = buildClientSpecWith(routes, overrides, func(*schema.Registry) func(reflect.Type) string {
+			return namer.TypeOf
+		})
+		if err := namer.Err(); err != nil {
+			return goClientSpec{}, err
+		}
+		spec.Objects = nil
+		imports = append(imports, namer.ImportsUsedBy(goClientMethodTypes(spec.Services)...)...)
+	} else {
+		spec = buildClientSpecWith(routes, overrides, func(registry *schema.Registry) func(reflect.Type) string {
+			return registry.GoTypeOf
+		})
+	}
+
+	used := map[string]struct{}{}
+	for _, name := range goClientReservedNames(spec.Services) {
+		used[name] = struct{}{}
+	}
+	typeNames := make(map[string]string, len(spec.Objects))
+	for _, object := range spec.Objects {
+		typeNames[object.Name] = clientgen.UniqueGoIdentifier(object.Name, used)
+	}
+	out := goClientSpec{
+		Package:       opts.GoPackageName(),
+		Imports:       imports,
+		HasStreams:    spec.HasStreams,
+		HasIdempotent: spec.HasIdempotent,
+	}
+	for _, object := range spec.Objects {
+		goObject := goClientObject{Name: typeNames[object.Name]}
+		fieldNames := map[string]struct{}{}
+		for _, field := range object.Fields {
+			goObject.Fields = append(goObject.Fields, goClientFieldFor(field, typeNames, fieldNames))
+		}
+		out.Objects = append(out.Objects, goObject)
+	}
+	serviceFields := map[string]struct{}{}
+	for _, service := range spec.Services {
+		goService := goClientService{
+			Name:  service.Name,
+			Field: clientgen.UniqueGoIdentifier(service.Name, serviceFields),
+			Type:  goClientServiceType(service.Name),
+		}
+		methodNames := map[string]struct{}{}
+		for _, method := range service.Methods {
+			goService.Methods = append(goService.Methods, goClientMethod{
+				Name:               clientgen.UniqueGoIdentifier(method.Name, methodNames),
+				HasBody:            method.HasBody,
+				Streaming:          method.Streaming,
+				RequestType:        clientgen.RenameGoTypes(method.RequestType, typeNames),
+				ResponseType:       clientgen.RenameGoTypes(method.ResponseType, typeNames),
+				Call:               goClientCall(method),
+				Doc:                clientgen.GoCommentLines(method.Doc),
+				Deprecated:         method.Deprecated,
+				DeprecationMessage: method.DeprecationMessage,
+			})
+		}
+		out.Services = append(out.Services, goService)
+	}
+	return out, nil
+}
+
+func goClientMethodTypes(services []clientService) []string {
+	var types []string
+	for _, service := range services {
+		for _, method := range service.Methods {
+			types = append(types, method.RequestType, method.ResponseType)
+		}
+	}
+	return types
+}
+
+func goClientReservedNames(services []clientService) []string {
+	names := append([]string(nil), goClientRuntimeNames...)
+	for _, service := range services {
+		names = append(names, goClientServiceType(service.Name))
+	}
+	return names
+}
+
+func goClientServiceType(service string) string {
+	return clientgen.GoIdentifier(service) + "Service"
+}
+
+func goClientFieldFor(field schema.Field, typeNames map[string]string, used map[string]struct{}) goClientField {
+	tag := field.Name
+	if field.Optional {
+		tag += ",omitempty"
+	}
+	goField := goClientField{
+		Name: clientgen.UniqueGoIdentifier(field.Name, used),
+		Type: clientgen.RenameGoTypes(field.Type, typeNames),
+		Tag:  "`json:" + strconv.Quote(tag) + "`",
+	}
+	if field.Doc != "" {
+		goField.Doc = append(goField.Doc, field.Doc)
+	}
+	if comment := clientgen.ConstraintComment(field.EnumType, field.Constraints); comment != "" {
+		goField.Doc = append(goField.Doc, comment)
+	}
+	return goField
+}
+
+// goClientCall renders the rpcCall literal passed to the runtime.
+func goClientCall(method clientMethod) string {
+	call := "rpcCall{path: " + strconv.Quote(method.Path)
+	if method.HasBody {
+		call += ", body: request, hasBody: true"
+	}
+	if method.Streaming {
+		call += ", streaming: true"
+	}
+	if method.ReadOnly {
+		call += ", readOnly: true"
+	}
+	if method.Idempotent {
+		call += ", idempotent: true"
+	}
+	if method.HasAuth {
+		call += ", auth: &authGuard{in: " + strconv.Quote(method.Auth.In) + ", param: " + strconv.Quote(method.Auth.Param) + ", prefix: " + strconv.Quote(method.Auth.Prefix) + "}"
+	}
+	return call + "}"
+}
+
+// WriteClientGo writes a runtime-generated Go client to w.
+func (r *Router) WriteClientGo(w io.Writer) error {
+	body, err := r.clientGoBody()
+	if err != nil {
+		return err
+	}
+	hash := clientgen.HashBytes(body)
+	if err := clientgen.WriteArtifactHeader(w, "//", "Virtuous client hash", hash); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteClientGoFile writes a runtime-generated Go client to the file at path.
+func (r *Router) WriteClientGoFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WriteClientGo(f)
+}
+
+// WriteClientGoHash writes the hash of the stable Go client body to w.
+func (r *Router) WriteClientGoHash(w io.Writer) error {
+	body, err := r.clientGoBody()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, clientgen.HashBytes(body))
+	return err
+}
+
+// ServeClientGo writes a runtime-generated Go client as an HTTP response.
+func (r *Router) ServeClientGo(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/x-go; charset=utf-8")
+	if err := r.WriteClientGo(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SetGoClientOptions replaces the settings of generated Go clients.
+func (r *Router) SetGoClientOptions(opts GoClientOptions) {
+	r.goClient = opts
+}
+
+func (r *Router) clientGoBody() ([]byte, error) {
+	spec, err := buildGoClientSpec(r.Routes(), r.typeOverrides, r.goClient)
+	if err != nil {
+		return nil, err
+	}
+	body, err := clientgen.RenderTemplate(clientGoTemplate, spec)
+	if err != nil {
+		return nil, err
+	}
+	return clientgen.FormatGo(body)
+}
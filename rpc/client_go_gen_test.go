@@ -0,0 +1,213 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swetjen/virtuous/idempotency"
+	"github.com/swetjen/virtuous/internal/testtypes/client"
+)
+
+type goClientTask struct {
+	ID    string     `json:"id"`
+	Title string     `json:"title" minLength:"3"`
+	Tags  []string   `json:"tags,omitempty"`
+	Due   *time.Time `json:"due,omitempty"`
+	Owner *goClientOwner
+}
+
+type goClientOwner struct {
+	UserID string `json:"user_id"`
+}
+
+type goClientCreateTask struct {
+	Title string `json:"title"`
+}
+
+type goClientBearerGuard struct{}
+
+func (goClientBearerGuard) Spec() GuardSpec {
+	return GuardSpec{Name: "BearerAuth", In: "header", Param: "Authorization", Prefix: "Bearer"}
+}
+
+func (goClientBearerGuard) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CreateTask creates a task.
+func CreateTask(_ context.Context, req goClientCreateTask) (goClientTask, error) {
+	if req.Title == "" {
+		return goClientTask{}, Invalid("title is required", ErrorDetail{Field: "title", Message: "required"})
+	}
+	return goClientTask{ID: "t1", Title: req.Title, Tags: []string{"new"}, Owner: &goClientOwner{UserID: "u1"}}, nil
+}
+
+func CountTasks(_ context.Context) (client.Query, int) {
+	return client.Query{Limit: 7}, StatusOK
+}
+
+func WatchTasks(_ context.Context, req goClientCreateTask, stream Stream[goClientTask]) int {
+	for _, id := range []string{"a", "b"} {
+		if err := stream.Send(goClientTask{ID: id, Title: req.Title}); err != nil {
+			return StatusError
+		}
+	}
+	return StatusOK
+}
+
+func newGoClientRouter() *Router {
+	router := NewRouter(WithIdempotencyStore(idempotency.NewMemoryStore(0)))
+	router.HandleRPC(CreateTask, goClientBearerGuard{}, Idempotent())
+	router.HandleRPC(CountTasks, ReadOnly(), Deprecated("use ListTasks"))
+	router.HandleRPC(WatchTasks)
+	return router
+}
+
+func TestRPCGoClientRendersTypedServices(t *testing.T) {
+	var buf bytes.Buffer
+	router := newGoClientRouter()
+	router.SetGoClientOptions(GoClientOptions{Package: "tasks"})
+	if err := router.WriteClientGo(&buf); err != nil {
+		t.Fatalf("write go client: %v", err)
+	}
+	out := strings.Join(strings.Fields(buf.String()), " ")
+	for _, want := range []string{
+		"// Code generated by Virtuous ",
+		"package tasks",
+		"type RpcService struct",
+		"func (s *RpcService) CreateTask(ctx context.Context, request GoClientCreateTask, opts ...CallOption) (GoClientTask, error)",
+		"func (s *RpcService) WatchTasks(ctx context.Context, request GoClientCreateTask, opts ...CallOption) (*Stream[GoClientTask], error)",
+		"// Deprecated: use ListTasks",
+		"Title string `json:\"title\"`",
+		"UserID string `json:\"user_id\"`",
+		"Due *time.Time `json:\"due,omitempty\"`",
+		"Owner *GoClientOwner `json:\"Owner\"`",
+		"// constraints: minLength 3",
+		`auth: &authGuard{in: "header", param: "Authorization", prefix: "Bearer"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("go client missing %q:\n%s", want, out)
+		}
+	}
+
+	router.SetGoClientOptions(GoClientOptions{ImportTypes: true})
+	if err := router.WriteClientGo(&buf); err == nil || !strings.Contains(err.Error(), "rpc.goClientCreateTask") {
+		t.Fatalf("expected unexported types to be rejected, got %v", err)
+	}
+
+	imported := NewRouter()
+	imported.HandleRPC(CountTasks)
+	imported.SetGoClientOptions(GoClientOptions{ImportTypes: true})
+	buf.Reset()
+	if err := imported.WriteClientGo(&buf); err != nil {
+		t.Fatalf("write go client with imported types: %v", err)
+	}
+	out = buf.String()
+	if !strings.Contains(out, `"github.com/swetjen/virtuous/internal/testtypes/client"`) ||
+		!strings.Contains(out, "(client.Query, error)") ||
+		strings.Contains(out, "type Query struct") {
+		t.Fatalf("expected imported types:\n%s", out)
+	}
+}
+
+func TestRPCGoClientCallsServer(t *testing.T) {
+	goBin := filepath.Join(runtime.GOROOT(), "bin", "go")
+	if _, err := os.Stat(goBin); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	server := httptest.NewServer(newGoClientRouter())
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeGoClientModule(t, dir, newGoClientRouter(), `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gentest/client"
+)
+
+func main() {
+	ctx := context.Background()
+	c := client.New(os.Args[1])
+	task, err := c.Rpc.CreateTask(ctx, client.GoClientCreateTask{Title: "write"}, client.WithAuth("secret"), client.WithIdempotencyKey("k1"))
+	fmt.Println(task.ID, task.Title, task.Tags, task.Owner.UserID, err)
+	_, err = c.Rpc.CreateTask(ctx, client.GoClientCreateTask{}, client.WithAuth("secret"))
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		fmt.Println(apiErr.Status, apiErr.Code, apiErr.Message)
+	}
+	_, err = c.Rpc.CreateTask(ctx, client.GoClientCreateTask{Title: "write"})
+	if errors.As(err, &apiErr) {
+		fmt.Println(apiErr.Status)
+	}
+	count, err := c.Rpc.CountTasks(ctx)
+	fmt.Println(count.Limit, err)
+	stream, err := c.Rpc.WatchTasks(ctx, client.GoClientCreateTask{Title: "live"})
+	if err != nil {
+		panic(err)
+	}
+	defer stream.Close()
+	for stream.Next() {
+		fmt.Println(stream.Value().ID, stream.Value().Title)
+	}
+	fmt.Println(stream.Err())
+}
+`)
+	cmd := exec.Command(goBin, "run", ".", server.URL)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+	want := "t1 write [new] u1 <nil>\n" +
+		"422 invalid title is required\n" +
+		"401\n" +
+		"7 <nil>\n" +
+		"a live\n" +
+		"b live\n" +
+		"<nil>\n"
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func writeGoClientModule(t *testing.T, dir string, router *Router, main string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "client"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	files := map[string]string{
+		"go.mod":  "module gentest\n\ngo 1.22\n",
+		"main.go": main,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := router.WriteClientGoFile(filepath.Join(dir, "client", "client.gen.go")); err != nil {
+		t.Fatalf("write go client: %v", err)
+	}
+}
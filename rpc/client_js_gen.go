@@ -11,6 +11,52 @@ import (
 var clientJSTemplate = template.Must(template.New("virtuous-rpc-js").Parse(`/**
  * @typedef {Object} AuthOptions
  * @property {string} [auth]
+ * @property {number} [timeoutMs] - Deadline for this call in milliseconds, sent as X-Virtuous-Timeout-Ms. Defaults to ClientOptions.timeoutMs.
+ * @property {AbortSignal} [signal] - Cancels the call.
+{{- if .HasIdempotent }}
+ * @property {string} [idempotencyKey]
+{{- end }}
+ */
+
+/**
+ * A request about to be sent.
+ * @typedef {Object} RPCRequest
+ * @property {string} rpc - The called method, such as "Users.GetUser".
+ * @property {string} method
+ * @property {string} url
+ * @property {Object<string, string>} headers
+ * @property {string} [body]
+ */
+
+/**
+ * Runs before each attempt. It may change the request or return a replacement.
+ * @callback RequestInterceptor
+ * @param {RPCRequest} request
+ * @returns {RPCRequest|void|Promise<RPCRequest|void>}
+ */
+
+/**
+ * Runs after each response of a non-streaming call. It may return a replacement response.
+ * @callback ResponseInterceptor
+ * @param {Response} response
+ * @param {RPCRequest} request
+ * @returns {Response|void|Promise<Response|void>}
+ */
+
+/**
+ * @typedef {Object} RetryPolicy
+ * @property {number} [attempts] - Attempts including the first. Defaults to 3.
+ * @property {number} [backoffMs] - Delay before the first retry in milliseconds, doubled for each later retry. Defaults to 100.
+ * @property {number} [maxBackoffMs] - Longest delay between attempts in milliseconds. Defaults to 2000.
+ * @property {number[]} [statuses] - Response statuses that are retried. Defaults to 408, 429, 502, 503, and 504.
+ */
+
+/**
+ * @typedef {Object} ClientOptions
+ * @property {number} [timeoutMs] - Deadline in milliseconds for calls that do not set timeoutMs.
+ * @property {RetryPolicy} [retry] - Retries idempotent and read-only calls after network errors, timeouts, and retryable statuses.
+ * @property {RequestInterceptor[]} [onRequest] - Run in order before each attempt.
+ * @property {ResponseInterceptor[]} [onResponse] - Run in order after each response of a non-streaming call.
  */
 
 /**
@@ -29,13 +75,203 @@ export class RPCError extends Error {
 		this.body = body
 	}
 }
+{{- if .HasIdempotent }}
+
+/**
+ * @returns {string}
+ */
+function newIdempotencyKey() {
+	const cryptoObj = globalThis.crypto
+	if (cryptoObj && cryptoObj.randomUUID) {
+		return cryptoObj.randomUUID()
+	}
+	return Date.now().toString(16) + "-" + Math.random().toString(16).slice(2) + Math.random().toString(16).slice(2)
+}
+{{- end }}
+
+// callSignal combines the caller's signal with a per-attempt timeout.
+/**
+ * @param {AbortSignal|undefined} signal
+ * @param {number|undefined} timeoutMs
+ * @returns {{"{{"}} signal: AbortSignal|undefined, done: () => void }}
+ */
+function callSignal(signal, timeoutMs) {
+	if (!timeoutMs) {
+		return { signal, done: () => {} }
+	}
+	const controller = new AbortController()
+	const abort = () => controller.abort(signal && signal.reason)
+	const timer = setTimeout(() => controller.abort(new DOMException("call timed out after " + timeoutMs + "ms", "TimeoutError")), timeoutMs)
+	if (signal) {
+		if (signal.aborted) {
+			abort()
+		} else {
+			signal.addEventListener("abort", abort, { once: true })
+		}
+	}
+	return {
+		signal: controller.signal,
+		done: () => {
+			clearTimeout(timer)
+			if (signal) {
+				signal.removeEventListener("abort", abort)
+			}
+		},
+	}
+}
+
+/**
+ * @param {ClientOptions} client
+ * @param {RPCRequest} request
+ * @returns {Promise<RPCRequest>}
+ */
+async function interceptRequest(client, request) {
+	let current = { ...request, headers: { ...request.headers } }
+	for (const intercept of client.onRequest || []) {
+		current = (await intercept(current)) || current
+	}
+	return current
+}
+
+/**
+ * @param {RetryPolicy} policy
+ * @param {number} attempt
+ * @returns {number}
+ */
+function retryDelay(policy, attempt) {
+	return Math.min(policy.maxBackoffMs ?? 2000, (policy.backoffMs ?? 100) * 2 ** (attempt - 1))
+}
+
+// call sends a non-streaming request and reads its body, retrying when
+// retryable and the client has a retry policy.
+/**
+ * @param {ClientOptions} client
+ * @param {RPCRequest} request
+ * @param {AuthOptions|undefined} options
+ * @param {boolean} retryable
+ * @param {RequestCredentials} [credentials]
+ * @returns {Promise<[Response, string]>}
+ */
+async function call(client, request, options, retryable, credentials) {
+	const signal = options && options.signal
+	const timeoutMs = (options && options.timeoutMs) || client.timeoutMs
+	if (timeoutMs) {
+		request.headers["X-Virtuous-Timeout-Ms"] = String(Math.ceil(timeoutMs))
+	}
+	const policy = retryable ? client.retry : undefined
+	const attempts = policy ? Math.max(1, policy.attempts ?? 3) : 1
+	for (let attempt = 1; ; attempt++) {
+		const current = await interceptRequest(client, request)
+		const attemptSignal = callSignal(signal, timeoutMs)
+		try {
+			let response = await fetch(current.url, { method: current.method, headers: current.headers, body: current.body, signal: attemptSignal.signal, credentials })
+			for (const intercept of client.onResponse || []) {
+				response = (await intercept(response, current)) || response
+			}
+			const text = await response.text()
+			if (policy && attempt < attempts && (policy.statuses || [408, 429, 502, 503, 504]).includes(response.status)) {
+				await new Promise((resolve) => setTimeout(resolve, retryDelay(policy, attempt)))
+				continue
+			}
+			return [response, text]
+		} catch (e) {
+			if (!policy || attempt >= attempts || (signal && signal.aborted)) {
+				throw e
+			}
+			await new Promise((resolve) => setTimeout(resolve, retryDelay(policy, attempt)))
+		} finally {
+			attemptSignal.done()
+		}
+	}
+}
+{{ if .HasStreams }}
+/**
+ * @param {ClientOptions} client
+ * @param {RPCRequest} request
+ * @param {AuthOptions|undefined} options
+ * @param {RequestCredentials} [credentials]
+ * @returns {Promise<Response>}
+ */
+async function openStream(client, request, options, credentials) {
+	const current = await interceptRequest(client, request)
+	return await fetch(current.url, { method: current.method, headers: current.headers, body: current.body, signal: options && options.signal, credentials })
+}
+
+/**
+ * @typedef {Object} RPCStreamStatus
+ * @property {number} status
+ */
 
+/**
+ * @param {Response} response
+ * @returns {Promise<RPCError>}
+ */
+async function streamError(response) {
+	const text = await response.text()
+	let body = null
+	if (text) {
+		try {
+			body = JSON.parse(text)
+		} catch (e) {
+			body = null
+		}
+	}
+	return new RPCError(response.status, body, response.status + " " + response.statusText)
+}
+
+/**
+ * @param {Response} response
+ * @returns {AsyncGenerator<any, void, undefined>}
+ */
+async function* readEventStream(response) {
+	if (!response.body) {
+		throw new RPCError(response.status, null, "stream response has no body")
+	}
+	const reader = response.body.getReader()
+	const decoder = new TextDecoder()
+	let buffer = ""
+	while (true) {
+		const { value, done } = await reader.read()
+		if (done) {
+			break
+		}
+		buffer += decoder.decode(value, { stream: true })
+		let boundary = buffer.indexOf("\n\n")
+		while (boundary >= 0) {
+			const frame = buffer.slice(0, boundary)
+			buffer = buffer.slice(boundary + 2)
+			boundary = buffer.indexOf("\n\n")
+			let event = "message"
+			const data = []
+			for (const line of frame.split("\n")) {
+				if (line.startsWith("event:")) {
+					event = line.slice(6).trim()
+				} else if (line.startsWith("data:")) {
+					data.push(line.slice(5).trim())
+				}
+			}
+			if (event === "done") {
+				return
+			}
+			if (event === "error") {
+				const body = data.length ? JSON.parse(data.join("\n")) : { status: 500 }
+				throw new RPCError(body.status, body, body.status + " stream error")
+			}
+			if (data.length) {
+				yield JSON.parse(data.join("\n"))
+			}
+		}
+	}
+	throw new RPCError(response.status, null, "stream closed before completion")
+}
+{{ end }}
 // Type definitions
 {{- range $object := .Objects }}
 /**
  * @typedef {Object} {{ $object.Name }}
 {{- range $field := $object.Fields }}
- * @property{{- if $field.Nullable }} {{ printf "{%s|null}" $field.Type }}{{ else }} {{ printf "{%s}" $field.Type }}{{ end }} {{ if $field.Optional }}[{{ $field.Name }}]{{ else }}{{ $field.Name }}{{ end }}{{ if $field.Doc }} - {{ $field.Doc }}{{ end }}
+{{- $fieldType := $field.Type }}{{ if $field.EnumType }}{{ $fieldType = $field.EnumType }}{{ end }}
+ * @property{{- if $field.Nullable }} {{ printf "{%s|null}" $fieldType }}{{ else }} {{ printf "{%s}" $fieldType }}{{ end }} {{ if $field.Optional }}[{{ $field.Name }}]{{ else }}{{ $field.Name }}{{ end }}{{ if $field.Doc }} - {{ $field.Doc }}{{ end }}{{ if $field.Constraints }}{{ if not $field.Doc }} -{{ end }} ({{ range $i, $constraint := $field.Constraints }}{{ if $i }}; {{ end }}{{ $constraint }}{{ end }}){{ end }}
 {{- end }}
  */
 
@@ -43,28 +279,50 @@ export class RPCError extends Error {
 
 /**
  * @param {string} [basepath="/"]
+ * @param {ClientOptions} [clientOptions]
  * @returns {object}
  */
-export function createClient(basepath = "/") {
+export function createClient(basepath = "/", clientOptions = {}) {
 	return {
 {{- range $service := .Services }}
 		{{ $service.Name }}: {
 {{- range $method := $service.Methods }}
 			/**
+{{- range $line := $method.Doc }}
+			 *{{ if $line }} {{ $line }}{{ end }}
+{{- end }}
+{{- if $method.Deprecated }}
+			 * @deprecated{{ if $method.DeprecationDoc }} {{ $method.DeprecationDoc }}{{ end }}
+{{- end }}
 {{- if $method.HasBody }}
 			 * @param { {{- if $method.RequestType }}{{ $method.RequestType }}{{ else }}any{{ end }} } request
 			 * @param {AuthOptions} [options]
 {{- else }}
 			 * @param {AuthOptions} [options]
 {{- end }}
-			 * @returns {Promise<{{- if $method.ResponseType }}{{ $method.ResponseType }}{{ else }}any{{ end }}>} 
+{{- if $method.Streaming }}
+			 * @returns {AsyncGenerator<{{ $method.ResponseType }}, void, undefined>}
 			 */
+			async *{{ $method.Name }}({{ if $method.HasBody }}request, {{ end }}options) {
+{{- else }}
+			 * @returns {Promise<{{- if $method.ResponseType }}{{ $method.ResponseType }}{{ else }}any{{ end }}>} 
+{{ if ne $method.ErrorType $method.ResponseType }}			 * @throws {RPCError<{{ $method.ErrorType }}>}
+{{ end }}			 */
 			async {{ $method.Name }}({{ if $method.HasBody }}request, {{ end }}options) {
+{{- end }}
 				const headers = {
-					"Accept": "application/json",
+					"Accept": {{ if $method.Streaming }}"text/event-stream"{{ else }}"application/json"{{ end }},
+{{- if not $method.ReadOnly }}
 					"Content-Type": "application/json",
+{{- end }}
 				}
 				let url = basepath + "{{ $method.Path }}"
+{{- if and $method.ReadOnly $method.HasBody }}
+				url = url + "?request=" + encodeURIComponent(JSON.stringify(request))
+{{- end }}
+{{- if $method.Idempotent }}
+				headers["Idempotency-Key"] = (options && options.idempotencyKey) || newIdempotencyKey()
+{{- end }}
 {{- if $method.HasAuth }}
 				const authValue = options && options.auth
 				if (authValue) {
@@ -80,19 +338,23 @@ export function createClient(basepath = "/") {
 {{- end }}
 				}
 {{- end }}
-				const response = await fetch(url, {
-					method: "POST",
+				const rpcRequest = {
+					rpc: "{{ $service.Name }}.{{ $method.Name }}",
+					method: "{{ if $method.ReadOnly }}GET{{ else }}POST{{ end }}",
+					url,
 					headers,
-{{- if $method.HasAuth }}
-{{- if eq $method.Auth.In "cookie" }}
-					credentials: "same-origin",
-{{- end }}
-{{- end }}
-{{- if $method.HasBody }}
+{{- if and $method.HasBody (not $method.ReadOnly) }}
 					body: JSON.stringify(request),
 {{- end }}
-				})
-				const text = await response.text()
+				}
+{{- if $method.Streaming }}
+				const response = await openStream(clientOptions, rpcRequest, options{{ if and $method.HasAuth (eq $method.Auth.In "cookie") }}, "same-origin"{{ end }})
+				if (!response.ok) {
+					throw await streamError(response)
+				}
+				yield* readEventStream(response)
+{{- else }}
+				const [response, text] = await call(clientOptions, rpcRequest, options, {{ or $method.Idempotent $method.ReadOnly }}{{ if and $method.HasAuth (eq $method.Auth.In "cookie") }}, "same-origin"{{ end }})
 				let json = null
 				if (text) {
 					try {
@@ -108,6 +370,7 @@ export function createClient(basepath = "/") {
 					throw new RPCError(response.status, json, response.status + " " + response.statusText)
 				}
 				return json
+{{- end }}
 			},
 {{- end }}
 		},
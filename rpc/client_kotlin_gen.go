@@ -0,0 +1,406 @@
+package rpc
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"text/template"
+
+	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/schema"
+)
+
+// KotlinClientOptions configures generated Kotlin clients.
+type KotlinClientOptions = clientgen.KotlinClientOptions
+
+var clientKotlinTemplate = template.Must(template.New("virtuous-rpc-kotlin").Parse(`package {{ .Package }}
+
+import java.io.IOException
+import java.net.HttpURLConnection
+import java.net.URL
+import java.net.URLEncoder
+import java.util.UUID
+import kotlinx.coroutines.Dispatchers
+import kotlinx.coroutines.flow.Flow
+import kotlinx.coroutines.flow.flow
+import kotlinx.coroutines.flow.flowOn
+import kotlinx.coroutines.flow.map
+import kotlinx.coroutines.flow.transformWhile
+import kotlinx.coroutines.withContext
+import kotlinx.serialization.SerialName
+import kotlinx.serialization.Serializable
+import kotlinx.serialization.decodeFromString
+import kotlinx.serialization.encodeToString
+import kotlinx.serialization.json.Json
+import kotlinx.serialization.json.JsonElement
+import kotlinx.serialization.json.JsonObject
+import kotlinx.serialization.json.JsonPrimitive
+import kotlinx.serialization.json.contentOrNull
+import kotlinx.serialization.json.intOrNull
+
+/** Options for a single call. */
+data class CallOptions(
+    /** Credential for guarded methods, sent where the method's guard reads it. */
+    val auth: String? = null,
+    /** Deadline for the call in milliseconds, sent as X-Virtuous-Timeout-Ms. */
+    val timeoutMs: Long? = null,
+    /** Idempotency-Key for idempotent methods. A random key is used when null. */
+    val idempotencyKey: String? = null,
+    /** Extra request headers. */
+    val headers: Map<String, String> = emptyMap(),
+)
+
+/** Thrown when a call responds with a non-2xx status. */
+class RPCException(
+    /** The HTTP status code. */
+    val status: Int,
+    /** The raw response body. */
+    val body: String,
+) : Exception(describe(status, body)) {
+    /** The code of the Virtuous error envelope, if any. */
+    val code: String? = envelopeField(body, "code")
+
+    /** The message of the Virtuous error envelope, if any. */
+    val errorMessage: String? = envelopeField(body, "message")
+
+    /** Decodes the error body. */
+    inline fun <reified T> decode(): T = RPCClient.json.decodeFromString(body)
+
+    private companion object {
+        fun envelopeField(body: String, name: String): String? {
+            val element: JsonElement = try {
+                Json.parseToJsonElement(body)
+            } catch (e: Exception) {
+                return null
+            }
+            return ((element as? JsonObject)?.get(name) as? JsonPrimitive)?.contentOrNull
+        }
+
+        fun describe(status: Int, body: String): String {
+            val message = envelopeField(body, "message")
+            return if (message.isNullOrEmpty()) "status " + status else "status " + status + ": " + message
+        }
+    }
+}
+
+/** An HTTP request made by an [RPCClient]. */
+data class RPCHttpRequest(
+    val method: String,
+    val url: String,
+    val headers: Map<String, String>,
+    val body: String?,
+)
+
+/** An HTTP response read by an [RPCEngine]. */
+data class RPCHttpResponse(
+    val status: Int,
+    val body: String,
+)
+
+/** Sends the requests of an [RPCClient]. Implement it to use OkHttp, Ktor, or a test double. */
+interface RPCEngine {
+    /** Sends request and reads the whole response. */
+    suspend fun execute(request: RPCHttpRequest): RPCHttpResponse
+
+    /** Sends request and emits the lines of a 2xx response body, throwing [RPCException] otherwise. */
+    fun lines(request: RPCHttpRequest): Flow<String>
+}
+
+/** The default [RPCEngine], built on [HttpURLConnection]. */
+class URLConnectionEngine : RPCEngine {
+    override suspend fun execute(request: RPCHttpRequest): RPCHttpResponse = withContext(Dispatchers.IO) {
+        val connection = connect(request)
+        try {
+            val status = connection.responseCode
+            val stream = if (status in 200..299) connection.inputStream else connection.errorStream
+            RPCHttpResponse(status, stream?.bufferedReader()?.use { it.readText() } ?: "")
+        } finally {
+            connection.disconnect()
+        }
+    }
+
+    override fun lines(request: RPCHttpRequest): Flow<String> = flow {
+        val connection = connect(request)
+        try {
+            val status = connection.responseCode
+            if (status !in 200..299) {
+                throw RPCException(status, connection.errorStream?.bufferedReader()?.use { it.readText() } ?: "")
+            }
+            connection.inputStream.bufferedReader().use { reader ->
+                while (true) {
+                    emit(reader.readLine() ?: break)
+                }
+            }
+        } finally {
+            connection.disconnect()
+        }
+    }.flowOn(Dispatchers.IO)
+
+    private fun connect(request: RPCHttpRequest): HttpURLConnection {
+        val connection = URL(request.url).openConnection() as HttpURLConnection
+        connection.requestMethod = request.method
+        for ((key, value) in request.headers) {
+            connection.setRequestProperty(key, value)
+        }
+        val body = request.body
+        if (body != null) {
+            connection.doOutput = true
+            connection.outputStream.use { it.write(body.toByteArray(Charsets.UTF_8)) }
+        }
+        return connection
+    }
+}
+
+internal class AuthGuard(val location: String, val param: String, val prefix: String)
+
+/** Calls the methods of a Virtuous RPC API served at baseUrl, such as https://api.example.com. */
+class RPCClient(baseUrl: String, val engine: RPCEngine = URLConnectionEngine()) {
+    val baseUrl: String = baseUrl.trimEnd('/')
+{{- range $service := .Services }}
+    val {{ $service.Property }} = {{ $service.Type }}(this)
+{{- end }}
+
+    internal fun request(
+        path: String,
+        body: String?,
+        readOnly: Boolean,
+        idempotent: Boolean,
+        streaming: Boolean,
+        auth: AuthGuard?,
+        options: CallOptions,
+    ): RPCHttpRequest {
+        val query = mutableListOf<Pair<String, String>>()
+        val headers = options.headers.toMutableMap()
+        if (readOnly && body != null) {
+            query.add("request" to body)
+        }
+        val value = options.auth
+        if (auth != null && !value.isNullOrEmpty()) {
+            val credential = if (auth.prefix.isEmpty()) value else auth.prefix + " " + value
+            when (auth.location) {
+                "query" -> query.add(auth.param to credential)
+                "cookie" -> headers["Cookie"] = auth.param + "=" + credential
+                else -> headers[auth.param] = credential
+            }
+        }
+        var url = baseUrl + path
+        if (query.isNotEmpty()) {
+            url += "?" + query.joinToString("&") { escape(it.first) + "=" + escape(it.second) }
+        }
+        headers["Accept"] = if (streaming) "text/event-stream" else "application/json"
+        if (!readOnly) {
+            headers["Content-Type"] = "application/json"
+        }
+        if (idempotent) {
+            headers["Idempotency-Key"] = options.idempotencyKey ?: UUID.randomUUID().toString()
+        }
+        val timeoutMs = options.timeoutMs
+        if (!streaming && timeoutMs != null) {
+            headers["X-Virtuous-Timeout-Ms"] = timeoutMs.toString()
+        }
+        return RPCHttpRequest(if (readOnly) "GET" else "POST", url, headers, if (readOnly) null else body)
+    }
+
+    internal suspend fun send(request: RPCHttpRequest): String {
+        val response = engine.execute(request)
+        if (response.status !in 200..299) {
+            throw RPCException(response.status, response.body)
+        }
+        return response.body
+    }
+
+    // events emits the data of each message event until the done event.
+    internal fun events(request: RPCHttpRequest): Flow<String> = flow {
+        var event = "message"
+        var done = false
+        engine.lines(request).transformWhile { line ->
+            when {
+                line.startsWith("event:") -> {
+                    event = line.substring(6).trim()
+                    true
+                }
+                !line.startsWith("data:") -> true
+                else -> {
+                    val data = line.substring(5).trim()
+                    val current = event
+                    event = "message"
+                    when (current) {
+                        "done" -> {
+                            done = true
+                            false
+                        }
+                        "error" -> throw RPCException(streamStatus(data), data)
+                        else -> {
+                            emit(data)
+                            true
+                        }
+                    }
+                }
+            }
+        }.collect { emit(it) }
+        if (!done) {
+            throw IOException("stream closed before completion")
+        }
+    }
+
+    companion object {
+        /** The JSON configuration used for request and response bodies. */
+        val json = Json { ignoreUnknownKeys = true }
+
+        private fun escape(value: String): String = URLEncoder.encode(value, "UTF-8").replace("+", "%20")
+
+        private fun streamStatus(data: String): Int {
+            val element: JsonElement = try {
+                Json.parseToJsonElement(data)
+            } catch (e: Exception) {
+                return 500
+            }
+            return ((element as? JsonObject)?.get("status") as? JsonPrimitive)?.intOrNull ?: 500
+        }
+    }
+}
+{{- range $object := .Objects }}
+
+@Serializable
+{{- if $object.Fields }}
+data class {{ $object.Name }}(
+{{- range $field := $object.Fields }}
+{{- if $field.Doc }}
+    /** {{ range $i, $line := $field.Doc }}{{ if $i }} {{ end }}{{ $line }}{{ end }} */
+{{- end }}
+    @SerialName({{ $field.Wire }}) val {{ $field.Name }}: {{ $field.Type }}{{ if $field.Optional }}? = null{{ end }},
+{{- end }}
+)
+{{- else }}
+class {{ $object.Name }}
+{{- end }}
+{{- end }}
+{{- range $service := .Services }}
+
+/** Calls the {{ $service.Name }} methods. */
+class {{ $service.Type }} internal constructor(private val client: RPCClient) {
+{{- range $i, $method := $service.Methods }}
+{{- if $i }}
+{{ end }}
+{{- if $method.Doc }}
+    /**
+{{- range $line := $method.Doc }}
+     *{{ if $line }} {{ $line }}{{ end }}
+{{- end }}
+     */
+{{- end }}
+{{- if $method.Deprecated }}
+    @Deprecated({{ $method.Deprecation }})
+{{- end }}
+{{- if $method.Streaming }}
+    fun {{ $method.Name }}({{ if $method.HasBody }}request: {{ $method.RequestType }}, {{ end }}options: CallOptions = CallOptions()): Flow<{{ $method.ResponseType }}> {
+{{- else }}
+    suspend fun {{ $method.Name }}({{ if $method.HasBody }}request: {{ $method.RequestType }}, {{ end }}options: CallOptions = CallOptions()){{ if $method.ResponseType }}: {{ $method.ResponseType }}{{ end }} {
+{{- end }}
+        val httpRequest = client.request(
+            path = {{ $method.Path }},
+            body = {{ if $method.HasBody }}RPCClient.json.encodeToString(request){{ else }}null{{ end }},
+            readOnly = {{ $method.ReadOnly }},
+            idempotent = {{ $method.Idempotent }},
+            streaming = {{ $method.Streaming }},
+            auth = {{ if $method.Auth }}{{ $method.Auth }}{{ else }}null{{ end }},
+            options = options,
+        )
+{{- if $method.Streaming }}
+        return client.events(httpRequest).map { RPCClient.json.decodeFromString<{{ $method.ResponseType }}>(it) }
+{{- else if $method.ResponseType }}
+        return RPCClient.json.decodeFromString<{{ $method.ResponseType }}>(client.send(httpRequest))
+{{- else }}
+        client.send(httpRequest)
+{{- end }}
+    }
+{{- end }}
+}
+{{- end }}
+`))
+
+// kotlinClientReserved are the type names the Kotlin runtime declares or
+// uses.
+var kotlinClientReserved = []string{
+	"AuthGuard", "CallOptions", "Dispatchers", "Exception", "Flow", "HttpURLConnection",
+	"IOException", "Json", "JsonPrimitive", "RPCClient", "RPCEngine", "RPCException",
+	"RPCHttpRequest", "RPCHttpResponse", "URL", "URLConnectionEngine", "URLEncoder", "UUID",
+}
+
+func kotlinClientLanguage() mobileLanguage {
+	return mobileLanguage{
+		typeFn: func(registry *schema.Registry) func(reflect.Type) string {
+			return registry.KotlinTypeOf
+		},
+		reserved: kotlinClientReserved,
+		members:  []string{"baseUrl", "engine", "events", "json", "request", "send"},
+		name:     clientgen.KotlinName,
+		quote:    clientgen.KotlinString,
+		auth: func(guard GuardSpec) string {
+			return "AuthGuard(" + clientgen.KotlinString(guard.In) + ", " + clientgen.KotlinString(guard.Param) + ", " + clientgen.KotlinString(guard.Prefix) + ")"
+		},
+		docs: func(lines []string) []string {
+			out := make([]string, len(lines))
+			for i, line := range lines {
+				out[i] = clientgen.BlockCommentText(line)
+			}
+			return out
+		},
+	}
+}
+
+// WriteClientKotlin writes a runtime-generated Kotlin client to w.
+func (r *Router) WriteClientKotlin(w io.Writer) error {
+	body, err := r.clientKotlinBody()
+	if err != nil {
+		return err
+	}
+	hash := clientgen.HashBytes(body)
+	if err := clientgen.WriteArtifactHeader(w, "//", "Virtuous client hash", hash); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteClientKotlinFile writes a runtime-generated Kotlin client to the file
+// at path.
+func (r *Router) WriteClientKotlinFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WriteClientKotlin(f)
+}
+
+// WriteClientKotlinHash writes the hash of the stable Kotlin client body to w.
+func (r *Router) WriteClientKotlinHash(w io.Writer) error {
+	body, err := r.clientKotlinBody()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, clientgen.HashBytes(body))
+	return err
+}
+
+// ServeClientKotlin writes a runtime-generated Kotlin client as an HTTP
+// response.
+func (r *Router) ServeClientKotlin(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/x-kotlin; charset=utf-8")
+	if err := r.WriteClientKotlin(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SetKotlinClientOptions replaces the settings of generated Kotlin clients.
+func (r *Router) SetKotlinClientOptions(opts KotlinClientOptions) {
+	r.kotlinClient = opts
+}
+
+func (r *Router) clientKotlinBody() ([]byte, error) {
+	spec := buildMobileClientSpec(r.Routes(), r.typeOverrides, kotlinClientLanguage())
+	spec.Package = r.kotlinClient.KotlinPackageName()
+	return clientgen.RenderTemplate(clientKotlinTemplate, spec)
+}
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/schema"
+)
+
+// mobileClientSpec is the view model shared by the Swift and Kotlin
+// templates.
+type mobileClientSpec struct {
+	Package    string
+	Services   []mobileClientService
+	Objects    []mobileClientObject
+	HasStreams bool
+}
+
+type mobileClientService struct {
+	Name     string
+	Property string
+	Type     string
+	Methods  []mobileClientMethod
+}
+
+type mobileClientMethod struct {
+	Name         string
+	Path         string
+	HasBody      bool
+	Streaming    bool
+	ReadOnly     bool
+	Idempotent   bool
+	RequestType  string
+	ResponseType string
+	Auth         string
+	Doc          []string
+	Deprecated   bool
+	Deprecation  string
+}
+
+type mobileClientObject struct {
+	Name   string
+	Fields []mobileClientField
+}
+
+type mobileClientField struct {
+	Name     string
+	Wire     string
+	Type     string
+	Optional bool
+	Doc      []string
+}
+
+// mobileLanguage holds the per-language rules of a mobile client.
+type mobileLanguage struct {
+	typeFn func(*schema.Registry) func(reflect.Type) string
+	// reserved are file-scope type names used by the runtime.
+	reserved []string
+	// members are client member names that service properties must avoid.
+	members []string
+	name    func(string) string
+	quote   func(string) string
+	auth    func(GuardSpec) string
+	docs    func([]string) []string
+}
+
+func buildMobileClientSpec(routes []Route, overrides map[string]TypeOverride, lang mobileLanguage) mobileClientSpec {
+	spec := buildClientSpecWith(routes, overrides, lang.typeFn)
+
+	used := map[string]struct{}{}
+	for _, name := range lang.reserved {
+		used[name] = struct{}{}
+	}
+	for _, service := range spec.Services {
+		used[mobileServiceType(service.Name)] = struct{}{}
+	}
+	typeNames := make(map[string]string, len(spec.Objects))
+	for _, object := range spec.Objects {
+		typeNames[object.Name] = clientgen.UniqueGoIdentifier(object.Name, used)
+	}
+
+	out := mobileClientSpec{HasStreams: spec.HasStreams}
+	for _, object := range spec.Objects {
+		mobileObject := mobileClientObject{Name: typeNames[object.Name]}
+		fieldNames := map[string]struct{}{}
+		for _, field := range object.Fields {
+			mobileField := mobileClientField{
+				Name:     lang.name(clientgen.UniqueLowerCamelIdentifier(field.Name, fieldNames)),
+				Wire:     lang.quote(field.Name),
+				Type:     clientgen.RenameTypeTokens(field.Type, typeNames),
+				Optional: field.Optional || field.Nullable,
+			}
+			if field.Doc != "" {
+				mobileField.Doc = append(mobileField.Doc, strings.Join(strings.Fields(field.Doc), " "))
+			}
+			if comment := clientgen.ConstraintComment(field.EnumType, field.Constraints); comment != "" {
+				mobileField.Doc = append(mobileField.Doc, comment)
+			}
+			mobileField.Doc = lang.docs(mobileField.Doc)
+			mobileObject.Fields = append(mobileObject.Fields, mobileField)
+		}
+		out.Objects = append(out.Objects, mobileObject)
+	}
+
+	properties := map[string]struct{}{}
+	for _, member := range lang.members {
+		properties[member] = struct{}{}
+	}
+	for _, service := range spec.Services {
+		mobileService := mobileClientService{
+			Name:     service.Name,
+			Property: lang.name(clientgen.UniqueLowerCamelIdentifier(service.Name, properties)),
+			Type:     mobileServiceType(service.Name),
+		}
+		methodNames := map[string]struct{}{"client": {}}
+		for _, method := range service.Methods {
+			mobileMethod := mobileClientMethod{
+				Name:         lang.name(clientgen.UniqueLowerCamelIdentifier(method.Name, methodNames)),
+				Path:         lang.quote(method.Path),
+				HasBody:      method.HasBody,
+				Streaming:    method.Streaming,
+				ReadOnly:     method.ReadOnly,
+				Idempotent:   method.Idempotent,
+				RequestType:  clientgen.RenameTypeTokens(method.RequestType, typeNames),
+				ResponseType: clientgen.RenameTypeTokens(method.ResponseType, typeNames),
+				Doc:          lang.docs(method.Doc),
+				Deprecated:   method.Deprecated,
+				Deprecation:  lang.quote(strings.Join(strings.Fields(method.DeprecationMessage), " ")),
+			}
+			if method.HasAuth {
+				mobileMethod.Auth = lang.auth(method.Auth)
+			}
+			mobileService.Methods = append(mobileService.Methods, mobileMethod)
+		}
+		out.Services = append(out.Services, mobileService)
+	}
+	return out
+}
+
+func mobileServiceType(service string) string {
+	return clientgen.GoIdentifier(service) + "Service"
+}
@@ -0,0 +1,230 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRPCSwiftClientRendersMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newGoClientRouter().WriteClientSwift(&buf); err != nil {
+		t.Fatalf("write swift client: %v", err)
+	}
+	out := strings.Join(strings.Fields(buf.String()), " ")
+	for _, want := range []string{
+		"// Code generated by Virtuous ",
+		`case owner = "Owner"`,
+		`case userId = "user_id"`,
+		"/// constraints: minLength 3",
+		`@available(*, deprecated, message: "use ListTasks")`,
+		"public func watchTasks(_ request: GoClientCreateTask, options: RPCCallOptions = RPCCallOptions()) throws -> AsyncThrowingStream<GoClientTask, Error> {",
+		`auth: RPCAuthGuard(location: "header", param: "Authorization", prefix: "Bearer")`,
+		"readOnly: true, idempotent: false",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("swift client missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRPCKotlinClientRendersMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	router := newGoClientRouter()
+	router.SetKotlinClientOptions(KotlinClientOptions{Package: "com.example.tasks"})
+	if err := router.WriteClientKotlin(&buf); err != nil {
+		t.Fatalf("write kotlin client: %v", err)
+	}
+	out := strings.Join(strings.Fields(buf.String()), " ")
+	for _, want := range []string{
+		"// Code generated by Virtuous ",
+		"package com.example.tasks",
+		`@SerialName("due") val due: String? = null,`,
+		`@SerialName("Owner") val owner: GoClientOwner? = null,`,
+		`@SerialName("user_id") val userId: String,`,
+		"/** constraints: minLength 3 */",
+		`@Deprecated("use ListTasks")`,
+		"fun watchTasks(request: GoClientCreateTask, options: CallOptions = CallOptions()): Flow<GoClientTask> {",
+		`auth = AuthGuard("header", "Authorization", "Bearer"),`,
+		"readOnly = true, idempotent = false,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("kotlin client missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRPCSwiftClientCallsServer(t *testing.T) {
+	swiftc, err := exec.LookPath("swiftc")
+	if err != nil {
+		t.Skip("swiftc is not installed")
+	}
+	server := httptest.NewServer(newGoClientRouter())
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := newGoClientRouter().WriteClientSwiftFile(filepath.Join(dir, "client.swift")); err != nil {
+		t.Fatalf("write swift client: %v", err)
+	}
+	// main.swift may use top-level await. Streams are left out because
+	// URLSession cannot stream bytes on Linux.
+	writeMobileHarness(t, filepath.Join(dir, "main.swift"), `import Foundation
+#if canImport(FoundationNetworking)
+import FoundationNetworking
+#endif
+
+let client = RPCClient(baseURL: URL(string: CommandLine.arguments[1])!)
+let task = try await client.rpc.createTask(GoClientCreateTask(title: "write"), options: RPCCallOptions(auth: "secret", idempotencyKey: "k1"))
+print(task.id, task.title, task.tags ?? [], task.owner?.userId ?? "")
+do {
+    _ = try await client.rpc.createTask(GoClientCreateTask(title: ""), options: RPCCallOptions(auth: "secret"))
+} catch let err as RPCError {
+    print(err.status, err.code ?? "", err.message ?? "")
+}
+do {
+    _ = try await client.rpc.createTask(GoClientCreateTask(title: "write"))
+} catch let err as RPCError {
+    print(err.status)
+}
+let count = try await client.rpc.countTasks()
+print(count.limit)
+`)
+	bin := filepath.Join(dir, "harness")
+	if out, err := exec.Command(swiftc, "-o", bin, filepath.Join(dir, "client.swift"), filepath.Join(dir, "main.swift")).CombinedOutput(); err != nil {
+		t.Fatalf("swiftc: %v\n%s", err, out)
+	}
+	out, err := exec.Command(bin, server.URL).CombinedOutput()
+	if err != nil {
+		t.Fatalf("swift harness: %v\n%s", err, out)
+	}
+	want := "t1 write [\"new\"] u1\n" +
+		"422 invalid title is required\n" +
+		"401\n" +
+		"7\n"
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+// TestRPCKotlinClientCallsServer needs kotlinc, java, and the
+// kotlinx-serialization-json and kotlinx-coroutines-core jars on
+// VIRTUOUS_KOTLIN_CLASSPATH, since kotlinc ships neither runtime library.
+func TestRPCKotlinClientCallsServer(t *testing.T) {
+	kotlinc, err := exec.LookPath("kotlinc")
+	if err != nil {
+		t.Skip("kotlinc is not installed")
+	}
+	java, err := exec.LookPath("java")
+	if err != nil {
+		t.Skip("java is not installed")
+	}
+	classpath := os.Getenv("VIRTUOUS_KOTLIN_CLASSPATH")
+	if classpath == "" {
+		t.Skip("VIRTUOUS_KOTLIN_CLASSPATH is not set")
+	}
+	home, err := filepath.EvalSymlinks(kotlinc)
+	if err != nil {
+		t.Fatalf("resolve kotlinc: %v", err)
+	}
+	plugin := filepath.Join(filepath.Dir(filepath.Dir(home)), "lib", "kotlinx-serialization-compiler-plugin.jar")
+	if _, err := os.Stat(plugin); err != nil {
+		t.Skip("kotlinc has no serialization plugin")
+	}
+	server := httptest.NewServer(newGoClientRouter())
+	defer server.Close()
+
+	dir := t.TempDir()
+	router := newGoClientRouter()
+	router.SetKotlinClientOptions(KotlinClientOptions{Package: "com.example.tasks"})
+	if err := router.WriteClientKotlinFile(filepath.Join(dir, "Client.kt")); err != nil {
+		t.Fatalf("write kotlin client: %v", err)
+	}
+	writeMobileHarness(t, filepath.Join(dir, "Main.kt"), `@file:Suppress("DEPRECATION")
+
+package com.example.tasks
+
+import kotlinx.coroutines.runBlocking
+
+fun main(args: Array<String>) = runBlocking {
+    val client = RPCClient(args[0])
+    val task = client.rpc.createTask(GoClientCreateTask(title = "write"), CallOptions(auth = "secret", idempotencyKey = "k1"))
+    println("${task.id} ${task.title} ${task.tags} ${task.owner?.userId}")
+    try {
+        client.rpc.createTask(GoClientCreateTask(title = ""), CallOptions(auth = "secret"))
+    } catch (e: RPCException) {
+        println("${e.status} ${e.code} ${e.errorMessage}")
+    }
+    try {
+        client.rpc.createTask(GoClientCreateTask(title = "write"))
+    } catch (e: RPCException) {
+        println(e.status)
+    }
+    println(client.rpc.countTasks().limit)
+    client.rpc.watchTasks(GoClientCreateTask(title = "live")).collect { println("${it.id} ${it.title}") }
+}
+`)
+	jar := filepath.Join(dir, "harness.jar")
+	compile := exec.Command(kotlinc, "-Xplugin="+plugin, "-cp", classpath, "-include-runtime", "-d", jar,
+		filepath.Join(dir, "Client.kt"), filepath.Join(dir, "Main.kt"))
+	if out, err := compile.CombinedOutput(); err != nil {
+		t.Fatalf("kotlinc: %v\n%s", err, out)
+	}
+	out, err := exec.Command(java, "-cp", jar+string(os.PathListSeparator)+classpath, "com.example.tasks.MainKt", server.URL).CombinedOutput()
+	if err != nil {
+		t.Fatalf("kotlin harness: %v\n%s", err, out)
+	}
+	want := "t1 write [new] u1\n" +
+		"422 invalid title is required\n" +
+		"401\n" +
+		"7\n" +
+		"a live\n" +
+		"b live\n"
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func writeMobileHarness(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+type task struct {
+	Name string `json:"name"`
+}
+
+type flow struct {
+	Done bool `json:"done"`
+}
+
+func SyncTask(_ context.Context, req task) (flow, int) {
+	return flow{}, StatusOK
+}
+
+func TestRPCMobileClientsAvoidRuntimeNames(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(SyncTask)
+
+	var swift bytes.Buffer
+	if err := router.WriteClientSwift(&swift); err != nil {
+		t.Fatalf("write swift client: %v", err)
+	}
+	if strings.Contains(swift.String(), "public struct Task:") || !strings.Contains(swift.String(), "_ request: Task2,") {
+		t.Fatalf("swift client did not rename runtime type:\n%s", swift.String())
+	}
+
+	var kotlin bytes.Buffer
+	if err := router.WriteClientKotlin(&kotlin); err != nil {
+		t.Fatalf("write kotlin client: %v", err)
+	}
+	if strings.Contains(kotlin.String(), "data class Flow(") || !strings.Contains(kotlin.String(), "Flow2 {") {
+		t.Fatalf("kotlin client did not rename runtime type:\n%s", kotlin.String())
+	}
+}
@@ -0,0 +1,232 @@
+package rpc
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newClientOptionsRouter() *Router {
+	router := newGoClientRouter()
+	router.HandleRPC(greet)
+	return router
+}
+
+func TestRPCTSClientAcceptsClientOptions(t *testing.T) {
+	var ts bytes.Buffer
+	if err := newClientOptionsRouter().WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	for _, want := range []string{
+		"signal?: AbortSignal",
+		"export type ClientOptions = {",
+		"retry?: RetryPolicy",
+		"onRequest?: RequestInterceptor[]",
+		`export function createClient(basepath: string = "/", clientOptions: ClientOptions = {}) {`,
+		`const [response, text] = await call(clientOptions, rpcRequest, options, true)`,
+		`const [response, text] = await call(clientOptions, rpcRequest, options, false)`,
+		`const response = await openStream(clientOptions, rpcRequest, options)`,
+	} {
+		assertRPCContains(t, ts.String(), want)
+	}
+}
+
+func TestRPCJSClientRetriesTimesOutAndIntercepts(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	var js bytes.Buffer
+	if err := newClientOptionsRouter().WriteClientJS(&js); err != nil {
+		t.Fatalf("write js client: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client.gen.mjs"), js.Bytes(), 0644); err != nil {
+		t.Fatalf("write js client: %v", err)
+	}
+	harness := `
+import { createClient } from "./client.gen.mjs";
+
+const calls = [];
+let statuses = [];
+globalThis.fetch = (url, init) => new Promise((resolve, reject) => {
+  calls.push({ url, init });
+  const status = statuses.shift();
+  if (status === "hang") {
+    if (init.signal.aborted) {
+      reject(init.signal.reason);
+      return;
+    }
+    init.signal.addEventListener("abort", () => reject(init.signal.reason));
+    return;
+  }
+  if (status === "reset") {
+    reject(new TypeError("fetch failed"));
+    return;
+  }
+  resolve(new Response(JSON.stringify({ id: "t1", title: "ok", message: "hi" }), { status }));
+});
+
+const seen = [];
+const client = createClient("https://api.example", {
+  timeoutMs: 50,
+  retry: { backoffMs: 1 },
+  onRequest: [(request) => { request.headers["X-Trace"] = request.rpc; }],
+  onResponse: [(response, request) => { seen.push(request.rpc + " " + response.status); }],
+});
+
+statuses = [503, "reset", 200];
+const task = await client.rpc.CreateTask({ title: "ok" }, { auth: "k" });
+if (task.id !== "t1" || calls.length !== 3) throw new Error("expected three attempts, got " + calls.length);
+const keys = new Set(calls.map((c) => c.init.headers["Idempotency-Key"]));
+if (keys.size !== 1) throw new Error("idempotency key changed between attempts");
+if (calls[2].init.headers["X-Trace"] !== "rpc.CreateTask") throw new Error("request interceptor did not run");
+if (calls[2].init.headers["X-Virtuous-Timeout-Ms"] !== "50") throw new Error("missing timeout header");
+if (seen.join(",") !== "rpc.CreateTask 503,rpc.CreateTask 200") throw new Error("response interceptor saw " + seen);
+
+calls.length = 0;
+statuses = ["hang", 200];
+await client.rpc.CountTasks();
+if (calls.length !== 2 || calls[0].init.method !== "GET") throw new Error("expected timed-out read to be retried");
+
+calls.length = 0;
+statuses = [503, 200];
+try {
+  await client.rpc.greet({ name: "a" });
+  throw new Error("expected RPCError");
+} catch (err) {
+  if (err.status !== 503 || calls.length !== 1) throw new Error("non-idempotent call was retried");
+}
+
+calls.length = 0;
+statuses = ["hang", 200];
+const controller = new AbortController();
+const pending = client.rpc.CreateTask({ title: "ok" }, { auth: "k", signal: controller.signal, timeoutMs: 10000 });
+controller.abort();
+try {
+  await pending;
+  throw new Error("expected abort");
+} catch (err) {
+  if (err.name !== "AbortError" || calls.length !== 1) throw new Error("aborted call was retried: " + err);
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "harness.mjs"), []byte(harness), 0644); err != nil {
+		t.Fatalf("write node harness: %v", err)
+	}
+	output, err := exec.Command(node, filepath.Join(dir, "harness.mjs")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("js client options failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+}
+
+func TestRPCPythonClientRetriesTimesOutAndIntercepts(t *testing.T) {
+	pyPath := filepath.Join(t.TempDir(), "client.gen.py")
+	if err := newClientOptionsRouter().WriteClientPYFile(pyPath); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	snippet := pythonRPCImportSnippet(pyPath) + `
+import json
+from urllib import error
+
+calls = []
+statuses = []
+
+class FakeResponse:
+    def __init__(self, status):
+        self._status = status
+        self.headers = {"Content-Type": "application/json"}
+    def __enter__(self):
+        return self
+    def __exit__(self, exc_type, exc, tb):
+        return False
+    def getcode(self):
+        return self._status
+    def read(self):
+        return json.dumps({"id": "t1", "title": "ok", "message": "hi"}).encode("utf-8")
+
+def fake_urlopen(req, timeout=None):
+    calls.append((req, timeout))
+    status = statuses.pop(0)
+    if status == "reset":
+        raise error.URLError(ConnectionResetError("reset"))
+    if status >= 400:
+        raise error.HTTPError(req.full_url, status, "failed", {}, None)
+    return FakeResponse(status)
+
+mod.request.urlopen = fake_urlopen
+seen = []
+def trace(call):
+    call.headers["X-Trace"] = call.rpc
+def record(response, call):
+    seen.append((call.rpc, response.status))
+client = mod.create_client("https://api.example", timeout_ms=250, retry=mod.RetryPolicy(backoff_ms=1), on_request=[trace], on_response=[record])
+
+statuses[:] = [503, "reset", 200]
+task = client.rpc.CreateTask(mod.goClientCreateTask(title="ok"), bearerAuth="k")
+assert task.id == "t1" and len(calls) == 3, (task, calls)
+assert len({req.get_header("Idempotency-key") for req, _ in calls}) == 1
+assert calls[2][0].get_header("X-trace") == "rpc.CreateTask"
+assert calls[2][0].get_header("X-virtuous-timeout-ms") == "250" and calls[2][1] == 0.25
+assert seen == [("rpc.CreateTask", 503), ("rpc.CreateTask", 200)], seen
+
+calls.clear()
+statuses[:] = [503, 200]
+try:
+    client.rpc.greet(mod.greetReq(name="a"))
+    raise AssertionError("expected RPCError")
+except mod.RPCError as err:
+    assert err.status == 503 and len(calls) == 1, (err.status, calls)
+`
+	if err := runRPCPython("-c", snippet); err != nil {
+		t.Fatalf("python client options failed: %v", err)
+	}
+}
+
+func TestRPCAsyncPythonClientRetriesTimesOutAndIntercepts(t *testing.T) {
+	pyPath := filepath.Join(t.TempDir(), "client.gen.async.py")
+	if err := newClientOptionsRouter().WriteClientPYAsyncFile(pyPath); err != nil {
+		t.Fatalf("write async python client: %v", err)
+	}
+	snippet := pythonRPCImportSnippet(pyPath) + `
+import asyncio
+
+async def main():
+    calls = []
+    statuses = []
+    async def transport(method, url, headers, data):
+        calls.append((method, headers))
+        status = statuses.pop(0)
+        if status == "hang":
+            await asyncio.sleep(10)
+        async def chunks():
+            yield b'{"id":"t1","title":"ok","limit":7}'
+        return status, {"content-type": "application/json"}, chunks()
+
+    async def refresh(call):
+        await asyncio.sleep(0)
+        call.headers["Authorization"] = "Bearer fresh"
+    client = mod.create_client("https://api.example", transport=transport, timeout_ms=50, retry=mod.RetryPolicy(backoff_ms=1), on_request=[refresh])
+
+    statuses[:] = ["hang", 502, 200]
+    task = await client.rpc.CreateTask(mod.goClientCreateTask(title="ok"))
+    assert task.id == "t1" and len(calls) == 3, calls
+    assert len({headers["Idempotency-Key"] for _, headers in calls}) == 1
+    assert calls[2][1]["Authorization"] == "Bearer fresh" and calls[2][1]["X-Virtuous-Timeout-Ms"] == "50"
+
+    calls.clear()
+    statuses[:] = ["hang", 200]
+    try:
+        await client.rpc.greet(mod.greetReq(name="a"))
+        raise AssertionError("expected timeout")
+    except asyncio.TimeoutError:
+        assert len(calls) == 1, calls
+
+asyncio.run(main())
+`
+	if err := runRPCPython("-c", snippet); err != nil {
+		t.Fatalf("async python client options failed: %v", err)
+	}
+}
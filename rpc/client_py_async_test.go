@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRPCAsyncPythonClientMatchesSyncSignatures(t *testing.T) {
+	router := newGoClientRouter()
+	var buf bytes.Buffer
+	if err := router.WriteClientPYAsync(&buf); err != nil {
+		t.Fatalf("write async python client: %v", err)
+	}
+	text := buf.String()
+	for _, want := range []string{
+		"import asyncio\n",
+		`async def CreateTask(self, body:"goClientCreateTask", bearerAuth: str | None = None, idempotency_key: str | None = None, timeout_ms: int | None = None) ->"goClientTask":`,
+		`def WatchTasks(self, body:"goClientCreateTask") ->AsyncIterator["goClientTask"]:`,
+		`return await _rpc_request(self._options, "rpc.CreateTask", url, headers, data, goClientTask, RPCErrorBody, timeout_ms, retryable=True)`,
+		`def create_client(base_url: str = "/", codec: str = "json", transport: Optional[AsyncTransport] = None, *, timeout_ms: Optional[int] = None, retry: Optional[RetryPolicy] = None, on_request: Optional[list[RequestInterceptor]] = None, on_response: Optional[list[ResponseInterceptor]] = None) -> _VirtuousClient:`,
+	} {
+		assertRPCContains(t, text, want)
+	}
+	if strings.Contains(text, "def _rpc_request(url") {
+		t.Fatalf("async client should not include the blocking transport")
+	}
+}
+
+func TestRPCAsyncPythonClientCallsServer(t *testing.T) {
+	server := httptest.NewServer(newGoClientRouter())
+	defer server.Close()
+
+	pyPath := filepath.Join(t.TempDir(), "client.gen.async.py")
+	if err := newGoClientRouter().WriteClientPYAsyncFile(pyPath); err != nil {
+		t.Fatalf("write async python client: %v", err)
+	}
+	snippet := pythonRPCImportSnippet(pyPath) + `
+import asyncio, warnings
+
+async def main():
+    client = mod.create_client(` + "r'" + server.URL + "'" + `)
+    task = await client.rpc.CreateTask(mod.goClientCreateTask(title="write"), bearerAuth="secret")
+    assert isinstance(task, mod.goClientTask) and isinstance(task.Owner, mod.goClientOwner), task
+    assert task.Owner.user_id == "u1", task
+    try:
+        await client.rpc.CreateTask(mod.goClientCreateTask(title=""), bearerAuth="secret")
+        raise AssertionError("expected RPCError")
+    except mod.RPCError as err:
+        assert err.status == 422 and err.body.code == "invalid", (err.status, err.body)
+    try:
+        await client.rpc.CreateTask(mod.goClientCreateTask(title="write"))
+        raise AssertionError("expected RPCError")
+    except mod.RPCError as err:
+        assert err.status == 401, err.status
+    with warnings.catch_warnings(record=True) as caught:
+        warnings.simplefilter("always")
+        query = await client.rpc.CountTasks(timeout_ms=500)
+    assert query.limit == 7 and caught, query
+    ids = [item.id async for item in client.rpc.WatchTasks(mod.goClientCreateTask(title="watch"))]
+    assert ids == ["a", "b"], ids
+
+    calls = []
+    async def transport(method, url, headers, data):
+        calls.append((method, url, headers.get("Authorization")))
+        async def chunks():
+            yield b'{"id":"t9",'
+            yield b'"title":"fake"}'
+        return 200, {"content-type": "application/json"}, chunks()
+    fake = mod.create_client("https://api.example", transport=transport)
+    task = await fake.rpc.CreateTask(mod.goClientCreateTask(title="x"), bearerAuth="k")
+    assert task.id == "t9" and calls == [("POST", "https://api.example/rpc/rpc/create-task", "Bearer k")], (task, calls)
+
+asyncio.run(main())
+`
+	if err := runRPCPython("-c", snippet); err != nil {
+		t.Fatalf("async python client failed: %v", err)
+	}
+}
+
+func TestRPCAsyncPythonClientIsSigned(t *testing.T) {
+	router := NewRouter(WithPythonClientSigning(testRPCPythonSigning(t)))
+	router.HandleRPC(rpcClientHandler)
+	var buf bytes.Buffer
+	if err := router.WriteClientPYAsync(&buf); err != nil {
+		t.Fatalf("write async python client: %v", err)
+	}
+	assertRPCContains(t, buf.String(), "# Virtuous-Signature-End\n")
+
+	var hash bytes.Buffer
+	if err := router.WriteClientPYAsyncHash(&hash); err != nil {
+		t.Fatalf("write async python hash: %v", err)
+	}
+	assertRPCContains(t, buf.String(), "Virtuous client hash: "+hash.String())
+}
@@ -9,16 +9,34 @@ import (
 	"text/template"
 )
 
-var clientPyTemplate = template.Must(template.New("virtuous-rpc-py").Parse(`"""Runtime-generated Python client for Virtuous RPC routes."""
+var clientPyTemplate = template.Must(template.New("virtuous-rpc-py").Parse(`"""Runtime-generated {{ if .Async }}async {{ end }}Python client for Virtuous RPC routes."""
 
-from dataclasses import dataclass, field, fields, is_dataclass
+{{ if .Async }}import asyncio
+{{ end }}from dataclasses import dataclass, field, fields, is_dataclass
 from datetime import date as _date, datetime as _datetime
 from decimal import Decimal as _Decimal
+import gzip
 import http
+{{- if .Async }}
+import inspect
+{{- end }}
 import json
+{{- if not .Async }}
+import time
+{{- end }}
 import types
-from typing import Any, Optional, Union, get_args, get_origin, get_type_hints
+{{- if .Async }}
+from typing import Any, AsyncIterator, Awaitable, Callable, Optional, Union, get_args, get_origin, get_type_hints
+{{- else }}
+from typing import Any, Callable, Iterator, Optional, Union, get_args, get_origin, get_type_hints
+{{- end }}
 from urllib import error, parse, request
+{{- if .HasIdempotent }}
+import uuid
+{{- end }}
+{{- if .HasDeprecated }}
+import warnings
+{{- end }}
 
 # Type definitions
 {{- range $i, $object := .Objects }}
@@ -31,6 +49,9 @@ class {{ $object.Name }}:
 {{- range $field := $object.Fields }}
 {{- if $field.Doc }}
     # {{ $field.Doc }}
+{{- end }}
+{{- if $field.Constraints }}
+    # {{ $field.Constraints }}
 {{- end }}
     {{ $field.Declaration }}
 {{- end }}
@@ -44,18 +65,96 @@ class RPCError(RuntimeError):
         self.status = status
         self.body = body
 
+
+@dataclass
+class RetryPolicy:
+    """Retries idempotent and read-only calls after network errors, timeouts, and retryable statuses."""
+    attempts: int = 3
+    backoff_ms: int = 100
+    max_backoff_ms: int = 2000
+    statuses: tuple[int, ...] = (408, 429, 502, 503, 504)
+
+
+@dataclass
+class RPCRequest:
+    """A request about to be sent. rpc names the called method, such as "Users.GetUser"."""
+    rpc: str
+    method: str
+    url: str
+    headers: dict[str, str]
+    data: Optional[bytes] = None
+
+
+@dataclass
+class RPCResponse:
+    """A response to a non-streaming call, with the body as sent."""
+    status: int
+    headers: dict[str, str]
+    body: bytes
+
+{{ if .Async }}
+# AsyncTransport sends one request and returns the status, the response
+# headers, and the raw body as chunks. Pass one to create_client to send
+# requests with httpx or aiohttp; the default runs urllib in a worker thread.
+AsyncTransport = Callable[[str, str, dict[str, str], Optional[bytes]], Awaitable[tuple[int, dict[str, str], AsyncIterator[bytes]]]]
+
+# Interceptors run in order before each attempt and after each response of a
+# non-streaming call. They may change their argument, return a replacement,
+# or be coroutines.
+RequestInterceptor = Callable[[RPCRequest], Union[Optional[RPCRequest], Awaitable[Optional[RPCRequest]]]]
+ResponseInterceptor = Callable[[RPCResponse, RPCRequest], Union[Optional[RPCResponse], Awaitable[Optional[RPCResponse]]]]
+{{ else }}
+# Interceptors run in order before each attempt and after each response of a
+# non-streaming call. They may change their argument or return a replacement.
+RequestInterceptor = Callable[[RPCRequest], Optional[RPCRequest]]
+ResponseInterceptor = Callable[[RPCResponse, RPCRequest], Optional[RPCResponse]]
+{{ end }}
+
+@dataclass
+class _ClientOptions:
+    codec: str = "json"
+    timeout_ms: Optional[int] = None
+    retry: Optional[RetryPolicy] = None
+    on_request: list[RequestInterceptor] = field(default_factory=list)
+    on_response: list[ResponseInterceptor] = field(default_factory=list)
+{{- if .Async }}
+    transport: Optional[AsyncTransport] = None
+{{- end }}
+
+
 {{- range $service := .Services }}
 class {{ $service.ClassName }}:
-    def __init__(self, base_url: str):
+    def __init__(self, base_url: str, options: _ClientOptions):
         self._base_url = base_url
+        self._options = options
 
 {{- range $method := $service.Methods }}
-    def {{ $method.Name }}(self{{- if $method.HasBody }}, body: {{- if $method.RequestType }}{{ $method.RequestType }}{{- else }}Any{{- end }}{{- end }}{{- if $method.HasAuth }}, {{ $method.AuthParam }}: str | None = None{{- end }}) -> {{- if $method.ResponseType }}{{ $method.ResponseType }}{{- else }}None{{- end }}:
+    {{ if and $.Async (not $method.Streaming) }}async {{ end }}def {{ $method.Name }}(self{{- if $method.HasBody }}, body: {{- if $method.RequestType }}{{ $method.RequestType }}{{- else }}Any{{- end }}{{- end }}{{- if $method.HasAuth }}, {{ $method.AuthParam }}: str | None = None{{- end }}{{- if $method.Idempotent }}, {{ $method.IdempotencyParam }}: str | None = None{{- end }}{{- if $method.TimeoutParam }}, {{ $method.TimeoutParam }}: int | None = None{{- end }}) -> {{- if $method.Streaming }}{{ if $.Async }}AsyncIterator{{ else }}Iterator{{ end }}[{{ $method.ResponseType }}]{{- else if $method.ResponseType }}{{ $method.ResponseType }}{{- else }}None{{- end }}:
+{{- if $method.Docstring }}
+        {{ $method.Docstring }}
+{{- end }}
+{{- if $method.DeprecationWarning }}
+        warnings.warn({{ $method.DeprecationWarning }}, DeprecationWarning, stacklevel=2)
+{{- end }}
         headers = {
-            "Accept": "application/json",
+            "Accept": {{ if $method.Streaming }}"text/event-stream"{{ else }}"application/json"{{ end }},
+{{- if not $method.ReadOnly }}
             "Content-Type": "application/json",
+{{- end }}
         }
         url = self._base_url + "{{ $method.Path }}"
+{{- if and $method.ReadOnly $method.HasBody }}
+        url = _append_query(url, "request", json.dumps(_encode_value(body), separators=(",", ":")))
+{{- end }}
+{{- if $method.Idempotent }}
+        headers["Idempotency-Key"] = {{ $method.IdempotencyParam }} if {{ $method.IdempotencyParam }} is not None else str(uuid.uuid4())
+{{- end }}
+{{- if $method.TimeoutParam }}
+        if {{ $method.TimeoutParam }} is None:
+            {{ $method.TimeoutParam }} = self._options.timeout_ms
+        if {{ $method.TimeoutParam }} is not None:
+            headers["X-Virtuous-Timeout-Ms"] = str({{ $method.TimeoutParam }})
+{{- end }}
 {{- if $method.HasAuth }}
         if {{ $method.AuthParam }} is not None:
             auth_value = {{ $method.AuthParam }}
@@ -73,42 +172,243 @@ class {{ $service.ClassName }}:
 {{- end }}
 {{- end }}
         data = None
-{{- if $method.HasBody }}
+{{- if and $method.HasBody (not $method.ReadOnly) }}
+{{- if $method.Streaming }}
         data = json.dumps(_encode_value(body)).encode("utf-8")
+{{- else }}
+        data = _encode_body(self._options.codec, _encode_value(body))
+{{- end }}
+{{- end }}
+{{- if $method.Streaming }}
+        return _rpc_stream(self._options, {{ $method.RPC }}, url, headers, data, {{ $method.ResponseDecodeType }})
+{{- else }}
+        return {{ if $.Async }}await {{ end }}_rpc_request(self._options, {{ $method.RPC }}, url, headers, data, {{ if $method.ResponseDecodeType }}{{ $method.ResponseDecodeType }}{{ else }}None{{ end }}, {{ $method.ErrorDecodeType }}, {{ $method.TimeoutParam }}{{ if $method.ReadOnly }}, method="GET"{{ end }}{{ if or $method.Idempotent $method.ReadOnly }}, retryable=True{{ end }})
 {{- end }}
-        return _rpc_request(url, headers, data, {{ if $method.ResponseDecodeType }}{{ $method.ResponseDecodeType }}{{ else }}None{{ end }}, {{ $method.ErrorDecodeType }})
 
 {{- end }}
 {{- end }}
 
 class _VirtuousClient:
-    def __init__(self, base_url: str = "/"):
+{{- if .Async }}
+    def __init__(self, base_url: str = "/", codec: str = "json", transport: Optional[AsyncTransport] = None, *, timeout_ms: Optional[int] = None, retry: Optional[RetryPolicy] = None, on_request: Optional[list[RequestInterceptor]] = None, on_response: Optional[list[ResponseInterceptor]] = None):
+        _codec_media_type(codec)
+        self._base_url = base_url
+        options = _ClientOptions(codec, timeout_ms, retry, list(on_request or []), list(on_response or []), transport or _urllib_transport)
+{{- else }}
+    def __init__(self, base_url: str = "/", codec: str = "json", *, timeout_ms: Optional[int] = None, retry: Optional[RetryPolicy] = None, on_request: Optional[list[RequestInterceptor]] = None, on_response: Optional[list[ResponseInterceptor]] = None):
+        _codec_media_type(codec)
         self._base_url = base_url
+        options = _ClientOptions(codec, timeout_ms, retry, list(on_request or []), list(on_response or []))
+{{- end }}
 {{- range $service := .Services }}
-        self.{{ $service.AttrName }} = {{ $service.ClassName }}(base_url)
+        self.{{ $service.AttrName }} = {{ $service.ClassName }}(base_url, options)
+{{- end }}
+
+
+{{ if .Async }}def create_client(base_url: str = "/", codec: str = "json", transport: Optional[AsyncTransport] = None, *, timeout_ms: Optional[int] = None, retry: Optional[RetryPolicy] = None, on_request: Optional[list[RequestInterceptor]] = None, on_response: Optional[list[ResponseInterceptor]] = None) -> _VirtuousClient:
+    return _VirtuousClient(base_url, codec, transport, timeout_ms=timeout_ms, retry=retry, on_request=on_request, on_response=on_response)
+{{- else }}def create_client(base_url: str = "/", codec: str = "json", *, timeout_ms: Optional[int] = None, retry: Optional[RetryPolicy] = None, on_request: Optional[list[RequestInterceptor]] = None, on_response: Optional[list[ResponseInterceptor]] = None) -> _VirtuousClient:
+    return _VirtuousClient(base_url, codec, timeout_ms=timeout_ms, retry=retry, on_request=on_request, on_response=on_response)
 {{- end }}
 
 
-def create_client(base_url: str = "/") -> _VirtuousClient:
-    return _VirtuousClient(base_url)
+_CODEC_MEDIA_TYPES = {
+    "json": "application/json",
+    "msgpack": "application/msgpack",
+    "cbor": "application/cbor",
+}
+
+
+def _codec_media_type(codec: str) -> str:
+    media_type = _CODEC_MEDIA_TYPES.get(codec)
+    if media_type is None:
+        raise ValueError(f"unsupported codec {codec!r}; expected one of {', '.join(_CODEC_MEDIA_TYPES)}")
+    return media_type
+
+
+def _encode_body(codec: str, value: Any) -> bytes:
+    if codec == "msgpack":
+        import msgpack
+        return msgpack.packb(value, use_bin_type=True)
+    if codec == "cbor":
+        import cbor2
+        return cbor2.dumps(value)
+    return json.dumps(value).encode("utf-8")
+
+
+def _decode_body(media_type: str, raw: bytes) -> Any:
+    if media_type == "application/msgpack":
+        import msgpack
+        return msgpack.unpackb(raw, raw=False)
+    if media_type == "application/cbor":
+        import cbor2
+        return cbor2.loads(raw)
+    return json.loads(raw.decode("utf-8"))
+
+
+{{ if .Async }}async def _urllib_transport(method: str, url: str, headers: dict[str, str], data: Optional[bytes]) -> tuple[int, dict[str, str], AsyncIterator[bytes]]:
+    req = request.Request(url, data=data, method=method, headers=headers)
 
+    def open_response() -> Any:
+        try:
+            return request.urlopen(req)
+        except error.HTTPError as err:
+            return err
+
+    resp = await asyncio.to_thread(open_response)
+
+    async def chunks() -> AsyncIterator[bytes]:
+        try:
+            while True:
+                chunk = await asyncio.to_thread(resp.read1, 65536)
+                if not chunk:
+                    return
+                yield chunk
+        finally:
+            resp.close()
+
+    return resp.getcode(), dict(resp.headers.items()), chunks()
+
+
+async def _rpc_request(options: _ClientOptions, rpc: str, url: str, headers: dict[str, str], data: Any, response_type: Any, error_type: Any, timeout_ms: Optional[int] = None, method: str = "POST", retryable: bool = False) -> Any:
+    _prepare_headers(options.codec, headers, data)
+    policy = options.retry if retryable else None
+    attempts = max(1, policy.attempts) if policy is not None else 1
+    attempt = 1
+    while True:
+        call = await _intercept_request(options, RPCRequest(rpc, method, url, dict(headers), data))
+        try:
+            if timeout_ms is None:
+                response = await _send(options, call)
+            else:
+                response = await asyncio.wait_for(_send(options, call), timeout_ms / 1000)
+        except (OSError, asyncio.TimeoutError):
+            if attempt >= attempts:
+                raise
+            await asyncio.sleep(_retry_delay(policy, attempt))
+            attempt += 1
+            continue
+        for intercept in options.on_response:
+            result = intercept(response, call)
+            if inspect.isawaitable(result):
+                result = await result
+            response = result or response
+        if attempt < attempts and response.status in policy.statuses:
+            await asyncio.sleep(_retry_delay(policy, attempt))
+            attempt += 1
+            continue
+        break
+    status = response.status
+    raw, media_type = _unwrap_body(response.body, response.headers)
+    body = None
+    if raw:
+        try:
+            body = _decode_body(media_type, raw)
+        except ValueError as err:
+            raise RPCError(status, None, f"{status} {_status_text(status)}") from err
+    if status >= 400:
+        err_body = _decode_value(error_type, body)
+        raise RPCError(status, err_body, f"{status} {_status_text(status)}")
+    if response_type is None:
+        return None
+    return _decode_value(response_type, body)
 
-def _rpc_request(url: str, headers: dict[str, str], data: Any, response_type: Any, error_type: Any) -> Any:
-    req = request.Request(url, data=data, method="POST", headers=headers)
-    status = 0
-    text = ""
+{{ if .HasStreams }}
+async def _rpc_stream(options: _ClientOptions, rpc: str, url: str, headers: dict[str, str], data: Any, message_type: Any) -> AsyncIterator[Any]:
+    call = await _intercept_request(options, RPCRequest(rpc, "POST", url, dict(headers), data))
+    status, _, chunks = await options.transport(call.method, call.url, call.headers, call.data)
+    if status >= 400:
+        text = (await _read_chunks(chunks)).decode("utf-8")
+        body = None
+        if text:
+            try:
+                body = json.loads(text)
+            except json.JSONDecodeError:
+                body = None
+        raise RPCError(status, body, f"{status} {_status_text(status)}")
     try:
-        with request.urlopen(req) as resp:
-            status = resp.getcode()
-            text = resp.read().decode("utf-8")
-    except error.HTTPError as err:
-        status = err.code
-        text = err.read().decode("utf-8")
+        event = "message"
+        lines: list[str] = []
+        pending = b""
+        async for chunk in chunks:
+            pending += chunk
+            while b"\n" in pending:
+                raw, pending = pending.split(b"\n", 1)
+                line = raw.decode("utf-8").rstrip("\r")
+                if line.startswith("event:"):
+                    event = line[6:].strip()
+                    continue
+                if line.startswith("data:"):
+                    lines.append(line[5:].strip())
+                    continue
+                if line != "":
+                    continue
+                payload = "\n".join(lines)
+                current = event
+                event = "message"
+                lines = []
+                if current == "done":
+                    return
+                if current == "error":
+                    body = json.loads(payload) if payload else {"status": 500}
+                    status = body.get("status", 500)
+                    raise RPCError(status, body, f"{status} stream error")
+                if payload:
+                    yield _decode_value(message_type, json.loads(payload))
+    finally:
+        aclose = getattr(chunks, "aclose", None)
+        if aclose is not None:
+            await aclose()
+    raise RPCError(status, None, "stream closed before completion")
+
+{{ end }}
+async def _send(options: _ClientOptions, call: RPCRequest) -> RPCResponse:
+    status, headers, chunks = await options.transport(call.method, call.url, call.headers, call.data)
+    return RPCResponse(status, headers, await _read_chunks(chunks))
+
+
+async def _intercept_request(options: _ClientOptions, call: RPCRequest) -> RPCRequest:
+    for intercept in options.on_request:
+        result = intercept(call)
+        if inspect.isawaitable(result):
+            result = await result
+        call = result or call
+    return call
+
+
+async def _read_chunks(chunks: AsyncIterator[bytes]) -> bytes:
+    return b"".join([chunk async for chunk in chunks])
+
+
+{{ else }}def _rpc_request(options: _ClientOptions, rpc: str, url: str, headers: dict[str, str], data: Any, response_type: Any, error_type: Any, timeout_ms: Optional[int] = None, method: str = "POST", retryable: bool = False) -> Any:
+    _prepare_headers(options.codec, headers, data)
+    policy = options.retry if retryable else None
+    attempts = max(1, policy.attempts) if policy is not None else 1
+    attempt = 1
+    while True:
+        call = _intercept_request(options, RPCRequest(rpc, method, url, dict(headers), data))
+        try:
+            response = _send(call, timeout_ms)
+        except OSError:
+            if attempt >= attempts:
+                raise
+            time.sleep(_retry_delay(policy, attempt))
+            attempt += 1
+            continue
+        for intercept in options.on_response:
+            response = intercept(response, call) or response
+        if attempt < attempts and response.status in policy.statuses:
+            time.sleep(_retry_delay(policy, attempt))
+            attempt += 1
+            continue
+        break
+    status = response.status
+    raw, media_type = _unwrap_body(response.body, response.headers)
     body = None
-    if text:
+    if raw:
         try:
-            body = json.loads(text)
-        except json.JSONDecodeError as err:
+            body = _decode_body(media_type, raw)
+        except ValueError as err:
             raise RPCError(status, None, f"{status} {_status_text(status)}") from err
     if status >= 400:
         err_body = _decode_value(error_type, body)
@@ -117,6 +417,91 @@ def _rpc_request(url: str, headers: dict[str, str], data: Any, response_type: An
         return None
     return _decode_value(response_type, body)
 
+{{ if .HasStreams }}
+def _rpc_stream(options: _ClientOptions, rpc: str, url: str, headers: dict[str, str], data: Any, message_type: Any) -> Iterator[Any]:
+    call = _intercept_request(options, RPCRequest(rpc, "POST", url, dict(headers), data))
+    req = request.Request(call.url, data=call.data, method=call.method, headers=call.headers)
+    try:
+        resp = request.urlopen(req)
+    except error.HTTPError as err:
+        text = err.read().decode("utf-8")
+        body = None
+        if text:
+            try:
+                body = json.loads(text)
+            except json.JSONDecodeError:
+                body = None
+        raise RPCError(err.code, body, f"{err.code} {_status_text(err.code)}") from err
+    with resp:
+        event = "message"
+        lines: list[str] = []
+        for raw in resp:
+            line = raw.decode("utf-8").rstrip("\r\n")
+            if line.startswith("event:"):
+                event = line[6:].strip()
+                continue
+            if line.startswith("data:"):
+                lines.append(line[5:].strip())
+                continue
+            if line != "":
+                continue
+            payload = "\n".join(lines)
+            current = event
+            event = "message"
+            lines = []
+            if current == "done":
+                return
+            if current == "error":
+                body = json.loads(payload) if payload else {"status": 500}
+                status = body.get("status", 500)
+                raise RPCError(status, body, f"{status} stream error")
+            if payload:
+                yield _decode_value(message_type, json.loads(payload))
+    raise RPCError(resp.status, None, "stream closed before completion")
+
+{{ end }}
+def _send(call: RPCRequest, timeout_ms: Optional[int]) -> RPCResponse:
+    req = request.Request(call.url, data=call.data, method=call.method, headers=call.headers)
+    try:
+        resp = request.urlopen(req) if timeout_ms is None else request.urlopen(req, timeout=timeout_ms / 1000)
+        with resp:
+            return RPCResponse(resp.getcode(), _header_dict(resp), resp.read())
+    except error.HTTPError as err:
+        return RPCResponse(err.code, _header_dict(err), err.read())
+
+
+def _intercept_request(options: _ClientOptions, call: RPCRequest) -> RPCRequest:
+    for intercept in options.on_request:
+        call = intercept(call) or call
+    return call
+
+
+def _header_dict(resp: Any) -> dict[str, str]:
+    headers = getattr(resp, "headers", None)
+    if headers is None:
+        return {}
+    return dict(headers.items())
+
+
+{{ end }}def _prepare_headers(codec: str, headers: dict[str, str], data: Any) -> None:
+    if codec != "json":
+        headers["Accept"] = _codec_media_type(codec)
+        if data is not None:
+            headers["Content-Type"] = _codec_media_type(codec)
+    headers.setdefault("Accept-Encoding", "gzip")
+
+
+def _retry_delay(policy: RetryPolicy, attempt: int) -> float:
+    return min(policy.max_backoff_ms, policy.backoff_ms * 2 ** (attempt - 1)) / 1000
+
+
+def _unwrap_body(raw: bytes, headers: dict[str, str]) -> tuple[bytes, str]:
+    values = {key.lower(): value for key, value in headers.items()}
+    if values.get("content-encoding", "").strip().lower() == "gzip":
+        raw = gzip.decompress(raw)
+    media_type = values.get("content-type", "").split(";", 1)[0].strip().lower()
+    return raw, media_type
+
 
 def _status_text(code: int) -> str:
     try:
@@ -219,8 +604,13 @@ def _append_query(url: str, key: str, value: str) -> str:
 `))
 
 type pythonClientSpec struct {
-	Services []pythonClientService
-	Objects  []pythonClientObject
+	Services      []pythonClientService
+	Objects       []pythonClientObject
+	HasStreams    bool
+	HasDeprecated bool
+	HasIdempotent bool
+	// Async renders async def methods over an AsyncTransport.
+	Async bool
 }
 
 type pythonClientService struct {
@@ -233,6 +623,7 @@ type pythonClientMethod struct {
 	Name               string
 	Path               string
 	HasBody            bool
+	Streaming          bool
 	HasAuth            bool
 	Auth               GuardSpec
 	AuthParam          string
@@ -241,6 +632,14 @@ type pythonClientMethod struct {
 	ResponseDecodeType string
 	ErrorType          string
 	ErrorDecodeType    string
+	Docstring          string
+	DeprecationWarning string
+	Idempotent         bool
+	IdempotencyParam   string
+	TimeoutParam       string
+	ReadOnly           bool
+	// RPC is the quoted "Service.Method" name passed to interceptors.
+	RPC string
 }
 
 type pythonClientObject struct {
@@ -253,12 +652,15 @@ type pythonClientField struct {
 	WireName    string
 	Declaration string
 	Doc         string
+	Constraints string
 }
 
 func buildPythonClientRenderSpec(spec clientSpec) pythonClientSpec {
 	typeNames := pythonObjectNameMap(spec.Objects, spec.Services)
 	out := pythonClientSpec{
-		Objects: pythonObjects(spec.Objects, typeNames),
+		Objects:       pythonObjects(spec.Objects, typeNames),
+		HasStreams:    spec.HasStreams,
+		HasIdempotent: spec.HasIdempotent,
 	}
 	serviceAttrs := map[string]struct{}{"_base_url": {}}
 	serviceClasses := map[string]struct{}{}
@@ -269,7 +671,13 @@ func buildPythonClientRenderSpec(spec clientSpec) pythonClientSpec {
 		}
 		methodNames := map[string]struct{}{}
 		for _, method := range service.Methods {
-			pyService.Methods = append(pyService.Methods, pythonMethod(method, typeNames, methodNames))
+			pyMethod := pythonMethod(method, typeNames, methodNames)
+			pyMethod.RPC = clientgen.PythonStringLiteral(service.Name + "." + method.Name)
+			if method.Deprecated {
+				pyMethod.DeprecationWarning = clientgen.PythonStringLiteral(pythonDeprecationText(service.Name, method))
+				out.HasDeprecated = true
+			}
+			pyService.Methods = append(pyService.Methods, pyMethod)
 		}
 		out.Services = append(out.Services, pyService)
 	}
@@ -288,22 +696,47 @@ func pythonObjectNameMap(objects []clientObject, services []clientService) map[s
 func pythonReservedModuleNames(services []clientService) map[string]struct{} {
 	names := []string{
 		"Any",
+		"AsyncIterator",
+		"AsyncTransport",
+		"Awaitable",
+		"Callable",
+		"Iterator",
 		"Optional",
 		"RPCError",
+		"RPCRequest",
+		"RPCResponse",
+		"RequestInterceptor",
+		"ResponseInterceptor",
+		"RetryPolicy",
 		"Union",
+		"_CODEC_MEDIA_TYPES",
+		"_ClientOptions",
 		"_VirtuousClient",
 		"_append_query",
+		"_codec_media_type",
 		"_date",
 		"_decode_dataclass",
 		"_decode_date",
 		"_decode_datetime",
 		"_decode_decimal",
+		"_decode_body",
 		"_decode_value",
+		"_encode_body",
 		"_encode_value",
+		"_header_dict",
+		"_intercept_request",
+		"_prepare_headers",
+		"_read_chunks",
+		"_retry_delay",
 		"_datetime",
 		"_Decimal",
 		"_rpc_request",
+		"_rpc_stream",
+		"_send",
 		"_status_text",
+		"_unwrap_body",
+		"_urllib_transport",
+		"asyncio",
 		"create_client",
 		"dataclass",
 		"dict",
@@ -313,8 +746,10 @@ func pythonReservedModuleNames(services []clientService) map[string]struct{} {
 		"get_args",
 		"get_origin",
 		"get_type_hints",
+		"gzip",
 		"http",
 		"id",
+		"inspect",
 		"int",
 		"is_dataclass",
 		"json",
@@ -324,8 +759,11 @@ func pythonReservedModuleNames(services []clientService) map[string]struct{} {
 		"request",
 		"set",
 		"str",
+		"time",
 		"types",
 		"type",
+		"uuid",
+		"warnings",
 	}
 	out := make(map[string]struct{}, len(names))
 	for _, name := range names {
@@ -354,6 +792,7 @@ func pythonObjects(objects []clientObject, typeNames map[string]string) []python
 				WireName:    field.Name,
 				Declaration: pythonFieldDeclaration(name, field.Name, fieldType, field.Optional || field.Nullable),
 				Doc:         field.Doc,
+				Constraints: clientgen.PythonConstraintComment(field.EnumType, field.Constraints),
 			})
 		}
 		out = append(out, pyObject)
@@ -391,18 +830,38 @@ func pythonMethod(method clientMethod, typeNames map[string]string, methodNames
 		Name:         clientgen.UniquePythonIdentifier(method.Name, methodNames),
 		Path:         method.Path,
 		HasBody:      method.HasBody,
+		Streaming:    method.Streaming,
 		HasAuth:      method.HasAuth,
 		Auth:         method.Auth,
 		AuthParam:    clientgen.UniquePythonIdentifier(method.AuthParam, usedParams),
 		RequestType:  pythonTypeName(method.RequestType, typeNames),
 		ResponseType: pythonTypeName(method.ResponseType, typeNames),
 		ErrorType:    pythonTypeName(method.ErrorType, typeNames),
+		ReadOnly:     method.ReadOnly,
+	}
+	if method.Idempotent {
+		pyMethod.Idempotent = true
+		pyMethod.IdempotencyParam = clientgen.UniquePythonIdentifier("idempotency_key", usedParams)
+	}
+	if !method.Streaming {
+		pyMethod.TimeoutParam = clientgen.UniquePythonIdentifier("timeout_ms", usedParams)
+	}
+	if len(method.Doc) > 0 {
+		pyMethod.Docstring = clientgen.PythonStringLiteral(strings.ReplaceAll(strings.Join(method.Doc, "\n"), `*\/`, "*/"))
 	}
 	pyMethod.ResponseDecodeType = pythonRuntimeTypeName(pyMethod.ResponseType)
 	pyMethod.ErrorDecodeType = pythonRuntimeTypeName(pyMethod.ErrorType)
 	return pyMethod
 }
 
+func pythonDeprecationText(service string, method clientMethod) string {
+	text := service + "." + method.Name + " is deprecated"
+	if method.DeprecationMessage != "" {
+		text += ": " + method.DeprecationMessage
+	}
+	return text
+}
+
 func pythonTypeName(typeName string, names map[string]string) string {
 	out := typeName
 	for oldName, newName := range names {
@@ -518,3 +977,70 @@ func (r *Router) clientPYHash() (string, error) {
 	}
 	return clientgen.HashBytes(body), nil
 }
+
+// WriteClientPYAsync writes a runtime-generated async Python client to w. It
+// has the types and call semantics of the WriteClientPY client, with async
+// methods sent through a pluggable transport.
+func (r *Router) WriteClientPYAsync(w io.Writer) error {
+	body, err := r.clientPYAsyncBody()
+	if err != nil {
+		return err
+	}
+	hash := clientgen.HashBytes(body)
+	if err := clientgen.WriteArtifactHeader(w, "#", "Virtuous client hash", hash); err != nil {
+		return err
+	}
+	if r.pythonSigning != nil {
+		if err := clientgen.WritePythonSignatureEnvelope(w, *r.pythonSigning, body, hash); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteClientPYAsyncFile writes a runtime-generated async Python client to the
+// file at path.
+func (r *Router) WriteClientPYAsyncFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WriteClientPYAsync(f)
+}
+
+// WriteClientPYAsyncHash writes the hash of the stable async Python client
+// body to w.
+func (r *Router) WriteClientPYAsyncHash(w io.Writer) error {
+	body, err := r.clientPYAsyncBody()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, clientgen.HashBytes(body))
+	return err
+}
+
+// ServeClientPYAsync writes a runtime-generated async Python client as an
+// HTTP response.
+func (r *Router) ServeClientPYAsync(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/x-python; charset=utf-8")
+	if err := r.WriteClientPYAsync(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ServeClientPYAsyncHash writes the hash of the async Python client as an
+// HTTP response.
+func (r *Router) ServeClientPYAsyncHash(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := r.WriteClientPYAsyncHash(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (r *Router) clientPYAsyncBody() ([]byte, error) {
+	spec := buildPythonClientRenderSpec(buildPythonClientSpec(r.Routes(), r.typeOverrides))
+	spec.Async = true
+	return clientgen.RenderTemplate(clientPyTemplate, spec)
+}
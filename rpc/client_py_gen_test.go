@@ -112,7 +112,7 @@ func TestRPCPythonClientTransportDoesNotShadowClientModels(t *testing.T) {
 	pyText := string(py)
 	assertRPCContains(t, pyText, "class Client:")
 	assertRPCContains(t, pyText, "class _VirtuousClient:")
-	assertRPCContains(t, pyText, "def create_client(base_url: str = \"/\") -> _VirtuousClient:")
+	assertRPCContains(t, pyText, "def create_client(base_url: str = \"/\", codec: str = \"json\", *, timeout_ms: Optional[int] = None, retry: Optional[RetryPolicy] = None, on_request: Optional[list[RequestInterceptor]] = None, on_response: Optional[list[ResponseInterceptor]] = None) -> _VirtuousClient:")
 	if strings.Count(pyText, "class Client:") != 1 {
 		t.Fatalf("transport client should not shadow Client DTO:\n%s", pyText)
 	}
@@ -3,13 +3,22 @@ package rpc
 import (
 	"reflect"
 	"sort"
+	"strings"
 
+	"github.com/swetjen/virtuous/internal/clientgen"
 	"github.com/swetjen/virtuous/schema"
 )
 
 type clientSpec struct {
-	Services []clientService
-	Objects  []clientObject
+	Services   []clientService
+	Objects    []clientObject
+	HasStreams bool
+	// HasIdempotent adds the idempotencyKey option and key generator.
+	HasIdempotent bool
+	// JSONRPCPath enables the TS batch() helper when ServeJSONRPC is used.
+	JSONRPCPath     string
+	BatchAuth       []GuardSpec
+	BatchCookieAuth bool
 }
 
 type clientService struct {
@@ -21,12 +30,20 @@ type clientMethod struct {
 	Name         string
 	Path         string
 	HasBody      bool
+	Streaming    bool
 	HasAuth      bool
 	Auth         GuardSpec
 	AuthParam    string
 	RequestType  string
 	ResponseType string
 	ErrorType    string
+	Doc          []string
+	Deprecated   bool
+	Idempotent   bool
+	ReadOnly     bool
+	// DeprecationMessage is the raw text; DeprecationDoc is escaped for JSDoc.
+	DeprecationMessage string
+	DeprecationDoc     string
 }
 
 type clientObject = schema.Object
@@ -49,7 +66,11 @@ func buildClientSpecWith(
 	typeFnFactory func(*schema.Registry) func(reflect.Type) string,
 ) clientSpec {
 	serviceMap := make(map[string]*clientService)
+	hasStreams := false
+	hasIdempotent := false
 	registry := schema.NewRegistry(overrides)
+	registry.PreferNameOf(errorType, errorBodySchemaName)
+	registry.PreferNameOf(errorDetailType, errorDetailSchemaName)
 	typeFn := typeFnFactory(registry)
 	for _, route := range routes {
 		service := route.Service
@@ -77,13 +98,31 @@ func buildClientSpecWith(
 			responseType = typeFn(respType)
 		}
 
+		errorTypeName := responseType
+		if route.ErrorType != nil {
+			registry.AddTypeOf(route.ErrorType)
+			errorTypeName = typeFn(route.ErrorType)
+		} else if validatesRequest(route) && !route.Streaming {
+			// Validation failures use the error envelope even for handlers
+			// that return their response type for 422.
+			registry.AddTypeOf(errorType)
+			errorTypeName = responseType + " | " + typeFn(errorType)
+		}
+
 		method := clientMethod{
 			Name:         methodName,
 			Path:         route.Path,
 			HasBody:      route.RequestType != nil,
+			Streaming:    route.Streaming,
 			RequestType:  requestType,
 			ResponseType: responseType,
-			ErrorType:    responseType,
+			ErrorType:    errorTypeName,
+			Doc:          clientgen.DocLines(route.Docs.Summary, route.Docs.Description),
+			Deprecated:   route.Docs.Deprecated,
+		}
+		if route.Docs.Deprecated {
+			method.DeprecationMessage = route.Docs.DeprecationMessage
+			method.DeprecationDoc = strings.Join(clientgen.DocLines(strings.Join(strings.Fields(route.Docs.DeprecationMessage), " ")), "")
 		}
 		if len(route.Guards) > 0 {
 			// Current client templates expose a single auth input, so they bind
@@ -92,6 +131,14 @@ func buildClientSpecWith(
 			method.Auth = route.Guards[0]
 			method.AuthParam = authParamName(route.Guards[0].Name)
 		}
+		if route.Streaming {
+			hasStreams = true
+		}
+		if route.Idempotent {
+			method.Idempotent = true
+			hasIdempotent = true
+		}
+		method.ReadOnly = route.ReadOnly
 		cs.Methods = append(cs.Methods, method)
 	}
 
@@ -107,8 +154,10 @@ func buildClientSpecWith(
 	})
 
 	return clientSpec{
-		Services: services,
-		Objects:  registry.ObjectsWith(typeFn),
+		Services:      services,
+		Objects:       registry.ObjectsWith(typeFn),
+		HasStreams:    hasStreams,
+		HasIdempotent: hasIdempotent,
 	}
 }
 
@@ -0,0 +1,468 @@
+package rpc
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"text/template"
+
+	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/schema"
+)
+
+var clientSwiftTemplate = template.Must(template.New("virtuous-rpc-swift").Parse(`import Foundation
+#if canImport(FoundationNetworking)
+import FoundationNetworking
+#endif
+
+/// Options for a single call.
+public struct RPCCallOptions {
+    /// Credential for guarded methods, sent where the method's guard reads it.
+    public var auth: String?
+    /// Deadline for the call, sent as X-Virtuous-Timeout-Ms.
+    public var timeout: TimeInterval?
+    /// Idempotency-Key for idempotent methods. A random key is used when nil.
+    public var idempotencyKey: String?
+    /// Extra request headers.
+    public var headers: [String: String]
+
+    public init(auth: String? = nil, timeout: TimeInterval? = nil, idempotencyKey: String? = nil, headers: [String: String] = [:]) {
+        self.auth = auth
+        self.timeout = timeout
+        self.idempotencyKey = idempotencyKey
+        self.headers = headers
+    }
+}
+
+/// Thrown when a call responds with a non-2xx status.
+public struct RPCError: Error, CustomStringConvertible {
+    /// The HTTP status code.
+    public let status: Int
+    /// The code of the Virtuous error envelope, if any.
+    public let code: String?
+    /// The message of the Virtuous error envelope, if any.
+    public let message: String?
+    /// The raw response body.
+    public let body: Data
+
+    init(status: Int, body: Data) {
+        let envelope = try? JSONDecoder().decode(RPCErrorEnvelope.self, from: body)
+        self.status = status
+        self.code = envelope?.code
+        self.message = envelope?.message
+        self.body = body
+    }
+
+    public var description: String {
+        let text = String(status) + " " + HTTPURLResponse.localizedString(forStatusCode: status)
+        guard let message = message, !message.isEmpty else {
+            return text
+        }
+        return text + ": " + message
+    }
+
+    /// Decodes the error body.
+    public func decode<T: Decodable>(_ type: T.Type) throws -> T {
+        try RPCClient.makeDecoder().decode(type, from: body)
+    }
+}
+
+private struct RPCErrorEnvelope: Decodable {
+    let code: String?
+    let message: String?
+}
+
+/// An arbitrary JSON value.
+public enum JSONValue: Codable, Equatable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([JSONValue])
+    case object([String: JSONValue])
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if container.decodeNil() {
+            self = .null
+        } else if let value = try? container.decode(Bool.self) {
+            self = .bool(value)
+        } else if let value = try? container.decode(Double.self) {
+            self = .number(value)
+        } else if let value = try? container.decode(String.self) {
+            self = .string(value)
+        } else if let value = try? container.decode([JSONValue].self) {
+            self = .array(value)
+        } else {
+            self = .object(try container.decode([String: JSONValue].self))
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .null:
+            try container.encodeNil()
+        case .bool(let value):
+            try container.encode(value)
+        case .number(let value):
+            try container.encode(value)
+        case .string(let value):
+            try container.encode(value)
+        case .array(let value):
+            try container.encode(value)
+        case .object(let value):
+            try container.encode(value)
+        }
+    }
+}
+
+struct RPCAuthGuard {
+    let location: String
+    let param: String
+    let prefix: String
+}
+
+/// Calls the methods of a Virtuous RPC API.
+public final class RPCClient {
+    public let baseURL: URL
+    public let session: URLSession
+
+    /// Creates a client for the API served at baseURL, such as
+    /// https://api.example.com.
+    public init(baseURL: URL, session: URLSession = .shared) {
+        self.baseURL = baseURL
+        self.session = session
+    }
+{{- range $service := .Services }}
+
+    public var {{ $service.Property }}: {{ $service.Type }} {
+        {{ $service.Type }}(client: self)
+    }
+{{- end }}
+
+    static func makeEncoder() -> JSONEncoder {
+        let encoder = JSONEncoder()
+        encoder.dateEncodingStrategy = .custom { date, encoder in
+            var container = encoder.singleValueContainer()
+            let formatter = ISO8601DateFormatter()
+            formatter.formatOptions = [.withInternetDateTime, .withFractionalSeconds]
+            try container.encode(formatter.string(from: date))
+        }
+        return encoder
+    }
+
+    static func makeDecoder() -> JSONDecoder {
+        let decoder = JSONDecoder()
+        decoder.dateDecodingStrategy = .custom { decoder in
+            let container = try decoder.singleValueContainer()
+            let text = try container.decode(String.self)
+            guard let date = RPCClient.parseDate(text) else {
+                throw DecodingError.dataCorruptedError(in: container, debugDescription: "invalid date " + text)
+            }
+            return date
+        }
+        return decoder
+    }
+
+    // Go writes up to nine fractional digits; ISO8601DateFormatter reads three.
+    static func parseDate(_ text: String) -> Date? {
+        let formatter = ISO8601DateFormatter()
+        guard let dot = text.firstIndex(of: ".") else {
+            formatter.formatOptions = [.withInternetDateTime]
+            return formatter.date(from: text)
+        }
+        var end = text.index(after: dot)
+        while end < text.endIndex, text[end].isNumber {
+            end = text.index(after: end)
+        }
+        let digits = String((String(text[text.index(after: dot)..<end]) + "000").prefix(3))
+        formatter.formatOptions = [.withInternetDateTime, .withFractionalSeconds]
+        return formatter.date(from: String(text[..<dot]) + "." + digits + String(text[end...]))
+    }
+
+    func encode<T: Encodable>(_ value: T) throws -> Data {
+        try RPCClient.makeEncoder().encode(value)
+    }
+
+    func decode<T: Decodable>(_ type: T.Type, from data: Data) throws -> T {
+        try RPCClient.makeDecoder().decode(type, from: data)
+    }
+
+    func makeRequest(path: String, body: Data?, readOnly: Bool, idempotent: Bool, streaming: Bool, auth: RPCAuthGuard?, options: RPCCallOptions) throws -> URLRequest {
+        var query: [(String, String)] = []
+        var headers = options.headers
+        if readOnly, let body = body {
+            query.append(("request", String(decoding: body, as: UTF8.self)))
+        }
+        if let auth = auth, let value = options.auth, !value.isEmpty {
+            let credential = auth.prefix.isEmpty ? value : auth.prefix + " " + value
+            switch auth.location {
+            case "query":
+                query.append((auth.param, credential))
+            case "cookie":
+                headers["Cookie"] = auth.param + "=" + credential
+            default:
+                headers[auth.param] = credential
+            }
+        }
+        var base = baseURL.absoluteString
+        while base.hasSuffix("/") {
+            base.removeLast()
+        }
+        guard var components = URLComponents(string: base + path) else {
+            throw URLError(.badURL)
+        }
+        if !query.isEmpty {
+            components.percentEncodedQuery = query.map { RPCClient.escape($0.0) + "=" + RPCClient.escape($0.1) }.joined(separator: "&")
+        }
+        guard let url = components.url else {
+            throw URLError(.badURL)
+        }
+        var request = URLRequest(url: url)
+        request.httpMethod = readOnly ? "GET" : "POST"
+        for (key, value) in headers {
+            request.setValue(value, forHTTPHeaderField: key)
+        }
+        request.setValue(streaming ? "text/event-stream" : "application/json", forHTTPHeaderField: "Accept")
+        if !readOnly {
+            request.setValue("application/json", forHTTPHeaderField: "Content-Type")
+            request.httpBody = body
+        }
+        if idempotent {
+            request.setValue(options.idempotencyKey ?? UUID().uuidString, forHTTPHeaderField: "Idempotency-Key")
+        }
+        if !streaming, let timeout = options.timeout {
+            request.setValue(String(Int((timeout * 1000).rounded(.up))), forHTTPHeaderField: "X-Virtuous-Timeout-Ms")
+        }
+        return request
+    }
+
+    private static let queryAllowed = CharacterSet(charactersIn: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~")
+
+    private static func escape(_ value: String) -> String {
+        value.addingPercentEncoding(withAllowedCharacters: queryAllowed) ?? value
+    }
+
+    func send(_ request: URLRequest) async throws -> Data {
+        let (data, response): (Data, URLResponse) = try await withCheckedThrowingContinuation { continuation in
+            session.dataTask(with: request) { data, response, error in
+                if let error = error {
+                    continuation.resume(throwing: error)
+                } else if let response = response {
+                    continuation.resume(returning: (data ?? Data(), response))
+                } else {
+                    continuation.resume(throwing: URLError(.badServerResponse))
+                }
+            }.resume()
+        }
+        let status = (response as? HTTPURLResponse)?.statusCode ?? 0
+        guard (200..<300).contains(status) else {
+            throw RPCError(status: status, body: data)
+        }
+        return data
+    }
+{{- if .HasStreams }}
+#if !canImport(FoundationNetworking)
+
+    @available(macOS 12.0, iOS 15.0, tvOS 15.0, watchOS 8.0, *)
+    func stream<T: Decodable>(_ request: URLRequest, as type: T.Type) -> AsyncThrowingStream<T, Error> {
+        AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    let (bytes, response) = try await self.session.bytes(for: request)
+                    let status = (response as? HTTPURLResponse)?.statusCode ?? 0
+                    guard (200..<300).contains(status) else {
+                        var body = Data()
+                        for try await byte in bytes {
+                            body.append(byte)
+                        }
+                        throw RPCError(status: status, body: body)
+                    }
+                    var event = "message"
+                    for try await line in bytes.lines {
+                        if line.hasPrefix("event:") {
+                            event = line.dropFirst(6).trimmingCharacters(in: .whitespaces)
+                            continue
+                        }
+                        guard line.hasPrefix("data:") else {
+                            continue
+                        }
+                        let data = Data(line.dropFirst(5).trimmingCharacters(in: .whitespaces).utf8)
+                        switch event {
+                        case "done":
+                            continuation.finish()
+                            return
+                        case "error":
+                            let status = (try? JSONDecoder().decode(RPCStreamStatus.self, from: data))?.status ?? 500
+                            throw RPCError(status: status, body: data)
+                        default:
+                            continuation.yield(try self.decode(T.self, from: data))
+                        }
+                        event = "message"
+                    }
+                    throw URLError(.networkConnectionLost)
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in
+                task.cancel()
+            }
+        }
+    }
+#endif
+{{- end }}
+}
+{{- if .HasStreams }}
+
+private struct RPCStreamStatus: Decodable {
+    let status: Int
+}
+{{- end }}
+{{- range $object := .Objects }}
+
+public struct {{ $object.Name }}: Codable {
+{{- range $field := $object.Fields }}
+{{- range $line := $field.Doc }}
+    /// {{ $line }}
+{{- end }}
+    public var {{ $field.Name }}: {{ $field.Type }}{{ if $field.Optional }}?{{ end }}
+{{- end }}
+{{- if $object.Fields }}
+
+    enum CodingKeys: String, CodingKey {
+{{- range $field := $object.Fields }}
+        case {{ $field.Name }} = {{ $field.Wire }}
+{{- end }}
+    }
+{{- end }}
+
+    public init({{ range $i, $field := $object.Fields }}{{ if $i }}, {{ end }}{{ $field.Name }}: {{ $field.Type }}{{ if $field.Optional }}? = nil{{ end }}{{ end }}) {
+{{- range $field := $object.Fields }}
+        self.{{ $field.Name }} = {{ $field.Name }}
+{{- end }}
+    }
+}
+{{- end }}
+{{- range $service := .Services }}
+
+/// Calls the {{ $service.Name }} methods.
+public struct {{ $service.Type }} {
+    let client: RPCClient
+{{- range $method := $service.Methods }}
+{{ if $method.Streaming }}
+#if !canImport(FoundationNetworking)
+{{- end }}
+{{- range $line := $method.Doc }}
+    ///{{ if $line }} {{ $line }}{{ end }}
+{{- end }}
+{{- if $method.Deprecated }}
+    @available(*, deprecated, message: {{ $method.Deprecation }})
+{{- end }}
+{{- if $method.Streaming }}
+    @available(macOS 12.0, iOS 15.0, tvOS 15.0, watchOS 8.0, *)
+    public func {{ $method.Name }}({{ if $method.HasBody }}_ request: {{ $method.RequestType }}, {{ end }}options: RPCCallOptions = RPCCallOptions()) throws -> AsyncThrowingStream<{{ $method.ResponseType }}, Error> {
+{{- else }}
+    public func {{ $method.Name }}({{ if $method.HasBody }}_ request: {{ $method.RequestType }}, {{ end }}options: RPCCallOptions = RPCCallOptions()) async throws{{ if $method.ResponseType }} -> {{ $method.ResponseType }}{{ end }} {
+{{- end }}
+        let urlRequest = try client.makeRequest(
+            path: {{ $method.Path }},
+            body: {{ if $method.HasBody }}client.encode(request){{ else }}nil{{ end }},
+            readOnly: {{ $method.ReadOnly }},
+            idempotent: {{ $method.Idempotent }},
+            streaming: {{ $method.Streaming }},
+            auth: {{ if $method.Auth }}{{ $method.Auth }}{{ else }}nil{{ end }},
+            options: options
+        )
+{{- if $method.Streaming }}
+        return client.stream(urlRequest, as: {{ $method.ResponseType }}.self)
+    }
+#endif
+{{- else if $method.ResponseType }}
+        let data = try await client.send(urlRequest)
+        return try client.decode({{ $method.ResponseType }}.self, from: data)
+    }
+{{- else }}
+        _ = try await client.send(urlRequest)
+    }
+{{- end }}
+{{- end }}
+}
+{{- end }}
+`))
+
+// swiftClientReserved are the type names the Swift runtime declares or uses.
+var swiftClientReserved = []string{
+	"Error", "RPCAuthGuard", "RPCCallOptions", "RPCClient", "RPCError",
+	"RPCErrorEnvelope", "RPCStreamStatus", "Task",
+}
+
+func swiftClientLanguage() mobileLanguage {
+	return mobileLanguage{
+		typeFn: func(registry *schema.Registry) func(reflect.Type) string {
+			return registry.SwiftTypeOf
+		},
+		reserved: swiftClientReserved,
+		members: []string{
+			"baseURL", "decode", "encode", "escape", "makeDecoder", "makeEncoder",
+			"makeRequest", "parseDate", "queryAllowed", "send", "session", "stream",
+		},
+		name:  clientgen.SwiftName,
+		quote: clientgen.SwiftString,
+		auth: func(guard GuardSpec) string {
+			return "RPCAuthGuard(location: " + clientgen.SwiftString(guard.In) + ", param: " + clientgen.SwiftString(guard.Param) + ", prefix: " + clientgen.SwiftString(guard.Prefix) + ")"
+		},
+		docs: clientgen.GoCommentLines,
+	}
+}
+
+// WriteClientSwift writes a runtime-generated Swift client to w.
+func (r *Router) WriteClientSwift(w io.Writer) error {
+	body, err := r.clientSwiftBody()
+	if err != nil {
+		return err
+	}
+	hash := clientgen.HashBytes(body)
+	if err := clientgen.WriteArtifactHeader(w, "//", "Virtuous client hash", hash); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteClientSwiftFile writes a runtime-generated Swift client to the file at
+// path.
+func (r *Router) WriteClientSwiftFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WriteClientSwift(f)
+}
+
+// WriteClientSwiftHash writes the hash of the stable Swift client body to w.
+func (r *Router) WriteClientSwiftHash(w io.Writer) error {
+	body, err := r.clientSwiftBody()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, clientgen.HashBytes(body))
+	return err
+}
+
+// ServeClientSwift writes a runtime-generated Swift client as an HTTP
+// response.
+func (r *Router) ServeClientSwift(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/x-swift; charset=utf-8")
+	if err := r.WriteClientSwift(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (r *Router) clientSwiftBody() ([]byte, error) {
+	spec := buildMobileClientSpec(r.Routes(), r.typeOverrides, swiftClientLanguage())
+	return clientgen.RenderTemplate(clientSwiftTemplate, spec)
+}
@@ -10,6 +10,60 @@ import (
 
 var clientTSTemplate = template.Must(template.New("virtuous-rpc-ts").Parse(`export type AuthOptions = {
 	auth?: string
+	/** Deadline for this call in milliseconds, sent as X-Virtuous-Timeout-Ms. Defaults to ClientOptions.timeoutMs. */
+	timeoutMs?: number
+	/** Cancels the call. */
+	signal?: AbortSignal
+{{- if .HasIdempotent }}
+	idempotencyKey?: string
+{{- end }}
+}
+
+/** A request about to be sent. */
+export type RPCRequest = {
+	/** The called method, such as "Users.GetUser". */
+	rpc: string
+	method: string
+	url: string
+	headers: Record<string, string>
+	body?: string
+}
+
+/** Runs before each attempt. It may change the request or return a replacement. */
+export type RequestInterceptor = (request: RPCRequest) => RPCRequest | void | Promise<RPCRequest | void>
+
+/** Runs after each response of a non-streaming call. It may return a replacement response. */
+export type ResponseInterceptor = (response: Response, request: RPCRequest) => Response | void | Promise<Response | void>
+
+export type RetryPolicy = {
+	/** Attempts including the first. Defaults to 3. */
+	attempts?: number
+	/** Delay before the first retry in milliseconds, doubled for each later retry. Defaults to 100. */
+	backoffMs?: number
+	/** Longest delay between attempts in milliseconds. Defaults to 2000. */
+	maxBackoffMs?: number
+	/** Response statuses that are retried. Defaults to 408, 429, 502, 503, and 504. */
+	statuses?: number[]
+}
+
+/** A runtime schema, such as a Zod schema from client.gen.zod.ts. parse throws when the value does not match. */
+export type RPCSchema = {
+	parse(value: unknown): unknown
+}
+
+export type ClientOptions = {
+	/** Deadline in milliseconds for calls that do not set timeoutMs. */
+	timeoutMs?: number
+	/** Retries idempotent and read-only calls after network errors, timeouts, and retryable statuses. */
+	retry?: RetryPolicy
+	/** Run in order before each attempt. */
+	onRequest?: RequestInterceptor[]
+	/** Run in order after each response of a non-streaming call. */
+	onResponse?: ResponseInterceptor[]
+	/** Runtime schemas by method name, such as rpcSchemas from client.gen.zod.ts. Responses are validated against them. */
+	schemas?: Record<string, { request?: RPCSchema; response?: RPCSchema }>
+	/** Also validates requests before they are sent. */
+	validateRequests?: boolean
 }
 
 export class RPCError<E = unknown> extends Error {
@@ -21,25 +75,239 @@ export class RPCError<E = unknown> extends Error {
 		this.body = body
 	}
 }
+{{- if .HasIdempotent }}
+
+function newIdempotencyKey(): string {
+	const cryptoObj = (globalThis as { crypto?: { randomUUID?: () => string } }).crypto
+	if (cryptoObj && cryptoObj.randomUUID) {
+		return cryptoObj.randomUUID()
+	}
+	return Date.now().toString(16) + "-" + Math.random().toString(16).slice(2) + Math.random().toString(16).slice(2)
+}
+{{- end }}
+
+// callSignal combines the caller's signal with a per-attempt timeout.
+function callSignal(signal: AbortSignal | undefined, timeoutMs: number | undefined): { signal?: AbortSignal; done: () => void } {
+	if (!timeoutMs) {
+		return { signal, done: () => {} }
+	}
+	const controller = new AbortController()
+	const abort = () => controller.abort(signal && signal.reason)
+	const timer = setTimeout(() => controller.abort(new DOMException("call timed out after " + timeoutMs + "ms", "TimeoutError")), timeoutMs)
+	if (signal) {
+		if (signal.aborted) {
+			abort()
+		} else {
+			signal.addEventListener("abort", abort, { once: true })
+		}
+	}
+	return {
+		signal: controller.signal,
+		done: () => {
+			clearTimeout(timer)
+			if (signal) {
+				signal.removeEventListener("abort", abort)
+			}
+		},
+	}
+}
 
+async function interceptRequest(client: ClientOptions, request: RPCRequest): Promise<RPCRequest> {
+	let current: RPCRequest = { ...request, headers: { ...request.headers } }
+	for (const intercept of client.onRequest || []) {
+		current = (await intercept(current)) || current
+	}
+	return current
+}
+
+// validate parses value with the method's schema when one is set.
+function validate<T>(client: ClientOptions, rpc: string, kind: "request" | "response", value: T): T {
+	const schemas = client.schemas && client.schemas[rpc]
+	const schema = schemas && schemas[kind]
+	if (!schema || (kind === "request" && !client.validateRequests)) {
+		return value
+	}
+	return schema.parse(value) as T
+}
+
+function retryDelay(policy: RetryPolicy, attempt: number): number {
+	return Math.min(policy.maxBackoffMs ?? 2000, (policy.backoffMs ?? 100) * 2 ** (attempt - 1))
+}
+
+// call sends a non-streaming request and reads its body, retrying when
+// retryable and the client has a retry policy.
+async function call(client: ClientOptions, request: RPCRequest, options: AuthOptions | undefined, retryable: boolean, credentials?: RequestCredentials): Promise<[Response, string]> {
+	const signal = options && options.signal
+	const timeoutMs = (options && options.timeoutMs) || client.timeoutMs
+	if (timeoutMs) {
+		request.headers["X-Virtuous-Timeout-Ms"] = String(Math.ceil(timeoutMs))
+	}
+	const policy = retryable ? client.retry : undefined
+	const attempts = policy ? Math.max(1, policy.attempts ?? 3) : 1
+	for (let attempt = 1; ; attempt++) {
+		const current = await interceptRequest(client, request)
+		const attemptSignal = callSignal(signal, timeoutMs)
+		try {
+			let response = await fetch(current.url, { method: current.method, headers: current.headers, body: current.body, signal: attemptSignal.signal, credentials })
+			for (const intercept of client.onResponse || []) {
+				response = (await intercept(response, current)) || response
+			}
+			const text = await response.text()
+			if (policy && attempt < attempts && (policy.statuses || [408, 429, 502, 503, 504]).includes(response.status)) {
+				await new Promise((resolve) => setTimeout(resolve, retryDelay(policy, attempt)))
+				continue
+			}
+			return [response, text]
+		} catch (e) {
+			if (!policy || attempt >= attempts || (signal && signal.aborted)) {
+				throw e
+			}
+			await new Promise((resolve) => setTimeout(resolve, retryDelay(policy, attempt)))
+		} finally {
+			attemptSignal.done()
+		}
+	}
+}
+{{ if .HasStreams }}
+async function openStream(client: ClientOptions, request: RPCRequest, options: AuthOptions | undefined, credentials?: RequestCredentials): Promise<Response> {
+	const current = await interceptRequest(client, request)
+	return await fetch(current.url, { method: current.method, headers: current.headers, body: current.body, signal: options && options.signal, credentials })
+}
+
+export type RPCStreamStatus = {
+	status: number
+}
+
+async function streamError(response: Response): Promise<RPCError<RPCStreamStatus>> {
+	const text = await response.text()
+	let body: RPCStreamStatus | null = null
+	if (text) {
+		try {
+			body = JSON.parse(text)
+		} catch (e) {
+			body = null
+		}
+	}
+	return new RPCError<RPCStreamStatus>(response.status, body, response.status + " " + response.statusText)
+}
+
+async function* readEventStream<T>(response: Response, parse: (message: T) => T = (message) => message): AsyncGenerator<T, void, undefined> {
+	if (!response.body) {
+		throw new RPCError<RPCStreamStatus>(response.status, null, "stream response has no body")
+	}
+	const reader = response.body.getReader()
+	const decoder = new TextDecoder()
+	let buffer = ""
+	while (true) {
+		const { value, done } = await reader.read()
+		if (done) {
+			break
+		}
+		buffer += decoder.decode(value, { stream: true })
+		let boundary = buffer.indexOf("\n\n")
+		while (boundary >= 0) {
+			const frame = buffer.slice(0, boundary)
+			buffer = buffer.slice(boundary + 2)
+			boundary = buffer.indexOf("\n\n")
+			let event = "message"
+			const data: string[] = []
+			for (const line of frame.split("\n")) {
+				if (line.startsWith("event:")) {
+					event = line.slice(6).trim()
+				} else if (line.startsWith("data:")) {
+					data.push(line.slice(5).trim())
+				}
+			}
+			if (event === "done") {
+				return
+			}
+			if (event === "error") {
+				const body: RPCStreamStatus = data.length ? JSON.parse(data.join("\n")) : { status: 500 }
+				throw new RPCError<RPCStreamStatus>(body.status, body, body.status + " stream error")
+			}
+			if (data.length) {
+				yield parse(JSON.parse(data.join("\n")) as T)
+			}
+		}
+	}
+	throw new RPCError<RPCStreamStatus>(response.status, null, "stream closed before completion")
+}
+{{ end }}
 {{range $object := .Objects}}
 export interface {{$object.Name}} {
 {{- range $field := $object.Fields}}
-	{{$field.Name}}{{if $field.Optional}}?{{end}}: {{$field.Type}}{{if $field.Nullable}} | null{{end}};
+{{- if or $field.Doc $field.Constraints }}
+	/**{{ if $field.Doc }} {{ $field.Doc }}{{ end }}{{ range $constraint := $field.Constraints }} @{{ $constraint }}{{ end }} */
+{{- end }}
+	{{$field.Name}}{{if $field.Optional}}?{{end}}: {{if $field.EnumType}}{{$field.EnumType}}{{else}}{{$field.Type}}{{end}}{{if $field.Nullable}} | null{{end}};
 {{- end}}
 }
 {{end}}
-export function createClient(basepath: string = "/") {
+{{- if .JSONRPCPath }}
+export type RPCMethods = {
+{{- range $service := .Services }}
+{{- range $method := $service.Methods }}
+{{- if not $method.Streaming }}
+	"{{ $service.Name }}.{{ $method.Name }}": { params: {{ if $method.HasBody }}{{ $method.RequestType }}{{ else }}undefined{{ end }}; result: {{ if $method.ResponseType }}{{ $method.ResponseType }}{{ else }}void{{ end }} }
+{{- end }}
+{{- end }}
+{{- end }}
+}
+
+export type BatchCall = {
+	[M in keyof RPCMethods]: RPCMethods[M]["params"] extends undefined
+		? { method: M; params?: undefined }
+		: { method: M; params: RPCMethods[M]["params"] }
+}[keyof RPCMethods]
+
+export type JSONRPCError = {
+	code: number
+	message: string
+	data?: { status: number; body?: unknown }
+}
+
+export type BatchResult<T = unknown> = { ok: true; result: T } | { ok: false; error: JSONRPCError }
+
+export type BatchResults<T extends readonly BatchCall[]> = {
+	[K in keyof T]: BatchResult<T[K] extends { method: infer M } ? M extends keyof RPCMethods ? RPCMethods[M]["result"] : unknown : unknown>
+}
+{{ end }}
+export function createClient(basepath: string = "/", clientOptions: ClientOptions = {}) {
 	return {
 {{- range $service := .Services }}
 		{{ $service.Name }}: {
 {{- range $method := $service.Methods }}
+{{- if or $method.Doc $method.Deprecated }}
+			/**
+{{- range $line := $method.Doc }}
+			 *{{ if $line }} {{ $line }}{{ end }}
+{{- end }}
+{{- if $method.Deprecated }}
+			 * @deprecated{{ if $method.DeprecationDoc }} {{ $method.DeprecationDoc }}{{ end }}
+{{- end }}
+			 */
+{{- end }}
+{{- if $method.Streaming }}
+			async *{{ $method.Name }}({{ if $method.HasBody }}request: {{ $method.RequestType }}, {{ end }}options?: AuthOptions): AsyncGenerator<{{ $method.ResponseType }}, void, undefined> {
+{{- else }}
 			async {{ $method.Name }}({{ if $method.HasBody }}request: {{ $method.RequestType }}, {{ end }}options?: AuthOptions): Promise<{{ if $method.ResponseType }}{{ $method.ResponseType }}{{ else }}void{{ end }}> {
+{{- end }}
+{{- if $method.HasBody }}
+				request = validate(clientOptions, "{{ $service.Name }}.{{ $method.Name }}", "request", request)
+{{- end }}
 				const headers: Record<string, string> = {
-					"Accept": "application/json",
+					"Accept": {{ if $method.Streaming }}"text/event-stream"{{ else }}"application/json"{{ end }},
+{{- if not $method.ReadOnly }}
 					"Content-Type": "application/json",
+{{- end }}
 				}
 				let url = basepath + "{{ $method.Path }}"
+{{- if and $method.ReadOnly $method.HasBody }}
+				url = url + "?request=" + encodeURIComponent(JSON.stringify(request))
+{{- end }}
+{{- if $method.Idempotent }}
+				headers["Idempotency-Key"] = (options && options.idempotencyKey) || newIdempotencyKey()
+{{- end }}
 {{- if $method.HasAuth }}
 				const authValue = options && options.auth
 				if (authValue) {
@@ -55,19 +323,23 @@ export function createClient(basepath: string = "/") {
 {{- end }}
 				}
 {{- end }}
-				const response = await fetch(url, {
-					method: "POST",
+				const rpcRequest: RPCRequest = {
+					rpc: "{{ $service.Name }}.{{ $method.Name }}",
+					method: "{{ if $method.ReadOnly }}GET{{ else }}POST{{ end }}",
+					url,
 					headers,
-{{- if $method.HasAuth }}
-{{- if eq $method.Auth.In "cookie" }}
-					credentials: "same-origin",
-{{- end }}
-{{- end }}
-{{- if $method.HasBody }}
+{{- if and $method.HasBody (not $method.ReadOnly) }}
 					body: JSON.stringify(request),
 {{- end }}
-				})
-				const text = await response.text()
+				}
+{{- if $method.Streaming }}
+				const response = await openStream(clientOptions, rpcRequest, options{{ if and $method.HasAuth (eq $method.Auth.In "cookie") }}, "same-origin"{{ end }})
+				if (!response.ok) {
+					throw await streamError(response)
+				}
+				yield* readEventStream<{{ $method.ResponseType }}>(response, (message) => validate(clientOptions, "{{ $service.Name }}.{{ $method.Name }}", "response", message))
+{{- else }}
+				const [response, text] = await call(clientOptions, rpcRequest, options, {{ or $method.Idempotent $method.ReadOnly }}{{ if and $method.HasAuth (eq $method.Auth.In "cookie") }}, "same-origin"{{ end }})
 				let json: {{ if $method.ResponseType }}{{ $method.ResponseType }}{{ else }}Record<string, unknown>{{ end }} | null = null
 				if (text) {
 					try {
@@ -80,12 +352,78 @@ export function createClient(basepath: string = "/") {
 					}
 				}
 				if (!response.ok) {
-					throw new RPCError<{{ $method.ErrorType }}>(response.status, json as {{ $method.ErrorType }}, response.status + " " + response.statusText)
+					throw new RPCError<{{ $method.ErrorType }}>(response.status, json as {{ if ne $method.ErrorType $method.ResponseType }}unknown as {{ end }}{{ $method.ErrorType }}, response.status + " " + response.statusText)
 				}
-				return json as {{ if $method.ResponseType }}{{ $method.ResponseType }}{{ else }}void{{ end }}
+				return validate(clientOptions, "{{ $service.Name }}.{{ $method.Name }}", "response", json) as {{ if $method.ResponseType }}{{ $method.ResponseType }}{{ else }}void{{ end }}
+{{- end }}
 			},
 {{- end }}
 		},
+{{- end }}
+{{- if .JSONRPCPath }}
+		/**
+		 * Sends calls as one JSON-RPC 2.0 batch. Results keep the order of calls.
+		 */
+		async batch<T extends readonly BatchCall[]>(calls: [...T], options?: AuthOptions): Promise<BatchResults<T>> {
+			if (calls.length === 0) {
+				return [] as unknown as BatchResults<T>
+			}
+			const headers: Record<string, string> = {
+				"Accept": "application/json",
+				"Content-Type": "application/json",
+			}
+			let url = basepath + "{{ .JSONRPCPath }}"
+{{- if .BatchAuth }}
+			const authValue = options && options.auth
+			if (authValue) {
+{{- range $auth := .BatchAuth }}
+{{- if eq $auth.In "header" }}
+				headers["{{ $auth.Param }}"] = {{ if ne $auth.Prefix "" }}"{{ $auth.Prefix }} " + {{ end }}authValue
+{{- end }}
+{{- if eq $auth.In "query" }}
+				url = url + (url.includes("?") ? "&" : "?") + encodeURIComponent("{{ $auth.Param }}") + "=" + encodeURIComponent({{ if ne $auth.Prefix "" }}"{{ $auth.Prefix }} " + {{ end }}authValue)
+{{- end }}
+{{- if eq $auth.In "cookie" }}
+				document.cookie = "{{ $auth.Param }}=" + encodeURIComponent({{ if ne $auth.Prefix "" }}"{{ $auth.Prefix }} " + {{ end }}authValue) + "; path=/"
+{{- end }}
+{{- end }}
+			}
+{{- end }}
+			const [response, text] = await call(clientOptions, {
+				rpc: "batch",
+				method: "POST",
+				url,
+				headers,
+				body: JSON.stringify(calls.map((batchCall, id) => ({ jsonrpc: "2.0", id, method: batchCall.method, params: batchCall.params }))),
+			}, options, false{{ if .BatchCookieAuth }}, "same-origin"{{ end }})
+			let json: unknown = null
+			if (text) {
+				try {
+					json = JSON.parse(text)
+				} catch (e) {
+					if (!response.ok) {
+						throw new RPCError<unknown>(response.status, null, response.status + " " + response.statusText)
+					}
+					throw e
+				}
+			}
+			if (!response.ok) {
+				throw new RPCError<unknown>(response.status, json, response.status + " " + response.statusText)
+			}
+			const replies = (Array.isArray(json) ? json : [json]) as Array<{ id?: number | null; result?: unknown; error?: JSONRPCError }>
+			const results: Array<BatchResult | undefined> = calls.map(() => undefined)
+			let batchError: JSONRPCError = { code: -32603, message: "missing response" }
+			for (const reply of replies) {
+				if (typeof reply.id !== "number") {
+					if (reply.error) {
+						batchError = reply.error
+					}
+					continue
+				}
+				results[reply.id] = reply.error ? { ok: false, error: reply.error } : { ok: true, result: reply.result }
+			}
+			return results.map((result) => result || { ok: false, error: batchError }) as unknown as BatchResults<T>
+		},
 {{- end }}
 	}
 }
@@ -143,6 +481,7 @@ func (r *Router) ServeClientTSHash(w http.ResponseWriter, _ *http.Request) {
 
 func (r *Router) clientTSBody() ([]byte, error) {
 	spec := buildClientSpec(r.Routes(), r.typeOverrides)
+	r.applyJSONRPCClientSpec(&spec)
 	return clientgen.RenderTemplate(clientTSTemplate, spec)
 }
 
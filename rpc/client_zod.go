@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"text/template"
+
+	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/schema"
+)
+
+var clientZodTemplate = template.Must(template.New("virtuous-rpc-zod").Parse(`import { z } from "zod"
+{{ range $object := .Objects }}
+export const {{ $object.Schema }}{{ if $object.Recursive }}: z.ZodTypeAny{{ end }} = z.object({
+{{- range $field := $object.Fields }}
+{{- if $field.Doc }}
+	/** {{ $field.Doc }} */
+{{- end }}
+	{{ $field.Key }}: {{ $field.Type }},
+{{- end }}
+}).passthrough()
+{{ end }}
+/** Schemas by method name, for createClient's schemas option. */
+export const rpcSchemas = {
+{{- range $method := .Methods }}
+	"{{ $method.RPC }}": {
+{{- if $method.Request }}
+		request: {{ $method.Request }},
+{{- end }}
+{{- if $method.Response }}
+		response: {{ $method.Response }},
+{{- end }}
+	},
+{{- end }}
+}
+`))
+
+// zodClientSpec is the view model of the Zod schema artifact.
+type zodClientSpec struct {
+	Objects []schema.ZodObject
+	Methods []zodClientMethod
+}
+
+type zodClientMethod struct {
+	// RPC matches the rpc name the TS client passes to validate.
+	RPC      string
+	Request  string
+	Response string
+}
+
+func buildZodClientSpec(routes []Route, overrides map[string]TypeOverride) zodClientSpec {
+	var registry *schema.Registry
+	spec := buildClientSpecWith(routes, overrides, func(r *schema.Registry) func(reflect.Type) string {
+		registry = r
+		return r.ZodTypeOf
+	})
+	out := zodClientSpec{Objects: registry.ZodObjects()}
+	for _, service := range spec.Services {
+		for _, method := range service.Methods {
+			out.Methods = append(out.Methods, zodClientMethod{
+				RPC:      service.Name + "." + method.Name,
+				Request:  method.RequestType,
+				Response: method.ResponseType,
+			})
+		}
+	}
+	return out
+}
+
+// WriteClientZod writes Zod schemas for the router's types to w. Pass
+// rpcSchemas to the TS client's schemas option to validate responses at
+// runtime.
+func (r *Router) WriteClientZod(w io.Writer) error {
+	body, err := r.clientZodBody()
+	if err != nil {
+		return err
+	}
+	hash := clientgen.HashBytes(body)
+	if err := clientgen.WriteArtifactHeader(w, "//", "Virtuous client hash", hash); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteClientZodFile writes Zod schemas for the router's types to the file at
+// path.
+func (r *Router) WriteClientZodFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WriteClientZod(f)
+}
+
+// WriteClientZodHash writes the hash of the stable Zod schema body to w.
+func (r *Router) WriteClientZodHash(w io.Writer) error {
+	body, err := r.clientZodBody()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, clientgen.HashBytes(body))
+	return err
+}
+
+// ServeClientZod writes the Zod schemas as an HTTP response.
+func (r *Router) ServeClientZod(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/typescript")
+	if err := r.WriteClientZod(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (r *Router) clientZodBody() ([]byte, error) {
+	spec := buildZodClientSpec(r.Routes(), r.typeOverrides)
+	return clientgen.RenderTemplate(clientZodTemplate, spec)
+}
@@ -0,0 +1,193 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type zodOwner struct {
+	UserID string `json:"user_id" format:"uuid"`
+}
+
+type zodFolder struct {
+	Name     string       `json:"name" minLength:"1"`
+	Kind     string       `json:"kind" enum:"dir,link"`
+	Owner    *zodOwner    `json:"owner"`
+	Modified *time.Time   `json:"modified,omitempty"`
+	Folders  []*zodFolder `json:"folders"`
+}
+
+type zodQuota struct {
+	Used uint8 `json:"used"`
+}
+
+func ZodTree(_ context.Context, req zodFolder) (zodFolder, int) {
+	return req, StatusOK
+}
+
+func ZodQuota(_ context.Context) (zodQuota, int) {
+	return zodQuota{Used: 40}, StatusOK
+}
+
+func ZodWatch(_ context.Context, req zodFolder, stream Stream[zodFolder]) int {
+	if err := stream.Send(req); err != nil {
+		return StatusError
+	}
+	return StatusOK
+}
+
+func newZodRouter() *Router {
+	router := NewRouter()
+	router.HandleRPC(ZodTree)
+	router.HandleRPC(ZodQuota, ReadOnly())
+	router.HandleRPC(ZodWatch)
+	return router
+}
+
+func TestRPCZodSchemasRenderObjectsAndMethods(t *testing.T) {
+	var zod bytes.Buffer
+	if err := newZodRouter().WriteClientZod(&zod); err != nil {
+		t.Fatalf("write zod schemas: %v", err)
+	}
+	text := zod.String()
+	for _, want := range []string{
+		`import { z } from "zod"`,
+		"export const zodFolderSchema: z.ZodTypeAny = z.object({",
+		"\tname: z.string().min(1),",
+		`	kind: z.enum(["dir", "link"]),`,
+		"\towner: zodOwnerSchema.nullable(),",
+		"\tmodified: z.string().datetime({ offset: true }).nullable().optional(),",
+		"\tfolders: z.array(z.lazy(() => zodFolderSchema).nullable()).nullable(),",
+		"}).passthrough()",
+		"\t\"rpc.ZodQuota\": {\n\t\tresponse: zodQuotaSchema,\n\t},",
+		"\t\"rpc.ZodTree\": {\n\t\trequest: zodFolderSchema,\n\t\tresponse: zodFolderSchema,\n\t},",
+		"\t\"rpc.ZodWatch\": {\n\t\trequest: zodFolderSchema,\n\t\tresponse: zodFolderSchema,\n\t},",
+	} {
+		assertRPCContains(t, text, want)
+	}
+	if strings.Index(text, "export const zodOwnerSchema") > strings.Index(text, "export const zodFolderSchema") {
+		t.Fatalf("zodOwnerSchema must be declared before zodFolderSchema uses it")
+	}
+}
+
+func TestRPCTSClientValidatesWithSchemas(t *testing.T) {
+	var ts bytes.Buffer
+	if err := newZodRouter().WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	for _, want := range []string{
+		"schemas?: Record<string, { request?: RPCSchema; response?: RPCSchema }>",
+		"validateRequests?: boolean",
+		`request = validate(clientOptions, "rpc.ZodTree", "request", request)`,
+		`return validate(clientOptions, "rpc.ZodTree", "response", json) as zodFolder`,
+		`return validate(clientOptions, "rpc.ZodQuota", "response", json) as zodQuota`,
+		`yield* readEventStream<zodFolder>(response, (message) => validate(clientOptions, "rpc.ZodWatch", "response", message))`,
+	} {
+		assertRPCContains(t, ts.String(), want)
+	}
+}
+
+// TestRPCZodSchemasParse runs the schemas against the zod package in
+// VIRTUOUS_ZOD_DIR or the global node_modules. Without one it loads them
+// with a stand-in that accepts any chain, which only checks that every schema
+// is declared before it is used.
+func TestRPCZodSchemasParse(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	var zod bytes.Buffer
+	if err := newZodRouter().WriteClientZod(&zod); err != nil {
+		t.Fatalf("write zod schemas: %v", err)
+	}
+	dir := t.TempDir()
+	modules := filepath.Join(dir, "node_modules")
+	if err := os.MkdirAll(modules, 0755); err != nil {
+		t.Fatalf("create node_modules: %v", err)
+	}
+	harness := `import { rpcSchemas } from "./client.gen.zod.mjs";
+const keys = Object.keys(rpcSchemas).sort().join(",");
+if (keys !== "rpc.ZodQuota,rpc.ZodTree,rpc.ZodWatch") throw new Error("unexpected rpcSchemas: " + keys);
+`
+	if zodDir := findZod(); zodDir != "" {
+		if err := os.Symlink(zodDir, filepath.Join(modules, "zod")); err != nil {
+			t.Fatalf("link zod: %v", err)
+		}
+		harness += `const tree = rpcSchemas["rpc.ZodTree"].response;
+const owner = { user_id: "7f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d" };
+const folder = tree.parse({
+  name: "root", kind: "dir", owner, modified: "2024-05-01T10:00:00+02:00", extra: 1,
+  folders: [{ name: "src", kind: "link", owner: null, folders: null }],
+});
+if (folder.extra !== 1 || folder.folders[0].name !== "src") throw new Error("valid tree was changed: " + JSON.stringify(folder));
+const rejects = (schema, value, why) => {
+  if (schema.safeParse(value).success) throw new Error("accepted " + why);
+};
+rejects(tree, { name: "root", kind: "file", owner, folders: [] }, "an unknown kind");
+rejects(tree, { name: "", kind: "dir", owner, folders: [] }, "an empty name");
+rejects(tree, { name: "root", kind: "dir", owner: { user_id: "u1" }, folders: [] }, "a malformed uuid");
+rejects(tree, { name: "root", kind: "dir", owner, modified: "yesterday", folders: [] }, "a malformed date");
+rejects(tree, { name: "root", kind: "dir", owner, folders: [{ name: "src", kind: "file", owner, folders: [] }] }, "an invalid nested folder");
+const quota = rpcSchemas["rpc.ZodQuota"].response;
+if (quota.parse({ used: 255 }).used !== 255) throw new Error("rejected a uint8");
+rejects(quota, { used: 256 }, "an out of range uint8");
+rejects(quota, { used: 1.5 }, "a fractional uint8");
+`
+	} else {
+		stub := `const chain = () => new Proxy(function () {}, { get: () => chain, apply: () => chain() });
+export const z = chain();
+`
+		if err := os.MkdirAll(filepath.Join(modules, "zod"), 0755); err != nil {
+			t.Fatalf("create zod stub: %v", err)
+		}
+		files := map[string]string{
+			filepath.Join(modules, "zod", "package.json"): `{"name": "zod", "type": "module", "main": "index.js"}`,
+			filepath.Join(modules, "zod", "index.js"):     stub,
+		}
+		for path, content := range files {
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("write %s: %v", path, err)
+			}
+		}
+	}
+	// The only TypeScript in the file is the annotation on recursive schemas.
+	js := strings.ReplaceAll(zod.String(), ": z.ZodTypeAny =", " =")
+	files := map[string]string{
+		filepath.Join(dir, "client.gen.zod.mjs"): js,
+		filepath.Join(dir, "harness.mjs"):        harness,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	output, err := exec.Command(node, filepath.Join(dir, "harness.mjs")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("zod schemas failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+}
+
+func findZod() string {
+	if dir := os.Getenv("VIRTUOUS_ZOD_DIR"); dir != "" {
+		return dir
+	}
+	npm, err := exec.LookPath("npm")
+	if err != nil {
+		return ""
+	}
+	root, err := exec.Command(npm, "root", "-g").Output()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(strings.TrimSpace(string(root)), "zod")
+	if _, err := os.Stat(filepath.Join(dir, "package.json")); err != nil {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,205 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/swetjen/virtuous/internal/binarycodec"
+	"github.com/swetjen/virtuous/internal/jsondecode"
+)
+
+const (
+	// MediaTypeJSON is the default request and response media type.
+	MediaTypeJSON = "application/json"
+	// MediaTypeMessagePack is the media type of MessagePackCodec.
+	MediaTypeMessagePack = "application/msgpack"
+	// MediaTypeCBOR is the media type of CBORCodec.
+	MediaTypeCBOR = "application/cbor"
+)
+
+// Codec encodes and decodes request and response bodies for one media type.
+// Codecs must use the json tag names of struct fields so every codec shares
+// the schema of the JSON API.
+type Codec interface {
+	// MediaType is the Content-Type the codec reads and writes.
+	MediaType() string
+	// Marshal encodes v.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into v, a non-nil pointer. When strict is set it
+	// must reject unknown fields, duplicate keys, and trailing data.
+	Unmarshal(data []byte, v any, strict bool) error
+}
+
+// WithCodecs adds codecs that requests select with Content-Type and responses
+// with Accept. JSON is always available and is used when neither header names
+// a registered codec. A codec with the media type of an existing one
+// replaces it.
+func WithCodecs(codecs ...Codec) RouterOption {
+	return func(o *RouterOptions) {
+		o.Codecs = append(o.Codecs, codecs...)
+	}
+}
+
+// JSONCodec returns the default JSON codec.
+func JSONCodec() Codec {
+	return jsonCodec{}
+}
+
+// MessagePackCodec returns a MessagePack codec that follows the JSON data
+// model: struct fields use their json tag names, []byte is a base64 string,
+// and types with JSON or text marshalers keep their JSON shape.
+func MessagePackCodec() Codec {
+	return binaryCodec{mediaType: MediaTypeMessagePack, format: binarycodec.MessagePack}
+}
+
+// CBORCodec returns a CBOR codec that follows the JSON data model like
+// MessagePackCodec.
+func CBORCodec() Codec {
+	return binaryCodec{mediaType: MediaTypeCBOR, format: binarycodec.CBOR}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string { return MediaTypeJSON }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any, strict bool) error {
+	opts := jsondecode.Options{}
+	if strict {
+		opts = jsondecode.StrictOptions()
+	}
+	return jsondecode.Decode(bytes.NewReader(data), v, opts)
+}
+
+type binaryCodec struct {
+	mediaType string
+	format    binarycodec.Format
+}
+
+func (c binaryCodec) MediaType() string { return c.mediaType }
+
+func (c binaryCodec) Marshal(v any) ([]byte, error) {
+	return binarycodec.Marshal(c.format, v)
+}
+
+func (c binaryCodec) Unmarshal(data []byte, v any, strict bool) error {
+	return binarycodec.Unmarshal(c.format, data, v, strict)
+}
+
+// codecList returns JSON followed by extra, replacing codecs that share a
+// media type.
+func codecList(extra []Codec) []Codec {
+	codecs := []Codec{JSONCodec()}
+	for _, codec := range extra {
+		if codec == nil {
+			continue
+		}
+		replaced := false
+		for i, existing := range codecs {
+			if strings.EqualFold(existing.MediaType(), codec.MediaType()) {
+				codecs[i] = codec
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			codecs = append(codecs, codec)
+		}
+	}
+	return codecs
+}
+
+func (r *Router) codecFor(mediaType string) (Codec, bool) {
+	for _, codec := range r.codecs {
+		if strings.EqualFold(codec.MediaType(), mediaType) {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// requestCodec picks the codec named by the request's Content-Type, or JSON.
+func (r *Router) requestCodec(req *http.Request) Codec {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err == nil {
+		if codec, ok := r.codecFor(mediaType); ok {
+			return codec
+		}
+	}
+	return r.codecs[0]
+}
+
+// responseCodec picks the registered codec the request's Accept header
+// prefers. Wildcards and a missing Accept header answer in the request's
+// codec; anything else falls back to JSON.
+func (r *Router) responseCodec(req *http.Request) Codec {
+	accept := strings.TrimSpace(req.Header.Get("Accept"))
+	if accept == "" || len(r.codecs) == 1 {
+		return r.requestCodec(req)
+	}
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	for _, candidate := range ranges {
+		if candidate.mediaType == "*/*" || candidate.mediaType == "application/*" {
+			return r.requestCodec(req)
+		}
+		if codec, ok := r.codecFor(candidate.mediaType); ok {
+			return codec
+		}
+	}
+	return r.codecs[0]
+}
+
+// addCodecMediaTypes documents every registered codec next to the JSON
+// content of op's request body and responses.
+func (r *Router) addCodecMediaTypes(op *openAPIOperation) {
+	if len(r.codecs) == 1 {
+		return
+	}
+	extend := func(content map[string]openAPIMedia) {
+		media, ok := content[MediaTypeJSON]
+		if !ok {
+			return
+		}
+		for _, codec := range r.codecs[1:] {
+			content[codec.MediaType()] = media
+		}
+	}
+	if op.RequestBody != nil {
+		extend(op.RequestBody.Content)
+	}
+	for _, response := range op.Responses {
+		extend(response.Content)
+	}
+}
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/internal/binarycodec"
+)
+
+func postCodec(router *Router, path, contentType, accept string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRPCCodecNegotiation(t *testing.T) {
+	router := NewRouter(WithCodecs(MessagePackCodec(), CBORCodec()))
+	router.HandleRPC(greet)
+
+	body, _ := binarycodec.Marshal(binarycodec.MessagePack, greetReq{Name: "Ada"})
+	rec := postCodec(router, "/rpc/rpc/greet", MediaTypeMessagePack, "", body)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != MediaTypeMessagePack {
+		t.Fatalf("expected msgpack response, got %d %v", rec.Code, rec.Header())
+	}
+	var resp greetResp
+	if err := binarycodec.Unmarshal(binarycodec.MessagePack, rec.Body.Bytes(), &resp, true); err != nil || resp.Message != "hello Ada" {
+		t.Fatalf("unexpected msgpack response %+v (%v)", resp, err)
+	}
+
+	rec = postCodec(router, "/rpc/rpc/greet", MediaTypeMessagePack, "application/json;q=0.5, application/cbor", body)
+	resp = greetResp{}
+	if err := binarycodec.Unmarshal(binarycodec.CBOR, rec.Body.Bytes(), &resp, true); err != nil || resp.Message != "hello Ada" {
+		t.Fatalf("expected CBOR response for Accept, got %v %+v (%v)", rec.Header(), resp, err)
+	}
+
+	rec = postCodec(router, "/rpc/rpc/greet", "application/json", "", []byte(`{"name":"Ada"}`))
+	if rec.Header().Get("Content-Type") != "application/json" || !strings.Contains(rec.Body.String(), "hello Ada") {
+		t.Fatalf("expected JSON to keep working, got %v %s", rec.Header(), rec.Body.String())
+	}
+
+	jsonOnly := NewRouter()
+	jsonOnly.HandleRPC(greet)
+	rec = postCodec(jsonOnly, "/rpc/rpc/greet", MediaTypeMessagePack, MediaTypeMessagePack, body)
+	if rec.Code != StatusInvalid {
+		t.Fatalf("expected unregistered codec to be decoded as JSON and rejected, got %d", rec.Code)
+	}
+}
+
+func TestRPCCodecStrictDecodingAndBodyLimit(t *testing.T) {
+	router := NewRouter(WithCodecs(MessagePackCodec()), WithStrictJSONDecoding(), WithMaxRequestBodyBytes(64))
+	router.HandleRPC(greetWithError)
+
+	unknown, _ := binarycodec.Marshal(binarycodec.MessagePack, map[string]string{"name": "Ada", "extra": "x"})
+	if rec := postCodec(router, "/rpc/rpc/greet-with-error", MediaTypeMessagePack, "", unknown); rec.Code != StatusInvalid {
+		t.Fatalf("expected strict msgpack decoding to reject unknown fields, got %d", rec.Code)
+	}
+
+	large, _ := binarycodec.Marshal(binarycodec.MessagePack, greetReq{Name: strings.Repeat("a", 100)})
+	rec := postCodec(router, "/rpc/rpc/greet-with-error", MediaTypeMessagePack, "", large)
+	if rec.Code != http.StatusRequestEntityTooLarge || rec.Header().Get("Content-Type") != MediaTypeMessagePack {
+		t.Fatalf("expected 413 in msgpack, got %d %v", rec.Code, rec.Header())
+	}
+	var envelope Error
+	if err := binarycodec.Unmarshal(binarycodec.MessagePack, rec.Body.Bytes(), &envelope, true); err != nil || envelope.Code != ErrorCodeRequestTooLarge {
+		t.Fatalf("expected msgpack error envelope, got %+v (%v)", envelope, err)
+	}
+}
+
+func TestRPCCodecOpenAPIAndPythonClient(t *testing.T) {
+	router := NewRouter(WithCodecs(MessagePackCodec()))
+	router.HandleRPC(greet)
+
+	doc, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	if strings.Count(string(doc), `"application/msgpack"`) < 2 {
+		t.Fatalf("expected msgpack request and response media types:\n%s", doc)
+	}
+
+	var py bytes.Buffer
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	pyPath := filepath.Join(t.TempDir(), "client.gen.py")
+	if err := os.WriteFile(pyPath, py.Bytes(), 0644); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	snippet := pythonRPCImportSnippet(pyPath) + `
+import json as _json
+import sys
+import types
+
+fake = types.ModuleType("msgpack")
+fake.packb = lambda value, **kwargs: b"MP" + _json.dumps(value).encode("utf-8")
+fake.unpackb = lambda data, **kwargs: _json.loads(data[2:])
+sys.modules["msgpack"] = fake
+
+class FakeResponse:
+    headers = {"Content-Type": "application/msgpack"}
+    def __enter__(self):
+        return self
+    def __exit__(self, exc_type, exc, tb):
+        return False
+    def getcode(self):
+        return 200
+    def read(self):
+        return b'MP{"message":"hello Ada"}'
+
+sent = {}
+def urlopen(req):
+    sent["content_type"] = req.get_header("Content-type")
+    sent["accept"] = req.get_header("Accept")
+    sent["data"] = req.data
+    return FakeResponse()
+
+mod.request.urlopen = urlopen
+client = mod.create_client(base_url="https://core.example", codec="msgpack")
+resp = client.rpc.greet(mod.greetReq(name="Ada"))
+assert resp.message == "hello Ada", resp
+assert sent["content_type"] == "application/msgpack", sent
+assert sent["accept"] == "application/msgpack", sent
+assert sent["data"] == b'MP{"name": "Ada"}', sent
+try:
+    mod.create_client(codec="xml")
+    raise AssertionError("expected unsupported codec to fail")
+except ValueError:
+    pass
+`
+	if err := runRPCPython("-c", snippet); err != nil {
+		t.Fatalf("python msgpack client failed: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package rpc
+
+import "github.com/swetjen/virtuous/internal/compression"
+
+// CompressionOptions configures response compression. gzip is built in;
+// zstd and brotli are available when built with the virtuous_zstd or
+// virtuous_brotli tags.
+type CompressionOptions = compression.Options
+
+// WithCompression compresses responses of every handler for clients that
+// send a matching Accept-Encoding. Handlers can override it with Compression.
+func WithCompression(opts CompressionOptions) RouterOption {
+	return func(o *RouterOptions) {
+		copyOpts := opts
+		o.Compression = &copyOpts
+	}
+}
+
+// Compression overrides the router's compression options for one handler.
+// Set Disabled to turn compression off for it.
+func Compression(opts CompressionOptions) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		copyOpts := opts
+		c.compression = &copyOpts
+	})
+}
+
+// compressionFor resolves the compression options for a handler.
+func (r *Router) compressionFor(config handlerConfig) (CompressionOptions, bool) {
+	opts := r.compression
+	if config.compression != nil {
+		opts = config.compression
+	}
+	if opts == nil || opts.Disabled {
+		return CompressionOptions{}, false
+	}
+	return *opts, true
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/idempotency"
+)
+
+func TestRPCCompressionNegotiatesGzip(t *testing.T) {
+	var console bytes.Buffer
+	router := NewRouter(WithCompression(CompressionOptions{MinSize: 64}), WithDebugConsoleWriter(&console))
+	router.HandleRPC(greet)
+
+	name := strings.Repeat("Ada ", 100)
+	body, _ := json.Marshal(greetReq{Name: name})
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/greet", bytes.NewReader(body))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip response, got %d %v", rec.Code, rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	plain, _ := io.ReadAll(zr)
+	var resp greetResp
+	if err := json.Unmarshal(plain, &resp); err != nil || resp.Message != "hello "+name {
+		t.Fatalf("unexpected decompressed body %s (%v)", plain, err)
+	}
+
+	_, after, _ := strings.Cut(console.String(), "bytes=")
+	logged, _ := strconv.Atoi(strings.TrimSpace(after))
+	if logged <= 0 || logged >= len(plain) {
+		t.Fatalf("expected debug console to count compressed bytes, got %d of %d: %s", logged, len(plain), console.String())
+	}
+}
+
+func TestRPCCompressionHandlerOverride(t *testing.T) {
+	router := NewRouter(WithCompression(CompressionOptions{MinSize: 1}))
+	router.HandleRPC(greet, Compression(CompressionOptions{Disabled: true}))
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/greet", strings.NewReader(`{"name":"Ada"}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || !strings.Contains(rec.Body.String(), "hello Ada") {
+		t.Fatalf("expected handler override to disable compression, got %v %s", rec.Header(), rec.Body.String())
+	}
+}
+
+func TestRPCCompressionReplaysIdempotentResponses(t *testing.T) {
+	router := NewRouter(WithCompression(CompressionOptions{MinSize: 64}), WithIdempotencyStore(idempotency.NewMemoryStore(0)))
+	router.HandleRPC(greet, Idempotent())
+
+	name := strings.Repeat("Ada ", 1250)
+	body, _ := json.Marshal(greetReq{Name: name})
+	call := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/greet", bytes.NewReader(body))
+		req.Header.Set(idempotency.Header, "greet-1")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) greetResp {
+		t.Helper()
+		reader := io.Reader(rec.Body)
+		if rec.Header().Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("gzip reader: %v", err)
+			}
+			reader = zr
+		}
+		var resp greetResp
+		if err := json.NewDecoder(reader).Decode(&resp); err != nil || resp.Message != "hello "+name {
+			t.Fatalf("unexpected body (%v) with headers %v", err, rec.Header())
+		}
+		return resp
+	}
+
+	first := call("gzip")
+	if first.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip response, got %v", first.Header())
+	}
+	decode(first)
+	replayed := call("gzip")
+	if replayed.Header().Get(idempotency.ReplayedHeader) != "true" || replayed.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected compressed replay, got %v", replayed.Header())
+	}
+	decode(replayed)
+	plain := call("")
+	if plain.Header().Get(idempotency.ReplayedHeader) != "true" || plain.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected uncompressed replay, got %v", plain.Header())
+	}
+	decode(plain)
+}
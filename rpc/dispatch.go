@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/swetjen/virtuous/idempotency"
+)
+
+// forwardedCall carries the transport context of an MCP or JSON-RPC request
+// onto the RPC calls it dispatches.
+type forwardedCall struct {
+	ctx        context.Context
+	header     http.Header
+	rawQuery   string
+	remoteAddr string
+	// idempotencyKey replaces the request's Idempotency-Key, which is not
+	// forwarded, for one dispatched call.
+	idempotencyKey string
+}
+
+// dispatch serves body as a regular POST to route through the router, so
+// guards, interceptors, and observability apply as for direct HTTP calls.
+func (r *Router) dispatch(call forwardedCall, route Route, body []byte) (int, []byte, error) {
+	ctx := call.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	target := route.Path
+	if call.rawQuery != "" {
+		target += "?" + call.rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	for name, values := range call.header {
+		if forwardHeader(name) {
+			req.Header[name] = append([]string(nil), values...)
+		}
+	}
+	if call.idempotencyKey != "" {
+		req.Header.Set(idempotency.Header, call.idempotencyKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if call.remoteAddr != "" {
+		req.RemoteAddr = call.remoteAddr
+	}
+
+	rec := &dispatchRecorder{header: http.Header{}}
+	r.ServeHTTP(rec, req)
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return status, bytes.TrimSpace(rec.body.Bytes()), nil
+}
+
+// forwardHeader reports whether a request header is copied onto dispatched
+// calls. Transport headers and headers that describe a single call, such as
+// Idempotency-Key and the timeout, are dropped; credentials and tracing
+// headers are kept.
+func forwardHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	switch name {
+	case "Content-Length", "Content-Type", "Accept", "Accept-Encoding", "Connection", "Origin",
+		idempotency.Header, TimeoutHeader:
+		return false
+	}
+	return !strings.HasPrefix(name, "Mcp-")
+}
+
+// dispatchRecorder captures a dispatched call's response.
+type dispatchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *dispatchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *dispatchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *dispatchRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(p)
+}
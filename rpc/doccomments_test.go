@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/swetjen/virtuous/schema"
+)
+
+type commentedLookupReq struct {
+	Handle string `json:"handle"`
+}
+
+type commentedLookupResp struct {
+	DisplayName string `json:"displayName"`
+}
+
+func commentedLookup(_ context.Context, _ commentedLookupReq) (commentedLookupResp, int) {
+	return commentedLookupResp{}, StatusOK
+}
+
+func commentedLegacyLookup(_ context.Context, _ commentedLookupReq) (commentedLookupResp, int) {
+	return commentedLookupResp{}, StatusOK
+}
+
+const commentedHandlersSource = `package rpc
+
+type commentedLookupReq struct {
+	// Handle is the public username.
+	Handle string
+}
+
+// commentedLookup finds a user by handle. Handles are case-insensitive.
+//
+// Returns an empty display name for unknown users.
+func commentedLookup() {}
+
+// commentedLegacyLookup finds a user by numeric ID.
+//
+// Deprecated: use commentedLookup.
+func commentedLegacyLookup() {}
+`
+
+func TestRPCDocCommentsFallback(t *testing.T) {
+	err := schema.RegisterPackageDocComments("github.com/swetjen/virtuous/rpc", fstest.MapFS{
+		"handlers.go": {Data: []byte(commentedHandlersSource)},
+	})
+	if err != nil {
+		t.Fatalf("register doc comments: %v", err)
+	}
+	router := NewRouter()
+	router.HandleRPC(commentedLookup)
+	router.HandleRPC(commentedLegacyLookup, Summary("Explicit summary wins"))
+
+	routes := router.Routes()
+	lookup := routes[0].Docs
+	if lookup.Summary != "commentedLookup finds a user by handle." {
+		t.Fatalf("summary = %q", lookup.Summary)
+	}
+	if lookup.Description != "Handles are case-insensitive.\n\nReturns an empty display name for unknown users." {
+		t.Fatalf("description = %q", lookup.Description)
+	}
+	legacy := routes[1].Docs
+	if legacy.Summary != "Explicit summary wins" || legacy.Description != "" {
+		t.Fatalf("handler options should win over comments: %+v", legacy)
+	}
+	if !legacy.Deprecated || legacy.DeprecationMessage != "use commentedLookup." {
+		t.Fatalf("expected deprecation from doc comment: %+v", legacy)
+	}
+
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Deprecated bool   `json:"deprecated"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]schema.OpenAPISchema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	if doc.Paths["/rpc/rpc/commented-lookup"]["post"].Summary != lookup.Summary {
+		t.Fatalf("openapi summary missing: %+v", doc.Paths["/rpc/rpc/commented-lookup"])
+	}
+	if !doc.Paths["/rpc/rpc/commented-legacy-lookup"]["post"].Deprecated {
+		t.Fatalf("openapi deprecation missing")
+	}
+	if doc.Components.Schemas["commentedLookupReq"].Properties["handle"].Description != "Handle is the public username." {
+		t.Fatalf("field description missing: %+v", doc.Components.Schemas["commentedLookupReq"])
+	}
+
+	var ts bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), " * commentedLookup finds a user by handle.\n")
+	assertRPCContains(t, ts.String(), " * @deprecated use commentedLookup.\n")
+	assertRPCContains(t, ts.String(), "Handle is the public username.")
+
+	var py bytes.Buffer
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	assertRPCContains(t, py.String(), "# Handle is the public username.")
+	assertRPCContains(t, py.String(), `warnings.warn("rpc.commentedLegacyLookup is deprecated: use commentedLookup.", DeprecationWarning, stacklevel=2)`)
+}
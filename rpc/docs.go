@@ -221,8 +221,7 @@ func (r *Router) DocsHandler(opts ...DocOpt) http.Handler {
 		r.events = adminui.NewEventFeed(600)
 	}
 
-	openAPI, err := r.OpenAPI()
-	if err != nil {
+	if _, err := r.OpenAPI(); err != nil {
 		log.Fatal(err)
 	}
 	openAPIFile := docsAssetFile(config.OpenAPIFile, "openapi.json")
@@ -244,16 +243,26 @@ func (r *Router) DocsHandler(opts ...DocOpt) http.Handler {
 	}))
 
 	if modules[ModuleAPI] {
-		handler.Handle("GET /"+openAPIFile, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			adminui.SetDocsSecurityHeaders(w)
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			_, _ = w.Write(openAPI)
-		}))
+		handler.Handle("GET /"+openAPIFile, http.HandlerFunc(r.serveOpenAPI))
 	}
 
 	return wrapWithGuards(handler, config.DocsGuards)
 }
 
+// serveOpenAPI renders the OpenAPI document on each request, like the client
+// handlers, so an endpoint such as ServeJSONRPC that is registered after the
+// docs still shows up in them.
+func (r *Router) serveOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	openAPI, err := r.OpenAPI()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	adminui.SetDocsSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(openAPI)
+}
+
 // AdminHandler returns a mountable docs/admin handler with subtree-local admin endpoints.
 func (r *Router) AdminHandler(opts ...DocOpt) http.Handler {
 	config := applyDocOpts(opts...)
@@ -311,16 +320,10 @@ func (r *Router) ServeDocs(opts ...DocOpt) {
 
 	openAPIPath := ensureLeadingSlash(config.OpenAPIPath)
 	if modules[ModuleAPI] && openAPIPath != "" {
-		openAPI, err := r.OpenAPI()
-		if err != nil {
+		if _, err := r.OpenAPI(); err != nil {
 			log.Fatal(err)
 		}
-		openAPIHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			adminui.SetDocsSecurityHeaders(w)
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			_, _ = w.Write(openAPI)
-		})
-		r.mux.Handle("GET "+openAPIPath, wrapWithGuards(openAPIHandler, config.DocsGuards))
+		r.mux.Handle("GET "+openAPIPath, wrapWithGuards(http.HandlerFunc(r.serveOpenAPI), config.DocsGuards))
 	}
 
 	if modules[ModuleObservability] {
@@ -0,0 +1,193 @@
+package rpc
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/swetjen/virtuous/schema"
+)
+
+const (
+	ErrorCodeInvalid         = "invalid"
+	ErrorCodeInternal        = "internal"
+	ErrorCodeRequestTooLarge = "request_too_large"
+	ErrorCodeTimeout         = "timeout"
+)
+
+const (
+	errorBodySchemaName   = "RPCErrorBody"
+	errorDetailSchemaName = "RPCErrorDetail"
+)
+
+// Error is the standard error envelope written for handlers that return
+// (Resp, error) or (Resp, int, error).
+//
+// Status selects 422 or 500 for (Resp, error) handlers and defaults to 422.
+// It is not serialized. IncidentID is set by the router on 500s for recovered
+// panics and matches the incident ID in logs and observability.
+type Error struct {
+	Status     int           `json:"-"`
+	Code       string        `json:"code"`
+	Message    string        `json:"message"`
+	Details    []ErrorDetail `json:"details,omitempty"`
+	IncidentID string        `json:"incidentId,omitempty"`
+}
+
+// ErrorDetail describes one field-level problem in an Error.
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// Invalid returns a 422 error with optional field-level details.
+func Invalid(message string, details ...ErrorDetail) *Error {
+	return &Error{
+		Status:  StatusInvalid,
+		Code:    ErrorCodeInvalid,
+		Message: message,
+		Details: details,
+	}
+}
+
+// Internal returns a 500 error. The message is sent to clients as-is.
+func Internal(message string) *Error {
+	return &Error{
+		Status:  StatusError,
+		Code:    ErrorCodeInternal,
+		Message: message,
+	}
+}
+
+// FieldError returns an ErrorDetail for the JSON field name provided.
+func FieldError(field, message string) ErrorDetail {
+	return ErrorDetail{Field: field, Message: message}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e == nil {
+		return ""
+	}
+	message := strings.TrimSpace(e.Message)
+	if message == "" {
+		message = e.Code
+	}
+	if len(e.Details) == 0 {
+		return message
+	}
+	parts := make([]string, 0, len(e.Details))
+	for _, detail := range e.Details {
+		parts = append(parts, detail.Field+": "+detail.Message)
+	}
+	return message + " (" + strings.Join(parts, "; ") + ")"
+}
+
+var (
+	errorType         = reflect.TypeOf(Error{})
+	errorDetailType   = reflect.TypeOf(ErrorDetail{})
+	errorIfaceType    = reflect.TypeOf((*error)(nil)).Elem()
+	errorInternalBody = "internal server error"
+)
+
+// errorStatus resolves the status for an error returned without an explicit
+// status: *Error values default to 422 and any other error is a 500.
+func errorStatus(err error) int {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Status {
+		case StatusInvalid, StatusError:
+			return rpcErr.Status
+		case 0:
+			return StatusInvalid
+		}
+	}
+	return StatusError
+}
+
+// errorBody builds the envelope written for err at status. Messages from
+// non-rpc errors are only exposed for 4xx statuses.
+func errorBody(err error, status int) Error {
+	var body Error
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) && rpcErr != nil {
+		body = Error{
+			Code:       rpcErr.Code,
+			Message:    rpcErr.Message,
+			Details:    append([]ErrorDetail(nil), rpcErr.Details...),
+			IncidentID: rpcErr.IncidentID,
+		}
+	} else if status < 500 && err != nil {
+		body.Message = err.Error()
+	}
+	if body.Code == "" {
+		body.Code = defaultErrorCode(status)
+	}
+	if body.Message == "" {
+		if status >= 500 {
+			body.Message = errorInternalBody
+		} else {
+			body.Message = strings.ToLower(http.StatusText(status))
+		}
+	}
+	return body
+}
+
+func defaultErrorCode(status int) string {
+	switch {
+	case status == http.StatusRequestEntityTooLarge:
+		return ErrorCodeRequestTooLarge
+	case status == http.StatusGatewayTimeout:
+		return ErrorCodeTimeout
+	case status >= 500:
+		return ErrorCodeInternal
+	default:
+		return ErrorCodeInvalid
+	}
+}
+
+func errorFromValue(v reflect.Value) error {
+	if !v.IsValid() || (v.Kind() == reflect.Interface && v.IsNil()) {
+		return nil
+	}
+	err, _ := v.Interface().(error)
+	return err
+}
+
+func writeError(w http.ResponseWriter, codec Codec, status int, err error) {
+	writeResponse(w, codec, status, reflect.ValueOf(errorBody(err, status)))
+}
+
+func asValidationError(err error) (*schema.ValidationError, bool) {
+	var validationErr *schema.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr, true
+	}
+	return nil, false
+}
+
+// validationFailure converts request constraint violations into a 422 Error
+// with one detail per violation.
+func validationFailure(err *schema.ValidationError) *Error {
+	details := make([]ErrorDetail, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		details = append(details, ErrorDetail{
+			Field:   violation.Field,
+			Code:    violation.Code,
+			Message: violation.Message,
+		})
+	}
+	return Invalid("request validation failed", details...)
+}
+
+// validatesRequest reports whether the route's request type declares
+// constraints, in which case 422 responses may carry the error envelope.
+func validatesRequest(route Route) bool {
+	if route.RequestType == nil {
+		return false
+	}
+	validator, err := schema.ValidatorFor(route.RequestType)
+	return err == nil && validator.Active()
+}
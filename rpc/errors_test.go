@@ -0,0 +1,261 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type signupReq struct {
+	Email string `json:"email"`
+}
+
+type signupResp struct {
+	ID string `json:"id"`
+}
+
+func signupHandler(_ context.Context, req signupReq) (signupResp, error) {
+	switch req.Email {
+	case "":
+		return signupResp{}, Invalid("invalid signup", FieldError("email", "is required"))
+	case "taken@example.com":
+		return signupResp{}, fmt.Errorf("signup: %w", &Error{Code: "conflict", Message: "email is taken"})
+	case "db@example.com":
+		return signupResp{}, errors.New("connection refused")
+	}
+	return signupResp{ID: "u1"}, nil
+}
+
+func signupStatusHandler(_ context.Context, req signupReq) (signupResp, int, error) {
+	switch req.Email {
+	case "":
+		return signupResp{}, StatusInvalid, errors.New("email is required")
+	case "boom@example.com":
+		return signupResp{}, StatusOK, errors.New("boom")
+	case "plain@example.com":
+		return signupResp{}, StatusInvalid, nil
+	}
+	return signupResp{ID: "u2"}, StatusOK, nil
+}
+
+func invalidErrorReturn(_ context.Context, _ signupReq) (signupResp, string) {
+	return signupResp{}, ""
+}
+
+func invalidErrorPosition(_ context.Context, _ signupReq) (signupResp, error, int) {
+	return signupResp{}, nil, StatusOK
+}
+
+func postRPC(t *testing.T, router *Router, path, body string) (*httptest.ResponseRecorder, Error) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var envelope Error
+	if rec.Code != http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("decode error envelope: %v (%s)", err, rec.Body.String())
+		}
+	}
+	return rec, envelope
+}
+
+func TestRPCErrorReturnWritesEnvelope(t *testing.T) {
+	router := NewRouter(WithAdvancedObservability())
+	router.HandleRPC(signupHandler)
+	route := router.Routes()[0]
+	if route.ErrorType != errorType {
+		t.Fatalf("route error type = %v, want rpc.Error", route.ErrorType)
+	}
+
+	rec, _ := postRPC(t, router, route.Path, `{"email":"new@example.com"}`)
+	if rec.Code != StatusOK || !strings.Contains(rec.Body.String(), `"id":"u1"`) {
+		t.Fatalf("unexpected success response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec, body := postRPC(t, router, route.Path, `{"email":""}`)
+	if rec.Code != StatusInvalid {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	if body.Code != ErrorCodeInvalid || body.Message != "invalid signup" {
+		t.Fatalf("unexpected envelope: %+v", body)
+	}
+	if len(body.Details) != 1 || body.Details[0].Field != "email" || body.Details[0].Message != "is required" {
+		t.Fatalf("unexpected details: %+v", body.Details)
+	}
+
+	rec, body = postRPC(t, router, route.Path, `{"email":"taken@example.com"}`)
+	if rec.Code != StatusInvalid || body.Code != "conflict" || body.Message != "email is taken" {
+		t.Fatalf("wrapped rpc.Error should default to 422, got %d %+v", rec.Code, body)
+	}
+
+	rec, body = postRPC(t, router, route.Path, `{"email":"db@example.com"}`)
+	if rec.Code != StatusError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+	if body.Code != ErrorCodeInternal || body.Message != "internal server error" {
+		t.Fatalf("plain errors should not leak, got %+v", body)
+	}
+
+	rec, body = postRPC(t, router, route.Path, `{"email":`)
+	if rec.Code != StatusInvalid || body.Message != "invalid request body" {
+		t.Fatalf("unexpected decode failure response %d: %+v", rec.Code, body)
+	}
+
+	snapshot := router.observability.Snapshot()
+	found := false
+	for _, fingerprint := range snapshot.Errors {
+		if fingerprint.ErrorMessage == "connection refused" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected observability to record the returned error message, got %+v", snapshot.Errors)
+	}
+}
+
+func TestRPCErrorReturnWithStatus(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(signupStatusHandler)
+	path := router.Routes()[0].Path
+
+	rec, body := postRPC(t, router, path, `{"email":""}`)
+	if rec.Code != StatusInvalid || body.Message != "email is required" || body.Code != ErrorCodeInvalid {
+		t.Fatalf("unexpected 422 response %d: %+v", rec.Code, body)
+	}
+
+	rec, body = postRPC(t, router, path, `{"email":"boom@example.com"}`)
+	if rec.Code != StatusError || body.Code != ErrorCodeInternal {
+		t.Fatalf("error with status 200 should resolve to 500, got %d %+v", rec.Code, body)
+	}
+
+	rec, body = postRPC(t, router, path, `{"email":"plain@example.com"}`)
+	if rec.Code != StatusInvalid || body.Code != ErrorCodeInvalid {
+		t.Fatalf("status without error should still use envelope, got %d %+v", rec.Code, body)
+	}
+}
+
+func TestRPCErrorReturnInvalidSignaturesPanic(t *testing.T) {
+	router := NewRouter()
+	expectPanic(t, func() {
+		router.HandleRPC(invalidErrorReturn)
+	})
+	expectPanic(t, func() {
+		router.HandleRPC(invalidErrorPosition)
+	})
+}
+
+func TestRPCErrorEnvelopeOpenAPIComponent(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(signupHandler)
+	router.HandleRPC(signupStatusHandler)
+	router.HandleRPC(testHandler)
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	envelope, ok := schemas[errorBodySchemaName].(map[string]any)
+	if !ok {
+		t.Fatalf("expected %s component, got %v", errorBodySchemaName, schemas)
+	}
+	required := envelope["required"].([]any)
+	if len(required) != 2 || required[0] != "code" || required[1] != "message" {
+		t.Fatalf("unexpected envelope required fields: %v", required)
+	}
+	if _, ok := schemas[errorDetailSchemaName]; !ok {
+		t.Fatalf("expected %s component", errorDetailSchemaName)
+	}
+
+	paths := doc["paths"].(map[string]any)
+	errorRef := func(path, status string) any {
+		op := paths[path].(map[string]any)["post"].(map[string]any)
+		resp := op["responses"].(map[string]any)[status].(map[string]any)
+		media := resp["content"].(map[string]any)["application/json"].(map[string]any)
+		return media["schema"].(map[string]any)["$ref"]
+	}
+	want := "#/components/schemas/" + errorBodySchemaName
+	for _, path := range []string{"/rpc/rpc/signup-handler", "/rpc/rpc/signup-status-handler"} {
+		if got := errorRef(path, "422"); got != want {
+			t.Fatalf("%s 422 ref = %v, want %s", path, got, want)
+		}
+		if got := errorRef(path, "500"); got != want {
+			t.Fatalf("%s 500 ref = %v, want %s", path, got, want)
+		}
+	}
+	if got := errorRef("/rpc/rpc/test-handler", "422"); got != "#/components/schemas/testResp" {
+		t.Fatalf("legacy handlers should keep response schema for errors, got %v", got)
+	}
+}
+
+func TestRPCErrorEnvelopeClientsTypeErrorBody(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(signupHandler)
+	router.HandleRPC(testHandler)
+
+	var ts bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), "export interface RPCErrorBody {")
+	assertRPCContains(t, ts.String(), "details?: RPCErrorDetail[]")
+	assertRPCContains(t, ts.String(), "throw new RPCError<RPCErrorBody>(response.status, json as unknown as RPCErrorBody,")
+	assertRPCContains(t, ts.String(), "throw new RPCError<testResp>(response.status, json as testResp,")
+
+	var js bytes.Buffer
+	if err := router.WriteClientJS(&js); err != nil {
+		t.Fatalf("write js client: %v", err)
+	}
+	assertRPCContains(t, js.String(), "@typedef {Object} RPCErrorBody")
+	assertRPCContains(t, js.String(), "@throws {RPCError<RPCErrorBody>}")
+	if strings.Count(js.String(), "@throws") != 1 {
+		t.Fatalf("expected @throws only for error-returning handlers")
+	}
+
+	var py bytes.Buffer
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	assertRPCContains(t, py.String(), "class RPCErrorBody:")
+
+	dir := t.TempDir()
+	pyPath := filepath.Join(dir, "client.gen.py")
+	if err := os.WriteFile(pyPath, py.Bytes(), 0644); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	snippet := pythonRPCImportSnippet(pyPath) + `
+import io
+from urllib import error
+
+def fake_urlopen(req):
+    body = b'{"code":"invalid","message":"invalid signup","details":[{"field":"email","message":"is required"}]}'
+    raise error.HTTPError(req.full_url, 422, "Unprocessable Entity", {}, io.BytesIO(body))
+
+mod.request.urlopen = fake_urlopen
+client = mod.create_client(base_url="https://core.example")
+try:
+    client.rpc.signupHandler(mod.signupReq(email=""))
+    raise AssertionError("expected RPCError")
+except mod.RPCError as err:
+    assert err.status == 422
+    assert isinstance(err.body, mod.RPCErrorBody)
+    assert err.body.code == "invalid"
+    assert err.body.details[0].field == "email"
+    assert isinstance(err.body.details[0], mod.RPCErrorDetail)
+`
+	if err := runRPCPython("-c", snippet); err != nil {
+		t.Fatalf("python error envelope client failed: %v", err)
+	}
+}
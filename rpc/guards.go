@@ -1,9 +1,28 @@
 package rpc
 
-import "github.com/swetjen/virtuous/guard"
+import (
+	"context"
+
+	"github.com/swetjen/virtuous/guard"
+)
 
 // Guard carries auth metadata and middleware for a route.
 type Guard = guard.Guard
 
 // GuardSpec describes how to inject auth for a route.
 type GuardSpec = guard.Spec
+
+// PrincipalIdentifier is implemented by principals that expose a stable ID
+// for observability.
+type PrincipalIdentifier = guard.Identifier
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+// Guards call it from Middleware before passing the request on.
+func WithPrincipal(ctx context.Context, principal any) context.Context {
+	return guard.WithPrincipal(ctx, principal)
+}
+
+// Principal returns the principal attached by a guard when it has type T.
+func Principal[T any](ctx context.Context) (T, bool) {
+	return guard.Principal[T](ctx)
+}
@@ -2,26 +2,38 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 
-	"github.com/swetjen/virtuous/internal/jsondecode"
 	"github.com/swetjen/virtuous/internal/jsonlimit"
+	"github.com/swetjen/virtuous/schema"
 )
 
 type handlerSpec struct {
-	fn       reflect.Value
-	reqType  reflect.Type
-	respType reflect.Type
-	service  string
-	method   string
-	path     string
-	hasBody  bool
-	fullName string
+	fn         reflect.Value
+	reqType    reflect.Type
+	respType   reflect.Type
+	streamType reflect.Type
+	errType    reflect.Type
+	validator  *schema.Validator
+	service    string
+	method     string
+	path       string
+	hasBody    bool
+	streaming  bool
+	hasStatus  bool
+	hasError   bool
+	fullName   string
+	pkgPath    string
+	// validatorErr is why reqType has no validator. The route is registered
+	// without validation and HandleRPC logs the error.
+	validatorErr error
 }
 
 func parseHandler(fn any, prefix string) (handlerSpec, error) {
@@ -29,20 +41,74 @@ func parseHandler(fn any, prefix string) (handlerSpec, error) {
 	if value.Kind() != reflect.Func {
 		return handlerSpec{}, errors.New("rpc: handler must be a function")
 	}
+	spec, err := parseHandlerSignature(value)
+	if err != nil {
+		return handlerSpec{}, err
+	}
+	fullName, pkgName, funcName, err := resolveFuncName(fn)
+	if err != nil {
+		return handlerSpec{}, err
+	}
+	return spec.named(prefix, fullName, pkgName, funcName)
+}
+
+// parseHandlerSignature validates a handler function's parameters and
+// results. The returned spec has no name or path yet.
+func parseHandlerSignature(value reflect.Value) (handlerSpec, error) {
 	ft := value.Type()
-	if ft.NumOut() != 2 {
-		return handlerSpec{}, errors.New("rpc: handler must return (Resp, status)")
+
+	var streamType reflect.Type
+	var respType reflect.Type
+	hasStatus := true
+	hasError := false
+	numIn := ft.NumIn()
+	if numIn > 0 {
+		if msgType, ok := streamMessageType(ft.In(numIn - 1)); ok {
+			streamType = ft.In(numIn - 1)
+			respType = msgType
+			numIn--
+		}
 	}
-	respType := ft.Out(0)
-	if !isStructType(respType) {
-		return handlerSpec{}, errors.New("rpc: response type must be a struct or pointer to struct")
+
+	if streamType != nil {
+		if ft.NumOut() != 1 || ft.Out(0).Kind() != reflect.Int {
+			return handlerSpec{}, errors.New("rpc: streaming handler must return status int")
+		}
+		if !isStructType(respType) {
+			return handlerSpec{}, errors.New("rpc: stream message type must be a struct or pointer to struct")
+		}
+	} else {
+		switch ft.NumOut() {
+		case 2:
+			if ft.Out(1) == errorIfaceType {
+				hasStatus = false
+				hasError = true
+			} else if ft.Out(1).Kind() != reflect.Int {
+				return handlerSpec{}, errors.New("rpc: status return must be int")
+			}
+		case 3:
+			if ft.Out(1).Kind() != reflect.Int {
+				return handlerSpec{}, errors.New("rpc: status return must be int")
+			}
+			if ft.Out(2) != errorIfaceType {
+				return handlerSpec{}, errors.New("rpc: last return must be error")
+			}
+			hasError = true
+		default:
+			return handlerSpec{}, errors.New("rpc: handler must return (Resp, status), (Resp, error), or (Resp, status, error)")
+		}
+		respType = ft.Out(0)
+		if !isStructType(respType) {
+			return handlerSpec{}, errors.New("rpc: response type must be a struct or pointer to struct")
+		}
 	}
-	statusType := ft.Out(1)
-	if statusType.Kind() != reflect.Int {
-		return handlerSpec{}, errors.New("rpc: status return must be int")
+
+	var errType reflect.Type
+	if hasError {
+		errType = errorType
 	}
 
-	if ft.NumIn() < 1 || ft.NumIn() > 2 {
+	if numIn < 1 || numIn > 2 {
 		return handlerSpec{}, errors.New("rpc: handler must accept context.Context and optional request")
 	}
 	ctxType := ft.In(0)
@@ -51,33 +117,63 @@ func parseHandler(fn any, prefix string) (handlerSpec, error) {
 	}
 
 	var reqType reflect.Type
-	if ft.NumIn() == 2 {
+	var validator *schema.Validator
+	var validatorErr error
+	if numIn == 2 {
 		reqType = ft.In(1)
 		if !isStructType(reqType) {
 			return handlerSpec{}, errors.New("rpc: request type must be a struct or pointer to struct")
 		}
+		var err error
+		if validator, err = schema.ValidatorFor(reqType); err != nil {
+			validatorErr = fmt.Errorf("rpc: request validation: %w", err)
+		}
 	}
 
-	fullName, pkgName, funcName, err := resolveFuncName(fn)
-	if err != nil {
-		return handlerSpec{}, err
-	}
+	return handlerSpec{
+		fn:           value,
+		reqType:      reqType,
+		respType:     respType,
+		streamType:   streamType,
+		errType:      errType,
+		validator:    validator,
+		validatorErr: validatorErr,
+		hasBody:      reqType != nil,
+		streaming:    streamType != nil,
+		hasStatus:    hasStatus,
+		hasError:     hasError,
+	}, nil
+}
+
+// named sets the service, method, and route path for a parsed handler.
+func (spec handlerSpec) named(prefix, fullName, pkgName, funcName string) (handlerSpec, error) {
 	kebab := kebabCase(funcName)
 	if kebab == "" {
 		return handlerSpec{}, errors.New("rpc: handler name could not be inferred")
 	}
-	path := buildRPCPath(prefix, pkgName, kebab)
+	spec.service = pkgName
+	spec.method = funcName
+	spec.path = buildRPCPath(prefix, pkgName, kebab)
+	spec.fullName = fullName
+	spec.pkgPath = funcPkgPath(fullName)
+	return spec, nil
+}
 
-	return handlerSpec{
-		fn:       value,
-		reqType:  reqType,
-		respType: respType,
-		service:  pkgName,
-		method:   funcName,
-		path:     path,
-		hasBody:  reqType != nil,
-		fullName: fullName,
-	}, nil
+// withService replaces the service name and the route path derived from it.
+func (spec handlerSpec) withService(prefix, service string) handlerSpec {
+	spec.service = service
+	spec.path = buildRPCPath(prefix, service, kebabCase(spec.method))
+	return spec
+}
+
+// funcPkgPath returns the import path portion of a qualified function name
+// such as "example.com/app/users.(*Service).Create".
+func funcPkgPath(fullName string) string {
+	lastSlash := strings.LastIndex(fullName, "/")
+	if dot := strings.Index(fullName[lastSlash+1:], "."); dot >= 0 {
+		return fullName[:lastSlash+1+dot]
+	}
+	return fullName
 }
 
 func resolveFuncName(fn any) (fullName string, pkgName string, funcName string, err error) {
@@ -123,46 +219,134 @@ func isStructType(t reflect.Type) bool {
 	return base != nil && base.Kind() == reflect.Struct
 }
 
-func (router *Router) buildRPCHandler(spec handlerSpec) http.Handler {
+func (router *Router) buildRPCHandler(spec handlerSpec, interceptors []UnaryInterceptor, timeout time.Duration, config handlerConfig) http.Handler {
+	if spec.streaming {
+		return router.buildStreamHandler(spec)
+	}
+	call := chainUnary(spec.call, spec.unaryInfo(), interceptors)
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		if req.Method != http.MethodPost {
+		cacheable := config.readOnly && req.Method == http.MethodGet
+		if req.Method != http.MethodPost && !cacheable {
 			setTraceError(req.Context(), "method not allowed")
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
-		args := make([]reflect.Value, 0, 2)
-		args = append(args, reflect.ValueOf(req.Context()))
+		reqCodec, respCodec := router.requestCodec(req), router.responseCodec(req)
+		if cacheable {
+			req = withQueryBody(req)
+			reqCodec = JSONCodec()
+		}
+		req, cancel := withCallDeadline(req, timeout)
+		defer cancel()
 
+		var reqArg any
 		if spec.reqType != nil {
-			reqVal, err := decodeRequest(w, req, spec.reqType, router.maxBodyBytes, router.strictJSON)
+			reqVal, err := decodeRequest(w, req, reqCodec, spec.reqType, spec.validator, router.maxBodyBytes, router.strictJSON)
 			if err != nil {
+				if validationErr, ok := asValidationError(err); ok {
+					setTraceError(req.Context(), validationErr.Error())
+					writeError(w, respCodec, StatusInvalid, validationFailure(validationErr))
+					return
+				}
 				setTraceError(req.Context(), "invalid request body")
+				status := StatusInvalid
 				if jsonlimit.IsBodyTooLarge(err) {
-					writeJSON(w, http.StatusRequestEntityTooLarge, reflect.Zero(spec.respType))
+					status = http.StatusRequestEntityTooLarge
+				}
+				// Validating routes document rejected requests as the error
+				// envelope, so malformed bodies use it too.
+				if spec.hasError || spec.validator.Active() {
+					writeError(w, respCodec, status, errors.New("invalid request body"))
 					return
 				}
-				writeJSON(w, StatusInvalid, reflect.Zero(spec.respType))
+				writeResponse(w, respCodec, status, reflect.Zero(spec.respType))
 				return
 			}
-			args = append(args, reqVal)
+			reqArg = reqVal.Interface()
 		}
 
-		out := spec.fn.Call(args)
-		respVal := out[0]
-		statusVal := out[1]
-		status := int(statusVal.Int())
+		if expired(req.Context()) {
+			writeTimeout(w, req, respCodec)
+			return
+		}
+		resp, status, callErr := call(req.Context(), reqArg)
+		if timedOut(req.Context(), callErr) {
+			writeTimeout(w, req, respCodec)
+			return
+		}
+		respVal, err := unaryValue(spec.respType, resp, "response")
+		if err != nil && callErr == nil {
+			callErr = err
+			status = StatusError
+		}
+		if callErr != nil && (status == 0 || status == StatusOK) {
+			status = errorStatus(callErr)
+		}
 		if status != StatusOK && status != StatusInvalid && status != StatusError {
 			setTraceError(req.Context(), "invalid rpc status")
 			status = StatusError
 		}
+		if callErr != nil {
+			setTraceError(req.Context(), callErr.Error())
+			writeError(w, respCodec, status, callErr)
+			return
+		}
 		if status >= 400 {
+			if spec.hasError {
+				setTraceError(req.Context(), strings.ToLower(http.StatusText(status)))
+				writeError(w, respCodec, status, nil)
+				return
+			}
 			setTraceError(req.Context(), extractResponseErrorMessage(respVal))
 		}
-		writeJSON(w, status, respVal)
+		if cacheable && status == StatusOK {
+			if len(router.codecs) > 1 {
+				w.Header().Add("Vary", "Accept")
+			}
+			writeCached(w, req, respCodec, respVal, config.cache)
+			return
+		}
+		writeResponse(w, respCodec, status, respVal)
 	})
 }
 
-func decodeRequest(w http.ResponseWriter, r *http.Request, reqType reflect.Type, maxBytes int64, strictJSON bool) (reflect.Value, error) {
+// call invokes the handler function with ctx and the decoded request. It is
+// the innermost UnaryHandler of the interceptor chain.
+func (spec handlerSpec) call(ctx context.Context, req any) (any, int, error) {
+	args := make([]reflect.Value, 0, 2)
+	args = append(args, reflect.ValueOf(&ctx).Elem())
+	if spec.reqType != nil {
+		reqVal, err := unaryValue(spec.reqType, req, "request")
+		if err != nil {
+			return nil, StatusError, err
+		}
+		args = append(args, reqVal)
+	}
+
+	out := spec.fn.Call(args)
+	status := StatusOK
+	if spec.hasStatus {
+		status = int(out[1].Int())
+	}
+	var callErr error
+	if spec.hasError {
+		callErr = errorFromValue(out[len(out)-1])
+	}
+	return out[0].Interface(), status, callErr
+}
+
+func (spec handlerSpec) unaryInfo() UnaryInfo {
+	return UnaryInfo{
+		Service: spec.service,
+		Method:  spec.method,
+		Path:    spec.path,
+	}
+}
+
+// decodeRequest decodes the body into reqType with codec and applies the
+// request validator. Constraint failures are returned as
+// *schema.ValidationError.
+func decodeRequest(w http.ResponseWriter, r *http.Request, codec Codec, reqType reflect.Type, validator *schema.Validator, maxBytes int64, strict bool) (reflect.Value, error) {
 	if reqType == nil {
 		return reflect.Value{}, errors.New("rpc: request type missing")
 	}
@@ -172,36 +356,45 @@ func decodeRequest(w http.ResponseWriter, r *http.Request, reqType reflect.Type,
 	if r.ContentLength > maxBytes {
 		return reflect.Value{}, jsonlimit.ErrBodyTooLarge
 	}
-	body := jsonlimit.MaxBytesReader(w, r, maxBytes)
-	opts := jsondecode.Options{}
-	if strictJSON {
-		opts = jsondecode.StrictOptions()
+	body, err := io.ReadAll(jsonlimit.MaxBytesReader(w, r, maxBytes))
+	if err != nil {
+		return reflect.Value{}, err
 	}
 	var target reflect.Value
 	if reqType.Kind() == reflect.Ptr {
 		target = reflect.New(reqType.Elem())
-		if err := jsondecode.Decode(body, target.Interface(), opts); err != nil {
-			return reflect.Value{}, err
-		}
-		return target, nil
+	} else {
+		target = reflect.New(reqType)
 	}
-	target = reflect.New(reqType)
-	if err := jsondecode.Decode(body, target.Interface(), opts); err != nil {
+	if err := codec.Unmarshal(body, target.Interface(), strict); err != nil {
 		return reflect.Value{}, err
 	}
+	if err := validator.Validate(target.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	if reqType.Kind() == reflect.Ptr {
+		return target, nil
+	}
 	return target.Elem(), nil
 }
 
 func writeJSON(w http.ResponseWriter, status int, v reflect.Value) {
-	w.Header().Set("Content-Type", "application/json")
+	writeResponse(w, JSONCodec(), status, v)
+}
+
+func writeResponse(w http.ResponseWriter, codec Codec, status int, v reflect.Value) {
+	w.Header().Set("Content-Type", codec.MediaType())
 	w.WriteHeader(status)
 	if !v.IsValid() {
 		return
 	}
-	enc := json.NewEncoder(w)
+	data, err := codec.Marshal(v.Interface())
 	// At this point headers are already written; do not attempt to write another
 	// status line on encode/write failure.
-	_ = enc.Encode(v.Interface())
+	if err != nil {
+		return
+	}
+	_, _ = w.Write(data)
 }
 
 func buildRPCPath(prefix, pkgName, funcName string) string {
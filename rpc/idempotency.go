@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"net/http"
+
+	"github.com/swetjen/virtuous/idempotency"
+	"github.com/swetjen/virtuous/schema"
+)
+
+// Idempotent makes a handler honor the Idempotency-Key header: the first
+// response for a key is stored in the router's store (see
+// WithIdempotencyStore) and replayed for retries with the same body.
+// Generated clients send a random key for these handlers unless one is given.
+func Idempotent() HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.idempotent = true
+	})
+}
+
+func (r *Router) idempotentHandler(h http.Handler) http.Handler {
+	return idempotency.Middleware(r.idempotency, idempotency.Options{
+		MaxBodyBytes: r.maxBodyBytes,
+		Reject: func(w http.ResponseWriter, req *http.Request, err *idempotency.Error) {
+			writeError(w, r.responseCodec(req), err.Status, &Error{Code: err.Code, Message: err.Message})
+		},
+	})(h)
+}
+
+func applyIdempotencyDocs(op *openAPIOperation, errSchema *schema.OpenAPISchema) {
+	maxLength := idempotency.MaxKeyLength
+	op.Parameters = append(op.Parameters, openAPIParameter{
+		Name:        idempotency.Header,
+		In:          "header",
+		Description: "Unique key for safely retrying this call. Repeats with the same key and body replay the first response.",
+		Schema:      &schema.OpenAPISchema{Type: "string", MaxLength: &maxLength},
+	})
+	content := map[string]openAPIMedia{"application/json": {Schema: errSchema}}
+	op.Responses["400"] = openAPIResponse{Description: "Invalid idempotency key", Content: content}
+	op.Responses["409"] = openAPIResponse{Description: "A request with this idempotency key is in progress", Content: content}
+}
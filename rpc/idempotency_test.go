@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/idempotency"
+)
+
+var createGreetingCalls int
+
+func createGreeting(_ context.Context, req greetReq) (greetResp, int) {
+	createGreetingCalls++
+	return greetResp{Message: "hello " + req.Name}, StatusOK
+}
+
+func TestRPCIdempotentHandlerReplaysAndRejectsReuse(t *testing.T) {
+	createGreetingCalls = 0
+	router := NewRouter(WithIdempotencyStore(idempotency.NewMemoryStore(0)))
+	router.HandleRPC(createGreeting, Idempotent())
+	path := router.Routes()[0].Path
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotency.Header, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := post("k1", `{"name":"Ada"}`)
+	second := post("k1", `{"name":"Ada"}`)
+	if createGreetingCalls != 1 || second.Code != http.StatusOK || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected replay without a second call, calls=%d %d %s", createGreetingCalls, second.Code, second.Body.String())
+	}
+	if second.Header().Get(idempotency.ReplayedHeader) != "true" {
+		t.Fatalf("expected replayed header")
+	}
+
+	reused := post("k1", `{"name":"Grace"}`)
+	var envelope Error
+	if err := json.Unmarshal(reused.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode error: %v (%s)", err, reused.Body.String())
+	}
+	if reused.Code != http.StatusUnprocessableEntity || envelope.Code != idempotency.CodeKeyReused {
+		t.Fatalf("expected 422 envelope, got %d %s", reused.Code, reused.Body.String())
+	}
+}
+
+func TestRPCIdempotentRequiresStore(t *testing.T) {
+	defer func() {
+		if got := recover(); got == nil || !strings.Contains(got.(error).Error(), "requires WithIdempotencyStore") {
+			t.Fatalf("expected registration to fail without a store, got %v", got)
+		}
+	}()
+	NewRouter().HandleRPC(greet, Idempotent())
+}
+
+func TestRPCIdempotentDocsAndClients(t *testing.T) {
+	router := NewRouter(WithIdempotencyStore(idempotency.NewMemoryStore(0)))
+	router.HandleRPC(greet, Idempotent())
+	router.HandleRPC(testHandler)
+
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []openAPIParameter         `json:"parameters"`
+			Responses  map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	op := doc.Paths["/rpc/rpc/greet"]["post"]
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "Idempotency-Key" || op.Parameters[0].In != "header" || op.Parameters[0].Required {
+		t.Fatalf("expected optional Idempotency-Key header, got %+v", op.Parameters)
+	}
+	if _, ok := op.Responses["409"]; !ok {
+		t.Fatalf("expected 409 response, got %v", op.Responses)
+	}
+	if plain := doc.Paths["/rpc/rpc/test-handler"]["post"]; len(plain.Parameters) != 0 {
+		t.Fatalf("expected no header on non-idempotent route, got %+v", plain.Parameters)
+	}
+
+	var ts, js, py bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	if err := router.WriteClientJS(&js); err != nil {
+		t.Fatalf("write js client: %v", err)
+	}
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write py client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), "idempotencyKey?: string")
+	assertRPCContains(t, js.String(), "@property {string} [idempotencyKey]")
+	for _, out := range []string{ts.String(), js.String()} {
+		assertRPCContains(t, out, `headers["Idempotency-Key"] = (options && options.idempotencyKey) || newIdempotencyKey()`)
+		if strings.Count(out, `headers["Idempotency-Key"]`) != 1 {
+			t.Fatalf("expected only the idempotent method to send a key")
+		}
+	}
+	assertRPCContains(t, py.String(), "import uuid")
+	assertRPCContains(t, py.String(), "idempotency_key: str | None = None")
+	assertRPCContains(t, py.String(), `headers["Idempotency-Key"] = idempotency_key if idempotency_key is not None else str(uuid.uuid4())`)
+}
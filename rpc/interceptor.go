@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// UnaryInfo describes the RPC an interceptor is wrapping.
+type UnaryInfo struct {
+	Service string
+	Method  string
+	Path    string
+}
+
+// UnaryHandler invokes the next interceptor in the chain, or the RPC handler
+// itself. It returns the response value, the HTTP status, and the handler error
+// (always nil for handlers that do not return an error).
+type UnaryHandler func(ctx context.Context, req any) (resp any, status int, err error)
+
+// UnaryInterceptor wraps a unary RPC call. req is the decoded and validated
+// request value (nil for handlers without a request). Interceptors may replace
+// the context or request passed to next, and the response, status, or error
+// returned from it. Streaming handlers are not intercepted.
+type UnaryInterceptor func(ctx context.Context, req any, info UnaryInfo, next UnaryHandler) (resp any, status int, err error)
+
+// WithInterceptors applies unary interceptors to every RPC handler registered
+// on the router. Router interceptors run before per-route interceptors.
+func WithInterceptors(interceptors ...UnaryInterceptor) RouterOption {
+	return func(o *RouterOptions) {
+		o.Interceptors = append(o.Interceptors, interceptors...)
+	}
+}
+
+// Intercept applies unary interceptors to one RPC handler.
+func Intercept(interceptors ...UnaryInterceptor) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	})
+}
+
+// chainUnary composes interceptors around call; the first interceptor is
+// outermost.
+func chainUnary(call UnaryHandler, info UnaryInfo, interceptors []UnaryInterceptor) UnaryHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		if interceptor == nil {
+			continue
+		}
+		next := call
+		call = func(ctx context.Context, req any) (any, int, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return call
+}
+
+// unaryValue converts an interceptor-supplied value back to t.
+func unaryValue(t reflect.Type, value any, role string) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("rpc: interceptor %s has type %s, want %s", role, v.Type(), t)
+	}
+	return v, nil
+}
@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type greetReq struct {
+	Name string `json:"name"`
+}
+
+type greetResp struct {
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+func greet(_ context.Context, req greetReq) (greetResp, int) {
+	return greetResp{Message: "hello " + req.Name}, StatusOK
+}
+
+func greetWithError(_ context.Context, req greetReq) (greetResp, error) {
+	return greetResp{Message: "hello " + req.Name}, nil
+}
+
+func recordingInterceptor(name string, log *[]string) UnaryInterceptor {
+	return func(ctx context.Context, req any, info UnaryInfo, next UnaryHandler) (any, int, error) {
+		*log = append(*log, name+">"+info.Service+"."+info.Method)
+		resp, status, err := next(ctx, req)
+		*log = append(*log, name+"<")
+		return resp, status, err
+	}
+}
+
+func TestRPCInterceptorsRunInOrderWithMetadata(t *testing.T) {
+	var log []string
+	router := NewRouter(WithInterceptors(recordingInterceptor("router", &log)))
+	router.HandleRPC(greet, Intercept(recordingInterceptor("route", &log)))
+
+	rec, _ := postRPC(t, router, "/rpc/rpc/greet", `{"name":"Ada"}`)
+	if rec.Code != StatusOK || !strings.Contains(rec.Body.String(), `"message":"hello Ada"`) {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	want := []string{"router>rpc.greet", "route>rpc.greet", "route<", "router<"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("interceptor order = %v, want %v", log, want)
+	}
+}
+
+func TestRPCInterceptorsSeeAndReplaceValues(t *testing.T) {
+	var seenReq greetReq
+	var seenStatus int
+	mutate := func(ctx context.Context, req any, _ UnaryInfo, next UnaryHandler) (any, int, error) {
+		in := req.(greetReq)
+		seenReq = in
+		in.Name = strings.ToUpper(in.Name)
+		resp, status, err := next(ctx, in)
+		seenStatus = status
+		out := resp.(greetResp)
+		out.Message += "!"
+		return out, status, err
+	}
+	router := NewRouter()
+	router.HandleRPC(greet, Intercept(mutate))
+
+	rec, _ := postRPC(t, router, "/rpc/rpc/greet", `{"name":"ada"}`)
+	if seenReq.Name != "ada" || seenStatus != StatusOK {
+		t.Fatalf("interceptor saw req=%+v status=%d", seenReq, seenStatus)
+	}
+	if !strings.Contains(rec.Body.String(), `"message":"hello ADA!"`) {
+		t.Fatalf("expected mutated response, got %s", rec.Body.String())
+	}
+}
+
+func TestRPCInterceptorShortCircuitsWithError(t *testing.T) {
+	calls := 0
+	deny := func(context.Context, any, UnaryInfo, UnaryHandler) (any, int, error) {
+		calls++
+		return nil, 0, Invalid("blocked by policy")
+	}
+	router := NewRouter(
+		WithAdvancedObservability(WithObservabilitySampling(1)),
+		WithInterceptors(deny),
+	)
+	router.HandleRPC(greet)
+	router.HandleRPC(greetWithError)
+
+	for _, path := range []string{"/rpc/rpc/greet", "/rpc/rpc/greet-with-error"} {
+		rec, body := postRPC(t, router, path, `{"name":"Ada"}`)
+		if rec.Code != StatusInvalid || body.Message != "blocked by policy" {
+			t.Fatalf("%s: expected 422 envelope, got %d %s", path, rec.Code, rec.Body.String())
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expected interceptor to run twice, ran %d", calls)
+	}
+	for _, trace := range router.observability.Snapshot().RecentTraces {
+		if trace.ErrorMessage != "blocked by policy" {
+			t.Fatalf("expected interceptor error in trace, got %+v", trace)
+		}
+	}
+}
+
+func TestRPCInterceptorWrongResponseTypeIsServerError(t *testing.T) {
+	wrong := func(context.Context, any, UnaryInfo, UnaryHandler) (any, int, error) {
+		return "not a greetResp", StatusOK, nil
+	}
+	router := NewRouter()
+	router.HandleRPC(greetWithError, Intercept(wrong))
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/greet-with-error", strings.NewReader(`{"name":"Ada"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != StatusError {
+		t.Fatalf("expected 500, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRPCHandleRPCAcceptsGuardsAndGuardSlices(t *testing.T) {
+	router := NewRouter()
+	guards := []Guard{denyUnlessHeaderGuard{}, nil}
+	router.HandleRPC(greet, goClientBearerGuard{}, Guards(guards...), Summary("Greets"))
+
+	routes := router.Routes()
+	if len(routes) != 1 || len(routes[0].Guards) != 2 || routes[0].Guards[0].Name != "BearerAuth" {
+		t.Fatalf("expected both guards on the route, got %+v", routes)
+	}
+}
+
+type middlewareOnlyOption struct{}
+
+func (middlewareOnlyOption) Middleware() func(http.Handler) http.Handler { return nil }
+
+func TestRPCHandleRPCRejectsOptionsThatAreNotGuards(t *testing.T) {
+	defer func() {
+		rec := recover()
+		if err, ok := rec.(error); !ok || !strings.Contains(err.Error(), "is not a Guard") {
+			t.Fatalf("unexpected panic: %v", rec)
+		}
+	}()
+	NewRouter().HandleRPC(greet, middlewareOnlyOption{})
+}
@@ -0,0 +1,398 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/swetjen/virtuous/idempotency"
+	"github.com/swetjen/virtuous/internal/adminui"
+	"github.com/swetjen/virtuous/internal/jsonlimit"
+	"github.com/swetjen/virtuous/internal/jsonrpc"
+	"github.com/swetjen/virtuous/schema"
+)
+
+const defaultJSONRPCMaxBatch = 50
+
+// JSONRPCOptions configures the JSON-RPC 2.0 endpoint.
+type JSONRPCOptions struct {
+	// Path is the endpoint path. Defaults to "<prefix>/_jsonrpc".
+	Path string
+	// Guards protect the endpoint itself. Route guards still apply to every
+	// call in a batch.
+	Guards []Guard
+	// MaxBatch caps the number of calls in one batch. Defaults to 50.
+	MaxBatch int
+}
+
+// JSONRPCOpt mutates JSONRPCOptions.
+type JSONRPCOpt func(*JSONRPCOptions)
+
+// WithJSONRPCPath overrides the JSON-RPC endpoint path.
+func WithJSONRPCPath(path string) JSONRPCOpt {
+	return func(o *JSONRPCOptions) {
+		if path != "" {
+			o.Path = ensureLeadingSlash(path)
+		}
+	}
+}
+
+// WithJSONRPCGuards applies guards to the JSON-RPC endpoint.
+func WithJSONRPCGuards(guards ...Guard) JSONRPCOpt {
+	return func(o *JSONRPCOptions) {
+		o.Guards = append(o.Guards, guards...)
+	}
+}
+
+// WithJSONRPCMaxBatch overrides the maximum number of calls in one batch.
+func WithJSONRPCMaxBatch(limit int) JSONRPCOpt {
+	return func(o *JSONRPCOptions) {
+		if limit > 0 {
+			o.MaxBatch = limit
+		}
+	}
+}
+
+func (r *Router) applyJSONRPCOpts(opts ...JSONRPCOpt) JSONRPCOptions {
+	config := JSONRPCOptions{
+		Path:     ensureLeadingSlash(strings.TrimSuffix(normalizePrefix(r.prefix), "/") + "/_jsonrpc"),
+		MaxBatch: defaultJSONRPCMaxBatch,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// ServeJSONRPC registers a JSON-RPC 2.0 endpoint that dispatches
+// "service.Method" calls, including batches, to the registered handlers.
+// Each call runs through its route's guards, interceptors, and observability.
+// The endpoint is documented in OpenAPI and enables batch() in the generated
+// TS client.
+func (r *Router) ServeJSONRPC(opts ...JSONRPCOpt) {
+	config := r.applyJSONRPCOpts(opts...)
+	config.Guards = append([]Guard(nil), config.Guards...)
+	r.jsonRPC = &config
+	r.mux.Handle(config.Path, wrapWithGuards(http.HandlerFunc(r.serveJSONRPC), config.Guards))
+
+	if r.events == nil {
+		r.events = adminui.NewEventFeed(600)
+	}
+	r.events.RecordSystem("jsonrpc online: " + config.Path)
+	r.logger.Info("rpc jsonrpc online", "path", config.Path)
+}
+
+func (r *Router) serveJSONRPC(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	reader, err := jsonlimit.LimitReader(req, r.maxBodyBytes)
+	var body []byte
+	if err == nil {
+		body, err = io.ReadAll(reader)
+	}
+	if err != nil {
+		if jsonlimit.IsBodyTooLarge(err) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payload, ok := r.processJSONRPC(forwardedCall{
+		ctx:        req.Context(),
+		header:     req.Header,
+		rawQuery:   req.URL.RawQuery,
+		remoteAddr: req.RemoteAddr,
+	}, body)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(data)
+}
+
+// processJSONRPC runs one call or a batch in order. It reports false when
+// the input held only notifications.
+func (r *Router) processJSONRPC(call forwardedCall, data []byte) (any, bool) {
+	requests, invalid, batch, err := jsonrpc.Decode(data)
+	if err != nil {
+		return jsonrpc.Failure(nil, jsonrpc.CodeParseError, "parse error", nil), true
+	}
+	if batch && len(requests) == 0 {
+		return jsonrpc.Failure(nil, jsonrpc.CodeInvalidRequest, "invalid request", nil), true
+	}
+	if limit := r.jsonRPCMaxBatch(); len(requests) > limit {
+		return jsonrpc.Failure(nil, jsonrpc.CodeInvalidRequest, "batch exceeds "+strconv.Itoa(limit)+" calls", nil), true
+	}
+	responses := make([]jsonrpc.Response, 0, len(requests))
+	for i, req := range requests {
+		if invalid[i] != nil {
+			responses = append(responses, *invalid[i])
+			continue
+		}
+		resp := r.callJSONRPC(call, req)
+		if req.IsNotification() {
+			continue
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) == 0 {
+		return nil, false
+	}
+	if !batch {
+		return responses[0], true
+	}
+	return responses, true
+}
+
+func (r *Router) jsonRPCMaxBatch() int {
+	if r.jsonRPC != nil && r.jsonRPC.MaxBatch > 0 {
+		return r.jsonRPC.MaxBatch
+	}
+	return defaultJSONRPCMaxBatch
+}
+
+func (r *Router) callJSONRPC(call forwardedCall, req jsonrpc.Request) jsonrpc.Response {
+	route, ok := r.jsonRPCRoute(req.Method)
+	if !ok {
+		return jsonrpc.Failure(req.ID, jsonrpc.CodeMethodNotFound, "method not found: "+req.Method, nil)
+	}
+	if route.Streaming {
+		return jsonrpc.Failure(req.ID, jsonrpc.CodeMethodNotFound, "streaming method not supported: "+req.Method, nil)
+	}
+	var body []byte
+	if route.RequestType != nil {
+		body = bytes.TrimSpace(req.Params)
+		if len(body) == 0 || bytes.Equal(body, []byte("null")) {
+			body = []byte("{}")
+		}
+		if body[0] != '{' {
+			return jsonrpc.Failure(req.ID, jsonrpc.CodeInvalidParams, "params must be an object", nil)
+		}
+	}
+	if key := strings.TrimSpace(call.header.Get(idempotency.Header)); key != "" && !req.IsNotification() {
+		// Each call in a batch gets its own key, so a retried batch replays
+		// every call instead of the calls colliding on one key.
+		call.idempotencyKey = key + ":" + string(req.ID)
+	}
+	status, data, err := r.dispatch(call, route, body)
+	if err != nil {
+		return jsonrpc.Failure(req.ID, jsonrpc.CodeInternalError, err.Error(), nil)
+	}
+	if status == http.StatusOK {
+		if len(data) == 0 {
+			return jsonrpc.Result(req.ID, json.RawMessage("null"))
+		}
+		return jsonrpc.Result(req.ID, json.RawMessage(data))
+	}
+	return jsonrpc.Failure(req.ID, jsonRPCErrorCode(status), jsonRPCErrorMessage(status, data), jsonRPCErrorData{
+		Status: status,
+		Body:   jsonRPCErrorBody(data),
+	})
+}
+
+// jsonRPCErrorData is the error data attached to failed calls, carrying the
+// HTTP status and body the route returned.
+type jsonRPCErrorData struct {
+	Status int `json:"status"`
+	Body   any `json:"body,omitempty"`
+}
+
+// jsonRPCErrorCode maps the RPC status model onto JSON-RPC error codes:
+// 422 is invalid params, 500 is an internal error, and anything else (such
+// as a guard's 401) is a server error.
+func jsonRPCErrorCode(status int) int {
+	switch status {
+	case StatusInvalid:
+		return jsonrpc.CodeInvalidParams
+	case StatusError:
+		return jsonrpc.CodeInternalError
+	default:
+		return jsonrpc.CodeServerError
+	}
+}
+
+func jsonRPCErrorMessage(status int, body []byte) string {
+	var envelope struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Message != "" {
+		return envelope.Message
+	}
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+	return "HTTP " + strconv.Itoa(status)
+}
+
+func jsonRPCErrorBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return string(body)
+}
+
+func (r *Router) jsonRPCRoute(method string) (Route, bool) {
+	for _, route := range r.routes {
+		if jsonRPCMethodName(route) == method {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+func jsonRPCMethodName(route Route) string {
+	return route.Service + "." + route.Method
+}
+
+// jsonRPCOpenAPI documents the JSON-RPC endpoint. Method names are listed as
+// an enum; their params and results are the documented request and response
+// bodies of the matching routes.
+func (r *Router) jsonRPCOpenAPI(routes []Route, securitySchemes map[string]openAPISecurityScheme) (*openAPIOperation, map[string]schema.OpenAPISchema) {
+	methods := []any{}
+	lines := []string{}
+	for _, route := range routes {
+		if route.Streaming || route.Service == "" || route.Method == "" {
+			continue
+		}
+		name := jsonRPCMethodName(route)
+		methods = append(methods, name)
+		lines = append(lines, "- `"+name+"` → `"+route.Path+"`")
+	}
+	ref := func(name string) *schema.OpenAPISchema {
+		return &schema.OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	id := &schema.OpenAPISchema{
+		Description: "Request id echoed in the response. Omit it to send a notification.",
+		Nullable:    true,
+		OneOf:       []*schema.OpenAPISchema{{Type: "string"}, {Type: "integer"}},
+	}
+	components := map[string]schema.OpenAPISchema{
+		"JSONRPCRequest": {
+			Type:     "object",
+			Required: []string{"jsonrpc", "method"},
+			Properties: map[string]*schema.OpenAPISchema{
+				"jsonrpc": {Type: "string", Enum: []any{jsonrpc.Version}},
+				"id":      id,
+				"method":  {Type: "string", Enum: methods},
+				"params":  {Type: "object", Description: "The method's request body."},
+			},
+		},
+		"JSONRPCError": {
+			Type:     "object",
+			Required: []string{"code", "message"},
+			Properties: map[string]*schema.OpenAPISchema{
+				"code":    {Type: "integer"},
+				"message": {Type: "string"},
+				"data": {
+					Type: "object",
+					Properties: map[string]*schema.OpenAPISchema{
+						"status": {Type: "integer", Description: "HTTP status returned by the route."},
+						"body":   {Description: "Response body returned by the route."},
+					},
+				},
+			},
+		},
+		"JSONRPCResponse": {
+			Type:     "object",
+			Required: []string{"jsonrpc", "id"},
+			Properties: map[string]*schema.OpenAPISchema{
+				"jsonrpc": {Type: "string", Enum: []any{jsonrpc.Version}},
+				"id":      id,
+				"result":  {Description: "The method's response body."},
+				"error":   ref("JSONRPCError"),
+			},
+		},
+	}
+
+	op := &openAPIOperation{
+		Summary: "JSON-RPC 2.0 endpoint",
+		Description: joinParagraphs(
+			"Dispatches JSON-RPC 2.0 calls and batches to the RPC handlers. Each call runs with the guards of its route.",
+			"Status 422 maps to error code -32602, 500 to -32603, and any other status, such as 401, to -32000. The error data carries the route's status and body.",
+			"Methods:\n"+strings.Join(lines, "\n"),
+		),
+		Tags: []string{"JSON-RPC"},
+		RequestBody: &openAPIRequestBody{
+			Required: true,
+			Content: map[string]openAPIMedia{
+				"application/json": {Schema: &schema.OpenAPISchema{
+					OneOf: []*schema.OpenAPISchema{
+						ref("JSONRPCRequest"),
+						{Type: "array", Items: ref("JSONRPCRequest")},
+					},
+				}},
+			},
+		},
+		Responses: map[string]openAPIResponse{
+			"200": {
+				Description: http.StatusText(http.StatusOK),
+				Content: map[string]openAPIMedia{
+					"application/json": {Schema: &schema.OpenAPISchema{
+						OneOf: []*schema.OpenAPISchema{
+							ref("JSONRPCResponse"),
+							{Type: "array", Items: ref("JSONRPCResponse")},
+						},
+					}},
+				},
+			},
+			"204": {Description: "Only notifications were sent."},
+		},
+	}
+	if specs := guardSpecs(r.jsonRPC.Guards); len(specs) > 0 {
+		for _, guard := range specs {
+			securitySchemes[guard.Name] = openAPISecuritySchemeForGuard(guard)
+			op.Security = append(op.Security, map[string][]string{guard.Name: {}})
+		}
+		op.Responses["401"] = openAPIResponse{Description: http.StatusText(http.StatusUnauthorized)}
+	}
+	return op, components
+}
+
+// applyJSONRPCClientSpec enables batch() in the TS client. The batch request
+// carries the auth of the endpoint guards and of every guarded method, since
+// all calls in a batch share one set of headers.
+func (r *Router) applyJSONRPCClientSpec(spec *clientSpec) {
+	if r.jsonRPC == nil {
+		return
+	}
+	spec.JSONRPCPath = r.jsonRPC.Path
+	seen := map[string]bool{}
+	add := func(guard GuardSpec) {
+		key := guard.In + "\x00" + guard.Param + "\x00" + guard.Prefix
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		spec.BatchAuth = append(spec.BatchAuth, guard)
+		if guard.In == "cookie" {
+			spec.BatchCookieAuth = true
+		}
+	}
+	for _, guard := range guardSpecs(r.jsonRPC.Guards) {
+		add(guard)
+	}
+	for _, service := range spec.Services {
+		for _, method := range service.Methods {
+			if method.HasAuth && !method.Streaming {
+				add(method.Auth)
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swetjen/virtuous/idempotency"
+)
+
+func failingGreet(_ context.Context, _ greetReq) (greetResp, error) {
+	return greetResp{}, Internal("database unavailable")
+}
+
+type jsonRPCReply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Status int             `json:"status"`
+			Body   json.RawMessage `json:"body"`
+		} `json:"data"`
+	} `json:"error"`
+}
+
+func postJSONRPC(t *testing.T, router *Router, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rpc/_jsonrpc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func newJSONRPCRouter() *Router {
+	router := NewRouter()
+	router.HandleRPC(greet)
+	router.HandleRPC(testHandler)
+	router.HandleRPC(failingGreet)
+	router.HandleRPC(adminPurge, denyUnlessHeaderGuard{})
+	router.ServeJSONRPC()
+	return router
+}
+
+func TestRPCJSONRPCSingleCall(t *testing.T) {
+	router := newJSONRPCRouter()
+	rec := postJSONRPC(t, router, `{"jsonrpc":"2.0","id":7,"method":"rpc.greet","params":{"name":"Ada"}}`, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var reply jsonRPCReply
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("decode reply: %v (%s)", err, rec.Body.String())
+	}
+	if string(reply.ID) != "7" || reply.Error != nil || string(reply.Result) != `{"message":"hello Ada"}` {
+		t.Fatalf("unexpected reply: %s", rec.Body.String())
+	}
+
+	notify := postJSONRPC(t, router, `{"jsonrpc":"2.0","method":"rpc.greet","params":{"name":"Ada"}}`, nil)
+	if notify.Code != http.StatusNoContent || notify.Body.Len() != 0 {
+		t.Fatalf("expected 204 for notification, got %d %q", notify.Code, notify.Body.String())
+	}
+}
+
+func TestRPCJSONRPCBatchMapsStatuses(t *testing.T) {
+	router := newJSONRPCRouter()
+	rec := postJSONRPC(t, router, `[
+		{"jsonrpc":"2.0","id":1,"method":"rpc.greet","params":{"name":"Ada"}},
+		{"jsonrpc":"2.0","id":2,"method":"rpc.testHandler","params":{"name":""}},
+		{"jsonrpc":"2.0","id":3,"method":"rpc.failingGreet","params":{}},
+		{"jsonrpc":"2.0","id":4,"method":"rpc.adminPurge","params":{}},
+		{"jsonrpc":"2.0","id":5,"method":"rpc.missing"},
+		{"jsonrpc":"2.0","id":6,"method":"rpc.greet","params":["Ada"]},
+		{"jsonrpc":"2.0","method":"rpc.greet","params":{"name":"quiet"}},
+		{"id":8}
+	]`, nil)
+	var replies []jsonRPCReply
+	if err := json.Unmarshal(rec.Body.Bytes(), &replies); err != nil {
+		t.Fatalf("decode batch: %v (%s)", err, rec.Body.String())
+	}
+	if len(replies) != 7 {
+		t.Fatalf("expected 7 replies without the notification, got %s", rec.Body.String())
+	}
+	if replies[0].Error != nil || !strings.Contains(string(replies[0].Result), "hello Ada") {
+		t.Fatalf("unexpected first reply: %s", rec.Body.String())
+	}
+	for i, want := range []struct {
+		code   int
+		status int
+	}{
+		{-32602, 422},
+		{-32603, 500},
+		{-32000, 401},
+		{-32601, 0},
+		{-32602, 0},
+		{-32600, 0},
+	} {
+		got := replies[i+1].Error
+		if got == nil || got.Code != want.code || got.Data.Status != want.status {
+			t.Fatalf("reply %d: expected code %d status %d, got %s", i+2, want.code, want.status, rec.Body.String())
+		}
+	}
+	if replies[2].Error.Message != "database unavailable" || !strings.Contains(string(replies[2].Error.Data.Body), `"code":"internal"`) {
+		t.Fatalf("expected error envelope in data, got %s", rec.Body.String())
+	}
+
+	metrics := router.observability.Snapshot()
+	if metrics.Totals.RequestsLast24H != 5 {
+		t.Fatalf("expected one observed request per dispatched call, got %+v", metrics.Totals)
+	}
+
+	authed := postJSONRPC(t, router, `[{"jsonrpc":"2.0","id":1,"method":"rpc.adminPurge","params":{}}]`, http.Header{"Authorization": {"Bearer token"}})
+	if !strings.Contains(authed.Body.String(), `"result":{"message":"purged"}`) {
+		t.Fatalf("expected guard to pass with forwarded header, got %s", authed.Body.String())
+	}
+}
+
+func TestRPCJSONRPCRejectsInvalidEnvelopes(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet)
+	router.ServeJSONRPC(WithJSONRPCMaxBatch(1))
+
+	for body, code := range map[string]string{
+		`{`:  `"code":-32700`,
+		`[]`: `"code":-32600`,
+		`[{"jsonrpc":"2.0","id":1,"method":"rpc.greet"},{"jsonrpc":"2.0","id":2,"method":"rpc.greet"}]`: `batch exceeds 1 calls`,
+	} {
+		rec := postJSONRPC(t, router, body, nil)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), code) {
+			t.Fatalf("body %s: expected %s, got %d %s", body, code, rec.Code, rec.Body.String())
+		}
+	}
+	get := httptest.NewRecorder()
+	router.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/rpc/_jsonrpc", nil))
+	if get.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", get.Code)
+	}
+}
+
+func TestRPCJSONRPCOpenAPIAndTSClient(t *testing.T) {
+	router := newJSONRPCRouter()
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	op := string(doc.Paths["/rpc/_jsonrpc"]["post"])
+	if !strings.Contains(op, `"JSON-RPC"`) || !strings.Contains(op, `"204"`) {
+		t.Fatalf("expected JSON-RPC operation, got %s", op)
+	}
+	request := string(doc.Components.Schemas["JSONRPCRequest"])
+	if !strings.Contains(request, `"rpc.greet"`) || !strings.Contains(request, `"rpc.adminPurge"`) {
+		t.Fatalf("expected method enum, got %s", request)
+	}
+
+	var ts bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	out := ts.String()
+	assertRPCContains(t, out, `	"rpc.greet": { params: greetReq; result: greetResp }`)
+	assertRPCContains(t, out, "async batch<T extends readonly BatchCall[]>(calls: [...T], options?: AuthOptions): Promise<BatchResults<T>> {")
+	assertRPCContains(t, out, `let url = basepath + "/rpc/_jsonrpc"`)
+	assertRPCContains(t, out, `headers["Authorization"] = "Bearer " + authValue`)
+
+	plain := NewRouter()
+	plain.HandleRPC(greet)
+	var plainTS bytes.Buffer
+	if err := plain.WriteClientTS(&plainTS); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	if strings.Contains(plainTS.String(), "batch") {
+		t.Fatalf("expected no batch helper without ServeJSONRPC")
+	}
+}
+
+func TestRPCJSONRPCBatchGivesIdempotentCallsTheirOwnKeys(t *testing.T) {
+	createGreetingCalls = 0
+	router := NewRouter(WithIdempotencyStore(idempotency.NewMemoryStore(0)))
+	router.HandleRPC(createGreeting, Idempotent())
+	router.ServeJSONRPC()
+
+	batch := `[
+		{"jsonrpc":"2.0","id":1,"method":"rpc.createGreeting","params":{"name":"Ada"}},
+		{"jsonrpc":"2.0","id":2,"method":"rpc.createGreeting","params":{"name":"Grace"}}
+	]`
+	header := http.Header{idempotency.Header: {"batch-1"}, TimeoutHeader: {"1"}}
+	for attempt := 0; attempt < 2; attempt++ {
+		rec := postJSONRPC(t, router, batch, header)
+		var replies []jsonRPCReply
+		if err := json.Unmarshal(rec.Body.Bytes(), &replies); err != nil || len(replies) != 2 {
+			t.Fatalf("decode replies: %v (%s)", err, rec.Body.String())
+		}
+		for _, reply := range replies {
+			if reply.Error != nil {
+				t.Fatalf("attempt %d: call %s failed: %s", attempt, reply.ID, rec.Body.String())
+			}
+		}
+		if string(replies[1].Result) != `{"message":"hello Grace"}` {
+			t.Fatalf("unexpected second result: %s", rec.Body.String())
+		}
+	}
+	if createGreetingCalls != 2 {
+		t.Fatalf("expected a retried batch to be replayed, got %d calls", createGreetingCalls)
+	}
+}
+
+func TestRPCJSONRPCDocumentedWhenServedAfterDocs(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet)
+	router.ServeAllDocs()
+	router.ServeJSONRPC()
+
+	for _, path := range []string{"/rpc/openapi.json", "/rpc/docs/openapi.json"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", path, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), `"/rpc/_jsonrpc"`) {
+			t.Fatalf("GET %s: expected the JSON-RPC endpoint in OpenAPI", path)
+		}
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rpc/client.gen.ts", nil))
+	assertRPCContains(t, rec.Body.String(), `let url = basepath + "/rpc/_jsonrpc"`)
+}
@@ -0,0 +1,501 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/swetjen/virtuous/internal/adminui"
+	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/internal/jsonlimit"
+	"github.com/swetjen/virtuous/internal/jsonrpc"
+	"github.com/swetjen/virtuous/schema"
+)
+
+// MCPExposure controls whether a route is listed as an MCP tool.
+type MCPExposure int
+
+const (
+	// MCPDefault routes are listed only when ServeMCP uses WithMCPExposeAll.
+	MCPDefault MCPExposure = iota
+	// MCPExposed routes are always listed.
+	MCPExposed
+	// MCPHidden routes are never listed.
+	MCPHidden
+)
+
+// ExposeMCP lists a handler as a tool on the router's MCP endpoint.
+func ExposeMCP() HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.mcp = MCPExposed
+	})
+}
+
+// HideMCP keeps a handler off the MCP endpoint, even with WithMCPExposeAll.
+func HideMCP() HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.mcp = MCPHidden
+	})
+}
+
+const mcpLatestProtocolVersion = "2025-06-18"
+
+var mcpProtocolVersions = []string{mcpLatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// MCPOptions configures the MCP endpoint.
+type MCPOptions struct {
+	// Path is the streamable HTTP endpoint. Defaults to "<prefix>/_mcp".
+	Path string
+	// Guards protect the MCP endpoint itself. Route guards still apply to
+	// every tool call.
+	Guards []Guard
+	// ExposeAll lists every non-streaming route that is not hidden with
+	// HideMCP. By default only routes registered with ExposeMCP are listed.
+	ExposeAll bool
+	// Headers are added to every forwarded tool call, such as credentials
+	// for stdio servers where there is no incoming HTTP request.
+	Headers http.Header
+	// AllowedOrigins lists browser origins accepted besides the request host.
+	AllowedOrigins []string
+}
+
+// MCPOpt mutates MCPOptions.
+type MCPOpt func(*MCPOptions)
+
+// WithMCPPath overrides the MCP endpoint path.
+func WithMCPPath(path string) MCPOpt {
+	return func(o *MCPOptions) {
+		if path != "" {
+			o.Path = ensureLeadingSlash(path)
+		}
+	}
+}
+
+// WithMCPGuards applies guards to the MCP endpoint.
+func WithMCPGuards(guards ...Guard) MCPOpt {
+	return func(o *MCPOptions) {
+		o.Guards = append(o.Guards, guards...)
+	}
+}
+
+// WithMCPExposeAll lists every route that is not hidden with HideMCP.
+func WithMCPExposeAll() MCPOpt {
+	return func(o *MCPOptions) {
+		o.ExposeAll = true
+	}
+}
+
+// WithMCPHeader adds a header to every forwarded tool call.
+func WithMCPHeader(name, value string) MCPOpt {
+	return func(o *MCPOptions) {
+		if o.Headers == nil {
+			o.Headers = http.Header{}
+		}
+		o.Headers.Add(name, value)
+	}
+}
+
+// WithMCPAllowedOrigins accepts browser requests from the given origins.
+func WithMCPAllowedOrigins(origins ...string) MCPOpt {
+	return func(o *MCPOptions) {
+		o.AllowedOrigins = append(o.AllowedOrigins, origins...)
+	}
+}
+
+func (r *Router) applyMCPOpts(opts ...MCPOpt) MCPOptions {
+	config := MCPOptions{
+		Path: ensureLeadingSlash(strings.TrimSuffix(normalizePrefix(r.prefix), "/") + "/_mcp"),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// MCPHandler returns an http.Handler serving the Model Context Protocol over
+// streamable HTTP. Registered RPCs are listed as tools, and tool calls are
+// forwarded through the router so guards, interceptors, and observability
+// apply as for any other request.
+func (r *Router) MCPHandler(opts ...MCPOpt) http.Handler {
+	server := &mcpServer{router: r, config: r.applyMCPOpts(opts...)}
+	return wrapWithGuards(http.HandlerFunc(server.serveHTTP), server.config.Guards)
+}
+
+// ServeMCP registers the MCP endpoint on the router.
+func (r *Router) ServeMCP(opts ...MCPOpt) {
+	config := r.applyMCPOpts(opts...)
+	r.mux.Handle(config.Path, r.MCPHandler(opts...))
+
+	if r.events == nil {
+		r.events = adminui.NewEventFeed(600)
+	}
+	r.events.RecordSystem("mcp online: " + config.Path)
+	r.logger.Info("rpc mcp online", "path", config.Path)
+}
+
+// ServeMCPStdio serves the Model Context Protocol over newline-delimited
+// JSON-RPC messages until in is exhausted or ctx is canceled.
+func (r *Router) ServeMCPStdio(ctx context.Context, in io.Reader, out io.Writer, opts ...MCPOpt) error {
+	server := &mcpServer{router: r, config: r.applyMCPOpts(opts...)}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), int(max(r.maxBodyBytes, jsonlimit.DefaultMaxBytes)))
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		payload, ok := server.process(forwardedCall{ctx: ctx}, line)
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+type mcpServer struct {
+	router *Router
+	config MCPOptions
+}
+
+type mcpTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"inputSchema"`
+	route       Route
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type mcpToolResult struct {
+	Content           []mcpContent    `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+func (s *mcpServer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.allowOrigin(req) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	reader, err := jsonlimit.LimitReader(req, s.router.maxBodyBytes)
+	var body []byte
+	if err == nil {
+		body, err = io.ReadAll(reader)
+	}
+	if err != nil {
+		if jsonlimit.IsBodyTooLarge(err) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payload, ok := s.process(forwardedCall{ctx: req.Context(), header: req.Header, rawQuery: req.URL.RawQuery, remoteAddr: req.RemoteAddr}, body)
+	if !ok {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if prefersEventStream(req.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", MediaTypeEventStream)
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(data)
+}
+
+// allowOrigin rejects cross-origin browser requests to guard local servers
+// against DNS rebinding.
+func (s *mcpServer) allowOrigin(req *http.Request) bool {
+	origin := strings.TrimSpace(req.Header.Get("Origin"))
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, req.Host)
+}
+
+// prefersEventStream reports whether the client accepts SSE but not JSON.
+func prefersEventStream(accept string) bool {
+	return strings.Contains(accept, MediaTypeEventStream) && !strings.Contains(accept, "application/json")
+}
+
+// process handles one JSON-RPC message or batch. It reports false when the
+// input held only notifications and nothing should be written back.
+func (s *mcpServer) process(call forwardedCall, data []byte) (any, bool) {
+	requests, invalid, batch, err := jsonrpc.Decode(data)
+	if err != nil {
+		return jsonrpc.Failure(nil, jsonrpc.CodeParseError, "parse error", nil), true
+	}
+	if batch && len(requests) == 0 {
+		return jsonrpc.Failure(nil, jsonrpc.CodeInvalidRequest, "invalid request", nil), true
+	}
+	responses := make([]jsonrpc.Response, 0, len(requests))
+	for i, req := range requests {
+		if invalid[i] != nil {
+			responses = append(responses, *invalid[i])
+			continue
+		}
+		resp := s.handle(call, req)
+		if req.IsNotification() {
+			continue
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) == 0 {
+		return nil, false
+	}
+	if !batch {
+		return responses[0], true
+	}
+	return responses, true
+}
+
+func (s *mcpServer) handle(call forwardedCall, req jsonrpc.Request) jsonrpc.Response {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		return jsonrpc.Result(req.ID, map[string]any{
+			"protocolVersion": negotiateMCPVersion(params.ProtocolVersion),
+			"capabilities": map[string]any{
+				"tools": map[string]any{"listChanged": false},
+			},
+			"serverInfo": map[string]any{
+				"name":    s.router.docsTitle("Virtuous RPC"),
+				"version": s.serverVersion(),
+			},
+		})
+	case "ping", "notifications/initialized", "notifications/cancelled":
+		return jsonrpc.Result(req.ID, map[string]any{})
+	case "tools/list":
+		tools, err := s.tools()
+		if err != nil {
+			return jsonrpc.Failure(req.ID, jsonrpc.CodeInternalError, err.Error(), nil)
+		}
+		return jsonrpc.Result(req.ID, map[string]any{"tools": tools})
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return jsonrpc.Failure(req.ID, jsonrpc.CodeInvalidParams, "tools/call requires a tool name", nil)
+		}
+		tool, ok := s.tool(params.Name)
+		if !ok {
+			return jsonrpc.Failure(req.ID, jsonrpc.CodeInvalidParams, "unknown tool: "+params.Name, nil)
+		}
+		return jsonrpc.Result(req.ID, s.callTool(call, tool.route, params.Arguments))
+	default:
+		return jsonrpc.Failure(req.ID, jsonrpc.CodeMethodNotFound, "method not found: "+req.Method, nil)
+	}
+}
+
+func negotiateMCPVersion(requested string) string {
+	for _, version := range mcpProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return mcpLatestProtocolVersion
+}
+
+func (s *mcpServer) serverVersion() string {
+	if s.router.openAPIOptions != nil && strings.TrimSpace(s.router.openAPIOptions.Version) != "" {
+		return strings.TrimSpace(s.router.openAPIOptions.Version)
+	}
+	return clientgen.VirtuousVersionLabel()
+}
+
+func (s *mcpServer) exposed(route Route) bool {
+	if route.Streaming || route.Service == "" || route.Method == "" {
+		return false
+	}
+	switch route.MCP {
+	case MCPExposed:
+		return true
+	case MCPHidden:
+		return false
+	default:
+		return s.config.ExposeAll
+	}
+}
+
+func (s *mcpServer) tool(name string) (mcpTool, bool) {
+	for _, route := range s.router.Routes() {
+		if s.exposed(route) && mcpToolName(route) == name {
+			return mcpTool{Name: name, route: route}, true
+		}
+	}
+	return mcpTool{}, false
+}
+
+// tools lists exposed routes with input schemas from the same generator used
+// for OpenAPI, rewritten as self-contained JSON Schema documents.
+func (s *mcpServer) tools() ([]mcpTool, error) {
+	tools := []mcpTool{}
+	for _, route := range s.router.Routes() {
+		if !s.exposed(route) {
+			continue
+		}
+		inputSchema, err := s.inputSchema(route)
+		if err != nil {
+			return nil, err
+		}
+		description := joinParagraphs(route.Docs.Summary, route.Docs.Description)
+		if route.Docs.Deprecated {
+			description = joinParagraphs(description, strings.TrimSpace("Deprecated: "+route.Docs.DeprecationMessage))
+		}
+		tools = append(tools, mcpTool{
+			Name:        mcpToolName(route),
+			Description: description,
+			InputSchema: inputSchema,
+			route:       route,
+		})
+	}
+	return tools, nil
+}
+
+func mcpToolName(route Route) string {
+	return route.Service + "_" + route.Method
+}
+
+func (s *mcpServer) inputSchema(route Route) (any, error) {
+	if route.RequestType == nil {
+		return &schema.OpenAPISchema{Type: "object"}, nil
+	}
+	gen := schema.NewGenerator(s.router.typeOverrides)
+	root := gen.SchemaForType(route.RequestType)
+	if root == nil {
+		return nil, errors.New("rpc: no input schema for " + route.Path)
+	}
+	components := gen.Components()
+	if name, ok := strings.CutPrefix(root.Ref, openAPIComponentPrefix); ok {
+		component, found := components[name]
+		if !found {
+			return nil, errors.New("rpc: missing schema component " + name)
+		}
+		root = &component
+	}
+	defs := make(map[string]*schema.OpenAPISchema, len(components))
+	for name, component := range components {
+		component := component
+		rewriteSchemaRefs(&component)
+		defs[name] = &component
+	}
+	rewriteSchemaRefs(root)
+	if len(defs) == 0 {
+		return root, nil
+	}
+	return mcpInputSchema{OpenAPISchema: root, Defs: defs}, nil
+}
+
+const openAPIComponentPrefix = "#/components/schemas/"
+
+// mcpInputSchema adds JSON Schema $defs to a root schema.
+type mcpInputSchema struct {
+	*schema.OpenAPISchema
+	Defs map[string]*schema.OpenAPISchema `json:"$defs"`
+}
+
+// rewriteSchemaRefs points OpenAPI component refs at the local $defs.
+func rewriteSchemaRefs(s *schema.OpenAPISchema) {
+	if s == nil {
+		return
+	}
+	if name, ok := strings.CutPrefix(s.Ref, openAPIComponentPrefix); ok {
+		s.Ref = "#/$defs/" + name
+	}
+	for _, prop := range s.Properties {
+		rewriteSchemaRefs(prop)
+	}
+	rewriteSchemaRefs(s.Items)
+	rewriteSchemaRefs(s.AdditionalProperties)
+	for _, sub := range s.AllOf {
+		rewriteSchemaRefs(sub)
+	}
+	for _, sub := range s.OneOf {
+		rewriteSchemaRefs(sub)
+	}
+}
+
+// callTool forwards a tool call to the route as a regular RPC request.
+func (s *mcpServer) callTool(call forwardedCall, route Route, arguments json.RawMessage) mcpToolResult {
+	var body []byte
+	if route.RequestType != nil {
+		body = bytes.TrimSpace(arguments)
+		if len(body) == 0 || bytes.Equal(body, []byte("null")) {
+			body = []byte("{}")
+		}
+	}
+	if len(s.config.Headers) > 0 {
+		header := call.header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		for name, values := range s.config.Headers {
+			header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+		call.header = header
+	}
+	status, data, err := s.router.dispatch(call, route, body)
+	if err != nil {
+		return mcpErrorResult(err.Error())
+	}
+	text := string(data)
+	if status != http.StatusOK {
+		return mcpErrorResult(fmt.Sprintf("HTTP %d: %s", status, text))
+	}
+	result := mcpToolResult{Content: []mcpContent{{Type: "text", Text: text}}}
+	if strings.HasPrefix(text, "{") && json.Valid(data) {
+		result.StructuredContent = json.RawMessage(data)
+	}
+	return result
+}
+
+func mcpErrorResult(text string) mcpToolResult {
+	return mcpToolResult{Content: []mcpContent{{Type: "text", Text: text}}, IsError: true}
+}
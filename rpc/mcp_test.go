@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type mcpFilter struct {
+	Tag string `json:"tag"`
+}
+
+type mcpSearchReq struct {
+	Query  string    `json:"query" doc:"Free-text search query."`
+	Filter mcpFilter `json:"filter"`
+}
+
+func mcpSearch(_ context.Context, req mcpSearchReq) (greetResp, int) {
+	return greetResp{Message: req.Query + ":" + req.Filter.Tag}, StatusOK
+}
+
+func adminPurge(_ context.Context, _ greetReq) (greetResp, int) {
+	return greetResp{Message: "purged"}, StatusOK
+}
+
+type mcpResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func postMCP(t *testing.T, router *Router, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rpc/_mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeMCP(t *testing.T, data []byte) mcpResponse {
+	t.Helper()
+	var resp mcpResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("decode mcp response: %v (%s)", err, data)
+	}
+	return resp
+}
+
+func TestRPCMCPListsOptedInTools(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(mcpSearch, ExposeMCP(), Summary("Search things"))
+	router.HandleRPC(greet)
+	router.HandleRPC(adminPurge, HideMCP())
+	router.ServeMCP()
+
+	init := decodeMCP(t, postMCP(t, router, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`, nil).Body.Bytes())
+	if init.Error != nil || !strings.Contains(string(init.Result), `"protocolVersion":"2025-03-26"`) || !strings.Contains(string(init.Result), `"tools"`) {
+		t.Fatalf("unexpected initialize result: %s", init.Result)
+	}
+	if rec := postMCP(t, router, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, nil); rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Fatalf("expected 202 for notification, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	list := decodeMCP(t, postMCP(t, router, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, nil).Body.Bytes())
+	var result struct {
+		Tools []struct {
+			Name        string         `json:"name"`
+			Description string         `json:"description"`
+			InputSchema map[string]any `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(list.Result, &result); err != nil {
+		t.Fatalf("decode tools: %v", err)
+	}
+	if len(result.Tools) != 1 || result.Tools[0].Name != "rpc_mcpSearch" || result.Tools[0].Description != "Search things" {
+		t.Fatalf("unexpected tools: %+v", result.Tools)
+	}
+	schemaJSON, _ := json.Marshal(result.Tools[0].InputSchema)
+	for _, want := range []string{
+		`"type":"object"`,
+		`"description":"Free-text search query."`,
+		`"$ref":"#/$defs/mcpFilter"`,
+		`"$defs":{`,
+	} {
+		if !strings.Contains(string(schemaJSON), want) {
+			t.Fatalf("expected %s in input schema: %s", want, schemaJSON)
+		}
+	}
+
+	all := NewRouter()
+	all.HandleRPC(greet)
+	all.HandleRPC(adminPurge, HideMCP())
+	all.ServeMCP(WithMCPExposeAll())
+	listAll := postMCP(t, all, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`, nil).Body.String()
+	if !strings.Contains(listAll, `"rpc_greet"`) || strings.Contains(listAll, "adminPurge") {
+		t.Fatalf("unexpected expose-all tools: %s", listAll)
+	}
+}
+
+func TestRPCMCPCallForwardsThroughGuards(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(mcpSearch, ExposeMCP(), denyUnlessHeaderGuard{})
+	router.ServeMCP()
+	call := `{"jsonrpc":"2.0","id":"c1","method":"tools/call","params":{"name":"rpc_mcpSearch","arguments":{"query":"go","filter":{"tag":"x"}}}}`
+
+	denied := decodeMCP(t, postMCP(t, router, call, nil).Body.Bytes())
+	if denied.Error != nil || !strings.Contains(string(denied.Result), `"isError":true`) || !strings.Contains(string(denied.Result), "HTTP 401") {
+		t.Fatalf("expected guarded tool error, got %s", denied.Result)
+	}
+
+	allowed := decodeMCP(t, postMCP(t, router, call, http.Header{"Authorization": {"Bearer token"}}).Body.Bytes())
+	if string(allowed.ID) != `"c1"` || strings.Contains(string(allowed.Result), "isError") {
+		t.Fatalf("unexpected tool result: %s", allowed.Result)
+	}
+	if !strings.Contains(string(allowed.Result), `"structuredContent":{"message":"go:x"}`) {
+		t.Fatalf("expected structured content, got %s", allowed.Result)
+	}
+
+	metrics := router.observability.Snapshot()
+	if metrics.Totals.RequestsLast24H != 2 {
+		t.Fatalf("expected forwarded calls in observability, got %+v", metrics.Totals)
+	}
+
+	unknown := decodeMCP(t, postMCP(t, router, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"rpc_greet"}}`, nil).Body.Bytes())
+	if unknown.Error == nil || unknown.Error.Code != -32602 {
+		t.Fatalf("expected invalid params for unknown tool, got %+v", unknown)
+	}
+}
+
+func TestRPCMCPTransportChecks(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet, ExposeMCP())
+	router.ServeMCP(WithMCPAllowedOrigins("https://agent.example"))
+
+	get := httptest.NewRecorder()
+	router.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/rpc/_mcp", nil))
+	if get.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", get.Code)
+	}
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	if rec := postMCP(t, router, ping, http.Header{"Origin": {"https://evil.example"}}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for foreign origin, got %d", rec.Code)
+	}
+	if rec := postMCP(t, router, ping, http.Header{"Origin": {"https://agent.example"}}); rec.Code != http.StatusOK {
+		t.Fatalf("expected allowed origin, got %d", rec.Code)
+	}
+
+	sse := postMCP(t, router, ping, http.Header{"Accept": {"text/event-stream"}})
+	if sse.Header().Get("Content-Type") != MediaTypeEventStream || !strings.HasPrefix(sse.Body.String(), "event: message\ndata: {") {
+		t.Fatalf("expected SSE response, got %q %q", sse.Header().Get("Content-Type"), sse.Body.String())
+	}
+
+	parse := decodeMCP(t, postMCP(t, router, `{`, nil).Body.Bytes())
+	if parse.Error == nil || parse.Error.Code != -32700 {
+		t.Fatalf("expected parse error, got %+v", parse)
+	}
+	missing := decodeMCP(t, postMCP(t, router, `{"jsonrpc":"2.0","id":5,"method":"resources/list"}`, nil).Body.Bytes())
+	if missing.Error == nil || missing.Error.Code != -32601 {
+		t.Fatalf("expected method not found, got %+v", missing)
+	}
+}
+
+func TestRPCMCPStdio(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet, ExposeMCP(), denyUnlessHeaderGuard{})
+	in := strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2099-01-01"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"rpc_greet","arguments":{"name":"Ada"}}}`,
+	}, "\n"))
+	var out bytes.Buffer
+	if err := router.ServeMCPStdio(context.Background(), in, &out, WithMCPHeader("Authorization", "Bearer token")); err != nil {
+		t.Fatalf("serve stdio: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 responses, got %q", out.String())
+	}
+	if !strings.Contains(lines[0], `"protocolVersion":"2025-06-18"`) {
+		t.Fatalf("expected latest protocol version, got %s", lines[0])
+	}
+	call := decodeMCP(t, []byte(lines[1]))
+	if !strings.Contains(string(call.Result), `hello Ada`) || strings.Contains(string(call.Result), "isError") {
+		t.Fatalf("unexpected stdio tool result: %s", call.Result)
+	}
+}
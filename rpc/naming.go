@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// ServiceNaming derives a handler's service name from its package import
+// path. The service name is the route path segment after the prefix and the
+// namespace in generated clients, MCP tool names, and JSON-RPC method names.
+type ServiceNaming func(pkgPath string) string
+
+// WithServiceNaming sets how service names are derived from import paths.
+// The default uses the last element of the import path.
+func WithServiceNaming(naming ServiceNaming) RouterOption {
+	return func(o *RouterOptions) {
+		o.ServiceNaming = naming
+	}
+}
+
+// ImportPathNaming derives service names from the import path below
+// moduleRoot, joining the remaining elements with underscores. With root
+// "example.com/app/internal", package "example.com/app/internal/admin/users"
+// becomes service "admin_users". Packages outside moduleRoot use the last
+// element of their import path.
+func ImportPathNaming(moduleRoot string) ServiceNaming {
+	root := strings.Trim(moduleRoot, "/")
+	return func(pkgPath string) string {
+		rel := ""
+		if root != "" && strings.HasPrefix(pkgPath, root+"/") {
+			rel = strings.TrimPrefix(pkgPath, root+"/")
+		}
+		if rel == "" {
+			rel = path.Base(pkgPath)
+		}
+		return serviceIdentifier(rel)
+	}
+}
+
+// ServiceName registers a handler under name instead of the service name
+// derived from its package, for example to separate two packages that are
+// both called users.
+func ServiceName(name string) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.service = strings.TrimSpace(name)
+	})
+}
+
+// resolveService applies the ServiceName option or the router's naming
+// strategy to spec and checks that the name is not already used by handlers
+// from another package.
+func (r *Router) resolveService(spec handlerSpec, config handlerConfig) (handlerSpec, error) {
+	service := config.service
+	if service == "" && r.serviceNaming != nil {
+		service = r.serviceNaming(spec.pkgPath)
+	}
+	if service != "" && service != spec.service {
+		if !validServiceName(service) {
+			return handlerSpec{}, errors.New("rpc: invalid service name " + service + " for " + spec.fullName + "; use letters, digits, and underscores")
+		}
+		spec = spec.withService(r.prefix, service)
+	}
+	if owner, ok := r.servicePackages[spec.service]; ok && owner != spec.pkgPath {
+		return handlerSpec{}, errors.New("rpc: service name " + spec.service + " is used by both " + owner + " and " + spec.pkgPath +
+			"; set rpc.ServiceName on one of them or use rpc.WithServiceNaming(rpc.ImportPathNaming(moduleRoot))")
+	}
+	return spec, nil
+}
+
+func validServiceName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, ch := range name {
+		switch {
+		case ch == '_', ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z':
+		case ch >= '0' && ch <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func serviceIdentifier(rel string) string {
+	var b strings.Builder
+	for _, ch := range rel {
+		switch {
+		case ch == '_', ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+			b.WriteRune(ch)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
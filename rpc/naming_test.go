@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// registerAs mounts fn as if it were declared with the qualified name
+// fullName, so tests can simulate handlers from other packages.
+func registerAs(router *Router, fn any, fullName string, opts ...HandlerOption) error {
+	spec, err := parseHandlerSignature(reflect.ValueOf(fn))
+	if err != nil {
+		return err
+	}
+	dot := strings.LastIndex(fullName, ".")
+	pkgPath, funcName := fullName[:dot], fullName[dot+1:]
+	spec, err = spec.named(router.prefix, fullName, pkgPath[strings.LastIndex(pkgPath, "/")+1:], funcName)
+	if err != nil {
+		return err
+	}
+	spec, config, err := router.prepare(spec, opts)
+	if err != nil {
+		return err
+	}
+	router.mount(spec, config)
+	return nil
+}
+
+func TestRPCServiceNameCollisionIsReported(t *testing.T) {
+	router := NewRouter()
+	if err := registerAs(router, greet, "example.com/app/internal/admin/users.UserCreate"); err != nil {
+		t.Fatalf("register admin users: %v", err)
+	}
+	err := registerAs(router, greet, "example.com/app/internal/public/users.UserList")
+	if err == nil || !strings.Contains(err.Error(), "used by both example.com/app/internal/admin/users and example.com/app/internal/public/users") {
+		t.Fatalf("expected collision report, got %v", err)
+	}
+	if err := registerAs(router, greet, "example.com/app/internal/public/users.UserList", ServiceName("public_users")); err != nil {
+		t.Fatalf("expected alias to resolve collision: %v", err)
+	}
+	if err := registerAs(router, greet, "example.com/app/internal/admin/users.UserDelete"); err != nil {
+		t.Fatalf("expected same package to share its service: %v", err)
+	}
+	if err := registerAs(router, greet, "example.com/app/internal/admin/users.UserPurge", ServiceName("admin-users")); err == nil {
+		t.Fatalf("expected invalid service name to be rejected")
+	}
+
+	var paths []string
+	for _, route := range router.Routes() {
+		paths = append(paths, route.Service+" "+route.Path)
+	}
+	want := []string{"users /rpc/users/user-create", "public_users /rpc/public_users/user-list", "users /rpc/users/user-delete"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("unexpected routes %v", paths)
+	}
+}
+
+func TestRPCImportPathNamingCarriesIntoClients(t *testing.T) {
+	router := NewRouter(WithServiceNaming(ImportPathNaming("example.com/app/internal")))
+	for _, name := range []string{
+		"example.com/app/internal/admin/users.UserCreate",
+		"example.com/app/internal/public/users.UserCreate",
+		"example.com/other/billing.InvoiceGet",
+	} {
+		if err := registerAs(router, greet, name); err != nil {
+			t.Fatalf("register %s: %v", name, err)
+		}
+	}
+	var paths []string
+	for _, route := range router.Routes() {
+		paths = append(paths, route.Path)
+	}
+	want := []string{"/rpc/admin_users/user-create", "/rpc/public_users/user-create", "/rpc/billing/invoice-get"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("unexpected paths %v", paths)
+	}
+
+	var ts, py bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write py client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), "admin_users: {")
+	assertRPCContains(t, ts.String(), "public_users: {")
+	assertRPCContains(t, py.String(), "class _admin_usersService:")
+	assertRPCContains(t, py.String(), "self.public_users = _public_usersService(base_url, options)")
+}
+
+func TestRPCHandleServiceAcceptsServiceName(t *testing.T) {
+	router := NewRouter()
+	if err := router.HandleService(&accountService{greeting: "hi"}, ServiceName("accounts")); err != nil {
+		t.Fatalf("handle service: %v", err)
+	}
+	for _, route := range router.Routes() {
+		if route.Service != "accounts" || !strings.HasPrefix(route.Path, "/rpc/accounts/") {
+			t.Fatalf("expected aliased service, got %s %s", route.Service, route.Path)
+		}
+	}
+}
+
+func TestFuncPkgPath(t *testing.T) {
+	for name, want := range map[string]string{
+		"example.com/app/users.UserCreate":           "example.com/app/users",
+		"example.com/app/users.(*Service).Create-fm": "example.com/app/users",
+		"example.com/app.v2/users.Get[...]":          "example.com/app.v2/users",
+		"main.greet":                                 "main",
+	} {
+		if got := funcPkgPath(name); got != want {
+			t.Fatalf("funcPkgPath(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/swetjen/virtuous/guard"
 	"github.com/swetjen/virtuous/internal/adminui"
 )
 
@@ -67,13 +68,35 @@ func observabilitySampleRate(opts *AdvancedObservabilityOptions) float64 {
 	return opts.SampleRate
 }
 
+// RequestEvent is the observability record of one RPC call.
+type RequestEvent = adminui.RequestEvent
+
+// GuardDecision records one guard's allow or deny result for a call.
+type GuardDecision = adminui.GuardDecisionEvent
+
+// CallObserver receives the observability record and guard decisions of a
+// call once it finishes.
+type CallObserver func(event RequestEvent, guards []GuardDecision)
+
+type callObserverKey struct{}
+
+// WithCallObserver returns a copy of ctx that reports every RPC call served
+// with it to observe, in addition to the router's own tracking. Tests use it
+// to assert on what a call recorded; see the rpctest package.
+func WithCallObserver(ctx context.Context, observe CallObserver) context.Context {
+	return context.WithValue(ctx, callObserverKey{}, observe)
+}
+
 type requestTraceKey struct{}
 
 type requestTrace struct {
 	guards         []guardDecision
 	guardDenied    bool
+	principal      string
 	errorMessage   string
 	stackSignature string
+	timedOut       bool
+	incidentID     string
 }
 
 type guardDecision struct {
@@ -90,6 +113,7 @@ func (r *Router) wrapRPCHandler(spec handlerSpec, h http.Handler, guards []Guard
 	rpcName := rpcName(spec)
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		trace := &requestTrace{}
+		trace.recordPrincipal(req.Context())
 		req = req.WithContext(context.WithValue(req.Context(), requestTraceKey{}, trace))
 		recorder := &observabilityRecorder{ResponseWriter: w}
 		started := time.Now()
@@ -99,15 +123,20 @@ func (r *Router) wrapRPCHandler(spec handlerSpec, h http.Handler, guards []Guard
 		defer func() {
 			finishedAt = time.Now().UTC()
 			if rec := recover(); rec != nil {
-				recovered = rec
-				trace.setPanic(rec)
+				stack := debug.Stack()
+				trace.setPanic(rec, stack)
+				if r.recoverPanics && rec != http.ErrAbortHandler {
+					trace.incidentID = r.recoverPanic(recorder, req, recorder.Status() != 0, rpcName, rec, stack)
+				} else {
+					recovered = rec
+				}
 			}
 
 			status := recorder.Status()
 			if status == 0 && trace.guardDenied {
 				status = http.StatusUnauthorized
 			}
-			if status == 0 && recovered != nil {
+			if (status == 0 && recovered != nil) || trace.incidentID != "" {
 				status = StatusError
 			}
 			if status == 0 {
@@ -131,7 +160,7 @@ func (r *Router) wrapRPCHandler(spec handlerSpec, h http.Handler, guards []Guard
 					Allowed:   decision.allowed,
 				})
 			}
-			r.observability.RecordRequest(adminui.RequestEvent{
+			event := adminui.RequestEvent{
 				RPCName:        rpcName,
 				Path:           spec.path,
 				HTTPMethod:     req.Method,
@@ -139,9 +168,16 @@ func (r *Router) wrapRPCHandler(spec handlerSpec, h http.Handler, guards []Guard
 				DurationMS:     time.Since(started).Milliseconds(),
 				Timestamp:      finishedAt,
 				GuardOutcome:   trace.guardOutcome(),
+				Principal:      trace.principal,
 				ErrorMessage:   trace.errorMessage,
 				StackSignature: trace.stackSignature,
-			}, decisions)
+				TimedOut:       trace.timedOut,
+				IncidentID:     trace.incidentID,
+			}
+			r.observability.RecordRequest(event, decisions)
+			if observe, _ := req.Context().Value(callObserverKey{}).(CallObserver); observe != nil {
+				observe(event, decisions)
+			}
 
 			if recovered != nil {
 				panic(recovered)
@@ -174,6 +210,7 @@ func wrapWithObservedGuards(h http.Handler, guards []Guard) http.Handler {
 			passed := false
 			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				passed = true
+				trace.recordPrincipal(r.Context())
 				next.ServeHTTP(w, r)
 			})).ServeHTTP(w, r)
 			if guardName != "" {
@@ -206,12 +243,32 @@ func setTraceError(ctx context.Context, message string) {
 	}
 }
 
-func (t *requestTrace) setPanic(rec any) {
+func setTraceTimeout(ctx context.Context) {
+	trace := requestTraceFromContext(ctx)
+	if trace == nil {
+		return
+	}
+	trace.timedOut = true
+	trace.errorMessage = "deadline exceeded"
+}
+
+func (t *requestTrace) setPanic(rec any, stack []byte) {
 	if t == nil {
 		return
 	}
 	t.errorMessage = strings.TrimSpace(fmt.Sprint(rec))
-	t.stackSignature = stackSignature(debug.Stack())
+	t.stackSignature = stackSignature(stack)
+}
+
+// recordPrincipal stores the identifier of the principal attached by a guard.
+// Raw principals are never recorded; see guard.PrincipalID.
+func (t *requestTrace) recordPrincipal(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	if principal, ok := guard.PrincipalFrom(ctx); ok {
+		t.principal = guard.PrincipalID(principal)
+	}
 }
 
 func (t *requestTrace) guardOutcome() string {
@@ -326,6 +383,16 @@ func (r *observabilityRecorder) Write(b []byte) (int, error) {
 	return r.ResponseWriter.Write(b)
 }
 
+func (r *observabilityRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *observabilityRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func (r *observabilityRecorder) Status() int {
 	if r == nil {
 		return 0
@@ -15,6 +15,8 @@ import (
 func (r *Router) OpenAPI() ([]byte, error) {
 	routes := r.Routes()
 	gen := schema.NewGenerator(r.typeOverrides)
+	gen.PreferNameOf(errorType, errorBodySchemaName)
+	gen.PreferNameOf(errorDetailType, errorDetailSchemaName)
 	paths := make(map[string]map[string]*openAPIOperation)
 	securitySchemes := make(map[string]openAPISecurityScheme)
 
@@ -25,6 +27,7 @@ func (r *Router) OpenAPI() ([]byte, error) {
 		if route.Service != "" {
 			op.Tags = []string{titleTag(route.Service)}
 		}
+		applyRouteDocs(op, route.Docs)
 
 		if len(route.Guards) > 0 {
 			var secReq []map[string][]string
@@ -54,29 +57,84 @@ func (r *Router) OpenAPI() ([]byte, error) {
 			return nil, errors.New("rpc: response type is required for " + route.Path)
 		}
 		respSchema := gen.SchemaForType(route.ResponseType)
+		if route.Streaming {
+			op.Description = joinParagraphs(op.Description, "Streams response messages as Server-Sent Events.")
+			op.Responses["200"] = openAPIResponse{
+				Description: http.StatusText(http.StatusOK),
+				Content: map[string]openAPIMedia{
+					MediaTypeEventStream: {Schema: respSchema},
+				},
+			}
+			op.Responses["422"] = openAPIResponse{
+				Description: http.StatusText(http.StatusUnprocessableEntity),
+			}
+			op.Responses["500"] = openAPIResponse{
+				Description: http.StatusText(http.StatusInternalServerError),
+			}
+			r.addCodecMediaTypes(op)
+			if _, ok := paths[route.Path]; !ok {
+				paths[route.Path] = make(map[string]*openAPIOperation)
+			}
+			paths[route.Path]["post"] = op
+			continue
+		}
 		op.Responses["200"] = openAPIResponse{
 			Description: http.StatusText(http.StatusOK),
 			Content: map[string]openAPIMedia{
 				"application/json": {Schema: respSchema},
 			},
 		}
+		errSchema := respSchema
+		if route.ErrorType != nil {
+			errSchema = gen.SchemaForType(route.ErrorType)
+		}
+		invalidSchema := errSchema
+		if route.ErrorType == nil && validatesRequest(route) {
+			invalidSchema = &schema.OpenAPISchema{
+				OneOf: []*schema.OpenAPISchema{respSchema, gen.SchemaForType(errorType)},
+			}
+		}
 		op.Responses["422"] = openAPIResponse{
 			Description: http.StatusText(http.StatusUnprocessableEntity),
 			Content: map[string]openAPIMedia{
-				"application/json": {Schema: respSchema},
+				"application/json": {Schema: invalidSchema},
 			},
 		}
 		op.Responses["500"] = openAPIResponse{
 			Description: http.StatusText(http.StatusInternalServerError),
 			Content: map[string]openAPIMedia{
-				"application/json": {Schema: respSchema},
+				"application/json": {Schema: errSchema},
 			},
 		}
+		if route.Timeout > 0 {
+			op.Responses["504"] = openAPIResponse{
+				Description: http.StatusText(http.StatusGatewayTimeout),
+				Content: map[string]openAPIMedia{
+					"application/json": {Schema: gen.SchemaForType(errorType)},
+				},
+			}
+		}
+		if route.Idempotent {
+			applyIdempotencyDocs(op, gen.SchemaForType(errorType))
+		}
+		r.addCodecMediaTypes(op)
 
 		if _, ok := paths[route.Path]; !ok {
 			paths[route.Path] = make(map[string]*openAPIOperation)
 		}
 		paths[route.Path]["post"] = op
+		if route.ReadOnly {
+			paths[route.Path]["get"] = readOnlyOperation(op)
+		}
+	}
+
+	components := gen.Components()
+	if r.jsonRPC != nil {
+		op, jsonRPCComponents := r.jsonRPCOpenAPI(routes, securitySchemes)
+		paths[r.jsonRPC.Path] = map[string]*openAPIOperation{"post": op}
+		for name, component := range jsonRPCComponents {
+			components[name] = component
+		}
 	}
 
 	opts := openAPIDefaults
@@ -94,7 +152,7 @@ func (r *Router) OpenAPI() ([]byte, error) {
 		},
 		Paths: paths,
 		Components: openAPIComponents{
-			Schemas:         gen.Components(),
+			Schemas:         components,
 			SecuritySchemes: securitySchemes,
 		},
 		Tags:         openAPITags(opts.Tags),
@@ -163,6 +221,31 @@ type openAPIOperation struct {
 	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
 	Responses   map[string]openAPIResponse `json:"responses"`
 	Security    []map[string][]string      `json:"security,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+}
+
+func applyRouteDocs(op *openAPIOperation, docs RouteDocs) {
+	op.Summary = docs.Summary
+	op.Description = docs.Description
+	if len(docs.Tags) > 0 {
+		op.Tags = append([]string(nil), docs.Tags...)
+	}
+	if docs.Deprecated {
+		op.Deprecated = true
+		if docs.DeprecationMessage != "" {
+			op.Description = joinParagraphs(op.Description, "Deprecated: "+docs.DeprecationMessage)
+		}
+	}
+}
+
+func joinParagraphs(parts ...string) string {
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return strings.Join(out, "\n\n")
 }
 
 type openAPIRequestBody struct {
@@ -204,10 +287,12 @@ type openAPIMedia struct {
 }
 
 type openAPIParameter struct {
-	Name     string               `json:"name"`
-	In       string               `json:"in"`
-	Required bool                 `json:"required"`
-	Schema   schema.OpenAPISchema `json:"schema"`
+	Name        string                  `json:"name"`
+	In          string                  `json:"in"`
+	Required    bool                    `json:"required"`
+	Description string                  `json:"description,omitempty"`
+	Schema      *schema.OpenAPISchema   `json:"schema,omitempty"`
+	Content     map[string]openAPIMedia `json:"content,omitempty"`
 }
 
 // OpenAPIOptions controls top-level OpenAPI document metadata.
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/swetjen/virtuous/schema"
+)
+
+// HandlerOption configures a single HandleRPC registration. Every Guard is a
+// HandlerOption, as are the values returned by Guards, Intercept, Summary,
+// Description, Tags, Deprecated, Idempotent, Timeout, ReadOnly, Cache,
+// Compression, ServiceName, ExposeMCP, and HideMCP. Options that are not
+// guards return a nil Middleware.
+type HandlerOption interface {
+	Middleware() func(http.Handler) http.Handler
+}
+
+type handlerOptionFunc func(*handlerConfig)
+
+// Middleware implements HandlerOption.
+func (handlerOptionFunc) Middleware() func(http.Handler) http.Handler {
+	return nil
+}
+
+type handlerConfig struct {
+	guards       []Guard
+	interceptors []UnaryInterceptor
+	docs         RouteDocs
+	mcp          MCPExposure
+	idempotent   bool
+	timeout      time.Duration
+	service      string
+	readOnly     bool
+	cache        CachePolicy
+	compression  *CompressionOptions
+}
+
+// RouteDocs holds documentation metadata for an RPC route.
+type RouteDocs struct {
+	Summary     string
+	Description string
+	// Tags replace the default service tag in OpenAPI when set.
+	Tags []string
+	// Deprecated marks the route deprecated in OpenAPI and generated clients.
+	Deprecated bool
+	// DeprecationMessage optionally tells callers what to use instead.
+	DeprecationMessage string
+}
+
+// Guards attaches guards to a handler, for passing a []Guard. Guards can also
+// be passed to HandleRPC directly.
+func Guards(guards ...Guard) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		for _, guard := range guards {
+			if guard != nil {
+				c.guards = append(c.guards, guard)
+			}
+		}
+	})
+}
+
+// Summary sets the OpenAPI summary and generated client doc line for a handler.
+func Summary(summary string) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.docs.Summary = strings.TrimSpace(summary)
+	})
+}
+
+// Description sets the OpenAPI description for a handler.
+func Description(description string) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.docs.Description = strings.TrimSpace(description)
+	})
+}
+
+// Tags sets the OpenAPI tags for a handler, replacing the default service tag.
+func Tags(tags ...string) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		for _, tag := range tags {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				c.docs.Tags = append(c.docs.Tags, tag)
+			}
+		}
+	})
+}
+
+// Deprecated marks a handler deprecated. The optional message, such as
+// "use v2", is shown in OpenAPI, @deprecated JSDoc, and Python
+// DeprecationWarning text.
+func Deprecated(message string) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.docs.Deprecated = true
+		c.docs.DeprecationMessage = strings.TrimSpace(message)
+	})
+}
+
+// routeDocsWithComments fills summary, description, and deprecation from the
+// handler's Go doc comment when handler options did not set them. The first
+// sentence becomes the summary, and a "Deprecated:" paragraph marks the route
+// deprecated.
+func routeDocsWithComments(docs RouteDocs, docKey string) RouteDocs {
+	if docKey == "" {
+		return docs
+	}
+	text := schema.FuncDocComment(docKey)
+	if text == "" {
+		return docs
+	}
+	summary, description, deprecation, deprecated := splitDocComment(text)
+	if docs.Summary == "" && docs.Description == "" {
+		docs.Summary = summary
+		docs.Description = description
+	}
+	if !docs.Deprecated && deprecated {
+		docs.Deprecated = true
+		docs.DeprecationMessage = deprecation
+	}
+	return docs
+}
+
+func splitDocComment(text string) (summary, description, deprecation string, deprecated bool) {
+	var rest []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if message, ok := strings.CutPrefix(paragraph, "Deprecated:"); ok {
+			deprecated = true
+			deprecation = strings.Join(strings.Fields(message), " ")
+			continue
+		}
+		if summary == "" {
+			first := strings.Join(strings.Fields(paragraph), " ")
+			summary = first
+			if idx := strings.Index(first, ". "); idx >= 0 {
+				summary = first[:idx+1]
+				rest = append(rest, first[idx+2:])
+			}
+			continue
+		}
+		rest = append(rest, paragraph)
+	}
+	return summary, strings.Join(rest, "\n\n"), deprecation, deprecated
+}
+
+func resolveHandlerOptions(opts []HandlerOption) (handlerConfig, error) {
+	var config handlerConfig
+	for _, opt := range opts {
+		switch value := opt.(type) {
+		case nil:
+		case handlerOptionFunc:
+			value(&config)
+		case Guard:
+			config.guards = append(config.guards, value)
+		default:
+			return handlerConfig{}, fmt.Errorf("rpc: handler option %T is not a Guard", opt)
+		}
+	}
+	return config, nil
+}
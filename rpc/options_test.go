@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func greetLegacy(_ context.Context, req greetReq) (greetResp, int) {
+	return greetResp{Message: "hello " + req.Name}, StatusOK
+}
+
+func TestRPCRouteDocsOpenAPI(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet,
+		Summary("Greet a user"),
+		Description("Returns a greeting.\nNames are echoed verbatim."),
+		Tags("Greetings", "Public"),
+	)
+	router.HandleRPC(greetLegacy, Deprecated("use greet"))
+	router.HandleRPC(testHandler)
+
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary     string   `json:"summary"`
+			Description string   `json:"description"`
+			Tags        []string `json:"tags"`
+			Deprecated  bool     `json:"deprecated"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+
+	op := doc.Paths["/rpc/rpc/greet"]["post"]
+	if op.Summary != "Greet a user" || op.Description != "Returns a greeting.\nNames are echoed verbatim." {
+		t.Fatalf("unexpected summary/description: %+v", op)
+	}
+	if strings.Join(op.Tags, ",") != "Greetings,Public" || op.Deprecated {
+		t.Fatalf("unexpected tags/deprecation: %+v", op)
+	}
+
+	old := doc.Paths["/rpc/rpc/greet-legacy"]["post"]
+	if !old.Deprecated || old.Description != "Deprecated: use greet" || strings.Join(old.Tags, ",") != "Rpc" {
+		t.Fatalf("unexpected deprecated operation: %+v", old)
+	}
+	if plain := doc.Paths["/rpc/rpc/test-handler"]["post"]; plain.Summary != "" || plain.Deprecated {
+		t.Fatalf("expected undocumented operation, got %+v", plain)
+	}
+}
+
+func TestRPCRouteDocsClients(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet, Summary("Greet a user"), Description("Ends comments with */ safely."))
+	router.HandleRPC(greetLegacy, Deprecated("use greet"))
+
+	var ts bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), "\t\t\t/**\n\t\t\t * Greet a user\n\t\t\t *\n\t\t\t * Ends comments with *\\/ safely.\n\t\t\t */\n\t\t\tasync greet(")
+	assertRPCContains(t, ts.String(), "\t\t\t/**\n\t\t\t * @deprecated use greet\n\t\t\t */\n\t\t\tasync greetLegacy(")
+
+	var js bytes.Buffer
+	if err := router.WriteClientJS(&js); err != nil {
+		t.Fatalf("write js client: %v", err)
+	}
+	assertRPCContains(t, js.String(), "\t\t\t/**\n\t\t\t * Greet a user\n")
+	assertRPCContains(t, js.String(), "\t\t\t/**\n\t\t\t * @deprecated use greet\n\t\t\t * @param {greetReq } request\n")
+
+	var py bytes.Buffer
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	assertRPCContains(t, py.String(), "\nimport warnings\n")
+	assertRPCContains(t, py.String(), `        "Greet a user\n\nEnds comments with */ safely."`)
+
+	dir := t.TempDir()
+	pyPath := filepath.Join(dir, "client.gen.py")
+	if err := os.WriteFile(pyPath, py.Bytes(), 0644); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	snippet := pythonRPCImportSnippet(pyPath) + `
+import io
+import warnings
+
+class FakeResponse(io.BytesIO):
+    def getcode(self):
+        return 200
+    def __enter__(self):
+        return self
+    def __exit__(self, *args):
+        return False
+
+mod.request.urlopen = lambda req: FakeResponse(b'{"message":"hello"}')
+client = mod.create_client(base_url="https://core.example")
+assert client.rpc.greet.__doc__.startswith("Greet a user")
+with warnings.catch_warnings(record=True) as caught:
+    warnings.simplefilter("always")
+    client.rpc.greet(mod.greetReq(name="Ada"))
+    assert not caught
+    resp = client.rpc.greetLegacy(mod.greetReq(name="Ada"))
+    assert resp.message == "hello"
+    assert len(caught) == 1
+    assert issubclass(caught[0].category, DeprecationWarning)
+    assert str(caught[0].message) == "rpc.greetLegacy is deprecated: use greet"
+`
+	if err := runRPCPython("-c", snippet); err != nil {
+		t.Fatalf("python deprecation client failed: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type sessionPrincipal struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+}
+
+type accountPrincipal struct {
+	ID string
+}
+
+func (p accountPrincipal) PrincipalID() string {
+	return "account:" + p.ID
+}
+
+type principalGuard struct {
+	principal any
+}
+
+func (principalGuard) Spec() GuardSpec {
+	return GuardSpec{Name: "SessionAuth", In: "header", Param: "Authorization", Prefix: "Bearer"}
+}
+
+func (g principalGuard) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), g.principal)))
+		})
+	}
+}
+
+func whoAmI(ctx context.Context, _ testReq) (observabilityResp, int) {
+	principal, ok := Principal[sessionPrincipal](ctx)
+	if !ok {
+		return observabilityResp{Error: "no principal"}, StatusInvalid
+	}
+	return observabilityResp{Message: principal.UserID + "/" + principal.Role}, StatusOK
+}
+
+func whoAmIAccount(ctx context.Context, _ testReq) (observabilityResp, int) {
+	if _, ok := Principal[sessionPrincipal](ctx); ok {
+		return observabilityResp{Error: "unexpected principal type"}, StatusInvalid
+	}
+	account, ok := Principal[accountPrincipal](ctx)
+	if !ok {
+		return observabilityResp{Error: "no principal"}, StatusInvalid
+	}
+	return observabilityResp{Message: account.ID}, StatusOK
+}
+
+func TestRPCPrincipalReachesHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(whoAmI, principalGuard{principal: sessionPrincipal{UserID: "u-1", Role: "admin"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/who-am-i", strings.NewReader(`{"name":"Virtuous"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != StatusOK || !strings.Contains(rec.Body.String(), `"message":"u-1/admin"`) {
+		t.Fatalf("expected principal in handler, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRPCObservabilityRecordsPrincipalID(t *testing.T) {
+	router := NewRouter(WithAdvancedObservability(WithObservabilitySampling(1)))
+	router.HandleRPC(whoAmI, principalGuard{principal: sessionPrincipal{UserID: "u-1", Role: "admin"}})
+	router.HandleRPC(whoAmIAccount, principalGuard{principal: accountPrincipal{ID: "42"}})
+
+	for _, path := range []string{"/rpc/rpc/who-am-i", "/rpc/rpc/who-am-i-account"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"Virtuous"}`))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != StatusOK {
+			t.Fatalf("%s: expected 200, got %d %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	principals := map[string]string{}
+	for _, trace := range router.observability.Snapshot().RecentTraces {
+		principals[trace.RPCName] = trace.Principal
+	}
+	if got := principals["rpc.whoAmIAccount"]; got != "account:42" {
+		t.Fatalf("expected identifier principal, got %q", got)
+	}
+	if got, ok := principals["rpc.whoAmI"]; !ok || got != "" {
+		t.Fatalf("expected no principal for a value without PrincipalID, got %q (%t)", got, ok)
+	}
+}
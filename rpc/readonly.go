@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestQueryParam is the query parameter that carries the JSON request for
+// GET calls to read-only handlers.
+const RequestQueryParam = "request"
+
+// CachePolicy sets the Cache-Control header of successful GET responses from
+// a read-only handler.
+type CachePolicy struct {
+	// MaxAge lets caches reuse a response without revalidating. Zero sends
+	// no-cache, so caches revalidate with If-None-Match before each use.
+	MaxAge time.Duration
+	// Public lets shared caches such as CDNs store responses. Responses are
+	// private otherwise. Public policies are rejected on guarded handlers.
+	Public bool
+	// StaleWhileRevalidate lets caches serve a stale response while they
+	// revalidate in the background.
+	StaleWhileRevalidate time.Duration
+}
+
+// ReadOnly marks a handler as free of side effects. Read-only handlers also
+// accept GET with the JSON request in the RequestQueryParam query parameter,
+// and successful GET responses carry an ETag and answer a matching
+// If-None-Match with 304. Generated clients call them with GET.
+func ReadOnly() HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.readOnly = true
+	})
+}
+
+// Cache marks a handler ReadOnly and sets the cache policy for its GET
+// responses.
+func Cache(policy CachePolicy) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		c.readOnly = true
+		c.cache = policy
+	})
+}
+
+// header renders the policy as a Cache-Control value.
+func (p CachePolicy) header() string {
+	parts := []string{"private"}
+	if p.Public {
+		parts[0] = "public"
+	}
+	if p.MaxAge > 0 {
+		parts = append(parts, "max-age="+strconv.FormatInt(int64(p.MaxAge/time.Second), 10))
+	} else {
+		parts = append(parts, "no-cache")
+	}
+	if p.StaleWhileRevalidate > 0 {
+		parts = append(parts, "stale-while-revalidate="+strconv.FormatInt(int64(p.StaleWhileRevalidate/time.Second), 10))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// withQueryBody moves the RequestQueryParam value of a GET request into the
+// body so it decodes like a POST. A missing parameter decodes as {}.
+func withQueryBody(req *http.Request) *http.Request {
+	raw := req.URL.Query().Get(RequestQueryParam)
+	if raw == "" {
+		raw = "{}"
+	}
+	req = req.WithContext(req.Context())
+	req.Body = io.NopCloser(strings.NewReader(raw))
+	req.ContentLength = int64(len(raw))
+	return req
+}
+
+// writeCached writes a successful read-only GET response with an ETag
+// derived from the encoded body, or 304 when the client already holds it.
+func writeCached(w http.ResponseWriter, req *http.Request, codec Codec, v reflect.Value, policy CachePolicy) {
+	var body []byte
+	if v.IsValid() {
+		body, _ = codec.Marshal(v.Interface())
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", policy.header())
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", codec.MediaType())
+	w.WriteHeader(StatusOK)
+	_, _ = w.Write(body)
+}
+
+// etagMatches applies the weak comparison If-None-Match uses.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyOperation derives the GET operation of a read-only route from its
+// POST operation: the request body becomes the RequestQueryParam parameter,
+// and 304 is added for conditional requests.
+func readOnlyOperation(post *openAPIOperation) *openAPIOperation {
+	op := *post
+	op.RequestBody = nil
+	op.Parameters = append([]openAPIParameter(nil), post.Parameters...)
+	if post.RequestBody != nil {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:        RequestQueryParam,
+			In:          "query",
+			Required:    false,
+			Description: "JSON-encoded request. Omitted fields take their zero values.",
+			Content:     map[string]openAPIMedia{MediaTypeJSON: post.RequestBody.Content[MediaTypeJSON]},
+		})
+	}
+	op.Responses = make(map[string]openAPIResponse, len(post.Responses)+1)
+	for status, response := range post.Responses {
+		op.Responses[status] = response
+	}
+	op.Responses["304"] = openAPIResponse{
+		Description: http.StatusText(http.StatusNotModified),
+	}
+	return &op
+}
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRPCReadOnlyServesGetWithETag(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet, ReadOnly())
+
+	target := "/rpc/rpc/greet?request=" + url.QueryEscape(`{"name":"Ada"}`)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"hello Ada"`) || etag == "" {
+		t.Fatalf("expected cacheable GET response, got %d %q %s", rec.Code, etag, rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Fatalf("unexpected Cache-Control %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag {
+		t.Fatalf("expected 304 for matching ETag, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rpc/rpc/greet", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"hello "`) {
+		t.Fatalf("expected missing request to decode as {}, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/rpc/rpc/greet", strings.NewReader(`{"name":"Ada"}`)))
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Fatalf("expected plain POST response, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestRPCCachePolicyAndRegistration(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet, Cache(CachePolicy{MaxAge: time.Minute, Public: true, StaleWhileRevalidate: 30 * time.Second}))
+	router.HandleRPC(greetWithError)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rpc/rpc/greet", nil))
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=60, stale-while-revalidate=30" {
+		t.Fatalf("unexpected Cache-Control %q", got)
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rpc/rpc/greet-with-error", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected GET to be rejected on a regular route, got %d", rec.Code)
+	}
+
+	for name, register := range map[string]func(){
+		"public cache policy on guarded": func() {
+			NewRouter().HandleRPC(greet, Cache(CachePolicy{Public: true}), denyUnlessHeaderGuard{})
+		},
+		"read-only is not supported on streaming": func() { NewRouter().HandleRPC(tickerStream, ReadOnly()) },
+	} {
+		func() {
+			defer func() {
+				if got := recover(); got == nil || !strings.Contains(got.(error).Error(), name) {
+					t.Fatalf("expected %q error, got %v", name, got)
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestRPCReadOnlyDocsAndClients(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet, ReadOnly())
+	router.HandleRPC(greetWithError)
+
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name    string                     `json:"name"`
+				In      string                     `json:"in"`
+				Content map[string]json.RawMessage `json:"content"`
+			} `json:"parameters"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	get, ok := doc.Paths["/rpc/rpc/greet"]["get"]
+	if !ok || len(get.Parameters) != 1 || get.Parameters[0].Name != RequestQueryParam || get.Parameters[0].In != "query" || get.Parameters[0].Content["application/json"] == nil {
+		t.Fatalf("expected GET operation with JSON request parameter, got %+v", get)
+	}
+	if _, ok := get.Responses["304"]; !ok {
+		t.Fatalf("expected 304 response on GET operation")
+	}
+	if _, ok := doc.Paths["/rpc/rpc/greet-with-error"]["get"]; ok {
+		t.Fatalf("expected no GET operation for regular route")
+	}
+
+	var ts, js, py bytes.Buffer
+	for _, write := range []func() error{
+		func() error { return router.WriteClientTS(&ts) },
+		func() error { return router.WriteClientJS(&js) },
+		func() error { return router.WriteClientPY(&py) },
+	} {
+		if err := write(); err != nil {
+			t.Fatalf("write client: %v", err)
+		}
+	}
+	for _, out := range []string{ts.String(), js.String()} {
+		assertRPCContains(t, out, `url = url + "?request=" + encodeURIComponent(JSON.stringify(request))`)
+		if strings.Count(out, `method: "GET"`) != 1 || strings.Count(out, `method: "POST"`) != 1 {
+			t.Fatalf("expected one GET and one POST method:\n%s", out)
+		}
+	}
+	assertRPCContains(t, py.String(), `url = _append_query(url, "request", json.dumps(_encode_value(body), separators=(",", ":")))`)
+	assertRPCContains(t, py.String(), `method="GET", retryable=True)`)
+}
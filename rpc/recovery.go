@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/swetjen/virtuous/internal/debugconsole"
+	"github.com/swetjen/virtuous/internal/incident"
+)
+
+// recoverPanic logs a recovered handler panic with its stack and, when the
+// response has not started, answers with a 500 carrying the incident ID.
+// It returns the incident ID.
+func (r *Router) recoverPanic(w http.ResponseWriter, req *http.Request, started bool, rpcName string, rec any, stack []byte) string {
+	incidentID := incident.NewID()
+	debugconsole.SetIncidentID(req.Context(), incidentID)
+	if r.logger != nil {
+		r.logger.Error("rpc handler panicked",
+			"rpc", rpcName,
+			"incident_id", incidentID,
+			"panic", fmt.Sprint(rec),
+			"stack", string(stack),
+		)
+	}
+	if !started {
+		writeError(w, r.responseCodec(req), StatusError, &Error{
+			Code:       ErrorCodeInternal,
+			Message:    errorInternalBody,
+			IncidentID: incidentID,
+		})
+	}
+	return incidentID
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func panicGreet(context.Context, greetReq) (greetResp, error) {
+	panic("greeting exploded")
+}
+
+func TestRPCPanicRecoveryWritesIncident(t *testing.T) {
+	var logs bytes.Buffer
+	router := NewRouter(WithAdvancedObservability())
+	router.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	router.HandleRPC(panicGreet)
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/panic-greet", strings.NewReader(`{}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var body Error
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error: %v (%s)", err, rec.Body.String())
+	}
+	if rec.Code != StatusError || body.Code != ErrorCodeInternal || body.IncidentID == "" {
+		t.Fatalf("expected 500 with incident ID, got %d %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "greeting exploded") {
+		t.Fatalf("expected panic value to stay out of the response: %s", rec.Body.String())
+	}
+	if !strings.Contains(logs.String(), "incident_id="+body.IncidentID) || !strings.Contains(logs.String(), "panicGreet") {
+		t.Fatalf("expected incident and stack in logs, got %s", logs.String())
+	}
+
+	snapshot := router.observability.Snapshot()
+	if len(snapshot.Errors) != 1 || len(snapshot.Errors[0].IncidentIDs) != 1 || snapshot.Errors[0].IncidentIDs[0] != body.IncidentID {
+		t.Fatalf("expected incident linked to error fingerprint, got %+v", snapshot.Errors)
+	}
+	if len(snapshot.RecentTraces) != 1 || snapshot.RecentTraces[0].IncidentID != body.IncidentID {
+		t.Fatalf("expected incident on trace sample, got %+v", snapshot.RecentTraces)
+	}
+}
+
+func TestRPCWithoutPanicRecoveryRepanics(t *testing.T) {
+	defer func() {
+		if got := recover(); got != "greeting exploded" {
+			t.Fatalf("expected panic to propagate, got %v", got)
+		}
+	}()
+	router := NewRouter(WithoutPanicRecovery())
+	router.HandleRPC(panicGreet)
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/panic-greet", strings.NewReader(`{}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -2,12 +2,17 @@ package rpc
 
 import (
 	"crypto/ed25519"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/swetjen/virtuous/idempotency"
 	"github.com/swetjen/virtuous/internal/adminui"
 	"github.com/swetjen/virtuous/internal/clientgen"
+	"github.com/swetjen/virtuous/internal/compression"
 	"github.com/swetjen/virtuous/internal/debugconsole"
 	"github.com/swetjen/virtuous/internal/jsonlimit"
 )
@@ -21,6 +26,7 @@ type Router struct {
 	routes         []Route
 	prefix         string
 	guards         []Guard
+	interceptors   []UnaryInterceptor
 	logger         *slog.Logger
 	events         *adminui.EventFeed
 	observability  *adminui.ObservabilityTracker
@@ -33,18 +39,36 @@ type Router struct {
 	debugConsole   *debugconsole.Logger
 	debugHandler   http.Handler
 	pythonSigning  *clientgen.PythonClientSigning
+	goClient       GoClientOptions
+	kotlinClient   KotlinClientOptions
+	jsonRPC        *JSONRPCOptions
+	idempotency    idempotency.Store
+	timeout        time.Duration
+	recoverPanics  bool
+	serviceNaming  ServiceNaming
+	// servicePackages maps each mounted service name to its import path.
+	servicePackages map[string]string
+	compression     *CompressionOptions
+	codecs          []Codec
 }
 
 // RouterOptions configures a Router.
 type RouterOptions struct {
 	Prefix                string
 	Guards                []Guard
+	Interceptors          []UnaryInterceptor
 	AdvancedObservability *AdvancedObservabilityOptions
 	MaxRequestBodyBytes   int64
 	StrictJSONDecoding    bool
 	DebugConsoleWriter    io.Writer
 	DebugConsole          bool
 	PythonSigning         *clientgen.PythonClientSigning
+	IdempotencyStore      idempotency.Store
+	Timeout               time.Duration
+	DisablePanicRecovery  bool
+	ServiceNaming         ServiceNaming
+	Compression           *CompressionOptions
+	Codecs                []Codec
 }
 
 // RouterOption mutates RouterOptions.
@@ -64,6 +88,33 @@ func WithGuards(guards ...Guard) RouterOption {
 	}
 }
 
+// WithIdempotencyStore enables Idempotency-Key handling for handlers
+// registered with Idempotent, storing responses in store.
+func WithIdempotencyStore(store idempotency.Store) RouterOption {
+	return func(o *RouterOptions) {
+		o.IdempotencyStore = store
+	}
+}
+
+// WithTimeout bounds every unary handler's context to d unless the handler
+// sets its own Timeout. Client deadlines sent in TimeoutHeader are capped by
+// it.
+func WithTimeout(d time.Duration) RouterOption {
+	return func(o *RouterOptions) {
+		if d > 0 {
+			o.Timeout = d
+		}
+	}
+}
+
+// WithoutPanicRecovery lets handler panics propagate to net/http instead of
+// answering with a 500 that carries an incident ID.
+func WithoutPanicRecovery() RouterOption {
+	return func(o *RouterOptions) {
+		o.DisablePanicRecovery = true
+	}
+}
+
 // WithMaxRequestBodyBytes overrides the default RPC JSON request body cap.
 func WithMaxRequestBodyBytes(maxBytes int64) RouterOption {
 	return func(o *RouterOptions) {
@@ -120,17 +171,24 @@ func NewRouter(opts ...RouterOption) *Router {
 		opt(&config)
 	}
 	router := &Router{
-		mux:    http.NewServeMux(),
-		prefix: normalizePrefix(config.Prefix),
-		guards: append([]Guard(nil), config.Guards...),
-		logger: slog.Default(),
-		events: adminui.NewEventFeed(600),
+		mux:          http.NewServeMux(),
+		prefix:       normalizePrefix(config.Prefix),
+		guards:       append([]Guard(nil), config.Guards...),
+		interceptors: append([]UnaryInterceptor(nil), config.Interceptors...),
+		logger:       slog.Default(),
+		events:       adminui.NewEventFeed(600),
 		observability: adminui.NewObservabilityTracker(adminui.ObservabilityOptions{
 			Advanced:   config.AdvancedObservability != nil,
 			SampleRate: observabilitySampleRate(config.AdvancedObservability),
 		}),
-		maxBodyBytes: config.MaxRequestBodyBytes,
-		strictJSON:   config.StrictJSONDecoding,
+		maxBodyBytes:  config.MaxRequestBodyBytes,
+		strictJSON:    config.StrictJSONDecoding,
+		idempotency:   config.IdempotencyStore,
+		timeout:       config.Timeout,
+		recoverPanics: !config.DisablePanicRecovery,
+		serviceNaming: config.ServiceNaming,
+		compression:   config.Compression,
+		codecs:        codecList(config.Codecs),
 	}
 	if config.PythonSigning != nil {
 		copySigning := *config.PythonSigning
@@ -187,24 +245,92 @@ func (r *Router) SetOpenAPIOptions(opts OpenAPIOptions) {
 	r.openAPIOptions = &copyOpts
 }
 
-// HandleRPC registers a typed RPC handler.
-func (r *Router) HandleRPC(fn any, guards ...Guard) {
+// HandleRPC registers a typed RPC handler. Handlers whose last parameter is
+// an rpc.Stream[Msg] are served as Server-Sent Events streams. opts accepts
+// guards and handler options such as Intercept.
+func (r *Router) HandleRPC(fn any, opts ...HandlerOption) {
 	spec, err := parseHandler(fn, r.prefix)
 	if err != nil {
 		panic(err)
 	}
+	if spec.validatorErr != nil {
+		r.logger.Warn("rpc request validation disabled", "handler", spec.fullName, "error", spec.validatorErr)
+	}
+	spec, config, err := r.prepare(spec, opts)
+	if err != nil {
+		panic(err)
+	}
+	r.mount(spec, config)
+}
+
+// prepare resolves handler options and the service name, and checks that
+// spec can be mounted.
+func (r *Router) prepare(spec handlerSpec, opts []HandlerOption) (handlerSpec, handlerConfig, error) {
+	config, err := resolveHandlerOptions(opts)
+	if err != nil {
+		return handlerSpec{}, handlerConfig{}, err
+	}
+	spec, err = r.resolveService(spec, config)
+	if err != nil {
+		return handlerSpec{}, handlerConfig{}, err
+	}
+	if spec.streaming && len(config.interceptors) > 0 {
+		return handlerSpec{}, handlerConfig{}, errors.New("rpc: interceptors are not supported on streaming handler " + spec.path)
+	}
+	if spec.streaming && config.timeout > 0 {
+		return handlerSpec{}, handlerConfig{}, errors.New("rpc: timeouts are not supported on streaming handler " + spec.path)
+	}
+	if config.idempotent {
+		if spec.streaming {
+			return handlerSpec{}, handlerConfig{}, errors.New("rpc: idempotency is not supported on streaming handler " + spec.path)
+		}
+		if r.idempotency == nil {
+			return handlerSpec{}, handlerConfig{}, errors.New("rpc: idempotent handler " + spec.path + " requires WithIdempotencyStore")
+		}
+	}
+	if config.readOnly {
+		if spec.streaming {
+			return handlerSpec{}, handlerConfig{}, errors.New("rpc: read-only is not supported on streaming handler " + spec.path)
+		}
+		if config.idempotent {
+			return handlerSpec{}, handlerConfig{}, errors.New("rpc: read-only handler " + spec.path + " cannot be idempotent")
+		}
+		if config.cache.Public && len(r.guards)+len(config.guards) > 0 {
+			return handlerSpec{}, handlerConfig{}, errors.New("rpc: public cache policy on guarded handler " + spec.path + "; use a private policy")
+		}
+	}
 	for _, route := range r.routes {
 		if route.Path == spec.path {
-			panic("rpc: duplicate route for path " + spec.path)
+			return handlerSpec{}, handlerConfig{}, errors.New("rpc: duplicate route for path " + spec.path)
 		}
 	}
+	return spec, config, nil
+}
 
+func (r *Router) mount(spec handlerSpec, config handlerConfig) {
 	allGuards := append([]Guard(nil), r.guards...)
-	allGuards = append(allGuards, guards...)
+	allGuards = append(allGuards, config.guards...)
+	interceptors := append([]UnaryInterceptor(nil), r.interceptors...)
+	interceptors = append(interceptors, config.interceptors...)
 
-	handler := r.buildRPCHandler(spec)
+	timeout := config.timeout
+	if timeout == 0 && !spec.streaming {
+		timeout = r.timeout
+	}
+
+	handler := r.buildRPCHandler(spec, interceptors, timeout, config)
+	if config.idempotent {
+		handler = r.idempotentHandler(handler)
+	}
 	handler = r.wrapRPCHandler(spec, handler, allGuards)
+	if opts, ok := r.compressionFor(config); ok {
+		handler = compression.Handler(opts, handler)
+	}
 	r.mux.Handle(spec.path, handler)
+	if r.servicePackages == nil {
+		r.servicePackages = map[string]string{}
+	}
+	r.servicePackages[spec.service] = spec.pkgPath
 
 	route := Route{
 		Path:         spec.path,
@@ -212,7 +338,16 @@ func (r *Router) HandleRPC(fn any, guards ...Guard) {
 		Method:       spec.method,
 		RequestType:  spec.reqType,
 		ResponseType: spec.respType,
+		ErrorType:    spec.errType,
+		Streaming:    spec.streaming,
 		Guards:       guardSpecs(allGuards),
+		Docs:         config.docs,
+		MCP:          config.mcp,
+		Idempotent:   config.idempotent,
+		Timeout:      timeout,
+		ReadOnly:     config.readOnly,
+		Cache:        config.cache,
+		docKey:       spec.fullName,
 	}
 	r.routes = append(r.routes, route)
 }
@@ -230,9 +365,36 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 func (r *Router) Routes() []Route {
 	out := make([]Route, len(r.routes))
 	copy(out, r.routes)
+	for i := range out {
+		out[i].Docs = routeDocsWithComments(out[i].Docs, out[i].docKey)
+	}
 	return out
 }
 
+// RouteFor returns the route registered for fn, which may be a handler
+// function or a method value of a service mounted with HandleService.
+func (r *Router) RouteFor(fn any) (Route, bool) {
+	fullName, _, _, err := resolveFuncName(fn)
+	if err != nil {
+		return Route{}, false
+	}
+	key := handlerKey(fullName)
+	for _, route := range r.Routes() {
+		if handlerKey(route.docKey) == key {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// handlerKey normalizes a runtime function name so that method values such
+// as "app/users.(*Service).Create-fm" match the "app/users.Service.Create"
+// names recorded for service methods.
+func handlerKey(fullName string) string {
+	fullName = strings.TrimSuffix(fullName, "-fm")
+	return strings.NewReplacer("(*", "", ")", "").Replace(fullName)
+}
+
 func wrapWithGuards(h http.Handler, guards []Guard) http.Handler {
 	wrapped := h
 	for i := len(guards) - 1; i >= 0; i-- {
@@ -9,6 +9,17 @@ type ServeAllDocsOptions struct {
 	ClientJSPath string
 	ClientTSPath string
 	ClientPYPath string
+	// ClientPYAsyncPath serves the async Python client.
+	ClientPYAsyncPath string
+	// ClientGoPath serves the Go client when set. It is empty by default.
+	ClientGoPath string
+	// ClientSwiftPath and ClientKotlinPath serve the mobile clients when set.
+	// They are empty by default.
+	ClientSwiftPath  string
+	ClientKotlinPath string
+	// ClientZodPath serves the Zod schemas for the TS client when set. It is
+	// empty by default.
+	ClientZodPath string
 }
 
 // ServeAllDocsOpt mutates ServeAllDocsOptions.
@@ -50,6 +61,51 @@ func WithClientPYPath(path string) ServeAllDocsOpt {
 	}
 }
 
+// WithClientPYAsyncPath overrides the async Python client route path.
+func WithClientPYAsyncPath(path string) ServeAllDocsOpt {
+	return func(o *ServeAllDocsOptions) {
+		if path != "" {
+			o.ClientPYAsyncPath = ensureLeadingSlash(path)
+		}
+	}
+}
+
+// WithClientGoPath serves the Go client at path.
+func WithClientGoPath(path string) ServeAllDocsOpt {
+	return func(o *ServeAllDocsOptions) {
+		if path != "" {
+			o.ClientGoPath = ensureLeadingSlash(path)
+		}
+	}
+}
+
+// WithClientSwiftPath serves the Swift client at path.
+func WithClientSwiftPath(path string) ServeAllDocsOpt {
+	return func(o *ServeAllDocsOptions) {
+		if path != "" {
+			o.ClientSwiftPath = ensureLeadingSlash(path)
+		}
+	}
+}
+
+// WithClientKotlinPath serves the Kotlin client at path.
+func WithClientKotlinPath(path string) ServeAllDocsOpt {
+	return func(o *ServeAllDocsOptions) {
+		if path != "" {
+			o.ClientKotlinPath = ensureLeadingSlash(path)
+		}
+	}
+}
+
+// WithClientZodPath serves the Zod schemas at path.
+func WithClientZodPath(path string) ServeAllDocsOpt {
+	return func(o *ServeAllDocsOptions) {
+		if path != "" {
+			o.ClientZodPath = ensureLeadingSlash(path)
+		}
+	}
+}
+
 // WithoutDocs disables docs/OpenAPI route registration.
 func WithoutDocs() ServeAllDocsOpt {
 	return func(o *ServeAllDocsOptions) {
@@ -60,10 +116,11 @@ func WithoutDocs() ServeAllDocsOpt {
 // ServeAllDocs registers docs, OpenAPI, and client routes on the router.
 func (r *Router) ServeAllDocs(opts ...ServeAllDocsOpt) {
 	config := ServeAllDocsOptions{
-		DocsEnabled:  true,
-		ClientJSPath: "/rpc/client.gen.js",
-		ClientTSPath: "/rpc/client.gen.ts",
-		ClientPYPath: "/rpc/client.gen.py",
+		DocsEnabled:       true,
+		ClientJSPath:      "/rpc/client.gen.js",
+		ClientTSPath:      "/rpc/client.gen.ts",
+		ClientPYPath:      "/rpc/client.gen.py",
+		ClientPYAsyncPath: "/rpc/client.gen.async.py",
 	}
 	for _, opt := range opts {
 		opt(&config)
@@ -83,4 +140,24 @@ func (r *Router) ServeAllDocs(opts ...ServeAllDocsOpt) {
 		r.mux.Handle("GET "+config.ClientPYPath, http.HandlerFunc(r.ServeClientPY))
 		r.logger.Info("rpc client py available", "path", config.ClientPYPath)
 	}
+	if config.ClientPYAsyncPath != "" {
+		r.mux.Handle("GET "+config.ClientPYAsyncPath, http.HandlerFunc(r.ServeClientPYAsync))
+		r.logger.Info("rpc client py async available", "path", config.ClientPYAsyncPath)
+	}
+	if config.ClientGoPath != "" {
+		r.mux.Handle("GET "+config.ClientGoPath, http.HandlerFunc(r.ServeClientGo))
+		r.logger.Info("rpc client go available", "path", config.ClientGoPath)
+	}
+	if config.ClientSwiftPath != "" {
+		r.mux.Handle("GET "+config.ClientSwiftPath, http.HandlerFunc(r.ServeClientSwift))
+		r.logger.Info("rpc client swift available", "path", config.ClientSwiftPath)
+	}
+	if config.ClientKotlinPath != "" {
+		r.mux.Handle("GET "+config.ClientKotlinPath, http.HandlerFunc(r.ServeClientKotlin))
+		r.logger.Info("rpc client kotlin available", "path", config.ClientKotlinPath)
+	}
+	if config.ClientZodPath != "" {
+		r.mux.Handle("GET "+config.ClientZodPath, http.HandlerFunc(r.ServeClientZod))
+		r.logger.Info("rpc client zod available", "path", config.ClientZodPath)
+	}
 }
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+)
+
+// ServiceOptions customizes HandleService for individual methods.
+type ServiceOptions struct {
+	// Methods adds handler options, such as guards, to the named methods.
+	Methods map[string][]HandlerOption
+	// Exclude lists methods that HandleService does not register.
+	Exclude []string
+}
+
+// ServiceOptionsProvider is implemented by service structs that customize
+// HandleService. The RPCOptions method itself is never registered.
+type ServiceOptionsProvider interface {
+	RPCOptions() ServiceOptions
+}
+
+const serviceOptionsMethod = "RPCOptions"
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// HandleService registers every exported method of svc whose first parameter
+// is a context.Context as an RPC handler, using the method name and the
+// package of svc's type for the route path. opts apply to every method.
+//
+// Methods with a context parameter but an unsupported signature must be
+// excluded through RPCOptions. All problems are reported together in the
+// returned error, and nothing is registered unless every method is valid.
+func (r *Router) HandleService(svc any, opts ...HandlerOption) error {
+	value := reflect.ValueOf(svc)
+	if !value.IsValid() {
+		return errors.New("rpc: service must not be nil")
+	}
+	base := derefType(value.Type())
+	if base.Kind() != reflect.Struct {
+		return fmt.Errorf("rpc: service must be a struct or pointer to struct, got %s", value.Type())
+	}
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		return fmt.Errorf("rpc: service %s must not be nil", value.Type())
+	}
+
+	var options ServiceOptions
+	if provider, ok := svc.(ServiceOptionsProvider); ok {
+		options = provider.RPCOptions()
+	}
+	excluded := make(map[string]bool, len(options.Exclude))
+	for _, name := range options.Exclude {
+		excluded[name] = true
+	}
+
+	serviceName := base.Name()
+	pkgName := path.Base(base.PkgPath())
+	var errs []error
+	type pendingRoute struct {
+		spec   handlerSpec
+		config handlerConfig
+	}
+	var pending []pendingRoute
+	seen := map[string]bool{}
+	paths := map[string]string{}
+
+	valueType := value.Type()
+	for i := 0; i < valueType.NumMethod(); i++ {
+		method := valueType.Method(i)
+		seen[method.Name] = true
+		if method.Name == serviceOptionsMethod || excluded[method.Name] {
+			continue
+		}
+		fn := value.Method(i)
+		if ft := fn.Type(); ft.NumIn() == 0 || !ft.In(0).Implements(contextType) {
+			continue
+		}
+		spec, err := parseHandlerSignature(fn)
+		if err == nil {
+			fullName := base.PkgPath() + "." + serviceName + "." + method.Name
+			spec, err = spec.named(r.prefix, fullName, pkgName, method.Name)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s.%s: %w", serviceName, method.Name, err))
+			continue
+		}
+		methodOpts := append(append([]HandlerOption(nil), opts...), options.Methods[method.Name]...)
+		spec, config, err := r.prepare(spec, methodOpts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s.%s: %w", serviceName, method.Name, err))
+			continue
+		}
+		if other, ok := paths[spec.path]; ok {
+			errs = append(errs, fmt.Errorf("%s.%s: path %s also used by %s", serviceName, method.Name, spec.path, other))
+			continue
+		}
+		paths[spec.path] = method.Name
+		pending = append(pending, pendingRoute{spec: spec, config: config})
+	}
+
+	for _, name := range unknownServiceMethods(options, seen) {
+		errs = append(errs, fmt.Errorf("%s: RPCOptions references unknown method %s", serviceName, name))
+	}
+	if len(pending) == 0 && len(errs) == 0 {
+		errs = append(errs, fmt.Errorf("rpc: service %s has no RPC methods", value.Type()))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, item := range pending {
+		r.mount(item.spec, item.config)
+	}
+	return nil
+}
+
+func unknownServiceMethods(options ServiceOptions, seen map[string]bool) []string {
+	var unknown []string
+	for name := range options.Methods {
+		if !seen[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	for _, name := range options.Exclude {
+		if !seen[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
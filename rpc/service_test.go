@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type accountService struct {
+	greeting string
+}
+
+func (s *accountService) AccountGet(_ context.Context, req greetReq) (greetResp, int) {
+	return greetResp{Message: s.greeting + " " + req.Name}, StatusOK
+}
+
+func (s *accountService) AccountDelete(_ context.Context, req greetReq) (greetResp, error) {
+	return greetResp{Message: "deleted " + req.Name}, nil
+}
+
+func (s *accountService) AccountPurge(_ context.Context, _ greetReq) (greetResp, int) {
+	return greetResp{}, StatusOK
+}
+
+func (s *accountService) Close(context.Context) error {
+	return nil
+}
+
+func (s *accountService) Greeting() string {
+	return s.greeting
+}
+
+func (s *accountService) RPCOptions() ServiceOptions {
+	return ServiceOptions{
+		Methods: map[string][]HandlerOption{
+			"AccountDelete": {denyUnlessHeaderGuard{}},
+		},
+		Exclude: []string{"AccountPurge", "Close"},
+	}
+}
+
+type brokenService struct{}
+
+func (brokenService) Ping(context.Context, greetReq) string {
+	return "pong"
+}
+
+func (brokenService) Lookup(context.Context, greetReq, int) (greetResp, int) {
+	return greetResp{}, StatusOK
+}
+
+func (brokenService) Echo(_ context.Context, req greetReq) (greetResp, int) {
+	return greetResp{Message: req.Name}, StatusOK
+}
+
+func (brokenService) RPCOptions() ServiceOptions {
+	return ServiceOptions{Exclude: []string{"Missing"}}
+}
+
+func TestRPCHandleServiceRegistersMethods(t *testing.T) {
+	router := NewRouter()
+	if err := router.HandleService(&accountService{greeting: "hi"}); err != nil {
+		t.Fatalf("handle service: %v", err)
+	}
+
+	routes := router.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %+v", routes)
+	}
+	byPath := map[string]Route{}
+	for _, route := range routes {
+		byPath[route.Path] = route
+	}
+	get, ok := byPath["/rpc/rpc/account-get"]
+	if !ok || get.Service != "rpc" || get.Method != "AccountGet" || len(get.Guards) != 0 {
+		t.Fatalf("unexpected AccountGet route: %+v", get)
+	}
+	del, ok := byPath["/rpc/rpc/account-delete"]
+	if !ok || len(del.Guards) != 1 || del.Guards[0].Name != "BearerAuth" {
+		t.Fatalf("expected per-method guard on AccountDelete: %+v", del)
+	}
+
+	rec, _ := postRPC(t, router, "/rpc/rpc/account-get", `{"name":"Ada"}`)
+	if rec.Code != StatusOK || !strings.Contains(rec.Body.String(), `"message":"hi Ada"`) {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/account-delete", strings.NewReader(`{"name":"Ada"}`))
+	denied := httptest.NewRecorder()
+	router.ServeHTTP(denied, req)
+	if denied.Code != http.StatusUnauthorized {
+		t.Fatalf("expected guarded delete to return 401, got %d", denied.Code)
+	}
+}
+
+func TestRPCHandleServiceAppliesSharedOptions(t *testing.T) {
+	router := NewRouter()
+	if err := router.HandleService(&accountService{}, denyUnlessHeaderGuard{}); err != nil {
+		t.Fatalf("handle service: %v", err)
+	}
+	for _, route := range router.Routes() {
+		if len(route.Guards) == 0 {
+			t.Fatalf("expected shared guard on %s", route.Path)
+		}
+	}
+}
+
+func TestRPCHandleServiceReportsAllErrors(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet)
+	err := router.HandleService(brokenService{})
+	if err == nil {
+		t.Fatalf("expected registration error")
+	}
+	for _, want := range []string{
+		"brokenService.Ping: rpc: handler must return",
+		"brokenService.Lookup:",
+		"unknown method Missing",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in error:\n%v", want, err)
+		}
+	}
+	if len(router.Routes()) != 1 {
+		t.Fatalf("expected no service routes after failure, got %+v", router.Routes())
+	}
+}
+
+func TestRPCHandleServiceRejectsDuplicatePaths(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(greet)
+	if err := router.HandleService(&accountService{}); err != nil {
+		t.Fatalf("handle service: %v", err)
+	}
+	err := router.HandleService(&accountService{})
+	if err == nil || !strings.Contains(err.Error(), "duplicate route") {
+		t.Fatalf("expected duplicate route error, got %v", err)
+	}
+}
+
+func TestRPCHandleServiceRejectsNonStruct(t *testing.T) {
+	router := NewRouter()
+	if err := router.HandleService(nil); err == nil {
+		t.Fatalf("expected nil service error")
+	}
+	if err := router.HandleService(greet); err == nil {
+		t.Fatalf("expected non-struct service error")
+	}
+	var svc *accountService
+	if err := router.HandleService(svc); err == nil {
+		t.Fatalf("expected nil pointer service error")
+	}
+}
@@ -0,0 +1,204 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/swetjen/virtuous/internal/jsonlimit"
+)
+
+// MediaTypeEventStream is the response media type for streaming RPC handlers.
+const MediaTypeEventStream = "text/event-stream"
+
+// ErrStreamClosed is returned by Stream.Send after the handler has returned.
+var ErrStreamClosed = errors.New("rpc: stream closed")
+
+// Stream sends a sequence of typed messages to the caller of a streaming RPC.
+//
+// Streaming handlers use one of these signatures:
+//
+//	func(context.Context, Req, rpc.Stream[Msg]) int
+//	func(context.Context, rpc.Stream[Msg]) int
+//
+// Each Send is written as a Server-Sent Events "message" event. The returned
+// status ends the stream with a "done" event (200) or an "error" event.
+type Stream[T any] struct {
+	writer *streamWriter
+}
+
+// Send writes one message to the caller and flushes it immediately.
+func (s Stream[T]) Send(msg T) error {
+	if s.writer == nil {
+		return ErrStreamClosed
+	}
+	return s.writer.send(msg)
+}
+
+func (s *Stream[T]) bindStream(w *streamWriter) {
+	s.writer = w
+}
+
+func (s *Stream[T]) streamMessageType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+type streamBinder interface {
+	bindStream(*streamWriter)
+	streamMessageType() reflect.Type
+}
+
+var streamBinderType = reflect.TypeOf((*streamBinder)(nil)).Elem()
+
+// streamMessageType reports the message type when t is an rpc.Stream[T].
+func streamMessageType(t reflect.Type) (reflect.Type, bool) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	ptr := reflect.PointerTo(t)
+	if !ptr.Implements(streamBinderType) {
+		return nil, false
+	}
+	return reflect.New(t).Interface().(streamBinder).streamMessageType(), true
+}
+
+// streamStatus is the payload of terminal "error" events and of streaming
+// responses that fail before the first message is sent.
+type streamStatus struct {
+	Status int `json:"status"`
+}
+
+type streamWriter struct {
+	w       http.ResponseWriter
+	ctx     context.Context
+	mu      sync.Mutex
+	started bool
+	closed  bool
+}
+
+func newStreamWriter(ctx context.Context, w http.ResponseWriter) *streamWriter {
+	return &streamWriter{ctx: ctx, w: w}
+}
+
+func (s *streamWriter) send(msg any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStreamClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.start()
+	return s.writeEvent("message", data)
+}
+
+// finish closes the stream with the handler status. Failures before the first
+// message are reported as a plain HTTP status so clients can reject the call
+// before iterating.
+func (s *streamWriter) finish(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if !s.started && status != StatusOK {
+		writeStreamStatus(s.w, status)
+		return
+	}
+	s.start()
+	if status == StatusOK {
+		_ = s.writeEvent("done", []byte("{}"))
+		return
+	}
+	data, _ := json.Marshal(streamStatus{Status: status})
+	_ = s.writeEvent("error", data)
+}
+
+func (s *streamWriter) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	header := s.w.Header()
+	header.Set("Content-Type", MediaTypeEventStream)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(StatusOK)
+	_ = http.NewResponseController(s.w).Flush()
+}
+
+func (s *streamWriter) writeEvent(event string, data []byte) error {
+	buf := make([]byte, 0, len(event)+len(data)+16)
+	buf = append(buf, "event: "...)
+	buf = append(buf, event...)
+	buf = append(buf, "\ndata: "...)
+	buf = append(buf, data...)
+	buf = append(buf, "\n\n"...)
+	if _, err := s.w.Write(buf); err != nil {
+		return err
+	}
+	if err := http.NewResponseController(s.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+func writeStreamStatus(w http.ResponseWriter, status int) {
+	writeJSON(w, status, reflect.ValueOf(streamStatus{Status: status}))
+}
+
+func (router *Router) buildStreamHandler(spec handlerSpec) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			setTraceError(req.Context(), "method not allowed")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		args := make([]reflect.Value, 0, 3)
+		args = append(args, reflect.ValueOf(req.Context()))
+
+		if spec.reqType != nil {
+			reqVal, err := decodeRequest(w, req, router.requestCodec(req), spec.reqType, spec.validator, router.maxBodyBytes, router.strictJSON)
+			if err != nil {
+				if validationErr, ok := asValidationError(err); ok {
+					setTraceError(req.Context(), validationErr.Error())
+					writeStreamStatus(w, StatusInvalid)
+					return
+				}
+				setTraceError(req.Context(), "invalid request body")
+				if jsonlimit.IsBodyTooLarge(err) {
+					writeStreamStatus(w, http.StatusRequestEntityTooLarge)
+					return
+				}
+				writeStreamStatus(w, StatusInvalid)
+				return
+			}
+			args = append(args, reqVal)
+		}
+
+		writer := newStreamWriter(req.Context(), w)
+		stream := reflect.New(spec.streamType)
+		stream.Interface().(streamBinder).bindStream(writer)
+		args = append(args, stream.Elem())
+
+		out := spec.fn.Call(args)
+		status := int(out[0].Int())
+		if status != StatusOK && status != StatusInvalid && status != StatusError {
+			setTraceError(req.Context(), "invalid rpc status")
+			status = StatusError
+		}
+		if status >= 400 {
+			setTraceError(req.Context(), "rpc stream failed")
+		}
+		writer.finish(status)
+	})
+}
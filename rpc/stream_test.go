@@ -0,0 +1,255 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type progressReq struct {
+	Steps int `json:"steps"`
+}
+
+type progressMsg struct {
+	Step int    `json:"step"`
+	Note string `json:"note,omitempty"`
+}
+
+func progressStream(ctx context.Context, req progressReq, stream Stream[progressMsg]) int {
+	if req.Steps < 0 {
+		return StatusInvalid
+	}
+	for i := 1; i <= req.Steps; i++ {
+		if err := stream.Send(progressMsg{Step: i}); err != nil {
+			return StatusError
+		}
+	}
+	if req.Steps == 2 {
+		return StatusError
+	}
+	return StatusOK
+}
+
+func tickerStream(_ context.Context, stream Stream[*progressMsg]) int {
+	_ = stream.Send(&progressMsg{Step: 1, Note: "tick"})
+	return StatusOK
+}
+
+func invalidStreamReturn(_ context.Context, _ Stream[progressMsg]) (progressMsg, int) {
+	return progressMsg{}, StatusOK
+}
+
+func invalidStreamMessage(_ context.Context, _ Stream[string]) int {
+	return StatusOK
+}
+
+func TestRPCStreamWritesEventsAndDone(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(progressStream)
+	route := router.Routes()[0]
+	if !route.Streaming {
+		t.Fatalf("expected streaming route")
+	}
+	if route.ResponseType != reflect.TypeFor[progressMsg]() {
+		t.Fatalf("response type = %v, want progressMsg", route.ResponseType)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, route.Path, strings.NewReader(`{"steps":3}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != MediaTypeEventStream {
+		t.Fatalf("content type = %q, want %q", got, MediaTypeEventStream)
+	}
+	want := "event: message\ndata: {\"step\":1}\n\n" +
+		"event: message\ndata: {\"step\":2}\n\n" +
+		"event: message\ndata: {\"step\":3}\n\n" +
+		"event: done\ndata: {}\n\n"
+	if rec.Body.String() != want {
+		t.Fatalf("unexpected stream body:\n%s", rec.Body.String())
+	}
+}
+
+func TestRPCStreamStatusBeforeFirstMessageIsPlainResponse(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(progressStream)
+	path := router.Routes()[0].Path
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"steps":-1}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != StatusInvalid {
+		t.Fatalf("expected status 422, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("content type = %q, want application/json", got)
+	}
+	var body streamStatus
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode status body: %v", err)
+	}
+	if body.Status != StatusInvalid {
+		t.Fatalf("status body = %d, want %d", body.Status, StatusInvalid)
+	}
+}
+
+func TestRPCStreamStatusAfterFirstMessageIsErrorEvent(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(progressStream)
+	path := router.Routes()[0].Path
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"steps":2}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 once streaming, got %d", rec.Code)
+	}
+	if !strings.HasSuffix(rec.Body.String(), "event: error\ndata: {\"status\":500}\n\n") {
+		t.Fatalf("expected terminal error event, got:\n%s", rec.Body.String())
+	}
+	snapshot := router.observability.Snapshot()
+	if len(snapshot.Routes) != 1 || snapshot.Routes[0].RequestsLastMinute != 1 {
+		t.Fatalf("expected one observed stream request, got %+v", snapshot.Routes)
+	}
+}
+
+func TestRPCStreamSendAfterReturnFails(t *testing.T) {
+	var stream Stream[progressMsg]
+	if err := stream.Send(progressMsg{}); err != ErrStreamClosed {
+		t.Fatalf("unbound stream Send error = %v, want ErrStreamClosed", err)
+	}
+	writer := newStreamWriter(context.Background(), httptest.NewRecorder())
+	stream.bindStream(writer)
+	writer.finish(StatusOK)
+	if err := stream.Send(progressMsg{}); err != ErrStreamClosed {
+		t.Fatalf("closed stream Send error = %v, want ErrStreamClosed", err)
+	}
+}
+
+func TestRPCStreamInvalidSignaturesPanic(t *testing.T) {
+	router := NewRouter()
+	expectPanic(t, func() {
+		router.HandleRPC(invalidStreamReturn)
+	})
+	expectPanic(t, func() {
+		router.HandleRPC(invalidStreamMessage)
+	})
+}
+
+func TestRPCStreamOpenAPIUsesEventStreamMedia(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(progressStream)
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	paths := doc["paths"].(map[string]any)
+	op := paths["/rpc/rpc/progress-stream"].(map[string]any)["post"].(map[string]any)
+	ok := op["responses"].(map[string]any)["200"].(map[string]any)
+	content := ok["content"].(map[string]any)
+	media, exists := content[MediaTypeEventStream].(map[string]any)
+	if !exists {
+		t.Fatalf("expected %s response content, got %v", MediaTypeEventStream, content)
+	}
+	ref := media["schema"].(map[string]any)["$ref"]
+	if ref != "#/components/schemas/progressMsg" {
+		t.Fatalf("stream schema ref = %v", ref)
+	}
+	if _, exists := op["requestBody"]; !exists {
+		t.Fatalf("expected stream request body")
+	}
+}
+
+func TestRPCStreamClientsEmitAsyncIterators(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(progressStream)
+	router.HandleRPC(tickerStream)
+
+	var ts bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), "async *progressStream(request: progressReq, options?: AuthOptions): AsyncGenerator<progressMsg, void, undefined>")
+	assertRPCContains(t, ts.String(), `yield* readEventStream<progressMsg>(response, (message) => validate(clientOptions, "rpc.progressStream", "response", message))`)
+	assertRPCContains(t, ts.String(), `"Accept": "text/event-stream"`)
+
+	var js bytes.Buffer
+	if err := router.WriteClientJS(&js); err != nil {
+		t.Fatalf("write js client: %v", err)
+	}
+	assertRPCContains(t, js.String(), "async *tickerStream(options)")
+	assertRPCContains(t, js.String(), "@returns {AsyncGenerator<progressMsg, void, undefined>}")
+
+	var py bytes.Buffer
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	assertRPCContains(t, py.String(), `def progressStream(self, body:"progressReq") ->Iterator["progressMsg"]:`)
+	assertRPCContains(t, py.String(), `return _rpc_stream(self._options, "rpc.progressStream", url, headers, data, progressMsg)`)
+
+	dir := t.TempDir()
+	pyPath := filepath.Join(dir, "client.gen.py")
+	if err := os.WriteFile(pyPath, py.Bytes(), 0644); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	snippet := pythonRPCImportSnippet(pyPath) + `
+class FakeStream:
+    status = 200
+    def __enter__(self):
+        return self
+    def __exit__(self, exc_type, exc, tb):
+        return False
+    def __iter__(self):
+        return iter([
+            b"event: message\n", b'data: {"step":1}\n', b"\n",
+            b"event: message\n", b'data: {"step":2,"note":"x"}\n', b"\n",
+            b"event: done\n", b"data: {}\n", b"\n",
+        ])
+
+seen = []
+def fake_urlopen(req):
+    seen.append(req)
+    return FakeStream()
+
+mod.request.urlopen = fake_urlopen
+client = mod.create_client(base_url="https://core.example")
+messages = list(client.rpc.progressStream(mod.progressReq(steps=2)))
+assert [m.step for m in messages] == [1, 2]
+assert messages[1].note == "x"
+assert isinstance(messages[0], mod.progressMsg)
+assert seen[0].get_header("Accept") == "text/event-stream"
+
+class FailingStream(FakeStream):
+    def __iter__(self):
+        return iter([b"event: message\n", b'data: {"step":1}\n', b"\n", b"event: error\n", b'data: {"status":500}\n', b"\n"])
+
+mod.request.urlopen = lambda req: FailingStream()
+received = []
+try:
+    for message in client.rpc.progressStream(mod.progressReq(steps=2)):
+        received.append(message)
+    raise AssertionError("expected RPCError")
+except mod.RPCError as err:
+    assert err.status == 500
+assert len(received) == 1
+`
+	if err := runRPCPython("-c", snippet); err != nil {
+		t.Fatalf("python stream client failed: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeoutHeader carries a client's deadline for one call, in milliseconds.
+// The router applies it as a context deadline, capped by the route timeout.
+const TimeoutHeader = "X-Virtuous-Timeout-Ms"
+
+// Timeout bounds a handler's context to d, overriding the router-wide
+// WithTimeout value. Calls that run past it get a 504 with code "timeout".
+// Handlers must honor ctx for the deadline to take effect.
+func Timeout(d time.Duration) HandlerOption {
+	return handlerOptionFunc(func(c *handlerConfig) {
+		if d > 0 {
+			c.timeout = d
+		}
+	})
+}
+
+// withCallDeadline derives the handler context from the route timeout and
+// the client's TimeoutHeader. The header can shorten the route timeout but
+// never extend it.
+func withCallDeadline(req *http.Request, timeout time.Duration) (*http.Request, context.CancelFunc) {
+	if requested, ok := requestedTimeout(req); ok && (timeout <= 0 || requested < timeout) {
+		timeout = requested
+	}
+	if timeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return req.WithContext(ctx), cancel
+}
+
+func requestedTimeout(req *http.Request) (time.Duration, bool) {
+	raw := strings.TrimSpace(req.Header.Get(TimeoutHeader))
+	if raw == "" {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || ms <= 0 || ms > int64(time.Duration(1<<63-1)/time.Millisecond) {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// timedOut reports whether the handler gave up because the deadline set by
+// withCallDeadline expired. A handler that returns a result after the deadline
+// keeps it.
+func timedOut(ctx context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) && expired(ctx)
+}
+
+// expired reports whether the call's deadline passed before the handler ran.
+func expired(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+func writeTimeout(w http.ResponseWriter, req *http.Request, codec Codec) {
+	setTraceTimeout(req.Context())
+	writeError(w, codec, http.StatusGatewayTimeout, &Error{Code: ErrorCodeTimeout, Message: "deadline exceeded"})
+}
@@ -0,0 +1,163 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func slowGreet(ctx context.Context, _ greetReq) (greetResp, error) {
+	select {
+	case <-ctx.Done():
+		return greetResp{}, ctx.Err()
+	case <-time.After(2 * time.Second):
+		return greetResp{Message: "done"}, nil
+	}
+}
+
+func deadlineGreet(ctx context.Context) (greetResp, int) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return greetResp{Message: "none"}, StatusOK
+	}
+	if time.Until(deadline) > time.Minute {
+		return greetResp{Message: "long"}, StatusOK
+	}
+	return greetResp{Message: "short"}, StatusOK
+}
+
+func lateGreet(ctx context.Context, _ greetReq) (greetResp, error) {
+	<-ctx.Done()
+	time.Sleep(5 * time.Millisecond)
+	return greetResp{Message: "late"}, nil
+}
+
+func postWithTimeout(router *Router, path, timeout string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+	if timeout != "" {
+		req.Header.Set(TimeoutHeader, timeout)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRPCTimeoutReturns504AndIsObservedSeparately(t *testing.T) {
+	router := NewRouter(WithTimeout(20 * time.Millisecond))
+	router.HandleRPC(slowGreet)
+
+	started := time.Now()
+	rec := postWithTimeout(router, "/rpc/rpc/slow-greet", "")
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected the router timeout to cut the call short, took %s", elapsed)
+	}
+	var envelope Error
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode error: %v (%s)", err, rec.Body.String())
+	}
+	if rec.Code != http.StatusGatewayTimeout || envelope.Code != ErrorCodeTimeout {
+		t.Fatalf("expected 504 timeout envelope, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	snapshot := router.observability.Snapshot()
+	if snapshot.Totals.TimeoutsLast24H != 1 || snapshot.Totals.ServerErrorsLast24H != 0 {
+		t.Fatalf("expected one timeout and no server errors, got %+v", snapshot.Totals)
+	}
+}
+
+func TestRPCTimeoutHeaderIsCappedByRouteTimeout(t *testing.T) {
+	router := NewRouter(WithTimeout(time.Hour))
+	router.HandleRPC(deadlineGreet)
+	router.HandleRPC(slowGreet, Timeout(20*time.Millisecond))
+
+	for header, want := range map[string]string{
+		"":         "long",
+		"50":       "short",
+		"junk":     "long",
+		"-5":       "long",
+		"86400000": "long",
+	} {
+		rec := postWithTimeout(router, "/rpc/rpc/deadline-greet", header)
+		if !strings.Contains(rec.Body.String(), `"message":"`+want+`"`) {
+			t.Fatalf("header %q: expected %s deadline, got %s", header, want, rec.Body.String())
+		}
+	}
+	if rec := postWithTimeout(router, "/rpc/rpc/slow-greet", "600000"); rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected the route timeout to cap the client deadline, got %d", rec.Code)
+	}
+
+	unbounded := NewRouter()
+	unbounded.HandleRPC(deadlineGreet)
+	if rec := postWithTimeout(unbounded, "/rpc/rpc/deadline-greet", ""); !strings.Contains(rec.Body.String(), `"none"`) {
+		t.Fatalf("expected no deadline without a timeout, got %s", rec.Body.String())
+	}
+	if rec := postWithTimeout(unbounded, "/rpc/rpc/deadline-greet", "50"); !strings.Contains(rec.Body.String(), `"short"`) {
+		t.Fatalf("expected the client deadline to apply, got %s", rec.Body.String())
+	}
+}
+
+func TestRPCTimeoutRegistrationDocsAndClients(t *testing.T) {
+	defer func() {
+		if got := recover(); got == nil || !strings.Contains(got.(error).Error(), "timeouts are not supported on streaming") {
+			t.Fatalf("expected streaming timeout to be rejected, got %v", got)
+		}
+	}()
+
+	router := NewRouter()
+	router.HandleRPC(slowGreet, Timeout(time.Second))
+	router.HandleRPC(greet)
+	if got := router.Routes()[0].Timeout; got != time.Second {
+		t.Fatalf("expected route timeout, got %s", got)
+	}
+
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	if _, ok := doc.Paths["/rpc/rpc/slow-greet"]["post"].Responses["504"]; !ok {
+		t.Fatalf("expected 504 response on timed route")
+	}
+	if _, ok := doc.Paths["/rpc/rpc/greet"]["post"].Responses["504"]; ok {
+		t.Fatalf("expected no 504 response without a timeout")
+	}
+
+	var ts, py bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write py client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), "timeoutMs?: number")
+	assertRPCContains(t, ts.String(), `request.headers["X-Virtuous-Timeout-Ms"] = String(Math.ceil(timeoutMs))`)
+	assertRPCContains(t, py.String(), "timeout_ms: int | None = None")
+	assertRPCContains(t, py.String(), `headers["X-Virtuous-Timeout-Ms"] = str(timeout_ms)`)
+
+	router.HandleRPC(tickerStream, Timeout(time.Second))
+}
+
+func TestRPCTimeoutKeepsResultsReturnedAfterTheDeadline(t *testing.T) {
+	router := NewRouter(WithTimeout(10 * time.Millisecond))
+	router.HandleRPC(lateGreet)
+
+	rec := postWithTimeout(router, "/rpc/rpc/late-greet", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"message":"late"`) {
+		t.Fatalf("expected the late result, got %d %s", rec.Code, rec.Body.String())
+	}
+	if snapshot := router.observability.Snapshot(); snapshot.Totals.TimeoutsLast24H != 0 {
+		t.Fatalf("expected no timeout to be recorded, got %+v", snapshot.Totals)
+	}
+}
@@ -1,6 +1,9 @@
 package rpc
 
-import "reflect"
+import (
+	"reflect"
+	"time"
+)
 
 const (
 	StatusOK      = 200
@@ -10,10 +13,33 @@ const (
 
 // Route captures a registered RPC handler and its metadata.
 type Route struct {
-	Path         string
-	Service      string
-	Method       string
-	RequestType  reflect.Type
+	Path        string
+	Service     string
+	Method      string
+	RequestType reflect.Type
+	// ResponseType is the stream message type for streaming routes.
 	ResponseType reflect.Type
-	Guards       []GuardSpec
+	// ErrorType is set for handlers that return an error and describes the
+	// error envelope written for 422 and 500 responses.
+	ErrorType reflect.Type
+	Streaming bool
+	Guards    []GuardSpec
+	// Docs holds the summary, description, tags, and deprecation set through
+	// handler options, falling back to the handler's Go doc comment (see
+	// schema.RegisterDocComments).
+	Docs RouteDocs
+	// MCP records the ExposeMCP or HideMCP choice.
+	MCP MCPExposure
+	// Idempotent marks routes that honor Idempotency-Key.
+	Idempotent bool
+	// Timeout is the route's effective call timeout, zero when calls are
+	// unbounded.
+	Timeout time.Duration
+	// ReadOnly routes also accept GET.
+	ReadOnly bool
+	// Cache is the policy for responses of ReadOnly routes.
+	Cache CachePolicy
+
+	// docKey is the handler's runtime function name for doc comment lookup.
+	docKey string
 }
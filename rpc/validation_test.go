@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type inviteReq struct {
+	Email string   `json:"email" format:"email" required:"true"`
+	Role  string   `json:"role,omitempty" enum:"admin,member" default:"member"`
+	Seats int      `json:"seats" minimum:"1" maximum:"50"`
+	Teams []string `json:"teams,omitempty" maxItems:"2"`
+}
+
+type inviteResp struct {
+	Role  string `json:"role"`
+	Error string `json:"error,omitempty"`
+}
+
+var inviteCalls int
+
+func inviteMember(_ context.Context, req inviteReq) (inviteResp, int) {
+	inviteCalls++
+	return inviteResp{Role: req.Role}, StatusOK
+}
+
+func inviteMemberWithError(_ context.Context, req inviteReq) (inviteResp, error) {
+	return inviteResp{Role: req.Role}, nil
+}
+
+func inviteStream(_ context.Context, _ inviteReq, stream Stream[inviteResp]) int {
+	_ = stream.Send(inviteResp{})
+	return StatusOK
+}
+
+type docOnlyTagsReq struct {
+	Level int    `json:"level" enum:"low,high"`
+	Plan  string `json:"plan" default:"basic"`
+	Limit uint8  `json:"limit" default:"1000"`
+}
+
+func docOnlyTags(_ context.Context, req docOnlyTagsReq) (docOnlyTagsReq, int) {
+	return req, StatusOK
+}
+
+func TestRPCValidationLogsTagsThatDoNotParse(t *testing.T) {
+	var logs bytes.Buffer
+	router := NewRouter()
+	router.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	router.HandleRPC(docOnlyTags)
+
+	if !strings.Contains(logs.String(), "rpc request validation disabled") || !strings.Contains(logs.String(), "Level") {
+		t.Fatalf("expected a registration warning, got %q", logs.String())
+	}
+	rec, _ := postRPC(t, router, "/rpc/rpc/doc-only-tags", `{"level":7}`)
+	if rec.Code != StatusOK || !strings.Contains(rec.Body.String(), `"level":7`) {
+		t.Fatalf("expected the request to reach the handler unvalidated, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRPCValidationRejectsBeforeHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(inviteMember)
+	router.HandleRPC(inviteMemberWithError)
+	router.HandleRPC(inviteStream)
+
+	inviteCalls = 0
+	for _, path := range []string{"/rpc/rpc/invite-member", "/rpc/rpc/invite-member-with-error"} {
+		rec, body := postRPC(t, router, path, `{"email":"nope","role":"owner","seats":-1,"teams":["a","b","c"]}`)
+		if rec.Code != StatusInvalid {
+			t.Fatalf("%s: expected 422, got %d", path, rec.Code)
+		}
+		if body.Code != ErrorCodeInvalid || body.Message != "request validation failed" {
+			t.Fatalf("%s: unexpected envelope %+v", path, body)
+		}
+		got := map[string]string{}
+		for _, detail := range body.Details {
+			got[detail.Field] = detail.Code
+		}
+		want := map[string]string{"email": "format", "role": "enum", "seats": "minimum", "teams": "maxItems"}
+		if len(got) != len(want) {
+			t.Fatalf("%s: details = %+v", path, body.Details)
+		}
+		for field, code := range want {
+			if got[field] != code {
+				t.Fatalf("%s: %s code = %q, want %q", path, field, got[field], code)
+			}
+		}
+	}
+	if inviteCalls != 0 {
+		t.Fatalf("handler ran %d times for invalid requests", inviteCalls)
+	}
+
+	rec, _ := postRPC(t, router, "/rpc/rpc/invite-member", `{"email":"dev@example.com","seats":3}`)
+	if rec.Code != StatusOK || !strings.Contains(rec.Body.String(), `"role":"member"`) {
+		t.Fatalf("expected default role to reach handler, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec, body := postRPC(t, router, "/rpc/rpc/invite-member", `{"email":`)
+	if rec.Code != StatusInvalid || body.Code != ErrorCodeInvalid || body.Message != "invalid request body" {
+		t.Fatalf("expected malformed bodies to use the envelope, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/rpc/invite-stream", strings.NewReader(`{"email":""}`))
+	streamRec := httptest.NewRecorder()
+	router.ServeHTTP(streamRec, req)
+	if streamRec.Code != StatusInvalid || !strings.Contains(streamRec.Body.String(), `"status":422`) {
+		t.Fatalf("expected stream 422 status body, got %d %s", streamRec.Code, streamRec.Body.String())
+	}
+}
+
+func TestRPCValidationOpenAPIAndClients(t *testing.T) {
+	router := NewRouter()
+	router.HandleRPC(inviteMember)
+	router.HandleRPC(inviteMemberWithError)
+
+	data, err := router.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	props := schemas["inviteReq"].(map[string]any)["properties"].(map[string]any)
+	if props["teams"].(map[string]any)["maxItems"] != float64(2) {
+		t.Fatalf("teams maxItems missing: %v", props["teams"])
+	}
+	if props["seats"].(map[string]any)["minimum"] != float64(1) {
+		t.Fatalf("seats minimum missing: %v", props["seats"])
+	}
+	paths := doc["paths"].(map[string]any)
+	op := paths["/rpc/rpc/invite-member"].(map[string]any)["post"].(map[string]any)
+	invalid := op["responses"].(map[string]any)["422"].(map[string]any)
+	schemaDoc := invalid["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	oneOf, ok := schemaDoc["oneOf"].([]any)
+	if !ok || len(oneOf) != 2 {
+		t.Fatalf("expected oneOf 422 schema for validated legacy handler, got %v", schemaDoc)
+	}
+	if oneOf[1].(map[string]any)["$ref"] != "#/components/schemas/"+errorBodySchemaName {
+		t.Fatalf("unexpected oneOf members: %v", oneOf)
+	}
+
+	var ts bytes.Buffer
+	if err := router.WriteClientTS(&ts); err != nil {
+		t.Fatalf("write ts client: %v", err)
+	}
+	assertRPCContains(t, ts.String(), `role?: "admin" | "member";`)
+	assertRPCContains(t, ts.String(), "/** @minimum 1 @maximum 50 */")
+	assertRPCContains(t, ts.String(), "/** @format email */")
+	assertRPCContains(t, ts.String(), "throw new RPCError<inviteResp | RPCErrorBody>(")
+
+	var js bytes.Buffer
+	if err := router.WriteClientJS(&js); err != nil {
+		t.Fatalf("write js client: %v", err)
+	}
+	assertRPCContains(t, js.String(), `@property {"admin" | "member"} [role] - (default member)`)
+	assertRPCContains(t, js.String(), "@property {number} seats - (minimum 1; maximum 50)")
+
+	var py bytes.Buffer
+	if err := router.WriteClientPY(&py); err != nil {
+		t.Fatalf("write python client: %v", err)
+	}
+	assertRPCContains(t, py.String(), `# constraints: enum "admin" | "member"; default member`)
+	assertRPCContains(t, py.String(), "inviteResp | RPCErrorBody, timeout_ms)")
+}
@@ -1,6 +1,11 @@
 package virtuous
 
-import "github.com/swetjen/virtuous/rpc"
+import (
+	"time"
+
+	"github.com/swetjen/virtuous/idempotency"
+	"github.com/swetjen/virtuous/rpc"
+)
 
 // RPC type aliases for convenience.
 type RPCGuard = rpc.Guard
@@ -8,12 +13,34 @@ type RPCGuardSpec = rpc.GuardSpec
 type RPCRoute = rpc.Route
 type RPCRouter = rpc.Router
 type RPCTypeOverride = rpc.TypeOverride
+type RPCError = rpc.Error
+type RPCErrorDetail = rpc.ErrorDetail
+type RPCHandlerOption = rpc.HandlerOption
+type RPCUnaryInfo = rpc.UnaryInfo
+type RPCUnaryHandler = rpc.UnaryHandler
+type RPCUnaryInterceptor = rpc.UnaryInterceptor
+type RPCServiceOptions = rpc.ServiceOptions
+type RPCServiceNaming = rpc.ServiceNaming
+type RPCCachePolicy = rpc.CachePolicy
+type RPCCompressionOptions = rpc.CompressionOptions
+type RPCCodec = rpc.Codec
+type RPCRequestEvent = rpc.RequestEvent
+type RPCGuardDecision = rpc.GuardDecision
+type RPCCallObserver = rpc.CallObserver
+type RPCRouteDocs = rpc.RouteDocs
+type RPCMCPExposure = rpc.MCPExposure
+type RPCMCPOptions = rpc.MCPOptions
+type RPCMCPOpt = rpc.MCPOpt
+type RPCJSONRPCOptions = rpc.JSONRPCOptions
+type RPCJSONRPCOpt = rpc.JSONRPCOpt
 
 type RPCDocsOptions = rpc.DocsOptions
 type RPCDocOpt = rpc.DocOpt
 type RPCModule = rpc.Module
 type RPCServeAllDocsOptions = rpc.ServeAllDocsOptions
 type RPCServeAllDocsOpt = rpc.ServeAllDocsOpt
+type RPCGoClientOptions = rpc.GoClientOptions
+type RPCKotlinClientOptions = rpc.KotlinClientOptions
 
 type RPCOpenAPIOptions = rpc.OpenAPIOptions
 type RPCOpenAPIServer = rpc.OpenAPIServer
@@ -46,6 +73,42 @@ func RPCWithGuards(guards ...rpc.Guard) rpc.RouterOption {
 	return rpc.WithGuards(guards...)
 }
 
+func RPCWithInterceptors(interceptors ...rpc.UnaryInterceptor) rpc.RouterOption {
+	return rpc.WithInterceptors(interceptors...)
+}
+
+func RPCGuards(guards ...rpc.Guard) rpc.HandlerOption {
+	return rpc.Guards(guards...)
+}
+
+func RPCIntercept(interceptors ...rpc.UnaryInterceptor) rpc.HandlerOption {
+	return rpc.Intercept(interceptors...)
+}
+
+func RPCWithIdempotencyStore(store idempotency.Store) rpc.RouterOption {
+	return rpc.WithIdempotencyStore(store)
+}
+
+func RPCWithTimeout(d time.Duration) rpc.RouterOption {
+	return rpc.WithTimeout(d)
+}
+
+func RPCWithoutPanicRecovery() rpc.RouterOption {
+	return rpc.WithoutPanicRecovery()
+}
+
+func RPCWithServiceNaming(naming rpc.ServiceNaming) rpc.RouterOption {
+	return rpc.WithServiceNaming(naming)
+}
+
+func RPCWithCompression(opts rpc.CompressionOptions) rpc.RouterOption {
+	return rpc.WithCompression(opts)
+}
+
+func RPCWithCodecs(codecs ...rpc.Codec) rpc.RouterOption {
+	return rpc.WithCodecs(codecs...)
+}
+
 func RPCWithMaxRequestBodyBytes(maxBytes int64) rpc.RouterOption {
 	return rpc.WithMaxRequestBodyBytes(maxBytes)
 }
@@ -118,6 +181,26 @@ func RPCWithClientPYPath(path string) RPCServeAllDocsOpt {
 	return rpc.WithClientPYPath(path)
 }
 
+func RPCWithClientPYAsyncPath(path string) RPCServeAllDocsOpt {
+	return rpc.WithClientPYAsyncPath(path)
+}
+
+func RPCWithClientGoPath(path string) RPCServeAllDocsOpt {
+	return rpc.WithClientGoPath(path)
+}
+
+func RPCWithClientSwiftPath(path string) RPCServeAllDocsOpt {
+	return rpc.WithClientSwiftPath(path)
+}
+
+func RPCWithClientKotlinPath(path string) RPCServeAllDocsOpt {
+	return rpc.WithClientKotlinPath(path)
+}
+
+func RPCWithClientZodPath(path string) RPCServeAllDocsOpt {
+	return rpc.WithClientZodPath(path)
+}
+
 func RPCWithoutDocs() RPCServeAllDocsOpt {
 	return rpc.WithoutDocs()
 }
@@ -0,0 +1,168 @@
+// Package rpctest calls handlers registered on an rpc.Router from tests.
+//
+// Calls go through the router's real HTTP path: request encoding and
+// validation, guards, interceptors, timeouts, and response encoding. Each
+// Result also carries the observability record and guard decisions the call
+// produced.
+package rpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/swetjen/virtuous/rpc"
+)
+
+// Result is the outcome of one call.
+type Result[Resp any] struct {
+	// Status is the HTTP status the router answered with.
+	Status int
+	// Response is the decoded response body. It stays zero for error
+	// envelopes of handlers that return an error.
+	Response Resp
+	// Error is the decoded error envelope when the router or handler
+	// answered with one.
+	Error *rpc.Error
+	// Header and Body are the raw response.
+	Header http.Header
+	Body   []byte
+	// Events are the observability records the call produced.
+	Events []rpc.RequestEvent
+	// Guards are the guard decisions in the order the guards ran.
+	Guards []rpc.GuardDecision
+}
+
+// Option configures one call.
+type Option func(*callOptions)
+
+type callOptions struct {
+	ctx    context.Context
+	header http.Header
+}
+
+// WithAuth sets the Authorization header, for example "Bearer token".
+func WithAuth(value string) Option {
+	return WithHeader("Authorization", value)
+}
+
+// WithHeader sets a request header.
+func WithHeader(name, value string) Option {
+	return func(o *callOptions) {
+		o.header.Set(name, value)
+	}
+}
+
+// WithContext sets the request context. Its deadline and values reach
+// guards and the handler.
+func WithContext(ctx context.Context) Option {
+	return func(o *callOptions) {
+		if ctx != nil {
+			o.ctx = ctx
+		}
+	}
+}
+
+// Call invokes a handler with the func(context.Context, Req) (Resp, int)
+// signature through router. The test fails if fn is not registered on
+// router or the response cannot be decoded.
+func Call[Req, Resp any](t testing.TB, router *rpc.Router, fn func(context.Context, Req) (Resp, int), req Req, opts ...Option) Result[Resp] {
+	t.Helper()
+	return Invoke[Resp](t, router, fn, req, opts...)
+}
+
+// Invoke is Call for any unary handler signature, including handlers without
+// a request, for which req must be nil, and handlers that return an error.
+// Resp must be the handler's response type.
+func Invoke[Resp any](t testing.TB, router *rpc.Router, fn any, req any, opts ...Option) Result[Resp] {
+	t.Helper()
+	if router == nil {
+		t.Fatalf("rpctest: router is nil")
+	}
+	route, ok := router.RouteFor(fn)
+	if !ok {
+		t.Fatalf("rpctest: handler %T is not registered on the router", fn)
+	}
+	if route.Streaming {
+		t.Fatalf("rpctest: %s is a streaming handler", route.Path)
+	}
+	if want := reflect.TypeFor[Resp](); route.ResponseType != want {
+		t.Fatalf("rpctest: %s responds with %s, not %s", route.Path, route.ResponseType, want)
+	}
+
+	var body io.Reader = http.NoBody
+	if route.RequestType != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("rpctest: encode request for %s: %v", route.Path, err)
+		}
+		body = bytes.NewReader(data)
+	} else if req != nil {
+		t.Fatalf("rpctest: %s takes no request, got %T", route.Path, req)
+	}
+
+	options := callOptions{ctx: context.Background(), header: http.Header{}}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+
+	var result Result[Resp]
+	ctx := rpc.WithCallObserver(options.ctx, func(event rpc.RequestEvent, guards []rpc.GuardDecision) {
+		result.Events = append(result.Events, event)
+		result.Guards = append(result.Guards, guards...)
+	})
+	httpReq := httptest.NewRequestWithContext(ctx, http.MethodPost, route.Path, body)
+	httpReq.Header.Set("Content-Type", rpc.MediaTypeJSON)
+	httpReq.Header.Set("Accept", rpc.MediaTypeJSON)
+	for name, values := range options.header {
+		httpReq.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httpReq)
+
+	result.Status = rec.Code
+	result.Header = rec.Header()
+	result.Body = rec.Body.Bytes()
+	codec, ok := responseCodec(result.Header.Get("Content-Type"))
+	if !ok || len(result.Body) == 0 {
+		if result.Status == rpc.StatusOK && route.ResponseType != nil {
+			t.Fatalf("rpctest: %s answered 200 with %q and no decodable body", route.Path, result.Header.Get("Content-Type"))
+		}
+		return result
+	}
+	if result.Status == rpc.StatusOK || route.ErrorType == nil {
+		if err := codec.Unmarshal(result.Body, &result.Response, false); err != nil && result.Status == rpc.StatusOK {
+			t.Fatalf("rpctest: decode %s response: %v", route.Path, err)
+		}
+	}
+	if result.Status != rpc.StatusOK {
+		var envelope rpc.Error
+		if err := codec.Unmarshal(result.Body, &envelope, false); err == nil && envelope.Code != "" {
+			envelope.Status = result.Status
+			result.Error = &envelope
+		}
+	}
+	return result
+}
+
+// responseCodec returns the built-in codec for a response Content-Type.
+func responseCodec(contentType string) (rpc.Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	for _, codec := range []rpc.Codec{rpc.JSONCodec(), rpc.MessagePackCodec(), rpc.CBORCodec()} {
+		if codec.MediaType() == mediaType {
+			return codec, true
+		}
+	}
+	return nil, false
+}
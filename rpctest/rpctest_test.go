@@ -0,0 +1,163 @@
+package rpctest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swetjen/virtuous/rpc"
+)
+
+type loginRequest struct {
+	Email string `json:"email" minLength:"3"`
+}
+
+type loginResponse struct {
+	Token string `json:"token"`
+	Error string `json:"error,omitempty"`
+}
+
+type profileResponse struct {
+	Name string `json:"name"`
+}
+
+type accountPrincipal string
+
+func (p accountPrincipal) PrincipalID() string { return string(p) }
+
+func UserLogin(_ context.Context, req loginRequest) (loginResponse, int) {
+	if !strings.HasSuffix(req.Email, "@example.com") {
+		return loginResponse{Error: "unknown account"}, rpc.StatusInvalid
+	}
+	return loginResponse{Token: "t-" + req.Email}, rpc.StatusOK
+}
+
+func Profile(ctx context.Context) (profileResponse, error) {
+	principal, ok := rpc.Principal[accountPrincipal](ctx)
+	if !ok {
+		return profileResponse{}, errors.New("no principal")
+	}
+	if principal == "banned" {
+		return profileResponse{}, rpc.Invalid("account disabled")
+	}
+	return profileResponse{Name: string(principal)}, nil
+}
+
+type Accounts struct{}
+
+func (Accounts) Rename(_ context.Context, req loginRequest) (*profileResponse, int, error) {
+	return &profileResponse{Name: req.Email}, rpc.StatusOK, nil
+}
+
+type bearerGuard struct{}
+
+func (bearerGuard) Spec() rpc.GuardSpec {
+	return rpc.GuardSpec{Name: "BearerAuth", In: "header", Param: "Authorization", Prefix: "Bearer"}
+}
+
+func (bearerGuard) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				http.Error(w, "missing auth", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(rpc.WithPrincipal(r.Context(), accountPrincipal(token))))
+		})
+	}
+}
+
+func newRouter(t *testing.T) *rpc.Router {
+	t.Helper()
+	router := rpc.NewRouter()
+	router.HandleRPC(UserLogin)
+	router.HandleRPC(Profile, bearerGuard{})
+	if err := router.HandleService(Accounts{}); err != nil {
+		t.Fatalf("register service: %v", err)
+	}
+	return router
+}
+
+func TestCallDecodesResponseAndStatus(t *testing.T) {
+	router := newRouter(t)
+
+	res := Call(t, router, UserLogin, loginRequest{Email: "ada@example.com"})
+	if res.Status != rpc.StatusOK || res.Response.Token != "t-ada@example.com" || res.Error != nil {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if len(res.Events) != 1 || res.Events[0].RPCName != "rpctest.UserLogin" || res.Events[0].StatusCode != rpc.StatusOK {
+		t.Fatalf("unexpected events: %+v", res.Events)
+	}
+
+	res = Call(t, router, UserLogin, loginRequest{Email: "ada@other.test"})
+	if res.Status != rpc.StatusInvalid || res.Response.Error != "unknown account" {
+		t.Fatalf("expected 422 response body, got %+v", res)
+	}
+	if res.Events[0].ErrorMessage != "unknown account" {
+		t.Fatalf("expected recorded error message, got %+v", res.Events[0])
+	}
+
+	res = Call(t, router, UserLogin, loginRequest{Email: "a"})
+	if res.Status != rpc.StatusInvalid || res.Error == nil || len(res.Error.Details) != 1 || res.Error.Details[0].Field != "email" {
+		t.Fatalf("expected validation envelope, got %+v (%s)", res, res.Body)
+	}
+}
+
+func TestInvokeRecordsGuardDecisions(t *testing.T) {
+	router := newRouter(t)
+
+	denied := Invoke[profileResponse](t, router, Profile, nil)
+	if denied.Status != http.StatusUnauthorized || denied.Error != nil {
+		t.Fatalf("expected guard denial, got %+v", denied)
+	}
+	if len(denied.Guards) != 1 || denied.Guards[0].GuardName != "BearerAuth" || denied.Guards[0].Allowed {
+		t.Fatalf("unexpected guard decisions: %+v", denied.Guards)
+	}
+	if denied.Events[0].GuardOutcome != "deny" {
+		t.Fatalf("expected deny outcome, got %+v", denied.Events[0])
+	}
+
+	allowed := Invoke[profileResponse](t, router, Profile, nil, WithAuth("Bearer ada"))
+	if allowed.Status != rpc.StatusOK || allowed.Response.Name != "ada" {
+		t.Fatalf("unexpected result: %+v", allowed)
+	}
+	if len(allowed.Guards) != 1 || !allowed.Guards[0].Allowed || allowed.Events[0].Principal != "ada" {
+		t.Fatalf("expected allowed guard and principal, got %+v %+v", allowed.Guards, allowed.Events)
+	}
+
+	failed := Invoke[profileResponse](t, router, Profile, nil, WithAuth("Bearer banned"))
+	if failed.Status != rpc.StatusInvalid || failed.Error == nil || failed.Error.Message != "account disabled" || failed.Error.Status != rpc.StatusInvalid {
+		t.Fatalf("expected error envelope, got %+v", failed)
+	}
+}
+
+func TestInvokeServiceMethodValue(t *testing.T) {
+	router := newRouter(t)
+	accounts := Accounts{}
+
+	res := Invoke[*profileResponse](t, router, accounts.Rename, loginRequest{Email: "grace"}, WithHeader("X-Request-ID", "r1"))
+	if res.Status != rpc.StatusOK || res.Response == nil || res.Response.Name != "grace" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Events[0].Path != "/rpc/rpctest/rename" {
+		t.Fatalf("unexpected path: %+v", res.Events[0])
+	}
+}
+
+func TestCallWithContext(t *testing.T) {
+	router := newRouter(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	res := Call(t, router, UserLogin, loginRequest{Email: "ada@example.com"}, WithContext(ctx))
+	if res.Status != http.StatusGatewayTimeout || res.Error == nil || res.Error.Code != rpc.ErrorCodeTimeout {
+		t.Fatalf("expected expired deadline to time out, got %+v", res)
+	}
+	if !res.Events[0].TimedOut {
+		t.Fatalf("expected timed out event, got %+v", res.Events[0])
+	}
+}
@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/swetjen/virtuous/internal/reflectutil"
+)
+
+// constraintTags lists the validation tags rendered into generated clients,
+// in display order. Enum values are rendered separately as literal types.
+var constraintTags = []string{
+	"format", "minimum", "maximum", "minLength", "maxLength",
+	"pattern", "minItems", "maxItems", "default",
+}
+
+func fieldConstraints(field reflect.StructField) []string {
+	var out []string
+	for _, tag := range constraintTags {
+		value := strings.TrimSpace(field.Tag.Get(tag))
+		if value == "" {
+			continue
+		}
+		// Values are rendered inside generated doc comments.
+		value = strings.ReplaceAll(value, "*/", `*\/`)
+		out = append(out, tag+" "+value)
+	}
+	return out
+}
+
+// scalarEnum returns enum tag values for string, numeric, and bool fields.
+// Enum tags on other kinds are documented in OpenAPI only.
+func scalarEnum(field reflect.StructField) []any {
+	if !isScalarKind(reflectutil.DerefType(field.Type)) {
+		return nil
+	}
+	return parseEnumTag(field.Tag.Get("enum"), field.Type)
+}
+
+func enumLiteralType(values []any) string {
+	if len(values) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		parts = append(parts, string(data))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func isScalarKind(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
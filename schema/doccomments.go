@@ -0,0 +1,216 @@
+package schema
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/swetjen/virtuous/internal/reflectutil"
+)
+
+// DocComments maps Go declarations to their doc comments. Keys are
+// "pkg/path.Name" for functions and types, and "pkg/path.Type.Member" for
+// struct fields and methods.
+type DocComments map[string]string
+
+var (
+	docCommentsMu sync.RWMutex
+	docComments   = DocComments{}
+)
+
+// ParseDocComments parses the non-test Go files at the root of fsys, which
+// must hold the package imported as pkgPath. It is typically called with an
+// embed.FS of the package's own sources:
+//
+//	//go:embed *.go
+//	var sources embed.FS
+func ParseDocComments(pkgPath string, fsys fs.FS) (DocComments, error) {
+	pkgPath = strings.TrimSpace(pkgPath)
+	if pkgPath == "" {
+		return nil, fmt.Errorf("schema: doc comments need a package path")
+	}
+	names, err := fs.Glob(fsys, "*.go")
+	if err != nil {
+		return nil, fmt.Errorf("schema: doc comments: %w", err)
+	}
+	out := DocComments{}
+	fset := token.NewFileSet()
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("schema: doc comments: %w", err)
+		}
+		file, err := parser.ParseFile(fset, name, data, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("schema: doc comments: %w", err)
+		}
+		collectDocComments(out, pkgPath, file)
+	}
+	return out, nil
+}
+
+// RegisterDocComments makes docs available as a fallback for missing doc
+// tags and handler documentation. Later registrations win for the same key.
+func RegisterDocComments(docs DocComments) {
+	docCommentsMu.Lock()
+	defer docCommentsMu.Unlock()
+	for key, text := range docs {
+		docComments[key] = text
+	}
+}
+
+// RegisterPackageDocComments parses and registers the doc comments of one
+// package. See ParseDocComments.
+func RegisterPackageDocComments(pkgPath string, fsys fs.FS) error {
+	docs, err := ParseDocComments(pkgPath, fsys)
+	if err != nil {
+		return err
+	}
+	RegisterDocComments(docs)
+	return nil
+}
+
+// FuncDocComment returns the registered doc comment for a function or method,
+// accepting runtime names such as "pkg/path.(*Type).Method-fm".
+func FuncDocComment(name string) string {
+	return lookupDocComment(normalizeFuncName(name))
+}
+
+// TypeDocComment returns the registered doc comment for a named type as a
+// single line.
+func TypeDocComment(t reflect.Type) string {
+	t = reflectutil.DerefType(t)
+	if t == nil || t.Name() == "" || t.PkgPath() == "" {
+		return ""
+	}
+	return singleLine(lookupDocComment(t.PkgPath() + "." + t.Name()))
+}
+
+// fieldDoc returns the doc tag for a field, falling back to the field's
+// registered doc comment on the struct that declares it.
+func fieldDoc(parent reflect.Type, field reflectutil.JSONField) string {
+	if doc := reflectutil.FieldDoc(field.Field); doc != "" {
+		return doc
+	}
+	owner := reflectutil.DerefType(parent)
+	for i := 0; owner != nil && i < len(field.Index)-1; i++ {
+		if owner.Kind() != reflect.Struct {
+			return ""
+		}
+		owner = reflectutil.DerefType(owner.Field(field.Index[i]).Type)
+	}
+	if owner == nil || owner.Name() == "" || owner.PkgPath() == "" {
+		return ""
+	}
+	return singleLine(lookupDocComment(owner.PkgPath() + "." + owner.Name() + "." + field.Field.Name))
+}
+
+func lookupDocComment(key string) string {
+	docCommentsMu.RLock()
+	defer docCommentsMu.RUnlock()
+	return docComments[key]
+}
+
+func collectDocComments(out DocComments, pkgPath string, file *ast.File) {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			text := commentText(d.Doc)
+			if text == "" {
+				continue
+			}
+			key := pkgPath + "." + d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				recv := receiverName(d.Recv.List[0].Type)
+				if recv == "" {
+					continue
+				}
+				key = pkgPath + "." + recv + "." + d.Name.Name
+			}
+			out[key] = text
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				typeKey := pkgPath + "." + typeSpec.Name.Name
+				text := commentText(typeSpec.Doc)
+				if text == "" && len(d.Specs) == 1 {
+					text = commentText(d.Doc)
+				}
+				if text != "" {
+					out[typeKey] = text
+				}
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok || structType.Fields == nil {
+					continue
+				}
+				for _, field := range structType.Fields.List {
+					text := commentText(field.Doc)
+					if text == "" {
+						text = commentText(field.Comment)
+					}
+					if text == "" {
+						continue
+					}
+					for _, name := range field.Names {
+						out[typeKey+"."+name.Name] = text
+					}
+				}
+			}
+		}
+	}
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	default:
+		return ""
+	}
+}
+
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.TrimSpace(group.Text())
+}
+
+// normalizeFuncName converts runtime function names to DocComments keys.
+func normalizeFuncName(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.TrimSuffix(name, "-fm")
+	dir, base := path.Split(name)
+	base = strings.NewReplacer("(*", "", "(", "", ")", "").Replace(base)
+	if idx := strings.Index(base, "["); idx >= 0 {
+		if end := strings.Index(base[idx:], "]"); end >= 0 {
+			base = base[:idx] + base[idx+end+1:]
+		}
+	}
+	return dir + base
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+type commentedAddress struct {
+	City string `json:"city"`
+}
+
+type commentedBase struct {
+	ID string `json:"id"`
+}
+
+type commentedProfile struct {
+	commentedBase
+	Name    string           `json:"name"`
+	Email   string           `json:"email" doc:"Tag docs win."`
+	Address commentedAddress `json:"address"`
+}
+
+const commentedSource = `package schema
+
+// commentedAddress is a postal address.
+type commentedAddress struct {
+	// City is the
+	// municipality name.
+	City string
+}
+
+type commentedBase struct {
+	ID string // ID is the stable identifier.
+}
+
+// commentedProfile describes a user profile.
+type commentedProfile struct {
+	commentedBase
+	// Name is the display name.
+	Name string
+	// Email is ignored because the doc tag is set.
+	Email string
+	// Address is where the user lives.
+	Address commentedAddress
+}
+
+// Lookup finds a profile.
+//
+// Deprecated: use Find.
+func Lookup() {}
+
+// Find finds a profile.
+func (s *Store[T]) Find() {}
+`
+
+func registerCommentedSource(t *testing.T) {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"types.go":      {Data: []byte(commentedSource)},
+		"types_test.go": {Data: []byte("package schema\n\n// Hidden is ignored.\nfunc Hidden() {}\n")},
+	}
+	if err := RegisterPackageDocComments("github.com/swetjen/virtuous/schema", fsys); err != nil {
+		t.Fatalf("register doc comments: %v", err)
+	}
+}
+
+func TestParseDocCommentsKeys(t *testing.T) {
+	docs, err := ParseDocComments("example.com/app/users", fstest.MapFS{"types.go": {Data: []byte(commentedSource)}})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := map[string]string{
+		"example.com/app/users.commentedAddress.City": "City is the\nmunicipality name.",
+		"example.com/app/users.commentedBase.ID":      "ID is the stable identifier.",
+		"example.com/app/users.Lookup":                "Lookup finds a profile.\n\nDeprecated: use Find.",
+		"example.com/app/users.Store.Find":            "Find finds a profile.",
+	}
+	for key, text := range want {
+		if docs[key] != text {
+			t.Fatalf("%s = %q, want %q", key, docs[key], text)
+		}
+	}
+	if _, err := ParseDocComments("", fstest.MapFS{}); err == nil {
+		t.Fatalf("expected error for empty package path")
+	}
+}
+
+func TestDocCommentsFallbackForSchemasAndRegistry(t *testing.T) {
+	registerCommentedSource(t)
+
+	if got := FuncDocComment("github.com/swetjen/virtuous/schema.(*Store[...]).Find-fm"); got != "Find finds a profile." {
+		t.Fatalf("method doc = %q", got)
+	}
+	if got := FuncDocComment("github.com/swetjen/virtuous/schema.Hidden"); got != "" {
+		t.Fatalf("expected test files to be skipped, got %q", got)
+	}
+
+	gen := NewGenerator(nil)
+	gen.SchemaFor(commentedProfile{})
+	components := gen.Components()
+	profile := components["commentedProfile"]
+	if profile.Description != "commentedProfile describes a user profile." {
+		t.Fatalf("type description = %q", profile.Description)
+	}
+	props := profile.Properties
+	if props["name"].Description != "Name is the display name." {
+		t.Fatalf("name description = %q", props["name"].Description)
+	}
+	if props["email"].Description != "Tag docs win." {
+		t.Fatalf("email description = %q", props["email"].Description)
+	}
+	if props["id"].Description != "ID is the stable identifier." {
+		t.Fatalf("embedded id description = %q", props["id"].Description)
+	}
+	address := props["address"]
+	if address.Description != "Address is where the user lives." || len(address.AllOf) != 1 || address.AllOf[0].Ref == "" {
+		t.Fatalf("address schema = %+v", address)
+	}
+	if components["commentedAddress"].Properties["city"].Description != "City is the municipality name." {
+		t.Fatalf("city description = %q", components["commentedAddress"].Properties["city"].Description)
+	}
+
+	registry := NewRegistry(nil)
+	registry.AddType(commentedProfile{})
+	docs := map[string]string{}
+	for _, obj := range registry.Objects() {
+		if obj.Name != "commentedProfile" {
+			continue
+		}
+		for _, field := range obj.Fields {
+			docs[field.Name] = field.Doc
+		}
+	}
+	if docs["name"] != "Name is the display name." || docs["id"] != "ID is the stable identifier." || docs["email"] != "Tag docs win." {
+		t.Fatalf("registry docs = %#v", docs)
+	}
+	if TypeDocComment(reflect.TypeFor[*commentedAddress]()) != "commentedAddress is a postal address." {
+		t.Fatalf("pointer type doc lookup failed")
+	}
+}
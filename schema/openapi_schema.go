@@ -21,11 +21,17 @@ type OpenAPISchema struct {
 	Enum                 []any                     `json:"enum,omitempty"`
 	Minimum              *float64                  `json:"minimum,omitempty"`
 	Maximum              *float64                  `json:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
 	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
 	Items                *OpenAPISchema            `json:"items,omitempty"`
 	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
 	Required             []string                  `json:"required,omitempty"`
 	AllOf                []*OpenAPISchema          `json:"allOf,omitempty"`
+	OneOf                []*OpenAPISchema          `json:"oneOf,omitempty"`
 }
 
 // Generator builds OpenAPI schemas from Go types.
@@ -120,6 +126,7 @@ func (g *Generator) schemaFor(t reflect.Type) *OpenAPISchema {
 		g.seen[t] = name
 		g.components[name] = OpenAPISchema{}
 		schema := g.structSchema(t)
+		schema.Description = TypeDocComment(t)
 		g.components[name] = *schema
 		refSchema := &OpenAPISchema{Ref: "#/components/schemas/" + name}
 		if nullable {
@@ -176,13 +183,20 @@ func (g *Generator) structSchema(t reflect.Type) *OpenAPISchema {
 		if schema == nil {
 			continue
 		}
-		doc := reflectutil.FieldDoc(field)
 		schema = ApplyFieldMetadata(field, schema)
-		if doc != "" && schema.Description == "" {
-			schema.Description = doc
+		if doc := fieldDoc(t, jsonField); doc != "" && schema.Description == "" {
+			if schema.Ref != "" {
+				schema = &OpenAPISchema{
+					Description: doc,
+					Nullable:    schema.Nullable,
+					AllOf:       []*OpenAPISchema{{Ref: schema.Ref}},
+				}
+			} else {
+				schema.Description = doc
+			}
 		}
 		props[jsonField.Name] = schema
-		if !jsonField.OmitEmpty && !jsonField.ParentOptional && field.Type.Kind() != reflect.Ptr {
+		if isRequiredField(jsonField) {
 			required = append(required, jsonField.Name)
 		}
 	}
@@ -266,6 +280,11 @@ func ApplyFieldMetadata(field reflect.StructField, fieldSchema *OpenAPISchema) *
 			Enum:        schema.Enum,
 			Minimum:     schema.Minimum,
 			Maximum:     schema.Maximum,
+			MinLength:   schema.MinLength,
+			MaxLength:   schema.MaxLength,
+			Pattern:     schema.Pattern,
+			MinItems:    schema.MinItems,
+			MaxItems:    schema.MaxItems,
 		}
 		if schema.Ref != "" {
 			wrapped.AllOf = []*OpenAPISchema{{Ref: schema.Ref}}
@@ -296,6 +315,21 @@ func ApplyFieldMetadata(field reflect.StructField, fieldSchema *OpenAPISchema) *
 	if maximum, ok := parseFloatTag(field.Tag.Get("maximum")); ok {
 		schema.Maximum = &maximum
 	}
+	if minLength, ok := parseIntTag(field.Tag.Get("minLength")); ok {
+		schema.MinLength = &minLength
+	}
+	if maxLength, ok := parseIntTag(field.Tag.Get("maxLength")); ok {
+		schema.MaxLength = &maxLength
+	}
+	if pattern := strings.TrimSpace(field.Tag.Get("pattern")); pattern != "" {
+		schema.Pattern = pattern
+	}
+	if minItems, ok := parseIntTag(field.Tag.Get("minItems")); ok {
+		schema.MinItems = &minItems
+	}
+	if maxItems, ok := parseIntTag(field.Tag.Get("maxItems")); ok {
+		schema.MaxItems = &maxItems
+	}
 	if nullable {
 		schema.Nullable = true
 	}
@@ -306,13 +340,35 @@ func needsSchemaWrapper(schema *OpenAPISchema, field reflect.StructField) bool {
 	if schema.Ref == "" {
 		return false
 	}
-	return reflectutil.FieldDoc(field) != "" ||
-		strings.TrimSpace(field.Tag.Get("format")) != "" ||
-		strings.TrimSpace(field.Tag.Get("default")) != "" ||
-		strings.TrimSpace(field.Tag.Get("example")) != "" ||
-		strings.TrimSpace(field.Tag.Get("enum")) != "" ||
-		strings.TrimSpace(field.Tag.Get("minimum")) != "" ||
-		strings.TrimSpace(field.Tag.Get("maximum")) != ""
+	if reflectutil.FieldDoc(field) != "" {
+		return true
+	}
+	for _, tag := range schemaMetadataTags {
+		if strings.TrimSpace(field.Tag.Get(tag)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+var schemaMetadataTags = []string{
+	"format", "default", "example", "enum", "minimum", "maximum",
+	"minLength", "maxLength", "pattern", "minItems", "maxItems",
+}
+
+// isRequiredField reports whether a JSON field is required in schemas and
+// generated clients: non-pointer fields without omitempty, or any field
+// tagged required:"true".
+func isRequiredField(field reflectutil.JSONField) bool {
+	if hasRequiredTag(field.Field) {
+		return true
+	}
+	return !field.OmitEmpty && !field.ParentOptional && field.Field.Type.Kind() != reflect.Ptr
+}
+
+func hasRequiredTag(field reflect.StructField) bool {
+	required, err := strconv.ParseBool(strings.TrimSpace(field.Tag.Get("required")))
+	return err == nil && required
 }
 
 func parseSchemaTagValue(raw string, t reflect.Type) (any, bool) {
@@ -358,6 +414,15 @@ func parseFloatTag(raw string) (float64, bool) {
 	return value, err == nil
 }
 
+func parseIntTag(raw string) (int, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.Atoi(raw)
+	return value, err == nil && value >= 0
+}
+
 func parseEnumTag(raw string, t reflect.Type) []any {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
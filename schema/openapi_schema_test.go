@@ -326,3 +326,30 @@ func assertOpenAPIField(t *testing.T, component OpenAPISchema, name, typ, format
 		t.Fatalf("property %q required=%v, want %v in %#v", name, containsString(component.Required, name), required, component.Required)
 	}
 }
+
+type constrainedSchema struct {
+	Handle string   `json:"handle,omitempty" required:"true" minLength:"3" maxLength:"20" pattern:"^[a-z]+$"`
+	Tags   []string `json:"tags" minItems:"1" maxItems:"5"`
+	Note   *string  `json:"note,omitempty"`
+}
+
+func TestOpenAPISchemaValidationConstraintTags(t *testing.T) {
+	gen := NewGenerator(nil)
+	gen.SchemaFor(constrainedSchema{})
+	component := gen.Components()["constrainedSchema"]
+
+	handle := component.Properties["handle"]
+	if handle.MinLength == nil || *handle.MinLength != 3 || handle.MaxLength == nil || *handle.MaxLength != 20 {
+		t.Fatalf("handle lengths = %#v/%#v, want 3/20", handle.MinLength, handle.MaxLength)
+	}
+	if handle.Pattern != "^[a-z]+$" {
+		t.Fatalf("handle pattern = %q", handle.Pattern)
+	}
+	tags := component.Properties["tags"]
+	if tags.MinItems == nil || *tags.MinItems != 1 || tags.MaxItems == nil || *tags.MaxItems != 5 {
+		t.Fatalf("tags items = %#v/%#v, want 1/5", tags.MinItems, tags.MaxItems)
+	}
+	if !reflect.DeepEqual(component.Required, []string{"handle", "tags"}) {
+		t.Fatalf("required = %#v, want handle (required tag) and tags", component.Required)
+	}
+}
@@ -11,8 +11,17 @@ import (
 
 // TypeOverride customizes how a Go type is rendered for clients and OpenAPI.
 type TypeOverride struct {
-	JSType        string
-	PyType        string
+	JSType string
+	PyType string
+	// GoType is the type used by generated Go clients. It may reference the
+	// time and encoding/json packages. When empty, the type is derived from
+	// OpenAPIType and OpenAPIFormat.
+	GoType string
+	// SwiftType and KotlinType are the types used by generated Swift and
+	// Kotlin clients. When empty, they are derived from OpenAPIType and
+	// OpenAPIFormat.
+	SwiftType     string
+	KotlinType    string
 	OpenAPIType   string
 	OpenAPIFormat string
 	Nullable      bool
@@ -20,12 +29,18 @@ type TypeOverride struct {
 }
 
 // Field describes a schema field for client generation.
+//
+// EnumType renders enum tag values as a literal union (for example
+// `"draft" | "sent"`) and Constraints lists the remaining validation tags as
+// "name value" pairs in a stable order.
 type Field struct {
-	Name     string
-	Type     string
-	Optional bool
-	Nullable bool
-	Doc      string
+	Name        string
+	Type        string
+	Optional    bool
+	Nullable    bool
+	Doc         string
+	EnumType    string
+	Constraints []string
 }
 
 // Object describes a named schema object for client generation.
@@ -49,11 +64,15 @@ type objectDef struct {
 }
 
 type fieldDef struct {
-	Name     string
-	Type     reflect.Type
-	Optional bool
-	Nullable bool
-	Doc      string
+	Name        string
+	Type        reflect.Type
+	Optional    bool
+	Nullable    bool
+	Doc         string
+	Enum        []any
+	Constraints []string
+	// Struct keeps the tags that Zod schemas turn into checks.
+	Struct reflect.StructField
 }
 
 // NewRegistry returns a registry with overrides applied.
@@ -98,11 +117,13 @@ func (r *Registry) ObjectsWith(typeFn func(reflect.Type) string) []Object {
 				fieldType = "any"
 			}
 			clientObj.Fields = append(clientObj.Fields, Field{
-				Name:     field.Name,
-				Type:     fieldType,
-				Optional: field.Optional,
-				Nullable: field.Nullable,
-				Doc:      field.Doc,
+				Name:        field.Name,
+				Type:        fieldType,
+				Optional:    field.Optional,
+				Nullable:    field.Nullable,
+				Doc:         field.Doc,
+				EnumType:    enumLiteralType(field.Enum),
+				Constraints: field.Constraints,
 			})
 		}
 		objects = append(objects, clientObj)
@@ -129,6 +150,39 @@ func (r *Registry) JSTypeOf(t reflect.Type) string {
 	return r.jsType(t)
 }
 
+// GoType renders the Go type for a value.
+func (r *Registry) GoType(v any) string {
+	return r.goType(reflect.TypeOf(v))
+}
+
+// GoTypeOf renders the Go type for a Go type. Pointers are kept so optional
+// fields stay optional in generated structs.
+func (r *Registry) GoTypeOf(t reflect.Type) string {
+	return r.goType(t)
+}
+
+// SwiftType renders the Swift type for a value.
+func (r *Registry) SwiftType(v any) string {
+	return r.swiftType(reflect.TypeOf(v))
+}
+
+// SwiftTypeOf renders the Swift type for a Go type. Optionality is left to
+// the caller, which marks optional and nullable fields.
+func (r *Registry) SwiftTypeOf(t reflect.Type) string {
+	return r.swiftType(t)
+}
+
+// KotlinType renders the Kotlin type for a value.
+func (r *Registry) KotlinType(v any) string {
+	return r.kotlinType(reflect.TypeOf(v))
+}
+
+// KotlinTypeOf renders the Kotlin type for a Go type. Optionality is left to
+// the caller, which marks optional and nullable fields.
+func (r *Registry) KotlinTypeOf(t reflect.Type) string {
+	return r.kotlinType(t)
+}
+
 // PyType renders the Python type for a value.
 func (r *Registry) PyType(v any) string {
 	return r.pyType(reflect.TypeOf(v))
@@ -155,17 +209,26 @@ func defaultTypeOverrides() map[string]TypeOverride {
 		"time.Time": {
 			JSType:        "string",
 			PyType:        "datetime",
+			GoType:        "time.Time",
+			SwiftType:     "Date",
+			KotlinType:    "String",
 			OpenAPIType:   "string",
 			OpenAPIFormat: "date-time",
 		},
 		"encoding/json.RawMessage": {
 			JSType:        "object|any[]",
 			PyType:        "Any",
+			GoType:        "json.RawMessage",
+			SwiftType:     "JSONValue",
+			KotlinType:    "JsonElement",
 			ArbitraryJSON: true,
 		},
 		"github.com/swetjen/virtuous/httpapi.File": {
 			JSType:        "File|Blob",
 			PyType:        "bytes",
+			GoType:        "File",
+			SwiftType:     "Data",
+			KotlinType:    "String",
 			OpenAPIType:   "string",
 			OpenAPIFormat: "binary",
 		},
@@ -231,11 +294,14 @@ func (r *Registry) addType(t reflect.Type) {
 		for _, jsonField := range reflectutil.JSONFields(base) {
 			field := jsonField.Field
 			obj.Fields = append(obj.Fields, fieldDef{
-				Name:     jsonField.Name,
-				Type:     field.Type,
-				Optional: jsonField.OmitEmpty || jsonField.ParentOptional,
-				Nullable: r.isNullableType(field.Type),
-				Doc:      reflectutil.FieldDoc(field),
+				Name:        jsonField.Name,
+				Type:        field.Type,
+				Optional:    (jsonField.OmitEmpty || jsonField.ParentOptional) && !hasRequiredTag(field),
+				Nullable:    r.isNullableType(field.Type),
+				Doc:         fieldDoc(base, jsonField),
+				Enum:        scalarEnum(field),
+				Constraints: fieldConstraints(field),
+				Struct:      field,
 			})
 			r.addType(field.Type)
 		}
@@ -378,6 +444,290 @@ func (r *Registry) pyType(t reflect.Type) string {
 	}
 }
 
+func (r *Registry) goType(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	if override, ok := typeOverrideFor(r.overrides, reflectutil.DerefType(t)); ok {
+		goType := override.GoType
+		if goType == "" {
+			goType = goTypeForOverride(override)
+		}
+		if (t.Kind() == reflect.Ptr || override.Nullable) && goType != "any" && goType != "json.RawMessage" {
+			return "*" + goType
+		}
+		return goType
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := r.goType(t.Elem())
+		if elem == "" || elem == "any" || strings.HasPrefix(elem, "*") {
+			return elem
+		}
+		return "*" + elem
+	case reflect.Bool:
+		return "bool"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t.Kind().String()
+	case reflect.Interface:
+		return "any"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "[]byte"
+		}
+		return "[]" + r.goTypeOrAny(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + r.goTypeOrAny(t.Elem())
+	case reflect.Map:
+		key := "string"
+		if t.Key().Kind() != reflect.String {
+			key = r.goTypeOrAny(t.Key())
+		}
+		return "map[" + key + "]" + r.goTypeOrAny(t.Elem())
+	case reflect.Struct:
+		if t.Name() == "" {
+			return "map[string]any"
+		}
+		return r.objectName(t)
+	default:
+		return "any"
+	}
+}
+
+func (r *Registry) goTypeOrAny(t reflect.Type) string {
+	if goType := r.goType(t); goType != "" {
+		return goType
+	}
+	return "any"
+}
+
+// goTypeForOverride maps an override without a GoType to the closest Go type.
+func goTypeForOverride(override TypeOverride) string {
+	if override.ArbitraryJSON {
+		return "json.RawMessage"
+	}
+	switch override.OpenAPIType {
+	case "string":
+		switch override.OpenAPIFormat {
+		case "date-time":
+			return "time.Time"
+		case "binary", "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if override.OpenAPIFormat == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if override.OpenAPIFormat == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	switch override.JSType {
+	case "string":
+		return "string"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	return "any"
+}
+
+func (r *Registry) swiftType(t reflect.Type) string {
+	base := reflectutil.DerefType(t)
+	if base == nil {
+		return ""
+	}
+	if override, ok := typeOverrideFor(r.overrides, base); ok {
+		if override.SwiftType != "" {
+			return override.SwiftType
+		}
+		return swiftTypeForOverride(override)
+	}
+	switch base.Kind() {
+	case reflect.Bool:
+		return "Bool"
+	case reflect.String:
+		return "String"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return swiftIntegerTypes[base.Kind()]
+	case reflect.Float32:
+		return "Float"
+	case reflect.Float64:
+		return "Double"
+	case reflect.Slice, reflect.Array:
+		if base.Kind() == reflect.Slice && base.Elem().Kind() == reflect.Uint8 {
+			return "Data"
+		}
+		return "[" + r.swiftTypeOrJSON(base.Elem()) + "]"
+	case reflect.Map:
+		return "[String: " + r.swiftTypeOrJSON(base.Elem()) + "]"
+	case reflect.Struct:
+		if base.Name() == "" {
+			return "[String: JSONValue]"
+		}
+		return r.objectName(base)
+	default:
+		return "JSONValue"
+	}
+}
+
+var swiftIntegerTypes = map[reflect.Kind]string{
+	reflect.Int:    "Int",
+	reflect.Int8:   "Int8",
+	reflect.Int16:  "Int16",
+	reflect.Int32:  "Int32",
+	reflect.Int64:  "Int64",
+	reflect.Uint:   "UInt",
+	reflect.Uint8:  "UInt8",
+	reflect.Uint16: "UInt16",
+	reflect.Uint32: "UInt32",
+	reflect.Uint64: "UInt64",
+}
+
+func (r *Registry) swiftTypeOrJSON(t reflect.Type) string {
+	if swiftType := r.swiftType(t); swiftType != "" {
+		return swiftType
+	}
+	return "JSONValue"
+}
+
+// swiftTypeForOverride maps an override without a SwiftType to the closest
+// Swift type.
+func swiftTypeForOverride(override TypeOverride) string {
+	if override.ArbitraryJSON {
+		return "JSONValue"
+	}
+	switch override.OpenAPIType {
+	case "string":
+		switch override.OpenAPIFormat {
+		case "date-time":
+			return "Date"
+		case "binary", "byte":
+			return "Data"
+		}
+		return "String"
+	case "integer":
+		if override.OpenAPIFormat == "int32" {
+			return "Int32"
+		}
+		return "Int64"
+	case "number":
+		if override.OpenAPIFormat == "float" {
+			return "Float"
+		}
+		return "Double"
+	case "boolean":
+		return "Bool"
+	}
+	switch override.JSType {
+	case "string":
+		return "String"
+	case "number":
+		return "Double"
+	case "boolean":
+		return "Bool"
+	}
+	return "JSONValue"
+}
+
+func (r *Registry) kotlinType(t reflect.Type) string {
+	base := reflectutil.DerefType(t)
+	if base == nil {
+		return ""
+	}
+	if override, ok := typeOverrideFor(r.overrides, base); ok {
+		if override.KotlinType != "" {
+			return override.KotlinType
+		}
+		return kotlinTypeForOverride(override)
+	}
+	switch base.Kind() {
+	case reflect.Bool:
+		return "Boolean"
+	case reflect.String:
+		return "String"
+	case reflect.Int8:
+		return "Byte"
+	case reflect.Int16:
+		return "Short"
+	case reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "Int"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "Long"
+	case reflect.Float32:
+		return "Float"
+	case reflect.Float64:
+		return "Double"
+	case reflect.Slice, reflect.Array:
+		if base.Kind() == reflect.Slice && base.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings.
+			return "String"
+		}
+		return "List<" + r.kotlinTypeOrJSON(base.Elem()) + ">"
+	case reflect.Map:
+		return "Map<String, " + r.kotlinTypeOrJSON(base.Elem()) + ">"
+	case reflect.Struct:
+		if base.Name() == "" {
+			return "JsonObject"
+		}
+		return r.objectName(base)
+	default:
+		return "JsonElement"
+	}
+}
+
+func (r *Registry) kotlinTypeOrJSON(t reflect.Type) string {
+	if kotlinType := r.kotlinType(t); kotlinType != "" {
+		return kotlinType
+	}
+	return "JsonElement"
+}
+
+// kotlinTypeForOverride maps an override without a KotlinType to the closest
+// Kotlin type.
+func kotlinTypeForOverride(override TypeOverride) string {
+	if override.ArbitraryJSON {
+		return "JsonElement"
+	}
+	switch override.OpenAPIType {
+	case "string":
+		return "String"
+	case "integer":
+		if override.OpenAPIFormat == "int32" {
+			return "Int"
+		}
+		return "Long"
+	case "number":
+		if override.OpenAPIFormat == "float" {
+			return "Float"
+		}
+		return "Double"
+	case "boolean":
+		return "Boolean"
+	}
+	switch override.JSType {
+	case "string":
+		return "String"
+	case "number":
+		return "Double"
+	case "boolean":
+		return "Boolean"
+	}
+	return "JsonElement"
+}
+
 func quotePyType(name string) string {
 	if name == "" {
 		return ""
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgtype/zeronull"
@@ -236,3 +237,63 @@ func assertRegistryField(t *testing.T, object *Object, name, typ string, optiona
 		t.Fatalf("field %q = %#v, want type=%q optional=%v nullable=%v", name, *field, typ, optional, nullable)
 	}
 }
+
+func TestRegistryRendersEnumTypesAndConstraints(t *testing.T) {
+	registry := NewRegistry(nil)
+	registry.AddType(taggedSchema{})
+	registry.AddType(constrainedSchema{})
+
+	tagged := findObject(registry.Objects(), "taggedSchema")
+	constrained := findObject(registry.Objects(), "constrainedSchema")
+	if tagged == nil || constrained == nil {
+		t.Fatalf("missing tagged objects")
+	}
+	fields := map[string]Field{}
+	for _, field := range append(tagged.Fields, constrained.Fields...) {
+		fields[field.Name] = field
+	}
+	if got := fields["sort"].EnumType; got != `"name" | "created_at"` {
+		t.Fatalf("sort enum type = %q", got)
+	}
+	if got := fields["level"].EnumType; got != "1 | 2 | 3" {
+		t.Fatalf("level enum type = %q", got)
+	}
+	if got := fields["limit"].Constraints; !reflect.DeepEqual(got, []string{"minimum 1", "maximum 100", "default 20"}) {
+		t.Fatalf("limit constraints = %#v", got)
+	}
+	if got := fields["handle"].Constraints; !reflect.DeepEqual(got, []string{"minLength 3", "maxLength 20", "pattern ^[a-z]+$"}) {
+		t.Fatalf("handle constraints = %#v", got)
+	}
+	if fields["handle"].Optional {
+		t.Fatalf("required tag should make handle non-optional")
+	}
+	if !fields["note"].Optional {
+		t.Fatalf("note should stay optional")
+	}
+}
+
+func TestRegistryRendersSwiftAndKotlinTypes(t *testing.T) {
+	registry := NewRegistry(nil)
+	cases := []struct {
+		value  any
+		swift  string
+		kotlin string
+	}{
+		{int32(0), "Int32", "Int"},
+		{uint64(0), "UInt64", "Long"},
+		{[]byte(nil), "Data", "String"},
+		{map[string][]float64{}, "[String: [Double]]", "Map<String, List<Double>>"},
+		{time.Time{}, "Date", "String"},
+		{pgtype.Text{}, "String", "String"},
+		{pgtype.Int4{}, "Int32", "Int"},
+		{registryPgOverridePayload{}, "registryPgOverridePayload", "registryPgOverridePayload"},
+	}
+	for _, tc := range cases {
+		if got := registry.SwiftType(tc.value); got != tc.swift {
+			t.Fatalf("SwiftType(%T) = %q, want %q", tc.value, got, tc.swift)
+		}
+		if got := registry.KotlinType(tc.value); got != tc.kotlin {
+			t.Fatalf("KotlinType(%T) = %q, want %q", tc.value, got, tc.kotlin)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/swetjen/virtuous/internal/reflectutil"
+)
+
+// Violation describes one field that failed a declarative constraint.
+// Field is the JSON path of the value, such as "items[0].name".
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every constraint violation found in a value.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	if e == nil || len(e.Violations) == 0 {
+		return "validation failed"
+	}
+	parts := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		parts = append(parts, violation.Field+" "+violation.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Validator applies default tags and enforces constraint tags for one Go type.
+//
+// Supported tags are required, enum, minimum, maximum, format, default,
+// minLength, maxLength, pattern, minItems, and maxItems. Zero values are
+// treated as absent and skip every check except required. Defaults fill zero
+// values, so use a pointer when an explicit zero must be distinguished from a
+// missing field.
+type Validator struct {
+	plan   *valuePlan
+	active bool
+}
+
+type validatorEntry struct {
+	validator *Validator
+	err       error
+}
+
+var validatorCache sync.Map
+
+// ValidatorFor returns the cached validator for t. It returns an error when a
+// constraint tag cannot be parsed for its field type.
+func ValidatorFor(t reflect.Type) (*Validator, error) {
+	if cached, ok := validatorCache.Load(t); ok {
+		entry := cached.(validatorEntry)
+		return entry.validator, entry.err
+	}
+	compiler := validatorCompiler{plans: map[reflect.Type]*valuePlan{}}
+	plan, err := compiler.compile(t)
+	entry := validatorEntry{err: err}
+	if err == nil {
+		entry.validator = &Validator{plan: plan, active: plan.hasRules(map[*valuePlan]bool{})}
+	}
+	validatorCache.Store(t, entry)
+	return entry.validator, entry.err
+}
+
+// Validate applies defaults and checks constraints for v, which should be a
+// pointer so defaults can be written. It returns a *ValidationError when any
+// constraint fails.
+func Validate(v any) error {
+	validator, err := ValidatorFor(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	return validator.Validate(v)
+}
+
+// Active reports whether the type declares any constraint or default tags.
+func (v *Validator) Active() bool {
+	return v != nil && v.active
+}
+
+// Validate applies defaults and checks constraints for value.
+func (v *Validator) Validate(value any) error {
+	if !v.Active() {
+		return nil
+	}
+	var violations []Violation
+	v.plan.check(reflect.ValueOf(value), "", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+type valuePlan struct {
+	kind   reflect.Kind
+	fields []*fieldPlan
+	elem   *valuePlan
+}
+
+type fieldPlan struct {
+	name      string
+	index     []int
+	optional  bool
+	required  bool
+	def       *reflect.Value
+	enum      []any
+	minimum   *float64
+	maximum   *float64
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	format    string
+	minItems  *int
+	maxItems  *int
+	value     *valuePlan
+}
+
+type validatorCompiler struct {
+	plans map[reflect.Type]*valuePlan
+}
+
+func (c *validatorCompiler) compile(t reflect.Type) (*valuePlan, error) {
+	t = reflectutil.DerefType(t)
+	if t == nil {
+		return &valuePlan{}, nil
+	}
+	if plan, ok := c.plans[t]; ok {
+		return plan, nil
+	}
+	plan := &valuePlan{kind: t.Kind()}
+	c.plans[t] = plan
+	switch t.Kind() {
+	case reflect.Struct:
+		if isTimeType(t) {
+			plan.kind = reflect.Invalid
+			return plan, nil
+		}
+		for _, jsonField := range reflectutil.JSONFields(t) {
+			field, err := c.compileField(jsonField)
+			if err != nil {
+				return nil, fmt.Errorf("schema: %s.%s: %w", t.Name(), jsonField.Field.Name, err)
+			}
+			plan.fields = append(plan.fields, field)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		elem, err := c.compile(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		plan.elem = elem
+	}
+	return plan, nil
+}
+
+func (c *validatorCompiler) compileField(jsonField reflectutil.JSONField) (*fieldPlan, error) {
+	field := jsonField.Field
+	required := hasRequiredTag(field)
+	plan := &fieldPlan{
+		name:     jsonField.Name,
+		index:    jsonField.Index,
+		required: required,
+		optional: !required && (jsonField.OmitEmpty || jsonField.ParentOptional || field.Type.Kind() == reflect.Ptr),
+	}
+	base := reflectutil.DerefType(field.Type)
+
+	if raw := strings.TrimSpace(field.Tag.Get("default")); raw != "" && isScalarKind(base) {
+		value, err := parseScalar(raw, base)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default %q: %w", raw, err)
+		}
+		plan.def = &value
+	}
+	if raw := strings.TrimSpace(field.Tag.Get("enum")); raw != "" && isScalarKind(base) {
+		for _, part := range strings.Split(raw, ",") {
+			value, err := parseScalar(strings.TrimSpace(part), base)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q: %w", part, err)
+			}
+			plan.enum = append(plan.enum, value.Interface())
+		}
+	}
+	var err error
+	if plan.minimum, err = floatTag(field, "minimum"); err != nil {
+		return nil, err
+	}
+	if plan.maximum, err = floatTag(field, "maximum"); err != nil {
+		return nil, err
+	}
+	if plan.minLength, err = lengthTag(field, "minLength"); err != nil {
+		return nil, err
+	}
+	if plan.maxLength, err = lengthTag(field, "maxLength"); err != nil {
+		return nil, err
+	}
+	if plan.minItems, err = lengthTag(field, "minItems"); err != nil {
+		return nil, err
+	}
+	if plan.maxItems, err = lengthTag(field, "maxItems"); err != nil {
+		return nil, err
+	}
+	if raw := strings.TrimSpace(field.Tag.Get("pattern")); raw != "" {
+		pattern, err := regexp.Compile(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		plan.pattern = pattern
+	}
+	plan.format = strings.TrimSpace(field.Tag.Get("format"))
+
+	value, err := c.compile(field.Type)
+	if err != nil {
+		return nil, err
+	}
+	plan.value = value
+	return plan, nil
+}
+
+func floatTag(field reflect.StructField, tag string) (*float64, error) {
+	raw := strings.TrimSpace(field.Tag.Get(tag))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", tag, raw)
+	}
+	return &value, nil
+}
+
+func lengthTag(field reflect.StructField, tag string) (*int, error) {
+	raw := strings.TrimSpace(field.Tag.Get(tag))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid %s %q", tag, raw)
+	}
+	return &value, nil
+}
+
+func parseScalar(raw string, t reflect.Type) (reflect.Value, error) {
+	value := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetFloat(parsed)
+	default:
+		return reflect.Value{}, errors.New("unsupported kind " + t.Kind().String())
+	}
+	return value, nil
+}
+
+func (p *valuePlan) hasRules(seen map[*valuePlan]bool) bool {
+	if p == nil || seen[p] {
+		return false
+	}
+	seen[p] = true
+	for _, field := range p.fields {
+		if field.hasRules() || field.value.hasRules(seen) {
+			return true
+		}
+	}
+	return p.elem.hasRules(seen)
+}
+
+func (f *fieldPlan) hasRules() bool {
+	return f.required || f.def != nil || len(f.enum) > 0 ||
+		f.minimum != nil || f.maximum != nil ||
+		f.minLength != nil || f.maxLength != nil || f.pattern != nil ||
+		f.minItems != nil || f.maxItems != nil ||
+		(f.format != "" && formatCheckers[f.format] != nil)
+}
+
+func (p *valuePlan) check(v reflect.Value, path string, out *[]Violation) {
+	if p == nil {
+		return
+	}
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+	switch p.kind {
+	case reflect.Struct:
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		for _, field := range p.fields {
+			fv, err := v.FieldByIndexErr(field.index)
+			if err != nil {
+				continue
+			}
+			field.check(fv, joinPath(path, field.name), out)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			p.elem.check(v.Index(i), path+"["+strconv.Itoa(i)+"]", out)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			p.elem.check(v.MapIndex(key), path+"["+fmt.Sprint(key.Interface())+"]", out)
+		}
+	}
+}
+
+func (f *fieldPlan) check(v reflect.Value, path string, out *[]Violation) {
+	if f.def != nil && isMissing(v) && v.CanSet() {
+		if v.Kind() == reflect.Ptr {
+			ptr := reflect.New(v.Type().Elem())
+			ptr.Elem().Set(*f.def)
+			v.Set(ptr)
+		} else {
+			v.Set(*f.def)
+		}
+	}
+	if isMissing(v) {
+		if f.required {
+			*out = append(*out, Violation{Field: path, Code: "required", Message: "is required"})
+			return
+		}
+		if !f.optional {
+			// An absent field skips its own checks, but the fields of a
+			// zero struct still get their defaults and required checks.
+			f.value.check(v, path, out)
+		}
+		return
+	}
+	base := v
+	for base.Kind() == reflect.Ptr {
+		if base.IsNil() {
+			return
+		}
+		base = base.Elem()
+	}
+	add := func(code, message string) {
+		*out = append(*out, Violation{Field: path, Code: code, Message: message})
+	}
+
+	if len(f.enum) > 0 && !enumContains(f.enum, base) {
+		add("enum", "must be one of "+formatEnum(f.enum))
+	}
+	switch base.Kind() {
+	case reflect.String:
+		text := base.String()
+		length := utf8.RuneCountInString(text)
+		if f.minLength != nil && length < *f.minLength {
+			add("minLength", "must be at least "+strconv.Itoa(*f.minLength)+" characters")
+		}
+		if f.maxLength != nil && length > *f.maxLength {
+			add("maxLength", "must be at most "+strconv.Itoa(*f.maxLength)+" characters")
+		}
+		if f.pattern != nil && !f.pattern.MatchString(text) {
+			add("pattern", "must match pattern "+f.pattern.String())
+		}
+		if checker := formatCheckers[f.format]; checker != nil && !checker(text) {
+			add("format", "must be a valid "+f.format)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		number := numberValue(base)
+		if f.minimum != nil && number < *f.minimum {
+			add("minimum", "must be greater than or equal to "+strconv.FormatFloat(*f.minimum, 'g', -1, 64))
+		}
+		if f.maximum != nil && number > *f.maximum {
+			add("maximum", "must be less than or equal to "+strconv.FormatFloat(*f.maximum, 'g', -1, 64))
+		}
+	case reflect.Slice, reflect.Array:
+		if f.minItems != nil && base.Len() < *f.minItems {
+			add("minItems", "must contain at least "+strconv.Itoa(*f.minItems)+" items")
+		}
+		if f.maxItems != nil && base.Len() > *f.maxItems {
+			add("maxItems", "must contain at most "+strconv.Itoa(*f.maxItems)+" items")
+		}
+	}
+	f.value.check(base, path, out)
+}
+
+func isMissing(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func numberValue(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func enumContains(values []any, v reflect.Value) bool {
+	for _, value := range values {
+		candidate := reflect.ValueOf(value)
+		if candidate.Kind() == reflect.String || candidate.Kind() == reflect.Bool {
+			if reflect.DeepEqual(value, v.Interface()) {
+				return true
+			}
+			continue
+		}
+		if numberValue(candidate) == numberValue(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(values []any) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, fmt.Sprint(value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// formatCheckers enforces the string formats Virtuous understands. Other
+// format values are documented in OpenAPI but not enforced.
+var formatCheckers = map[string]func(string) bool{
+	"date": func(value string) bool {
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	},
+	"date-time": func(value string) bool {
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	},
+	"email": func(value string) bool {
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	},
+	"uuid": uuidPattern.MatchString,
+	"uri": func(value string) bool {
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != ""
+	},
+	"ipv4": func(value string) bool {
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	},
+	"ipv6": func(value string) bool {
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	},
+}
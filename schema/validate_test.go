@@ -0,0 +1,173 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validatedAddress struct {
+	City string `json:"city" minLength:"2"`
+	Zip  string `json:"zip,omitempty" pattern:"^[0-9]{5}$"`
+}
+
+type validatedRequest struct {
+	Email     string             `json:"email" format:"email" required:"true"`
+	Name      string             `json:"name" minLength:"2" maxLength:"5"`
+	Role      string             `json:"role,omitempty" enum:"admin,member" default:"member"`
+	Level     int                `json:"level,omitempty" enum:"1,2,3"`
+	Limit     int                `json:"limit" default:"20" minimum:"1" maximum:"100"`
+	Age       *int               `json:"age,omitempty" minimum:"13"`
+	Tags      []string           `json:"tags,omitempty" minItems:"1" maxItems:"2"`
+	ID        string             `json:"id,omitempty" format:"uuid"`
+	Address   *validatedAddress  `json:"address,omitempty"`
+	Addresses []validatedAddress `json:"addresses,omitempty"`
+	Opaque    string             `json:"opaque,omitempty" format:"ulid"`
+}
+
+type unvalidatedRequest struct {
+	Name string `json:"name" doc:"Display name"`
+}
+
+type invalidPatternRequest struct {
+	Slug string `json:"slug" pattern:"("`
+}
+
+type invalidLengthRequest struct {
+	Name string `json:"name" minLength:"many"`
+}
+
+type recursiveNode struct {
+	Name     string          `json:"name" required:"true" maxLength:"4"`
+	Children []recursiveNode `json:"children,omitempty"`
+}
+
+func violationCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	codes := map[string]string{}
+	for _, violation := range validationErr.Violations {
+		codes[violation.Field] = violation.Code
+	}
+	return codes
+}
+
+func TestValidateAppliesDefaultsAndAcceptsValidValues(t *testing.T) {
+	age := 30
+	req := validatedRequest{
+		Email:   "dev@example.com",
+		Name:    "Ada",
+		Age:     &age,
+		Tags:    []string{"a"},
+		ID:      "123e4567-e89b-12d3-a456-426614174000",
+		Address: &validatedAddress{City: "Oslo", Zip: "12345"},
+		Opaque:  "anything goes",
+	}
+	if err := Validate(&req); err != nil {
+		t.Fatalf("expected valid request, got %v", err)
+	}
+	if req.Role != "member" {
+		t.Fatalf("role default = %q, want member", req.Role)
+	}
+	if req.Limit != 20 {
+		t.Fatalf("limit default = %d, want 20", req.Limit)
+	}
+}
+
+func TestValidateReportsEveryViolation(t *testing.T) {
+	age := 9
+	req := validatedRequest{
+		Email:     "not-an-email",
+		Name:      "Virtuous",
+		Role:      "owner",
+		Level:     7,
+		Limit:     500,
+		Age:       &age,
+		Tags:      []string{"a", "b", "c"},
+		ID:        "nope",
+		Address:   &validatedAddress{City: "X", Zip: "abc"},
+		Addresses: []validatedAddress{{City: "Oslo"}, {City: "X"}},
+	}
+	codes := violationCodes(t, Validate(&req))
+	want := map[string]string{
+		"email":             "format",
+		"name":              "maxLength",
+		"role":              "enum",
+		"level":             "enum",
+		"limit":             "maximum",
+		"age":               "minimum",
+		"tags":              "maxItems",
+		"id":                "format",
+		"address.city":      "minLength",
+		"address.zip":       "pattern",
+		"addresses[1].city": "minLength",
+	}
+	if !reflect.DeepEqual(codes, want) {
+		t.Fatalf("violations = %#v, want %#v", codes, want)
+	}
+}
+
+func TestValidateRequiredAndAbsentOptionalFields(t *testing.T) {
+	req := validatedRequest{Name: "Ada", Limit: 1}
+	codes := violationCodes(t, Validate(&req))
+	if len(codes) != 1 || codes["email"] != "required" {
+		t.Fatalf("expected only required email violation, got %#v", codes)
+	}
+}
+
+func TestValidateSkipsConstraintsOnAbsentFields(t *testing.T) {
+	req := validatedRequest{Email: "dev@example.com", Limit: 1}
+	if err := Validate(&req); err != nil {
+		t.Fatalf("expected absent name and level to pass, got %v", err)
+	}
+
+	type account struct {
+		Plan    string           `json:"plan" enum:"free,pro"`
+		Seats   int              `json:"seats" minimum:"1"`
+		Owner   string           `json:"owner" required:"true" minLength:"2"`
+		Billing validatedAddress `json:"billing"`
+	}
+	codes := violationCodes(t, Validate(&account{}))
+	if len(codes) != 1 || codes["owner"] != "required" {
+		t.Fatalf("expected only the required owner violation, got %#v", codes)
+	}
+	codes = violationCodes(t, Validate(&account{Owner: "Ada", Billing: validatedAddress{Zip: "x"}}))
+	if len(codes) != 1 || codes["billing.zip"] != "pattern" {
+		t.Fatalf("expected fields of a nested struct to be checked, got %#v", codes)
+	}
+}
+
+func TestValidateRecursiveTypes(t *testing.T) {
+	node := recursiveNode{Name: "root", Children: []recursiveNode{{Name: "a"}, {Name: "b", Children: []recursiveNode{{Name: "leaf-1"}, {}}}}}
+	codes := violationCodes(t, Validate(&node))
+	if codes["children[1].children[0].name"] != "maxLength" || codes["children[1].children[1].name"] != "required" {
+		t.Fatalf("unexpected recursive violations: %#v", codes)
+	}
+}
+
+func TestValidatorForRejectsInvalidTags(t *testing.T) {
+	if _, err := ValidatorFor(reflect.TypeFor[invalidPatternRequest]()); err == nil || !strings.Contains(err.Error(), "pattern") {
+		t.Fatalf("expected invalid pattern error, got %v", err)
+	}
+	if _, err := ValidatorFor(reflect.TypeFor[invalidLengthRequest]()); err == nil || !strings.Contains(err.Error(), "minLength") {
+		t.Fatalf("expected invalid minLength error, got %v", err)
+	}
+}
+
+func TestValidatorInactiveWithoutConstraints(t *testing.T) {
+	validator, err := ValidatorFor(reflect.TypeFor[unvalidatedRequest]())
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	if validator.Active() {
+		t.Fatalf("expected inactive validator for untagged type")
+	}
+	active, err := ValidatorFor(reflect.TypeFor[*validatedRequest]())
+	if err != nil || !active.Active() {
+		t.Fatalf("expected active validator, got %v (%v)", active, err)
+	}
+}
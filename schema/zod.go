@@ -0,0 +1,380 @@
+package schema
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/swetjen/virtuous/internal/reflectutil"
+)
+
+// ZodObject is a registry object rendered as a Zod schema constant.
+//
+// Objects are ordered so that each schema is declared after the schemas it
+// uses. Recursive objects refer to their cycle through z.lazy and are
+// annotated as z.ZodTypeAny, since TypeScript cannot infer their type.
+type ZodObject struct {
+	Name      string
+	Schema    string
+	Recursive bool
+	Fields    []ZodField
+}
+
+// ZodField is an object property and its Zod expression. Key is quoted when
+// the wire name is not a JavaScript identifier.
+type ZodField struct {
+	Key  string
+	Type string
+	Doc  string
+}
+
+// ZodSchemaName returns the constant name of an object's Zod schema.
+func ZodSchemaName(object string) string {
+	return object + "Schema"
+}
+
+// ZodTypeOf renders the Zod schema expression for a Go type. Objects are
+// referenced by their schema constant.
+func (r *Registry) ZodTypeOf(t reflect.Type) string {
+	return r.zodType(t, nil)
+}
+
+// ZodObjects renders the reflected objects as Zod schemas.
+func (r *Registry) ZodObjects() []ZodObject {
+	types := make([]reflect.Type, 0, len(r.objects))
+	for t := range r.objects {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return r.objects[types[i]].Name < r.objects[types[j]].Name
+	})
+	edges := make(map[reflect.Type][]reflect.Type, len(types))
+	for _, t := range types {
+		seen := map[reflect.Type]bool{}
+		for _, field := range r.objects[t].Fields {
+			for _, ref := range r.objectRefs(field.Type) {
+				if !seen[ref] {
+					seen[ref] = true
+					edges[t] = append(edges[t], ref)
+				}
+			}
+		}
+	}
+	reaches := func(from, to reflect.Type) bool {
+		visited := map[reflect.Type]bool{}
+		stack := append([]reflect.Type(nil), edges[from]...)
+		for len(stack) > 0 {
+			next := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if next == to {
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				stack = append(stack, edges[next]...)
+			}
+		}
+		return false
+	}
+
+	var order []reflect.Type
+	done := map[reflect.Type]bool{}
+	var visit func(t reflect.Type)
+	visit = func(t reflect.Type) {
+		if done[t] {
+			return
+		}
+		done[t] = true
+		for _, ref := range edges[t] {
+			visit(ref)
+		}
+		order = append(order, t)
+	}
+	for _, t := range types {
+		visit(t)
+	}
+
+	objects := make([]ZodObject, 0, len(order))
+	for _, t := range order {
+		obj := r.objects[t]
+		// A reference back into the object's own cycle is not declared
+		// yet, so it is deferred with z.lazy.
+		lazy := func(ref reflect.Type) bool { return reaches(ref, t) }
+		zodObj := ZodObject{
+			Name:      obj.Name,
+			Schema:    ZodSchemaName(obj.Name),
+			Recursive: reaches(t, t),
+		}
+		for _, field := range obj.Fields {
+			zodObj.Fields = append(zodObj.Fields, ZodField{
+				Key:  zodKey(field.Name),
+				Type: r.zodField(field, lazy),
+				Doc:  field.Doc,
+			})
+		}
+		objects = append(objects, zodObj)
+	}
+	return objects
+}
+
+func (r *Registry) zodField(field fieldDef, lazy func(reflect.Type) bool) string {
+	base := reflectutil.DerefType(field.Type)
+	var out string
+	switch {
+	case base == nil:
+		out = "z.any()"
+	case r.isZodScalar(base):
+		out = zodScalar(ApplyFieldMetadata(field.Struct, r.zodScalarSchema(base)))
+	case base.Kind() == reflect.Slice || base.Kind() == reflect.Array:
+		out = r.zodType(base, lazy)
+		if minItems, ok := parseIntTag(field.Struct.Tag.Get("minItems")); ok {
+			out += ".min(" + strconv.Itoa(minItems) + ")"
+		}
+		if maxItems, ok := parseIntTag(field.Struct.Tag.Get("maxItems")); ok {
+			out += ".max(" + strconv.Itoa(maxItems) + ")"
+		}
+	default:
+		out = r.zodType(base, lazy)
+	}
+	if field.Nullable || isNilable(base) {
+		out += ".nullable()"
+	}
+	if field.Optional {
+		out += ".optional()"
+	}
+	return out
+}
+
+func (r *Registry) zodType(t reflect.Type, lazy func(reflect.Type) bool) string {
+	base := reflectutil.DerefType(t)
+	if base == nil {
+		return "z.any()"
+	}
+	if r.isZodScalar(base) {
+		return zodScalar(r.zodScalarSchema(base))
+	}
+	switch base.Kind() {
+	case reflect.Slice:
+		if base.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings.
+			return "z.string()"
+		}
+		return "z.array(" + r.zodElem(base.Elem(), lazy) + ")"
+	case reflect.Array:
+		return "z.array(" + r.zodElem(base.Elem(), lazy) + ").length(" + strconv.Itoa(base.Len()) + ")"
+	case reflect.Map:
+		return "z.record(z.string(), " + r.zodElem(base.Elem(), lazy) + ")"
+	case reflect.Struct:
+		if base.Name() == "" {
+			return "z.record(z.string(), z.any())"
+		}
+		name := ZodSchemaName(r.objectName(base))
+		if lazy != nil && lazy(base) {
+			return "z.lazy(() => " + name + ")"
+		}
+		return name
+	default:
+		return "z.any()"
+	}
+}
+
+func (r *Registry) zodElem(t reflect.Type, lazy func(reflect.Type) bool) string {
+	out := r.zodType(t, lazy)
+	if r.isNullableType(t) || isNilable(reflectutil.DerefType(t)) {
+		out += ".nullable()"
+	}
+	return out
+}
+
+// isNilable reports whether encoding/json writes the zero value of t as null.
+// Slices and maps are not nullable in the registry, but a nil one still
+// arrives as null.
+func isNilable(t reflect.Type) bool {
+	return t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Map)
+}
+
+// objectRefs lists the registry objects a field type refers to directly or
+// through slices, arrays, and maps.
+func (r *Registry) objectRefs(t reflect.Type) []reflect.Type {
+	base := reflectutil.DerefType(t)
+	if base == nil || r.isZodScalar(base) {
+		return nil
+	}
+	switch base.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return r.objectRefs(base.Elem())
+	case reflect.Struct:
+		if _, ok := r.objects[base]; ok {
+			return []reflect.Type{base}
+		}
+	}
+	return nil
+}
+
+func (r *Registry) isZodScalar(t reflect.Type) bool {
+	return r.isOverrideScalar(t) || isTimeType(t) || isScalarKind(t)
+}
+
+// zodScalarSchema returns the OpenAPI schema of a scalar type, which carries
+// the type, format, and range that the Zod expression checks.
+func (r *Registry) zodScalarSchema(t reflect.Type) *OpenAPISchema {
+	if override, ok := typeOverrideFor(r.overrides, t); ok && r.isOverrideScalar(t) {
+		if override.ArbitraryJSON {
+			return &OpenAPISchema{}
+		}
+		schema := &OpenAPISchema{Type: override.OpenAPIType, Format: override.OpenAPIFormat}
+		if schema.Type == "" && schema.Format != "" {
+			schema.Type = "string"
+		}
+		if schema.Type == "" {
+			switch override.JSType {
+			case "string", "boolean":
+				schema.Type = override.JSType
+			case "number":
+				schema.Type = "number"
+			}
+		}
+		return schema
+	}
+	if isTimeType(t) {
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	}
+	schema := (&Generator{overrides: r.overrides}).inlineSchema(t)
+	if t.Kind() == reflect.Int {
+		// OpenAPI documents int as int32, but a Go int holds 64 bits and
+		// the schema must not reject values the server can send.
+		schema.Format = "int64"
+	}
+	if minimum, maximum := integerBounds(t.Kind()); minimum != nil {
+		schema.Minimum, schema.Maximum = minimum, maximum
+	}
+	return schema
+}
+
+// integerBounds returns the range of an integer kind. Bounds beyond what a
+// JavaScript number holds exactly are left open.
+func integerBounds(kind reflect.Kind) (*float64, *float64) {
+	bounds := func(lo, hi float64) (*float64, *float64) { return &lo, &hi }
+	switch kind {
+	case reflect.Int8:
+		return bounds(math.MinInt8, math.MaxInt8)
+	case reflect.Int16:
+		return bounds(math.MinInt16, math.MaxInt16)
+	case reflect.Int32:
+		return bounds(math.MinInt32, math.MaxInt32)
+	case reflect.Uint8:
+		return bounds(0, math.MaxUint8)
+	case reflect.Uint16:
+		return bounds(0, math.MaxUint16)
+	case reflect.Uint32:
+		return bounds(0, math.MaxUint32)
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &zero, nil
+	}
+	return nil, nil
+}
+
+func zodScalar(schema *OpenAPISchema) string {
+	if len(schema.Enum) > 0 {
+		return zodEnum(schema.Enum)
+	}
+	var out string
+	switch schema.Type {
+	case "string":
+		out = "z.string()"
+		switch schema.Format {
+		case "date-time":
+			out += ".datetime({ offset: true })"
+		case "date":
+			out += ".date()"
+		case "uuid":
+			out += ".uuid()"
+		case "email":
+			out += ".email()"
+		case "uri", "url":
+			out += ".url()"
+		case "binary":
+			return "z.instanceof(Blob)"
+		}
+		if schema.MinLength != nil {
+			out += ".min(" + strconv.Itoa(*schema.MinLength) + ")"
+		}
+		if schema.MaxLength != nil {
+			out += ".max(" + strconv.Itoa(*schema.MaxLength) + ")"
+		}
+		if schema.Pattern != "" {
+			out += ".regex(new RegExp(" + jsString(schema.Pattern) + "))"
+		}
+		return out
+	case "integer":
+		out = "z.number().int()"
+		if schema.Format == "int32" {
+			if schema.Minimum == nil {
+				out += ".gte(-2147483648)"
+			}
+			if schema.Maximum == nil {
+				out += ".lte(2147483647)"
+			}
+		}
+	case "number":
+		out = "z.number()"
+	case "boolean":
+		return "z.boolean()"
+	default:
+		return "z.any()"
+	}
+	if schema.Minimum != nil {
+		out += ".gte(" + strconv.FormatFloat(*schema.Minimum, 'f', -1, 64) + ")"
+	}
+	if schema.Maximum != nil {
+		out += ".lte(" + strconv.FormatFloat(*schema.Maximum, 'f', -1, 64) + ")"
+	}
+	return out
+}
+
+func zodEnum(values []any) string {
+	literals := make([]string, 0, len(values))
+	allStrings := true
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "z.any()"
+		}
+		if _, ok := value.(string); !ok {
+			allStrings = false
+		}
+		literals = append(literals, string(data))
+	}
+	if allStrings {
+		return "z.enum([" + strings.Join(literals, ", ") + "])"
+	}
+	if len(literals) == 1 {
+		return "z.literal(" + literals[0] + ")"
+	}
+	for i, literal := range literals {
+		literals[i] = "z.literal(" + literal + ")"
+	}
+	return "z.union([" + strings.Join(literals, ", ") + "])"
+}
+
+func zodKey(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9' {
+			continue
+		}
+		return jsString(name)
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
+
+func jsString(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
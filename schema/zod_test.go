@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type zodAccount struct {
+	ID       string     `json:"id" format:"uuid"`
+	Status   string     `json:"status" enum:"active,disabled"`
+	Level    int        `json:"level" enum:"1,2"`
+	Age      int32      `json:"age"`
+	Score    uint       `json:"score" maximum:"100"`
+	Ratio    float64    `json:"ratio"`
+	Name     string     `json:"display-name" minLength:"1" maxLength:"64" pattern:"^[a-z]+$"`
+	Created  time.Time  `json:"created"`
+	Deleted  *time.Time `json:"deleted,omitempty"`
+	Tags     []string   `json:"tags" minItems:"1"`
+	Avatar   []byte     `json:"avatar"`
+	Labels   map[string]*int
+	Manager  *zodNode `json:"manager"`
+	Archived bool     `json:"archived,omitempty"`
+}
+
+type zodNode struct {
+	Name     string     `json:"name"`
+	Children []*zodNode `json:"children"`
+}
+
+type zodIntegers struct {
+	I8   int8    `json:"i8"`
+	I16  int16   `json:"i16"`
+	I64  int64   `json:"i64"`
+	U8   uint8   `json:"u8"`
+	U16  uint16  `json:"u16"`
+	U32  uint32  `json:"u32"`
+	U64  uint64  `json:"u64"`
+	Pct  uint8   `json:"pct" maximum:"100"`
+	Bits []uint8 `json:"bits"`
+}
+
+func zodObjectByName(objects []ZodObject, name string) (ZodObject, int) {
+	for i, object := range objects {
+		if object.Name == name {
+			return object, i
+		}
+	}
+	return ZodObject{}, -1
+}
+
+func TestRegistryZodObjectsMapSchemaMetadata(t *testing.T) {
+	registry := NewRegistry(nil)
+	registry.AddType(zodAccount{})
+
+	objects := registry.ZodObjects()
+	account, accountAt := zodObjectByName(objects, "zodAccount")
+	node, nodeAt := zodObjectByName(objects, "zodNode")
+	if accountAt < 0 || nodeAt < 0 {
+		t.Fatalf("missing objects: %+v", objects)
+	}
+	if nodeAt > accountAt {
+		t.Fatalf("zodNode must be declared before zodAccount uses it")
+	}
+	if account.Schema != "zodAccountSchema" || account.Recursive {
+		t.Fatalf("unexpected account object: %+v", account)
+	}
+
+	want := map[string]string{
+		"id":             "z.string().uuid()",
+		"status":         `z.enum(["active", "disabled"])`,
+		"level":          "z.union([z.literal(1), z.literal(2)])",
+		"age":            "z.number().int().gte(-2147483648).lte(2147483647)",
+		"score":          "z.number().int().gte(0).lte(100)",
+		"ratio":          "z.number()",
+		`"display-name"`: `z.string().min(1).max(64).regex(new RegExp("^[a-z]+$"))`,
+		"created":        "z.string().datetime({ offset: true })",
+		"deleted":        "z.string().datetime({ offset: true }).nullable().optional()",
+		"tags":           "z.array(z.string()).min(1).nullable()",
+		"avatar":         "z.string().nullable()",
+		"Labels":         "z.record(z.string(), z.number().int().nullable()).nullable()",
+		"manager":        "zodNodeSchema.nullable()",
+		"archived":       "z.boolean().optional()",
+	}
+	if len(account.Fields) != len(want) {
+		t.Fatalf("fields = %d, want %d: %+v", len(account.Fields), len(want), account.Fields)
+	}
+	for _, field := range account.Fields {
+		if want[field.Key] != field.Type {
+			t.Fatalf("field %s = %s, want %s", field.Key, field.Type, want[field.Key])
+		}
+	}
+
+	if !node.Recursive {
+		t.Fatalf("zodNode should be marked recursive")
+	}
+	children := node.Fields[1]
+	if children.Type != "z.array(z.lazy(() => zodNodeSchema).nullable()).nullable()" {
+		t.Fatalf("children = %s", children.Type)
+	}
+}
+
+func TestRegistryZodTypeOfReferencesSchemas(t *testing.T) {
+	registry := NewRegistry(nil)
+	registry.AddType(zodNode{})
+
+	if got := registry.ZodTypeOf(reflect.TypeOf(&zodNode{})); got != "zodNodeSchema" {
+		t.Fatalf("ZodTypeOf(*zodNode) = %s", got)
+	}
+	if got := registry.ZodTypeOf(reflect.TypeOf([]zodNode{})); !strings.HasPrefix(got, "z.array(zodNodeSchema") {
+		t.Fatalf("ZodTypeOf([]zodNode) = %s", got)
+	}
+}
+
+func TestRegistryZodIntegersUseKindBounds(t *testing.T) {
+	registry := NewRegistry(nil)
+	registry.AddType(zodIntegers{})
+
+	object, at := zodObjectByName(registry.ZodObjects(), "zodIntegers")
+	if at < 0 {
+		t.Fatalf("missing zodIntegers object")
+	}
+	want := map[string]string{
+		"i8":   "z.number().int().gte(-128).lte(127)",
+		"i16":  "z.number().int().gte(-32768).lte(32767)",
+		"i64":  "z.number().int()",
+		"u8":   "z.number().int().gte(0).lte(255)",
+		"u16":  "z.number().int().gte(0).lte(65535)",
+		"u32":  "z.number().int().gte(0).lte(4294967295)",
+		"u64":  "z.number().int().gte(0)",
+		"pct":  "z.number().int().gte(0).lte(100)",
+		"bits": "z.string().nullable()",
+	}
+	for _, field := range object.Fields {
+		if want[field.Key] != field.Type {
+			t.Fatalf("field %s = %s, want %s", field.Key, field.Type, want[field.Key])
+		}
+	}
+}